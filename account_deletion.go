@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	socialmedia "auto-gbp-review/social_media"
+
+	"github.com/gin-gonic/gin"
+	supa "github.com/nedpals/supabase-go"
+)
+
+// authUserDeleteAttempts is how many times deleting the Supabase auth user is
+// tried before the failure is left in the log for an admin to finish
+const authUserDeleteAttempts = 3
+
+// errWrongPassword is returned when the password re-entered to confirm deletion is wrong
+var errWrongPassword = errors.New("password is incorrect")
+
+// DeleteAccount permanently removes the logged-in merchant's account: it
+// revokes every platform grant, deletes all of the user's businesses with their
+// synced reviews and role, then deletes the Supabase auth user. The user must
+// re-enter their password.
+func (h *SocialMediaHandlers) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	userEmail := c.GetString("user_email")
+
+	// Staff accounts are removed by another admin, not from the merchant dashboard
+	if c.GetString("user_role") != "merchant" {
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Only merchant accounts can be deleted here",
+		})
+		return
+	}
+
+	if err := verifyPassword(userEmail, c.PostForm("password")); err != nil {
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Your password was incorrect. Your account has not been deleted.",
+		})
+		return
+	}
+
+	merchantIDs, err := h.db.accountMerchantIDs(userID)
+	if err != nil {
+		log.Printf("DeleteAccount error: Failed to load businesses for user %s - %v", userID, err)
+		c.Status(http.StatusInternalServerError)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to delete your account",
+		})
+		return
+	}
+
+	// Revoke grants while the tokens still exist; a platform being down
+	// shouldn't stop the account going
+	smDB := socialmedia.NewDB(h.db.DB)
+	revoked := 0
+	for _, merchantID := range merchantIDs {
+		connections, err := smDB.GetAPIConnectionsByMerchant(merchantID)
+		if err != nil {
+			log.Printf("DeleteAccount: Failed to load connections for merchant %d - %v", merchantID, err)
+			continue
+		}
+		for _, conn := range connections {
+			if err := h.syncService.RevokeConnectionToken(conn); err != nil {
+				log.Printf("DeleteAccount: Failed to revoke token for connection %d - %v", conn.ID, err)
+				continue
+			}
+			revoked++
+		}
+	}
+
+	if err := h.db.deleteAccountData(userID); err != nil {
+		log.Printf("DeleteAccount error: Failed to delete data for user %s - %v", userID, err)
+		c.Status(http.StatusInternalServerError)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to delete your account",
+		})
+		return
+	}
+
+	// Logged before the auth user goes, while user_id still references it
+	h.db.logAuditEvent(c, "account_deleted", "user", userID, map[string]interface{}{
+		"email":          userEmail,
+		"merchant_ids":   merchantIDs,
+		"tokens_revoked": revoked,
+	})
+
+	if err := deleteAuthUserWithRetry(userID); err != nil {
+		log.Printf("DeleteAccount error: Data for user %s was deleted but the auth user remains after %d attempts - %v",
+			userID, authUserDeleteAttempts, err)
+	}
+
+	if accessToken, _ := c.Cookie("sb_access_token"); accessToken != "" && supabaseSessions != nil {
+		supabaseSessions.Delete(accessToken)
+	}
+	c.SetCookie("sb_access_token", "", -1, cookiePath(), "", false, true)
+	c.SetCookie("sb_refresh_token", "", -1, cookiePath(), "", false, true)
+	c.SetCookie("auth_token", "", -1, cookiePath(), "", false, true)
+
+	c.Redirect(http.StatusFound, appPath("/"))
+}
+
+// verifyPassword checks the user's password by signing in with it
+func verifyPassword(email, password string) error {
+	if email == "" || password == "" {
+		return errWrongPassword
+	}
+
+	client := GetSupabaseClient()
+	ctx := context.Background()
+	user, err := client.Auth.SignIn(ctx, supa.UserCredentials{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return errWrongPassword
+	}
+
+	// Only the check was wanted; don't leave the extra session behind
+	if err := client.Auth.SignOut(ctx, user.AccessToken); err != nil {
+		log.Printf("Failed to sign out password check session: %v", err)
+	}
+	return nil
+}
+
+// deleteAuthUserWithRetry deletes the Supabase auth user, backing off between attempts
+func deleteAuthUserWithRetry(userID string) error {
+	var err error
+	for attempt := 1; attempt <= authUserDeleteAttempts; attempt++ {
+		err = deleteSupabaseAdminUser(GetSupabaseURL(), GetSupabaseServiceKey(), userID)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Attempt %d to delete auth user %s failed: %v", attempt, userID, err)
+		if attempt < authUserDeleteAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	return err
+}
+
+// accountMerchantIDs lists every business the user owns, including soft-deleted ones
+func (db *Database) accountMerchantIDs(authUserID string) ([]int, error) {
+	rows, err := db.Query("SELECT id FROM merchants WHERE auth_user_id = $1 ORDER BY id", authUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// deleteAccountData removes the user's synced reviews, businesses (cascading to
+// details, templates, connections and analytics) and role in one transaction
+func (db *Database) deleteAccountData(authUserID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Deleted up front so the largest table isn't left to the cascade from merchants
+	_, err = tx.Exec(`
+		DELETE FROM synced_reviews
+		WHERE merchant_id IN (SELECT id FROM merchants WHERE auth_user_id = $1)
+	`, authUserID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM merchants WHERE auth_user_id = $1", authUserID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM public.user_roles WHERE user_id = $1", authUserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+	socialmedia "auto-gbp-review/social_media"
+
+	"github.com/gin-gonic/gin"
+	supa "github.com/nedpals/supabase-go"
+)
+
+func TestDeleteAccountDataIsScopedToUser(t *testing.T) {
+	var statements []string
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if len(args) != 1 || args[0] != "user-1" {
+			t.Errorf("%s ran with %v, want only the user's id", query, args)
+		}
+		statements = append(statements, query)
+		if strings.Contains(query, "SELECT id FROM merchants") {
+			return &fakedb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(3)}, {int64(8)}}}, nil
+		}
+		return &fakedb.Result{RowsAffected: 1}, nil
+	})
+	defer conn.Close()
+	db := &Database{DB: conn}
+
+	ids, err := db.accountMerchantIDs("user-1")
+	if err != nil || len(ids) != 2 || ids[0] != 3 || ids[1] != 8 {
+		t.Errorf("accountMerchantIDs = %v, %v, want [3 8]", ids, err)
+	}
+
+	statements = nil
+	if err := db.deleteAccountData("user-1"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DELETE FROM synced_reviews", "DELETE FROM merchants", "DELETE FROM public.user_roles"}
+	if len(statements) != len(want) {
+		t.Fatalf("ran %d statements, want %d", len(statements), len(want))
+	}
+	for i, prefix := range want {
+		if !strings.Contains(statements[i], prefix) {
+			t.Errorf("statement %d = %s, want %s", i, statements[i], prefix)
+		}
+	}
+}
+
+func TestVerifyPasswordRequiresCredentials(t *testing.T) {
+	if err := verifyPassword("owner@example.com", ""); err != errWrongPassword {
+		t.Errorf("empty password: err = %v, want errWrongPassword", err)
+	}
+	if err := verifyPassword("", "secret123"); err != errWrongPassword {
+		t.Errorf("empty email: err = %v, want errWrongPassword", err)
+	}
+}
+
+// callLog records the order of calls across the database, platforms and Supabase
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+// revokeRecorder is a provider that logs each revoked token
+type revokeRecorder struct {
+	stubProvider
+	log *callLog
+}
+
+func (p revokeRecorder) RevokeToken(accessToken string) error {
+	p.log.add("revoke " + accessToken)
+	return nil
+}
+
+// mockSupabaseAuth serves password sign-in, sign-out and admin user deletion,
+// logging the deletions
+func mockSupabaseAuth(t *testing.T, calls *callLog) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/auth/v1/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token": "check", "user": {"id": "user-1"}}`))
+		case r.URL.Path == "/auth/v1/logout":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/auth/v1/admin/users/"):
+			calls.add("delete auth user " + strings.TrimPrefix(r.URL.Path, "/auth/v1/admin/users/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("SUPABASE_URL", server.URL)
+	t.Setenv("SUPABASE_SERVICE_ROLE_KEY", "service-key")
+	orig := supabaseClient
+	supabaseClient = supa.CreateClient(server.URL, "anon-key")
+	t.Cleanup(func() { supabaseClient = orig })
+}
+
+// deleteAccountRequest posts the delete account form as merchant user-1
+func deleteAccountRequest(h *SocialMediaHandlers) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST("/account/delete", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		c.Set("user_email", "owner@example.com")
+		c.Set("user_role", "merchant")
+	}, h.DeleteAccount)
+	return postForm(router, "/account/delete", url.Values{"password": {"secret123"}})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := &callLog{}
+	mockSupabaseAuth(t, calls)
+
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(query), "DELETE FROM"):
+			calls.add(strings.Fields(query)[2])
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SELECT id FROM merchants WHERE auth_user_id = $1"):
+			return &fakedb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(7)}}}, nil
+		case strings.Contains(query, "FROM api_connections"):
+			return &fakedb.Result{Columns: make([]string, 16), Rows: [][]driver.Value{
+				newConnectionsFixture().row(testConnection(1)),
+			}}, nil
+		case strings.Contains(query, "INSERT INTO audit_logs"):
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+	h.syncService = socialmedia.NewSyncService(socialmedia.NewDB(conn), plainTokens{})
+	h.syncService.RegisterProvider(revokeRecorder{stubProvider{platform: socialmedia.PlatformGoogleBusiness}, calls})
+
+	w := deleteAccountRequest(h)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != appPath("/") {
+		t.Fatalf("response = %d %q, want a redirect home (body %s)", w.Code, w.Header().Get("Location"), w.Body)
+	}
+
+	want := []string{
+		"revoke encrypted-token",
+		"synced_reviews", "merchants", "public.user_roles",
+		"delete auth user user-1",
+	}
+	if strings.Join(calls.calls, ", ") != strings.Join(want, ", ") {
+		t.Errorf("calls = %v, want %v", calls.calls, want)
+	}
+	if cookies := w.Header().Values("Set-Cookie"); len(cookies) != 3 || !strings.Contains(cookies[0], "sb_access_token=;") {
+		t.Errorf("session cookies not cleared: %v", cookies)
+	}
+}
+
+func TestDeleteAccountDatabaseFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := &callLog{}
+	mockSupabaseAuth(t, calls)
+
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		return nil, errors.New("connection refused")
+	})
+	defer conn.Close()
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+
+	if w := deleteAccountRequest(h); w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if len(calls.calls) != 0 {
+		t.Errorf("calls = %v, want the account left alone", calls.calls)
+	}
+}
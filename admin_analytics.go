@@ -0,0 +1,188 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminTopMerchantsLimit is how many merchants the admin overview ranks
+const adminTopMerchantsLimit = 10
+
+// analyticsPeriod is a reporting window selectable on the admin dashboard
+type analyticsPeriod struct {
+	Key   string
+	Label string
+	Days  int
+}
+
+// analyticsPeriods are the windows offered, the first being the default
+var analyticsPeriods = []analyticsPeriod{
+	{Key: "30d", Label: "Last 30 days", Days: 30},
+	{Key: "7d", Label: "Last 7 days", Days: 7},
+	{Key: "90d", Label: "Last 90 days", Days: 90},
+}
+
+// selectAnalyticsPeriod returns the period named by ?period=, or the default
+func selectAnalyticsPeriod(c *gin.Context) analyticsPeriod {
+	key := c.Query("period")
+	for _, p := range analyticsPeriods {
+		if p.Key == key {
+			return p
+		}
+	}
+	return analyticsPeriods[0]
+}
+
+// merchantEngagement is one merchant's non-bot views and clicks over a period
+type merchantEngagement struct {
+	ID           int    `json:"id"`
+	BusinessName string `json:"business_name"`
+	Slug         string `json:"slug"`
+	Views        int    `json:"views"`
+	Clicks       int    `json:"clicks"`
+}
+
+// platformClicks is the number of clicks on one platform's links
+type platformClicks struct {
+	Platform string `json:"platform"`
+	Clicks   int    `json:"clicks"`
+}
+
+// dailyViews is the page views across all merchants on one day
+type dailyViews struct {
+	Date  string `json:"date"`
+	Views int    `json:"views"`
+}
+
+// adminAnalytics is the engagement overview across every (non-deleted) merchant
+type adminAnalytics struct {
+	TotalViews       int                  `json:"total_views"`
+	TotalClicks      int                  `json:"total_clicks"`
+	TopMerchants     []merchantEngagement `json:"top_merchants"`
+	ClicksByPlatform []platformClicks     `json:"clicks_by_platform"`
+	ViewsByDay       []dailyViews         `json:"views_by_day"`
+}
+
+// getAdminAnalytics totals page views and link clicks since the given time,
+// leaving out bot traffic and deleted merchants. Views are weighted for sampling.
+func (db *Database) getAdminAnalytics(since time.Time, topN int) (*adminAnalytics, error) {
+	analytics := &adminAnalytics{}
+
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COALESCE(SUM(pv.weight), 0) FROM page_views pv
+				JOIN merchants m ON m.id = pv.merchant_id
+				WHERE NOT pv.is_bot AND pv.created_at >= $1 AND m.deleted_at IS NULL),
+			(SELECT COUNT(*) FROM link_clicks lc
+				JOIN merchants m ON m.id = lc.merchant_id
+				WHERE NOT lc.is_bot AND lc.created_at >= $1 AND m.deleted_at IS NULL)
+	`, since).Scan(&analytics.TotalViews, &analytics.TotalClicks)
+	if err != nil {
+		return nil, err
+	}
+
+	if analytics.TopMerchants, err = db.getTopMerchants(since, topN); err != nil {
+		return nil, err
+	}
+	if analytics.ClicksByPlatform, err = db.getClicksByPlatform(since); err != nil {
+		return nil, err
+	}
+	if analytics.ViewsByDay, err = db.getViewsByDay(since); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// getTopMerchants ranks merchants by views, then clicks, over the period.
+// Merchants with clicks but no views are included.
+func (db *Database) getTopMerchants(since time.Time, limit int) ([]merchantEngagement, error) {
+	rows, err := db.Query(`
+		WITH views AS (
+			SELECT merchant_id, SUM(weight) AS views
+			FROM page_views
+			WHERE NOT is_bot AND created_at >= $1
+			GROUP BY merchant_id
+		), clicks AS (
+			SELECT merchant_id, COUNT(*) AS clicks
+			FROM link_clicks
+			WHERE NOT is_bot AND created_at >= $1
+			GROUP BY merchant_id
+		)
+		SELECT m.id, m.business_name, m.slug, COALESCE(v.views, 0) AS views, COALESCE(k.clicks, 0) AS clicks
+		FROM views v
+		FULL JOIN clicks k ON k.merchant_id = v.merchant_id
+		JOIN merchants m ON m.id = COALESCE(v.merchant_id, k.merchant_id)
+		WHERE m.deleted_at IS NULL
+		ORDER BY views DESC, clicks DESC, m.id
+		LIMIT $2
+	`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merchants := []merchantEngagement{}
+	for rows.Next() {
+		var e merchantEngagement
+		if err := rows.Scan(&e.ID, &e.BusinessName, &e.Slug, &e.Views, &e.Clicks); err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, e)
+	}
+	return merchants, rows.Err()
+}
+
+// getClicksByPlatform counts clicks per platform over the period, most clicked first
+func (db *Database) getClicksByPlatform(since time.Time) ([]platformClicks, error) {
+	rows, err := db.Query(`
+		SELECT lc.platform, COUNT(*) AS clicks
+		FROM link_clicks lc
+		JOIN merchants m ON m.id = lc.merchant_id
+		WHERE NOT lc.is_bot AND lc.created_at >= $1 AND m.deleted_at IS NULL
+		GROUP BY lc.platform
+		ORDER BY clicks DESC, lc.platform
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	platforms := []platformClicks{}
+	for rows.Next() {
+		var p platformClicks
+		if err := rows.Scan(&p.Platform, &p.Clicks); err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, rows.Err()
+}
+
+// getViewsByDay totals page views per day over the period
+func (db *Database) getViewsByDay(since time.Time) ([]dailyViews, error) {
+	rows, err := db.Query(`
+		SELECT DATE(pv.created_at) AS day, SUM(pv.weight)
+		FROM page_views pv
+		JOIN merchants m ON m.id = pv.merchant_id
+		WHERE NOT pv.is_bot AND pv.created_at >= $1 AND m.deleted_at IS NULL
+		GROUP BY day
+		ORDER BY day
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []dailyViews{}
+	for rows.Next() {
+		var day time.Time
+		var d dailyViews
+		if err := rows.Scan(&day, &d.Views); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format("Jan 2")
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
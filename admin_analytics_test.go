@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSelectAnalyticsPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for query, want := range map[string]int{"": 30, "?period=7d": 7, "?period=90d": 90, "?period=1y": 30} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin"+query, nil)
+		if got := selectAnalyticsPeriod(c); got.Days != want {
+			t.Errorf("%q: %d days, want %d", query, got.Days, want)
+		}
+	}
+}
+
+func TestGetAdminAnalytics(t *testing.T) {
+	since := time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC)
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if args[0] != since {
+			t.Errorf("query ran from %v, want %v", args[0], since)
+		}
+		if !strings.Contains(query, "is_bot") || !strings.Contains(query, "deleted_at IS NULL") {
+			t.Errorf("query doesn't leave out bots and deleted merchants: %s", query)
+		}
+		switch {
+		case strings.Contains(query, "WITH views AS"):
+			if args[1] != int64(adminTopMerchantsLimit) {
+				t.Errorf("top merchants limit = %v", args[1])
+			}
+			return &fakedb.Result{Columns: make([]string, 5), Rows: [][]driver.Value{
+				{int64(3), "Kopi Corner", "kopi-corner", int64(120), int64(14)},
+				{int64(5), "Ben's", "bens", int64(0), int64(2)},
+			}}, nil
+		case strings.Contains(query, "GROUP BY lc.platform"):
+			return &fakedb.Result{Columns: make([]string, 2), Rows: [][]driver.Value{
+				{"whatsapp", int64(10)}, {"waze", int64(6)},
+			}}, nil
+		case strings.Contains(query, "GROUP BY day"):
+			return &fakedb.Result{Columns: make([]string, 2), Rows: [][]driver.Value{
+				{time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), int64(120)},
+			}}, nil
+		case strings.Contains(query, "SUM(pv.weight)"):
+			return &fakedb.Result{Columns: make([]string, 2), Rows: [][]driver.Value{{int64(120), int64(16)}}}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+
+	analytics, err := (&Database{DB: conn}).getAdminAnalytics(since, adminTopMerchantsLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analytics.TotalViews != 120 || analytics.TotalClicks != 16 {
+		t.Errorf("totals = %d views, %d clicks", analytics.TotalViews, analytics.TotalClicks)
+	}
+	if len(analytics.TopMerchants) != 2 || analytics.TopMerchants[1] != (merchantEngagement{ID: 5, BusinessName: "Ben's", Slug: "bens", Clicks: 2}) {
+		t.Errorf("top merchants = %+v", analytics.TopMerchants)
+	}
+	if len(analytics.ClicksByPlatform) != 2 || analytics.ClicksByPlatform[0] != (platformClicks{"whatsapp", 10}) {
+		t.Errorf("clicks by platform = %+v", analytics.ClicksByPlatform)
+	}
+	if len(analytics.ViewsByDay) != 1 || analytics.ViewsByDay[0] != (dailyViews{"Oct 1", 120}) {
+		t.Errorf("views by day = %+v", analytics.ViewsByDay)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// merchantAnalyticsMigration adds the partial indexes the per-merchant analytics rely on
+const merchantAnalyticsMigration = "supabase/migrations/20251030070000_merchant_analytics_indexes.sql"
+
+// merchantAnalyticsQueries runs the dashboard stats, weekly digest and data
+// export for merchant 7 against a recording database and returns the
+// page_views and link_clicks queries they ran, with their arguments
+func merchantAnalyticsQueries(t *testing.T) ([]string, [][]driver.Value) {
+	t.Helper()
+	var queries []string
+	var args [][]driver.Value
+	conn := fakedb.Open(func(query string, a []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "FROM page_views") || strings.Contains(query, "FROM link_clicks"):
+			queries = append(queries, query)
+			args = append(args, a)
+		case strings.Contains(query, "as total_reviews"):
+			return &fakedb.Result{Columns: make([]string, 5), Rows: [][]driver.Value{{int64(0), int64(0), int64(0), 0.0, nil}}}, nil
+		case strings.Contains(query, "FILTER (WHERE reviewed_at >= $2)"):
+			return &fakedb.Result{Columns: make([]string, 3), Rows: [][]driver.Value{{int64(0), int64(0), 0.0}}}, nil
+		}
+		return &fakedb.Result{}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	h := &Handlers{db: &Database{DB: conn}}
+
+	h.getMerchantStats(7)
+	if _, err := h.digestSummary(7, "Cafe", time.Now().AddDate(0, 0, -7)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.getDailyActivity(7); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) == 0 {
+		t.Fatal("no analytics queries recorded")
+	}
+	return queries, args
+}
+
+func TestMerchantAnalyticsQueriesMatchPartialIndexes(t *testing.T) {
+	migration, err := os.ReadFile(merchantAnalyticsMigration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(migration), "WHERE NOT is_bot;") != 3 {
+		t.Fatalf("%s: want three indexes over NOT is_bot", merchantAnalyticsMigration)
+	}
+
+	// The planner only uses a partial index when the query implies its predicate
+	queries, _ := merchantAnalyticsQueries(t)
+	for _, query := range queries {
+		tables := strings.Count(query, "FROM page_views") + strings.Count(query, "FROM link_clicks")
+		if strings.Count(query, "merchant_id = $1 AND NOT is_bot") != tables {
+			t.Errorf("query doesn't filter every table by merchant and NOT is_bot: %s", query)
+		}
+	}
+}
+
+// TestMerchantAnalyticsUseIndexes applies the migration to the database at
+// TEST_DATABASE_URL, which needs the app's schema, and checks the plan of
+// every per-merchant analytics query uses the new indexes. Nothing is kept:
+// it all runs in a transaction that is rolled back.
+func TestMerchantAnalyticsUseIndexes(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	migration, err := os.ReadFile(merchantAnalyticsMigration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(string(migration)); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	// An empty table is cheapest to scan, so take that option away
+	if _, err := tx.Exec("SET LOCAL enable_seqscan = off"); err != nil {
+		t.Fatal(err)
+	}
+
+	queries, args := merchantAnalyticsQueries(t)
+	for i, query := range queries {
+		params := make([]interface{}, len(args[i]))
+		for j, arg := range args[i] {
+			params[j] = arg
+		}
+		rows, err := tx.Query("EXPLAIN "+query, params...)
+		if err != nil {
+			t.Fatalf("EXPLAIN failed: %v\n%s", err, query)
+		}
+		var plan strings.Builder
+		for rows.Next() {
+			var line string
+			rows.Scan(&line)
+			plan.WriteString(line + "\n")
+		}
+		rows.Close()
+
+		if strings.Contains(query, "FROM page_views") && !strings.Contains(plan.String(), "idx_page_views_merchant_human") {
+			t.Errorf("page_views query doesn't use idx_page_views_merchant_human:\n%s\n%s", query, plan.String())
+		}
+		if strings.Contains(query, "FROM link_clicks") && !strings.Contains(plan.String(), "idx_link_clicks_merchant_platform") &&
+			!strings.Contains(plan.String(), "idx_link_clicks_merchant_human_created") {
+			t.Errorf("link_clicks query doesn't use the merchant link_clicks indexes:\n%s\n%s", query, plan.String())
+		}
+	}
+}
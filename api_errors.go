@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIError is the body of every /api error response:
+// {"error": {"code": "...", "message": "...", "details": ...}}
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// apiErrorCodes maps HTTP statuses to stable, machine-readable error codes
+var apiErrorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusGone:                  "gone",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusTooManyRequests:       "too_many_requests",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// apiErrorCode returns the error code for a status, falling back to internal_error
+func apiErrorCode(status int) string {
+	if code, ok := apiErrorCodes[status]; ok {
+		return code
+	}
+	return "internal_error"
+}
+
+// respondAPIError aborts the request with the standard JSON error envelope
+func respondAPIError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": APIError{Code: apiErrorCode(status), Message: message}})
+}
+
+// respondAPIErrorDetails is respondAPIError with extra context for the client
+func respondAPIErrorDetails(c *gin.Context, status int, message string, details interface{}) {
+	c.AbortWithStatusJSON(status, gin.H{"error": APIError{Code: apiErrorCode(status), Message: message, Details: details}})
+}
+
+// isAPIRequest reports whether the request expects a JSON API response: an
+// /api path that isn't an HTMX swap or a browser navigation
+func isAPIRequest(c *gin.Context) bool {
+	return strings.HasPrefix(c.Request.URL.Path, appPath("/api/")) &&
+		c.GetHeader("HX-Request") == "" &&
+		!strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+// NoRouteHandler answers unknown /api paths with the JSON envelope and
+// everything else with the HTML error page
+func NoRouteHandler(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, appPath("/api/")) {
+		respondAPIError(c, http.StatusNotFound, "Endpoint not found")
+		return
+	}
+	c.Status(http.StatusNotFound)
+	renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+		"error": "Page not found",
+	})
+}
+
+// NoMethodHandler is NoRouteHandler for a known path called with the wrong method
+func NoMethodHandler(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, appPath("/api/")) {
+		respondAPIError(c, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	c.Status(http.StatusMethodNotAllowed)
+	renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+		"error": "Page not found",
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// decodeAPIError parses the standard error envelope from a response
+func decodeAPIError(t *testing.T, w *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s isn't the error envelope: %v", w.Body, err)
+	}
+	return body.Error
+}
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		return nil, errors.New("connection refused")
+	})
+	defer conn.Close()
+	db := &Database{DB: conn}
+	h := &Handlers{db: db}
+	sh := &SocialMediaHandlers{db: db}
+
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NoRouteHandler)
+	router.NoMethod(NoMethodHandler)
+	router.POST("/api/admin/merchants/bulk-status", h.BulkMerchantStatus)
+	router.GET("/api/social-media/connections", asMerchant(7), sh.GetConnections)
+
+	tests := []struct {
+		name, method, path, body string
+		wantStatus               int
+		wantCode, wantMessage    string
+	}{
+		{"bad request", http.MethodPost, "/api/admin/merchants/bulk-status", `{"ids": [], "active": true}`,
+			http.StatusBadRequest, "bad_request", "No merchant IDs given"},
+		{"database failure", http.MethodGet, "/api/social-media/connections", "",
+			http.StatusInternalServerError, "internal_error", "Failed to get connections"},
+		{"unknown endpoint", http.MethodGet, "/api/nope", "",
+			http.StatusNotFound, "not_found", "Endpoint not found"},
+		{"wrong method", http.MethodDelete, "/api/social-media/connections", "",
+			http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := decodeAPIError(t, w); got.Code != tt.wantCode || got.Message != tt.wantMessage {
+				t.Errorf("error = %+v, want %s: %s", got, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/x", func(c *gin.Context) {
+		respondAPIErrorDetails(c, http.StatusTooManyRequests, "Slow down", gin.H{"retry_after": 30})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if got := w.Body.String(); got != `{"error":{"code":"too_many_requests","message":"Slow down","details":{"retry_after":30}}}` {
+		t.Errorf("body = %s", got)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API keys are apiKeyPrefix followed by 32 random bytes, base64url encoded.
+// Only a SHA-256 hash is stored; the key itself is shown once, when created.
+const (
+	apiKeyPrefix        = "agr_"
+	apiKeyRandomBytes   = 32
+	apiKeyDisplayLength = 12
+	apiKeyNameMaxLength = 100
+)
+
+// apiKeyTouchInterval is how stale last_used_at may get before a request
+// updates it, so busy keys don't write on every call
+const apiKeyTouchInterval = time.Minute
+
+// APIKey is a merchant's key as listed on the dashboard. The key itself is
+// only set in the response that created it.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// generateAPIKey returns a new random key
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey is the stored form of a key. Keys are long and random, so a
+// plain SHA-256 is enough; there is nothing to brute-force.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// bearerToken returns the token from an "Authorization: Bearer <token>" header
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// ListAPIKeys lists the selected business's API keys, revoked ones included.
+// Only the logged-in owner's own businesses can be selected.
+func (h *Handlers) ListAPIKeys(c *gin.Context) {
+	merchant, _, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		respondAPIError(c, http.StatusForbidden, "Merchant not found")
+		return
+	}
+	if err != nil || merchant == nil {
+		respondAPIError(c, http.StatusNotFound, "No business selected")
+		return
+	}
+
+	keys, err := h.getAPIKeys(merchant.ID)
+	if err != nil {
+		log.Printf("ListAPIKeys error: Failed to load API keys for merchant %d - %v", merchant.ID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to load API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey generates a key for the selected business. The response is the
+// only time the key is returned.
+func (h *Handlers) CreateAPIKey(c *gin.Context) {
+	merchant, _, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		respondAPIError(c, http.StatusForbidden, "Merchant not found")
+		return
+	}
+	if err != nil || merchant == nil {
+		respondAPIError(c, http.StatusNotFound, "No business selected")
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if len(name) > apiKeyNameMaxLength {
+		respondAPIError(c, http.StatusBadRequest, "Name must be at most 100 characters")
+		return
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		log.Printf("CreateAPIKey error: Failed to generate key for merchant %d - %v", merchant.ID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	apiKey := &APIKey{Name: name, Prefix: key[:apiKeyDisplayLength], Key: key}
+	err = h.db.QueryRow(`
+		INSERT INTO api_keys (merchant_id, name, key_prefix, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, merchant.ID, name, apiKey.Prefix, hashAPIKey(key)).Scan(&apiKey.ID, &apiKey.CreatedAt)
+	if err != nil {
+		log.Printf("CreateAPIKey error: Failed to store key for merchant %d - %v", merchant.ID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	h.logAuditEvent(c, "api_key_created", "merchant", strconv.Itoa(merchant.ID), map[string]interface{}{
+		"api_key_id": apiKey.ID,
+		"name":       name,
+		"prefix":     apiKey.Prefix,
+	})
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// RevokeAPIKey revokes one of the logged-in user's keys. Requests made with
+// it are rejected from then on.
+func (h *Handlers) RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	var merchantID int
+	err = h.db.QueryRow(`
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+		  AND merchant_id IN (SELECT id FROM merchants WHERE auth_user_id = $2 AND deleted_at IS NULL)
+		RETURNING merchant_id
+	`, keyID, c.GetString("user_id")).Scan(&merchantID)
+	if err == sql.ErrNoRows {
+		respondAPIError(c, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		log.Printf("RevokeAPIKey error: Failed to revoke key %d - %v", keyID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	h.logAuditEvent(c, "api_key_revoked", "merchant", strconv.Itoa(merchantID), map[string]interface{}{
+		"api_key_id": keyID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"revoked": true})
+}
+
+// APIKeyMiddleware authenticates /api/v1 requests by bearer API key and stores
+// the key's merchant as "merchant_id". A key only reaches its own merchant:
+// asking for any other merchant_id is refused.
+func (h *Handlers) APIKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := bearerToken(c)
+		if key == "" {
+			respondAPIError(c, http.StatusUnauthorized, "API key required")
+			return
+		}
+
+		keyID, merchantID, err := h.authenticateAPIKey(key)
+		if err == sql.ErrNoRows {
+			respondAPIError(c, http.StatusUnauthorized, "Invalid or revoked API key")
+			return
+		}
+		if err != nil {
+			log.Printf("APIKeyMiddleware error: Failed to check API key - %v", err)
+			respondAPIError(c, http.StatusInternalServerError, "Failed to check API key")
+			return
+		}
+
+		if requested := c.Query("merchant_id"); requested != "" && requested != strconv.Itoa(merchantID) {
+			respondAPIError(c, http.StatusForbidden, "API key does not have access to that business")
+			return
+		}
+
+		c.Set("merchant_id", merchantID)
+		c.Set("api_key_id", keyID)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey looks up an unrevoked key of a merchant that still exists,
+// returning sql.ErrNoRows if there is none, and records that it was used
+func (h *Handlers) authenticateAPIKey(key string) (keyID, merchantID int, err error) {
+	err = h.db.QueryRow(`
+		SELECT k.id, k.merchant_id
+		FROM api_keys k
+		JOIN merchants m ON m.id = k.merchant_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND m.deleted_at IS NULL
+	`, hashAPIKey(key)).Scan(&keyID, &merchantID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - $2 * INTERVAL '1 second')
+	`, keyID, int(apiKeyTouchInterval.Seconds()))
+	if err != nil {
+		log.Printf("Failed to update last use of API key %d: %v", keyID, err)
+	}
+	return keyID, merchantID, nil
+}
+
+// getAPIKeys lists a merchant's keys, newest first
+func (h *Handlers) getAPIKeys(merchantID int) ([]APIKey, error) {
+	rows, err := h.db.Query(`
+		SELECT id, name, key_prefix, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE merchant_id = $1
+		ORDER BY created_at DESC
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIStats returns the key's merchant's page view and link click statistics
+func (h *Handlers) GetAPIStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"stats": h.getMerchantStats(c.GetInt("merchant_id"))})
+}
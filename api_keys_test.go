@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAPIKey is a row of the fake api_keys table
+type fakeAPIKey struct {
+	id         int64
+	merchantID int64
+	revoked    bool
+}
+
+// apiKeyTestRouter serves /api/v1 with APIKeyMiddleware over an in-memory
+// api_keys table keyed by hash. The v1 handlers are replaced by one that
+// echoes the merchant the middleware selected. Every query run is recorded.
+func apiKeyTestRouter(t *testing.T, keys map[string]fakeAPIKey, queries *[]string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if queries != nil {
+			*queries = append(*queries, query)
+		}
+		if strings.Contains(query, "FROM api_keys k") {
+			res := &fakedb.Result{Columns: []string{"id", "merchant_id"}}
+			if key, ok := keys[args[0].(string)]; ok && !key.revoked {
+				res.Rows = [][]driver.Value{{key.id, key.merchantID}}
+			}
+			return res, nil
+		}
+		return &fakedb.Result{}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/api/v1/stats", h.APIKeyMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"merchant_id": c.GetInt("merchant_id")})
+	})
+	return router
+}
+
+func apiKeyRequest(router *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	valid, _ := generateAPIKey()
+	revoked, _ := generateAPIKey()
+	unknown, _ := generateAPIKey()
+	router := apiKeyTestRouter(t, map[string]fakeAPIKey{
+		hashAPIKey(valid):   {id: 1, merchantID: 7},
+		hashAPIKey(revoked): {id: 2, merchantID: 7, revoked: true},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"valid key", "/api/v1/stats", valid, http.StatusOK},
+		{"valid key, own merchant_id", "/api/v1/stats?merchant_id=7", valid, http.StatusOK},
+		{"valid key, other merchant_id", "/api/v1/stats?merchant_id=8", valid, http.StatusForbidden},
+		{"revoked key", "/api/v1/stats", revoked, http.StatusUnauthorized},
+		{"unknown key", "/api/v1/stats", unknown, http.StatusUnauthorized},
+		{"no key", "/api/v1/stats", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := apiKeyRequest(router, tt.path, tt.key)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK && !strings.Contains(w.Body.String(), `"merchant_id":7`) {
+				t.Errorf("body = %s, want the key's merchant 7", w.Body)
+			}
+		})
+	}
+}
+
+func TestAPIKeyLookupUsesHash(t *testing.T) {
+	key, _ := generateAPIKey()
+	var queries []string
+	router := apiKeyTestRouter(t, map[string]fakeAPIKey{hashAPIKey(key): {id: 1, merchantID: 7}}, &queries)
+
+	if w := apiKeyRequest(router, "/api/v1/stats", key); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	for _, q := range queries {
+		if strings.Contains(q, key) {
+			t.Errorf("query contains the raw key: %s", q)
+		}
+	}
+}
+
+func TestGenerateAndHashAPIKey(t *testing.T) {
+	a, err := generateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := generateAPIKey()
+
+	if !strings.HasPrefix(a, apiKeyPrefix) || a == b {
+		t.Errorf("generateAPIKey = %q, %q; want distinct keys starting %q", a, b, apiKeyPrefix)
+	}
+	if hashAPIKey(a) != hashAPIKey(a) || hashAPIKey(a) == hashAPIKey(b) {
+		t.Error("hashAPIKey must be deterministic and differ between keys")
+	}
+	if h := hashAPIKey(a); len(h) != 64 || strings.Contains(h, a) {
+		t.Errorf("hashAPIKey = %q, want 64 hex characters not containing the key", h)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for header, want := range map[string]string{
+		"Bearer abc":   "abc",
+		"bearer  abc ": "abc",
+		"Basic abc":    "",
+		"Bearer":       "",
+		"":             "",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", header)
+		if got := bearerToken(c); got != want {
+			t.Errorf("bearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestSyncedReviewsLimitIsCapped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var limits []driver.Value
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if strings.Contains(query, "COUNT(*) FROM synced_reviews") {
+			return &fakedb.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(0)}}}, nil
+		}
+		if strings.Contains(query, "LIMIT") {
+			limits = append(limits, args[len(args)-2])
+		}
+		return &fakedb.Result{}, nil
+	})
+	defer conn.Close()
+
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/api/v1/reviews", func(c *gin.Context) { c.Set("merchant_id", 7) }, h.GetSyncedReviews)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reviews?limit=100000000", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+	if len(limits) != 1 || limits[0] != int64(maxSyncedReviewsLimit) {
+		t.Errorf("query limits = %v, want [%d]", limits, maxSyncedReviewsLimit)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// basePath is the sub-path the app is mounted under behind a reverse proxy
+// (e.g. "/reviews"). Empty when served from the root.
+var basePath string
+
+// loadBasePath reads and normalizes BASE_PATH to a leading slash and no trailing slash
+func loadBasePath() {
+	basePath = strings.TrimRight(strings.TrimSpace(os.Getenv("BASE_PATH")), "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+}
+
+// appPath prefixes an absolute app path with the configured base path
+func appPath(path string) string {
+	return basePath + path
+}
+
+// cookiePath returns the path cookies should be scoped to
+func cookiePath() string {
+	if basePath == "" {
+		return "/"
+	}
+	return basePath
+}
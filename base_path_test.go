@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withBasePath loads BASE_PATH from value for one test
+func withBasePath(t *testing.T, value string) {
+	t.Helper()
+	t.Setenv("BASE_PATH", value)
+	orig := basePath
+	loadBasePath()
+	t.Cleanup(func() { basePath = orig })
+}
+
+func TestLoadBasePath(t *testing.T) {
+	for env, want := range map[string]string{
+		"":            "",
+		"/":           "",
+		"reviews":     "/reviews",
+		"/reviews/":   "/reviews",
+		" /reviews  ": "/reviews",
+		"/a/b":        "/a/b",
+	} {
+		withBasePath(t, env)
+		if basePath != want {
+			t.Errorf("BASE_PATH=%q: basePath = %q, want %q", env, basePath, want)
+		}
+	}
+}
+
+func TestAppPathAndCookiePath(t *testing.T) {
+	withBasePath(t, "")
+	if appPath("/login") != "/login" || cookiePath() != "/" {
+		t.Errorf("no base path: appPath = %q, cookiePath = %q", appPath("/login"), cookiePath())
+	}
+
+	withBasePath(t, "/reviews")
+	if appPath("/login") != "/reviews/login" || cookiePath() != "/reviews" {
+		t.Errorf("base path: appPath = %q, cookiePath = %q", appPath("/login"), cookiePath())
+	}
+}
+
+func TestBusinessPageUnderBasePath(t *testing.T) {
+	withBasePath(t, "/reviews")
+	gin.SetMode(gin.TestMode)
+	f := newBusinessPageFixture()
+	h := f.handlers(t, nil)
+
+	router := gin.New()
+	router.Group(basePath).GET("/", h.Home)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reviews/?id=cafe", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the page served under the base path", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`"/reviews" + '/go/'`, `"/reviews" + '/api/track/view`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %s", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?id=cafe", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("root: status = %d, want 404 outside the base path", w.Code)
+	}
+}
+
+func TestLayoutAssetURLsUseBasePath(t *testing.T) {
+	withBasePath(t, "/reviews")
+
+	tmpl, err := parseTemplate("templates/layouts/base.html", "templates/home.html", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, applyPageDefaults(gin.H{}, "en")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `href="/reviews/static/css/app.css"`) {
+		t.Error("stylesheet URL isn't under the base path")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultBotUserAgents are lowercase user-agent fragments of crawlers, link
+// previewers, uptime monitors and HTTP libraries (the keep-alive pinger uses
+// Go's client). ANALYTICS_BOT_USER_AGENTS adds more.
+var defaultBotUserAgents = []string{
+	"bot", "crawl", "spider", "slurp",
+	"facebookexternalhit", "embedly", "preview",
+	"uptimerobot", "pingdom", "statuscake", "site24x7", "monitor", "render",
+	"headlesschrome", "lighthouse", "phantomjs",
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client", "okhttp", "axios/", "node-fetch", "java/",
+}
+
+// botUserAgents is the active list, built by loadBotFilter
+var botUserAgents = defaultBotUserAgents
+
+// loadBotFilter appends the comma-separated fragments in ANALYTICS_BOT_USER_AGENTS
+// to the default list, or replaces it when ANALYTICS_BOT_USER_AGENTS_REPLACE=true
+func loadBotFilter() {
+	var extra []string
+	for _, fragment := range strings.Split(os.Getenv("ANALYTICS_BOT_USER_AGENTS"), ",") {
+		if fragment = strings.ToLower(strings.TrimSpace(fragment)); fragment != "" {
+			extra = append(extra, fragment)
+		}
+	}
+
+	if os.Getenv("ANALYTICS_BOT_USER_AGENTS_REPLACE") == "true" {
+		botUserAgents = extra
+		return
+	}
+	botUserAgents = append(append([]string{}, defaultBotUserAgents...), extra...)
+}
+
+// isBotUserAgent reports whether a request with this User-Agent should be
+// treated as automated. A missing User-Agent counts as a bot; browsers always send one.
+func isBotUserAgent(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, fragment := range botUserAgents {
+		if strings.Contains(ua, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestIsBotUserAgent(t *testing.T) {
+	t.Cleanup(func() { botUserAgents = defaultBotUserAgents })
+	botUserAgents = defaultBotUserAgents
+
+	tests := []struct {
+		userAgent string
+		bot       bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0 Safari/537.36", false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148 Instagram 300.0", false},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"WhatsApp/2.23.20.0 preview", true},
+		{"Mozilla/5.0 (compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", true},
+		{"Go-http-client/1.1", true},
+		{"curl/8.4.0", true},
+		{"", true},
+		{"   ", true},
+	}
+	for _, tt := range tests {
+		if got := isBotUserAgent(tt.userAgent); got != tt.bot {
+			t.Errorf("isBotUserAgent(%q) = %v, want %v", tt.userAgent, got, tt.bot)
+		}
+	}
+}
+
+func TestLoadBotFilter(t *testing.T) {
+	t.Cleanup(func() { botUserAgents = defaultBotUserAgents })
+	const custom = "Mozilla/5.0 AcmeScanner/1.0"
+
+	t.Setenv("ANALYTICS_BOT_USER_AGENTS", " AcmeScanner , ")
+	t.Setenv("ANALYTICS_BOT_USER_AGENTS_REPLACE", "")
+	loadBotFilter()
+	if !isBotUserAgent(custom) || !isBotUserAgent("curl/8.4.0") {
+		t.Error("added fragment should be matched alongside the defaults")
+	}
+
+	t.Setenv("ANALYTICS_BOT_USER_AGENTS_REPLACE", "true")
+	loadBotFilter()
+	if !isBotUserAgent(custom) || isBotUserAgent("curl/8.4.0") {
+		t.Error("replace mode should match only the configured fragments")
+	}
+	if len(defaultBotUserAgents) == 0 || defaultBotUserAgents[0] != "bot" {
+		t.Error("loadBotFilter modified the default list")
+	}
+}
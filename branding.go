@@ -0,0 +1,42 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// Branding holds per-deployment product name, theme and logo for white-label installs
+type Branding struct {
+	AppName           string
+	DefaultThemeColor string
+	LogoURL           string
+}
+
+// branding is loaded from the environment at startup
+var branding = Branding{
+	AppName:           "ViralEngine",
+	DefaultThemeColor: "#3B82F6",
+}
+
+// loadBranding reads APP_NAME, DEFAULT_THEME_COLOR and APP_LOGO_URL, keeping defaults for unset values
+func loadBranding() {
+	branding = Branding{
+		AppName:           getEnvWithDefault("APP_NAME", "ViralEngine"),
+		DefaultThemeColor: getEnvWithDefault("DEFAULT_THEME_COLOR", "#3B82F6"),
+		LogoURL:           getEnvWithDefault("APP_LOGO_URL", ""),
+	}
+}
+
+// applyPageDefaults fills in the branding, theme options, default title and locale shared by every page
+func applyPageDefaults(data gin.H, locale string) gin.H {
+	if data == nil {
+		data = gin.H{}
+	}
+	if _, exists := data["title"]; !exists {
+		data["title"] = branding.AppName
+	}
+	data["appName"] = branding.AppName
+	data["appLogoURL"] = branding.LogoURL
+	data["defaultThemeColor"] = branding.DefaultThemeColor
+	data["themeFonts"] = themeFonts
+	data["locale"] = locale
+	data["basePath"] = basePath
+	return data
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withBranding loads branding from the environment for one test
+func withBranding(t *testing.T, env map[string]string) {
+	t.Helper()
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	orig := branding
+	loadBranding()
+	t.Cleanup(func() { branding = orig })
+}
+
+func TestDefaultTitleFromAppName(t *testing.T) {
+	withBranding(t, map[string]string{"APP_NAME": "ReviewHub", "APP_LOGO_URL": "https://cdn.example.com/logo.png"})
+
+	data := applyPageDefaults(nil, "en")
+	if data["title"] != "ReviewHub" || data["appName"] != "ReviewHub" || data["appLogoURL"] != "https://cdn.example.com/logo.png" {
+		t.Errorf("page defaults = %v, want ReviewHub branding", data)
+	}
+	if data := applyPageDefaults(gin.H{"title": "Dashboard"}, "en"); data["title"] != "Dashboard" {
+		t.Errorf("title = %v, want the page's own title kept", data["title"])
+	}
+
+	tmpl, err := parseTemplate("templates/layouts/base.html", "templates/home.html", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, applyPageDefaults(gin.H{}, "en")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), " - ReviewHub</title>") {
+		t.Error("rendered home page title doesn't use APP_NAME")
+	}
+}
+
+func TestBrandingDefaults(t *testing.T) {
+	withBranding(t, map[string]string{"APP_NAME": "", "DEFAULT_THEME_COLOR": "", "APP_LOGO_URL": ""})
+
+	if branding.AppName != "ViralEngine" || branding.DefaultThemeColor != "#3B82F6" || branding.LogoURL != "" {
+		t.Errorf("branding = %+v, want the built-in defaults", branding)
+	}
+}
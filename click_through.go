@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// xiaohongshuProfileURL is prefixed to a bare Xiaohongshu user ID
+const xiaohongshuProfileURL = "https://www.xiaohongshu.com/user/profile/"
+
+// clickDestination resolves where a public page button for platform leads,
+// and the link_type its clicks are recorded under. ok is false when the
+// platform is unknown or the merchant hasn't set that link.
+func clickDestination(details *MerchantDetails, links businessLinks, platform string) (destination, linkType string, ok bool) {
+	linkType = "social"
+	switch platform {
+	case "website":
+		destination = details.WebsiteURL
+	case "facebook":
+		destination = details.FacebookURL
+	case "instagram":
+		destination = details.InstagramURL
+	case "tiktok":
+		destination = details.TiktokURL
+	case "threads":
+		destination = details.ThreadsURL
+	case "googleplay":
+		destination = details.GooglePlayURL
+	case "appstore":
+		destination = details.AppStoreURL
+	case "xiaohongshu":
+		destination = details.XiaohongshuID
+		if destination != "" && !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+			destination = xiaohongshuProfileURL + url.PathEscape(destination)
+		}
+	case "googlemaps":
+		destination = details.GoogleMapsURL
+	case "waze":
+		// A link the merchant entered wins over the generated one
+		destination = details.WazeURL
+		if destination == "" {
+			destination = links.WazeURL
+		}
+	case "whatsapp":
+		destination, linkType = links.WhatsAppAppLink, "contact"
+	case "whatsapp_web":
+		destination, linkType = links.WhatsAppWebLink, "contact"
+	default:
+		return "", "", false
+	}
+
+	// Only ever send visitors to a web address
+	parsed, err := url.Parse(destination)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "", false
+	}
+	return destination, linkType, true
+}
+
+// ClickThrough records a click on a public page button and redirects to its
+// destination, so the click is counted even when the browser leaves the page
+// straight away. Rate-limited clicks still redirect but aren't recorded.
+func (h *Handlers) ClickThrough(c *gin.Context) {
+	merchantID, err := strconv.Atoi(c.Param("merchantId"))
+	if err != nil {
+		NoRouteHandler(c)
+		return
+	}
+
+	merchant, err := h.getMerchantByID(merchantID)
+	if err == sql.ErrNoRows || (err == nil && (!merchant.IsActive || merchant.DeletedAt != nil)) {
+		NoRouteHandler(c)
+		return
+	}
+	if err != nil {
+		log.Printf("ClickThrough error: Failed to load merchant %d - %v", merchantID, err)
+		c.Status(http.StatusInternalServerError)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to open this link",
+		})
+		return
+	}
+
+	details, err := h.getMerchantDetails(merchant.ID)
+	if err != nil {
+		log.Printf("ClickThrough error: Failed to load details for merchant %d - %v", merchantID, err)
+		c.Status(http.StatusInternalServerError)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to open this link",
+		})
+		return
+	}
+
+	platform := c.Param("platform")
+	var links businessLinks
+	if platform == "waze" || strings.HasPrefix(platform, "whatsapp") {
+		details.GooglePlaceID = h.googlePlaceID(merchant, details)
+		links = generateBusinessLinks(merchant, details, detectLocale(c))
+	}
+
+	destination, linkType, ok := clickDestination(details, links, platform)
+	if !ok {
+		NoRouteHandler(c)
+		return
+	}
+
+	if trackingIPLimiter.Allow(c.ClientIP()) && trackingMerchantLimiter.Allow(strconv.Itoa(merchant.ID)) {
+		if err := h.recordLinkClick(c, merchant.ID, platform, linkType); err != nil {
+			log.Printf("Failed to log link click: %v", err)
+		}
+	} else {
+		log.Printf("Click-through not recorded (rate limited): merchant_id=%d, ip=%s", merchant.ID, c.ClientIP())
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, destination)
+}
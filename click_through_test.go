@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClickDestination(t *testing.T) {
+	details := &MerchantDetails{
+		WebsiteURL:    "https://kopi.example.com",
+		FacebookURL:   "https://facebook.com/kopi",
+		InstagramURL:  "javascript:alert(1)",
+		XiaohongshuID: "5f1a2b",
+		GoogleMapsURL: "https://maps.app.goo.gl/abc",
+	}
+	links := businessLinks{
+		WazeURL:         "https://waze.com/ul?q=kopi",
+		WhatsAppAppLink: "https://api.whatsapp.com/send/?phone=60123",
+		WhatsAppWebLink: "https://web.whatsapp.com/send?phone=60123",
+	}
+
+	tests := []struct {
+		platform    string
+		destination string
+		linkType    string
+		ok          bool
+	}{
+		{"website", "https://kopi.example.com", "social", true},
+		{"facebook", "https://facebook.com/kopi", "social", true},
+		{"xiaohongshu", xiaohongshuProfileURL + "5f1a2b", "social", true},
+		{"googlemaps", "https://maps.app.goo.gl/abc", "social", true},
+		{"waze", "https://waze.com/ul?q=kopi", "social", true},
+		{"whatsapp", "https://api.whatsapp.com/send/?phone=60123", "contact", true},
+		{"whatsapp_web", "https://web.whatsapp.com/send?phone=60123", "contact", true},
+		{"instagram", "", "", false}, // not a web address
+		{"tiktok", "", "", false},    // not set
+		{"myspace", "", "", false},   // unknown platform
+	}
+	for _, tt := range tests {
+		destination, linkType, ok := clickDestination(details, links, tt.platform)
+		if destination != tt.destination || linkType != tt.linkType || ok != tt.ok {
+			t.Errorf("%s: %q, %q, %v; want %q, %q, %v", tt.platform, destination, linkType, ok, tt.destination, tt.linkType, tt.ok)
+		}
+	}
+
+	// A Waze link the merchant entered wins over the generated one
+	details.WazeURL = "https://waze.com/ul/hw23"
+	if destination, _, _ := clickDestination(details, links, "waze"); destination != details.WazeURL {
+		t.Errorf("waze: %q, want the merchant's own link", destination)
+	}
+}
+
+func TestClickThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newBusinessPageFixture()
+	f.details.WebsiteURL = "https://kopi.example.com"
+	h := f.handlers(t, nil)
+
+	router := gin.New()
+	router.GET("/go/:merchantId/:platform", h.ClickThrough)
+	click := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.RemoteAddr = "203.0.113.9:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := click("/go/1/website")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://kopi.example.com" {
+		t.Fatalf("response = %d %q, want a redirect to the website", w.Code, w.Header().Get("Location"))
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", w.Header().Get("Cache-Control"))
+	}
+	want := "[[1 website social 203.0.113.9 Mozilla/5.0 false]]"
+	if got := fmt.Sprint(f.clicks); got != want {
+		t.Errorf("link_clicks inserts = %s, want %s", got, want)
+	}
+
+	// Unset links, unknown merchants and bad ids aren't recorded
+	for _, path := range []string{"/go/1/tiktok", "/go/2/website", "/go/cafe/website"} {
+		if w := click(path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, w.Code)
+		}
+	}
+	if len(f.clicks) != 1 {
+		t.Errorf("recorded %d clicks, want 1", len(f.clicks))
+	}
+}
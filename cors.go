@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsAllowedOrigins are the origins allowed to call the public API (CORS_ALLOWED_ORIGINS).
+// "*" allows any origin. Empty means no cross-origin access.
+var corsAllowedOrigins []string
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds
+const corsMaxAge = "600"
+
+// loadCORSConfig reads CORS_ALLOWED_ORIGINS as a comma-separated list of origins
+func loadCORSConfig() {
+	corsAllowedOrigins = nil
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			corsAllowedOrigins = append(corsAllowedOrigins, origin)
+		}
+	}
+}
+
+// corsOriginAllowed returns the Access-Control-Allow-Origin value for origin, or "" if it isn't allowed
+func corsOriginAllowed(origin string) string {
+	for _, allowed := range corsAllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// PublicCORS lets embedded widgets on merchant sites read the public API.
+// Only for unauthenticated routes: no credentials are allowed. Preflight
+// requests are answered here; disallowed origins get no CORS headers, so the
+// browser blocks the response.
+func PublicCORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin != "" {
+			c.Header("Vary", "Origin")
+			if allowOrigin := corsOriginAllowed(origin); allowOrigin != "" {
+				c.Header("Access-Control-Allow-Origin", allowOrigin)
+				c.Header("Access-Control-Allow-Methods", "GET, OPTIONS")
+				c.Header("Access-Control-Allow-Headers", "Content-Type, HX-Request, HX-Target, HX-Current-URL")
+				c.Header("Access-Control-Max-Age", corsMaxAge)
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// publicGET registers a GET route together with the OPTIONS route its preflight needs
+func publicGET(group *gin.RouterGroup, path string, handler gin.HandlerFunc) {
+	group.GET(path, handler)
+	group.OPTIONS(path, func(c *gin.Context) {})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withCORSOrigins loads value as CORS_ALLOWED_ORIGINS for the rest of the test
+func withCORSOrigins(t *testing.T, value string) {
+	t.Helper()
+	t.Setenv("CORS_ALLOWED_ORIGINS", value)
+	loadCORSConfig()
+	t.Cleanup(func() { corsAllowedOrigins = nil })
+}
+
+// corsRouter serves one public GET route behind PublicCORS
+func corsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/api/public", PublicCORS())
+	publicGET(group, "/reviews", func(c *gin.Context) { c.String(http.StatusOK, "reviews") })
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/public/reviews", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPublicCORS(t *testing.T) {
+	withCORSOrigins(t, " https://cafe.example.com/ , https://shop.example.com")
+	router := corsRouter()
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"allowed origin", http.MethodGet, "https://cafe.example.com", http.StatusOK, "https://cafe.example.com"},
+		{"allowed origin, other case", http.MethodGet, "https://SHOP.example.com", http.StatusOK, "https://SHOP.example.com"},
+		{"disallowed origin", http.MethodGet, "https://evil.example.com", http.StatusOK, ""},
+		{"same origin", http.MethodGet, "", http.StatusOK, ""},
+		{"preflight", http.MethodOptions, "https://cafe.example.com", http.StatusNoContent, "https://cafe.example.com"},
+		{"disallowed preflight", http.MethodOptions, "https://evil.example.com", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := corsRequest(router, tt.method, tt.origin)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+			}
+			if tt.wantAllow != "" && w.Header().Get("Access-Control-Allow-Methods") != "GET, OPTIONS" {
+				t.Errorf("Access-Control-Allow-Methods = %q", w.Header().Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestPublicCORSWildcardAndUnset(t *testing.T) {
+	withCORSOrigins(t, "*")
+	if got := corsRequest(corsRouter(), http.MethodGet, "https://any.example.com").Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("wildcard: Access-Control-Allow-Origin = %q, want *", got)
+	}
+
+	withCORSOrigins(t, "")
+	if got := corsRequest(corsRouter(), http.MethodGet, "https://cafe.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("unset: Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	socialmedia "auto-gbp-review/social_media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportReviewPageSize is how many synced reviews are read per query while exporting
+const exportReviewPageSize = 500
+
+// merchantExport is everything stored about one merchant, as downloaded by its
+// owner. Connections serialize without their tokens or admin notes.
+type merchantExport struct {
+	ExportedAt      time.Time                    `json:"exported_at"`
+	Merchant        *Merchant                    `json:"merchant"`
+	Details         *MerchantDetails             `json:"details"`
+	ReviewTemplates []Review                     `json:"review_templates"`
+	Connections     []*socialmedia.APIConnection `json:"connections"`
+	SyncedReviews   []*socialmedia.SyncedReview  `json:"synced_reviews"`
+	Notifications   map[string]bool              `json:"notifications"`
+	Analytics       map[string]interface{}       `json:"analytics"`
+	DailyActivity   []exportDay                  `json:"daily_activity"`
+}
+
+// exportDay is one day of (non-bot) page views and link clicks
+type exportDay struct {
+	Date   string `json:"date"`
+	Views  int    `json:"views"`
+	Clicks int    `json:"clicks"`
+}
+
+// ExportMerchantData downloads the selected business's data as a JSON file.
+// Only the logged-in owner's own businesses can be selected.
+func (h *Handlers) ExportMerchantData(c *gin.Context) {
+	merchant, _, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "You don't have access to that business",
+		})
+		return
+	}
+	if err != nil || merchant == nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "No business to export",
+		})
+		return
+	}
+
+	export, err := h.buildMerchantExport(merchant)
+	if err != nil {
+		log.Printf("ExportMerchantData error: Failed to export merchant %d - %v", merchant.ID, err)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to export your data",
+		})
+		return
+	}
+
+	h.logAuditEvent(c, "merchant_data_exported", "merchant", strconv.Itoa(merchant.ID), map[string]interface{}{
+		"business_name":  merchant.BusinessName,
+		"synced_reviews": len(export.SyncedReviews),
+	})
+
+	filename := fmt.Sprintf("%s-export-%s.json", merchant.Slug, export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		log.Printf("ExportMerchantData error: Failed to write export for merchant %d - %v", merchant.ID, err)
+	}
+}
+
+// buildMerchantExport gathers the merchant's profile, templates, connections,
+// synced reviews and analytics
+func (h *Handlers) buildMerchantExport(merchant *Merchant) (*merchantExport, error) {
+	smDB := socialmedia.NewDB(h.db.DB)
+
+	details, err := h.getMerchantDetails(merchant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("details: %w", err)
+	}
+
+	templates, err := h.getReviewsByMerchantID(merchant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("review templates: %w", err)
+	}
+
+	connections, err := smDB.GetAPIConnectionsByMerchant(merchant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("connections: %w", err)
+	}
+
+	var synced []*socialmedia.SyncedReview
+	for offset := 0; ; offset += exportReviewPageSize {
+		page, err := smDB.GetAllSyncedReviewsByMerchant(merchant.ID, exportReviewPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("synced reviews: %w", err)
+		}
+		synced = append(synced, page...)
+		if len(page) < exportReviewPageSize {
+			break
+		}
+	}
+
+	notifications := map[string]bool{}
+	if settings, err := smDB.GetNotificationSettings(merchant.ID); err == nil {
+		notifications["notify_on_negative"] = settings.NotifyOnNegative
+		notifications["weekly_digest"] = settings.WeeklyDigest
+	}
+
+	daily, err := h.getDailyActivity(merchant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("daily activity: %w", err)
+	}
+
+	if templates == nil {
+		templates = []Review{}
+	}
+	if connections == nil {
+		connections = []*socialmedia.APIConnection{}
+	}
+	if synced == nil {
+		synced = []*socialmedia.SyncedReview{}
+	}
+
+	return &merchantExport{
+		ExportedAt:      time.Now().UTC(),
+		Merchant:        merchant,
+		Details:         details,
+		ReviewTemplates: templates,
+		Connections:     connections,
+		SyncedReviews:   synced,
+		Notifications:   notifications,
+		Analytics:       h.getMerchantStats(merchant.ID),
+		DailyActivity:   daily,
+	}, nil
+}
+
+// getDailyActivity totals the merchant's page views and link clicks per day.
+// Visitor IPs and user agents stay out of the export.
+func (h *Handlers) getDailyActivity(merchantID int) ([]exportDay, error) {
+	rows, err := h.db.Query(`
+		SELECT day, SUM(views), SUM(clicks) FROM (
+			SELECT DATE(created_at) AS day, SUM(weight) AS views, 0 AS clicks
+			FROM page_views WHERE merchant_id = $1 AND NOT is_bot GROUP BY day
+			UNION ALL
+			SELECT DATE(created_at) AS day, 0 AS views, COUNT(*) AS clicks
+			FROM link_clicks WHERE merchant_id = $1 AND NOT is_bot GROUP BY day
+		) activity
+		GROUP BY day
+		ORDER BY day
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []exportDay{}
+	for rows.Next() {
+		var day time.Time
+		var d exportDay
+		if err := rows.Scan(&day, &d.Views, &d.Clicks); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format("2006-01-02")
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
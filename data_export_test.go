@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+	socialmedia "auto-gbp-review/social_media"
+)
+
+func TestGetDailyActivity(t *testing.T) {
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if !strings.Contains(query, "FROM page_views") || !strings.Contains(query, "NOT is_bot") || args[0] != int64(7) {
+			t.Fatalf("unexpected query %s with %v", query, args)
+		}
+		return &fakedb.Result{Columns: []string{"day", "views", "clicks"}, Rows: [][]driver.Value{
+			{time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), int64(12), int64(3)},
+			{time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), int64(0), int64(1)},
+		}}, nil
+	})
+	defer conn.Close()
+
+	h := &Handlers{db: &Database{DB: conn}}
+	days, err := h.getDailyActivity(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []exportDay{{Date: "2026-10-01", Views: 12, Clicks: 3}, {Date: "2026-10-02", Views: 0, Clicks: 1}}
+	if len(days) != len(want) || days[0] != want[0] || days[1] != want[1] {
+		t.Errorf("days = %+v, want %+v", days, want)
+	}
+}
+
+func TestMerchantExportOmitsSecrets(t *testing.T) {
+	export := &merchantExport{
+		Merchant: &Merchant{ID: 7, BusinessName: "Kopi Corner"},
+		Connections: []*socialmedia.APIConnection{{
+			ID: 1, Platform: socialmedia.PlatformGoogleBusiness,
+			AccessToken: "secret-access", RefreshToken: "secret-refresh", AdminNotes: "card declined",
+		}},
+	}
+	body, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-access", "secret-refresh", "card declined"} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("export contains %q:\n%s", secret, body)
+		}
+	}
+	if !strings.Contains(string(body), `"platform":"google_business"`) {
+		t.Errorf("export is missing the connection:\n%s", body)
+	}
+}
@@ -3,14 +3,19 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type Database struct {
 	*sql.DB
+	// migrated is set once startup migrations have been applied; /readyz waits on it
+	migrated bool
 }
 
 func InitDatabase() (*Database, error) {
@@ -43,20 +48,32 @@ func InitDatabase() (*Database, error) {
 		return nil, err
 	}
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
+	// Set connection pool settings (tune per instance size via env)
+	maxOpenConns := envInt("DB_MAX_OPEN_CONNS", 10)
+	maxIdleConns := envInt("DB_MAX_IDLE_CONNS", 5)
+	if maxIdleConns > maxOpenConns {
+		maxIdleConns = maxOpenConns
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	// The Supabase session pooler drops idle connections, so recycle ours before it does.
+	// A connection that still goes bad fails with driver.ErrBadConn, which
+	// database/sql retries on a fresh connection itself. Other errors aren't
+	// retried: the statement may already have been applied.
+	db.SetConnMaxIdleTime(envDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute))
+	db.SetConnMaxLifetime(envDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute))
 
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	database := &Database{db}
+	database := &Database{DB: db}
 
 	// Run migrations
 	if err := database.migrate(); err != nil {
 		return nil, err
 	}
+	database.migrated = true
 
 	return database, nil
 }
@@ -124,6 +141,35 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// envInt reads a positive integer from the environment, falling back to defaultValue
+func envInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Invalid %s %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envDuration reads a positive Go duration (e.g. "30m") from the environment,
+// falling back to defaultValue
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
 func extractProjectID(supabaseURL string) string {
 	// Extract project ID from https://your-project.supabase.co
 	// Remove the protocol and split by dots
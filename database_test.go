@@ -0,0 +1,78 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+func TestBadConnectionIsRetried(t *testing.T) {
+	calls := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		calls++
+		if calls == 1 {
+			// The pooler dropped the connection while it sat idle
+			return nil, driver.ErrBadConn
+		}
+		return &fakedb.Result{Columns: []string{"n"}, Rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	defer conn.Close()
+	db := &Database{DB: conn}
+
+	var n int
+	if err := db.QueryRow("SELECT 1").Scan(&n); err != nil {
+		t.Fatalf("QueryRow after bad connection: %v", err)
+	}
+	if n != 1 || calls != 2 {
+		t.Errorf("n = %d after %d calls, want 1 after 2", n, calls)
+	}
+}
+
+func TestDroppedWriteIsNotRetried(t *testing.T) {
+	calls := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		calls++
+		// The connection died after the server may have applied the insert
+		return nil, io.ErrUnexpectedEOF
+	})
+	defer conn.Close()
+	db := &Database{DB: conn}
+
+	_, err := db.Exec("INSERT INTO link_clicks (merchant_id) VALUES ($1)", 1)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if calls != 1 {
+		t.Errorf("insert ran %d times, want 1", calls)
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	for value, want := range map[string]int{"": 10, "25": 25, "0": 10, "-3": 10, "many": 10} {
+		t.Setenv("DB_MAX_OPEN_CONNS", value)
+		if got := envInt("DB_MAX_OPEN_CONNS", 10); got != want {
+			t.Errorf("DB_MAX_OPEN_CONNS=%q: envInt = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":      30 * time.Minute,
+		"1h":    time.Hour,
+		"90s":   90 * time.Second,
+		"0s":    30 * time.Minute,
+		"-5m":   30 * time.Minute,
+		"30":    30 * time.Minute, // no unit
+		"never": 30 * time.Minute,
+	} {
+		t.Setenv("DB_CONN_MAX_LIFETIME", value)
+		if got := envDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute); got != want {
+			t.Errorf("DB_CONN_MAX_LIFETIME=%q: envDuration = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"auto-gbp-review/social_media"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// digestCheckInterval is how often the weekly digest job looks for merchants that are due
+const digestCheckInterval = 15 * time.Minute
+
+// digestPeriod is the window each digest summarizes
+const digestPeriod = 7 * 24 * time.Hour
+
+// weeklyDigest sends each opted-in merchant a summary of the past week on
+// DIGEST_SEND_DAY at DIGEST_SEND_HOUR (server local time, default Monday 9:00).
+// last_digest_sent_at keeps it to one email per week across restarts.
+type weeklyDigest struct {
+	handlers     *Handlers
+	emailer      socialmedia.Emailer
+	sendDay      time.Weekday
+	sendHour     int
+	dashboardURL string
+}
+
+// digestSummary holds the figures of one merchant's weekly digest
+type digestSummary struct {
+	BusinessName      string
+	NewReviews        int
+	AvgRating         float64
+	PreviousAvgRating float64 // 0 when there were no rated reviews before the week
+	PageViews         int
+	TopPlatform       string // Empty when nothing was clicked
+	TopPlatformClicks int
+}
+
+// newWeeklyDigestFromEnv returns nil when SMTP isn't configured
+func newWeeklyDigestFromEnv(handlers *Handlers) *weeklyDigest {
+	emailer := socialmedia.EmailerFromEnv()
+	if emailer == nil {
+		return nil
+	}
+
+	sendDay := time.Monday
+	if day := strings.ToLower(strings.TrimSpace(os.Getenv("DIGEST_SEND_DAY"))); day != "" {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.ToLower(d.String()) == day {
+				sendDay, found = d, true
+			}
+		}
+		if !found {
+			log.Printf("Invalid DIGEST_SEND_DAY %q, using %s", day, sendDay)
+		}
+	}
+
+	sendHour := 9
+	if hour, err := strconv.Atoi(os.Getenv("DIGEST_SEND_HOUR")); err == nil && hour >= 0 && hour <= 23 {
+		sendHour = hour
+	}
+
+	return &weeklyDigest{
+		handlers:     handlers,
+		emailer:      emailer,
+		sendDay:      sendDay,
+		sendHour:     sendHour,
+		dashboardURL: dashboardURL(),
+	}
+}
+
+// Start checks for due digests in the background
+func (d *weeklyDigest) Start() {
+	log.Printf("Weekly digest scheduled for %s at %02d:00", d.sendDay, d.sendHour)
+	go func() {
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			d.sendDue(now)
+		}
+	}()
+}
+
+// scheduledAt returns the most recent send time at or before now
+func (d *weeklyDigest) scheduledAt(now time.Time) time.Time {
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), d.sendHour, 0, 0, 0, now.Location())
+	daysBack := (int(now.Weekday()) - int(d.sendDay) + 7) % 7
+	scheduled = scheduled.AddDate(0, 0, -daysBack)
+	if scheduled.After(now) {
+		scheduled = scheduled.AddDate(0, 0, -7)
+	}
+	return scheduled
+}
+
+// sendDue emails every opted-in merchant that hasn't had this week's digest.
+// Only runs on the send day itself, so opting in mid-week waits for the next one.
+func (d *weeklyDigest) sendDue(now time.Time) {
+	scheduled := d.scheduledAt(now)
+	if now.Sub(scheduled) >= 24*time.Hour {
+		return
+	}
+
+	rows, err := d.handlers.db.Query(`
+		SELECT m.id, m.business_name, u.email
+		FROM merchants m
+		JOIN auth.users u ON u.id = m.auth_user_id
+		WHERE m.weekly_digest = true AND m.is_active = true AND m.deleted_at IS NULL
+			AND (m.last_digest_sent_at IS NULL OR m.last_digest_sent_at < $1)
+	`, scheduled)
+	if err != nil {
+		log.Printf("Weekly digest: failed to load merchants: %v", err)
+		return
+	}
+
+	type recipient struct {
+		merchantID   int
+		businessName string
+		email        string
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.merchantID, &r.businessName, &r.email); err == nil && r.email != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	rows.Close()
+
+	for _, r := range recipients {
+		summary, err := d.handlers.digestSummary(r.merchantID, r.businessName, now.Add(-digestPeriod))
+		if err != nil {
+			log.Printf("Weekly digest: failed to build summary for merchant %d: %v", r.merchantID, err)
+			continue
+		}
+
+		subject, body := composeWeeklyDigest(summary, d.dashboardURL)
+		if err := d.emailer.Send(r.email, subject, body); err != nil {
+			log.Printf("Weekly digest: failed to send to merchant %d: %v", r.merchantID, err)
+			continue
+		}
+
+		if _, err := d.handlers.db.Exec("UPDATE merchants SET last_digest_sent_at = $1 WHERE id = $2", now, r.merchantID); err != nil {
+			log.Printf("Weekly digest: failed to record send for merchant %d: %v", r.merchantID, err)
+		}
+	}
+}
+
+// digestSummary gathers a merchant's figures for the week starting at since
+func (h *Handlers) digestSummary(merchantID int, businessName string, since time.Time) (*digestSummary, error) {
+	summary := &digestSummary{BusinessName: businessName}
+
+	// Page views come from the dashboard's last-7-days series
+	stats := h.getMerchantStats(merchantID)
+	if days, ok := stats["views_last_7days"].([]map[string]interface{}); ok {
+		for _, day := range days {
+			if count, ok := day["count"].(int); ok {
+				summary.PageViews += count
+			}
+		}
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	reviewStats, err := smDB.GetMerchantReviewStats(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if avg, ok := reviewStats["avg_rating"].(string); ok {
+		summary.AvgRating, _ = strconv.ParseFloat(avg, 64)
+	}
+
+	activity, err := smDB.GetReviewActivitySince(merchantID, since)
+	if err != nil {
+		return nil, err
+	}
+	summary.NewReviews = activity.NewReviews
+	summary.PreviousAvgRating = activity.PreviousAvgRating
+
+	// Top clicked platform over the week; clicks_by_platform in getMerchantStats is all-time
+	err = h.db.QueryRow(`
+		SELECT platform, COUNT(*) FROM link_clicks
+		WHERE merchant_id = $1 AND NOT is_bot AND created_at >= $2
+		GROUP BY platform
+		ORDER BY COUNT(*) DESC
+		LIMIT 1
+	`, merchantID, since).Scan(&summary.TopPlatform, &summary.TopPlatformClicks)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// composeWeeklyDigest builds the subject and body of a weekly digest email
+func composeWeeklyDigest(s *digestSummary, dashboardURL string) (string, string) {
+	subject := fmt.Sprintf("Your week at %s", s.BusinessName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Here's how %s did over the past 7 days.\n\n", s.BusinessName)
+	fmt.Fprintf(&b, "New reviews: %d\n", s.NewReviews)
+
+	fmt.Fprintf(&b, "Average rating: %.1f", s.AvgRating)
+	if s.PreviousAvgRating > 0 {
+		change := s.AvgRating - s.PreviousAvgRating
+		switch {
+		case change >= 0.05:
+			fmt.Fprintf(&b, " (up %.1f)", change)
+		case change <= -0.05:
+			fmt.Fprintf(&b, " (down %.1f)", -change)
+		default:
+			b.WriteString(" (no change)")
+		}
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "Page views: %d\n", s.PageViews)
+	if s.TopPlatform != "" {
+		fmt.Fprintf(&b, "Most clicked link: %s (%d clicks)\n", s.TopPlatform, s.TopPlatformClicks)
+	} else {
+		b.WriteString("Most clicked link: no clicks this week\n")
+	}
+
+	if dashboardURL != "" {
+		fmt.Fprintf(&b, "\nSee the details on your dashboard: %s\n", dashboardURL)
+	}
+	b.WriteString("\nYou can turn off this email on the integrations page.\n")
+	return subject, b.String()
+}
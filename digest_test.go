@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestScheduledAt(t *testing.T) {
+	d := &weeklyDigest{sendDay: time.Monday, sendHour: 9}
+	monday9 := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{monday9, monday9},
+		{monday9.Add(3 * time.Hour), monday9},
+		// Before 9:00 on Monday the last send was the week before
+		{monday9.Add(-time.Hour), monday9.AddDate(0, 0, -7)},
+		{monday9.AddDate(0, 0, 4), monday9},
+	}
+	for _, tt := range tests {
+		if got := d.scheduledAt(tt.now); !got.Equal(tt.want) {
+			t.Errorf("scheduledAt(%s) = %s, want %s", tt.now.Format(time.RFC1123), got.Format(time.RFC1123), tt.want.Format(time.RFC1123))
+		}
+	}
+}
+
+func TestComposeWeeklyDigest(t *testing.T) {
+	tests := []struct {
+		previous float64
+		want     string
+	}{
+		{0, "Average rating: 4.5\n"},
+		{4.2, "Average rating: 4.5 (up 0.3)\n"},
+		{4.8, "Average rating: 4.5 (down 0.3)\n"},
+		{4.48, "Average rating: 4.5 (no change)\n"},
+	}
+	for _, tt := range tests {
+		_, body := composeWeeklyDigest(&digestSummary{BusinessName: "Cafe", AvgRating: 4.5, PreviousAvgRating: tt.previous}, "")
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("previous %v: body missing %q:\n%s", tt.previous, tt.want, body)
+		}
+	}
+
+	subject, body := composeWeeklyDigest(&digestSummary{
+		BusinessName:      "Cafe",
+		NewReviews:        3,
+		PageViews:         120,
+		TopPlatform:       "whatsapp",
+		TopPlatformClicks: 14,
+	}, "https://example.com/dashboard")
+	if subject != "Your week at Cafe" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{
+		"New reviews: 3\n",
+		"Page views: 120\n",
+		"Most clicked link: whatsapp (14 clicks)\n",
+		"See the details on your dashboard: https://example.com/dashboard\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+
+	if _, body := composeWeeklyDigest(&digestSummary{BusinessName: "Cafe"}, ""); !strings.Contains(body, "no clicks this week") || strings.Contains(body, "dashboard:") {
+		t.Errorf("quiet week body:\n%s", body)
+	}
+}
+
+func TestNewWeeklyDigestFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	if newWeeklyDigestFromEnv(&Handlers{}) != nil {
+		t.Error("digest enabled without SMTP")
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("DIGEST_SEND_DAY", "Friday")
+	t.Setenv("DIGEST_SEND_HOUR", "17")
+	if d := newWeeklyDigestFromEnv(&Handlers{}); d.sendDay != time.Friday || d.sendHour != 17 {
+		t.Errorf("schedule = %s %d:00, want Friday 17:00", d.sendDay, d.sendHour)
+	}
+
+	t.Setenv("DIGEST_SEND_DAY", "someday")
+	t.Setenv("DIGEST_SEND_HOUR", "24")
+	if d := newWeeklyDigestFromEnv(&Handlers{}); d.sendDay != time.Monday || d.sendHour != 9 {
+		t.Errorf("invalid settings: schedule = %s %d:00, want the Monday 9:00 default", d.sendDay, d.sendHour)
+	}
+}
@@ -1,28 +1,48 @@
 package main
 
 import (
+	"auto-gbp-review/social_media"
 	"auto-gbp-review/utils"
 	"bytes"
+	"crypto/sha1"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 )
 
+// maxBulkMerchantIDs caps how many merchants a single bulk status request may change
+const maxBulkMerchantIDs = 100
+
 type Handlers struct {
-	db *Database
+	db        *Database
+	pageCache *pageCache
+	storage   Storage
 }
 
 func NewHandlers(db *Database) *Handlers {
-	return &Handlers{db: db}
+	storage, err := NewStorage()
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	return &Handlers{
+		db:        db,
+		pageCache: newPageCacheFromEnv(),
+		storage:   storage,
+	}
 }
 
 // Home page
@@ -37,7 +57,7 @@ func (h *Handlers) Home(c *gin.Context) {
 
 	log.Println("Rendering home page")
 	renderPage(c, "templates/layouts/base.html", "templates/home.html", gin.H{
-		"title": "ViralEngine",
+		"title": branding.AppName,
 		"Year":  time.Now().Year(),
 	})
 	log.Println("Home page rendered")
@@ -53,6 +73,9 @@ func (h *Handlers) BusinessPage(c *gin.Context, businessID string) {
 	if id, parseErr := strconv.Atoi(businessID); parseErr == nil {
 		// It's a numeric ID
 		merchant, err = h.getMerchantByID(id)
+		if err == nil && merchant.DeletedAt != nil {
+			err = sql.ErrNoRows
+		}
 	} else {
 		// It's a slug
 		merchant, err = h.getMerchantBySlug(businessID)
@@ -65,6 +88,36 @@ func (h *Handlers) BusinessPage(c *gin.Context, businessID string) {
 		return
 	}
 
+	// Serve cached HTML to anonymous visitors; logged-in users may be previewing their own page
+	_, cookieErr := c.Cookie("sb_access_token")
+	cacheable := cookieErr != nil
+	locale := detectLocale(c)
+	origin := publicOrigin(c)
+	// The ETag doubles as the page cache key, so a sync can't leave an old page behind
+	var version string
+	if cacheable {
+		// Let browsers and crawlers revalidate instead of re-downloading an unchanged page
+		if lastModified, etag, err := h.businessPageVersion(merchant.ID, locale, origin); err != nil {
+			log.Printf("Failed to compute page version for merchant %d: %v", merchant.ID, err)
+		} else {
+			version = etag
+			c.Header("ETag", etag)
+			c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Vary", "Accept-Language, Cookie")
+			if notModified(c, etag, lastModified) {
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+
+		if html, ok := h.pageCache.Get(merchant.ID, locale, origin, version); ok {
+			c.Header("X-Page-Cache", "HIT")
+			c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+			return
+		}
+	}
+
 	// Get merchant details
 	details, err := h.getMerchantDetails(merchant.ID)
 	if err != nil {
@@ -81,48 +134,281 @@ func (h *Handlers) BusinessPage(c *gin.Context, businessID string) {
 		reviews = []Review{} // Empty slice if no reviews or error
 	}
 
+	// Headline rating from synced reviews; omitted when there are no rated reviews
+	var ratingStats map[string]interface{}
+	if stats, err := socialmedia.NewDB(h.db.DB).GetMerchantReviewStats(merchant.ID); err != nil {
+		log.Printf("Failed to fetch review stats for merchant %d: %v", merchant.ID, err)
+	} else if rated, _ := stats["rated_reviews"].(int); rated > 0 {
+		ratingStats = stats
+	}
+
+	details.GooglePlaceID = h.googlePlaceID(merchant, details)
+	links := generateBusinessLinks(merchant, details, locale)
+
+	// Merchants who haven't connected Google still get a badge from the public Places rating
+	var googleRating map[string]interface{}
+	if ratingStats == nil && links.GooglePlaceID != "" {
+		if rating, total, err := utils.GetGooglePlaceDetails(links.GooglePlaceID); err != nil {
+			log.Printf("Failed to fetch Google rating for merchant %d: %v", merchant.ID, err)
+		} else if total > 0 {
+			googleRating = map[string]interface{}{
+				"rating": fmt.Sprintf("%.1f", rating),
+				"total":  total,
+			}
+		}
+	}
+
+	data := gin.H{
+		"title":           merchant.BusinessName,
+		"merchant":        merchant,
+		"details":         details,
+		"theme":           themeForDetails(details),
+		"noindex":         details.NoIndex,
+		"og":              businessOpenGraph(origin, merchant, details, reviews),
+		"reviews":         reviews,
+		"cleanPhone":      links.CleanPhone,
+		"whatsappWebLink": links.WhatsAppWebLink,
+		"whatsappAppLink": links.WhatsAppAppLink,
+		"googlePlaceID":   links.GooglePlaceID,
+		"wazeURL":         links.WazeURL,
+		"ratingStats":     ratingStats,
+		"googleRating":    googleRating,
+	}
+
+	if !cacheable || h.pageCache == nil || version == "" {
+		renderPage(c, "templates/layouts/base.html", "templates/business.html", data)
+		return
+	}
+
+	html, err := renderPageHTML("templates/layouts/base.html", "templates/business.html", locale, data)
+	if err != nil {
+		log.Printf("Template error rendering business page: %v", err)
+		c.String(http.StatusInternalServerError, "Template error: %s", err.Error())
+		return
+	}
+	h.pageCache.Set(merchant.ID, locale, origin, version, html)
+	c.Header("X-Page-Cache", "MISS")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+}
+
+// businessLinks are the contact and navigation links generated for a merchant's public page
+type businessLinks struct {
+	CleanPhone      string `json:"phone"`
+	WhatsAppWebLink string `json:"whatsapp_web_link,omitempty"`
+	WhatsAppAppLink string `json:"whatsapp_app_link,omitempty"`
+	GooglePlaceID   string `json:"google_place_id,omitempty"`
+	WazeURL         string `json:"waze_url,omitempty"`
+}
+
+// generateBusinessLinks builds the tel:, WhatsApp and Waze links from the merchant's
+// details, using the WhatsApp message for the visitor's locale. The Place ID
+// must already be resolved with googlePlaceID.
+func generateBusinessLinks(merchant *Merchant, details *MerchantDetails, locale string) businessLinks {
+	var links businessLinks
+
 	// Clean phone number for tel: links
-	cleanPhone := ""
 	if details.PhoneNumber != "" {
-		cleanPhone = strings.ReplaceAll(details.PhoneNumber, " ", "")
+		cleanPhone := strings.ReplaceAll(details.PhoneNumber, " ", "")
 		cleanPhone = strings.ReplaceAll(cleanPhone, "(", "")
 		cleanPhone = strings.ReplaceAll(cleanPhone, ")", "")
 		cleanPhone = strings.ReplaceAll(cleanPhone, "-", "")
 		cleanPhone = strings.ReplaceAll(cleanPhone, ".", "")
+		links.CleanPhone = cleanPhone
+	}
+
+	links.GooglePlaceID = details.GooglePlaceID
+
+	if preset := details.whatsAppPresetFor(locale); details.PhoneNumber != "" && preset != "" {
+		links.WhatsAppWebLink = utils.GenerateWhatsAppWebLink(links.CleanPhone, preset)
+		links.WhatsAppAppLink = utils.GenerateWhatsAppAppLink(links.CleanPhone, preset)
 	}
 
-	googlePlaceID := ""
 	if details.Address != "" {
-		if placeID, err := utils.GetGooglePlaceID(merchant.BusinessName, details.Address); err == nil {
-			googlePlaceID = placeID
-		}
+		links.WazeURL = utils.GenerateWazeURL(merchant.BusinessName, details.Address, links.GooglePlaceID, details.Country)
 	}
 
-	whatsappWebLink := ""
-	whatsappAppLink := ""
-	if details.PhoneNumber != "" && details.WhatsAppPresetText != "" {
-		whatsappWebLink = utils.GenerateWhatsAppWebLink(cleanPhone, details.WhatsAppPresetText)
-		whatsappAppLink = utils.GenerateWhatsAppAppLink(cleanPhone, details.WhatsAppPresetText)
+	return links
+}
+
+// googlePlaceID returns the merchant's Google Place ID, asking the Places API
+// only when none is cached for the current name and address
+func (h *Handlers) googlePlaceID(merchant *Merchant, details *MerchantDetails) string {
+	if details.GooglePlaceID != "" || details.Address == "" {
+		return details.GooglePlaceID
 	}
 
-	wazeURL := ""
-	if details.Address != "" {
-		wazeURL = utils.GenerateWazeURL(merchant.BusinessName, details.Address, googlePlaceID)
+	placeID, err := utils.GetGooglePlaceID(merchant.BusinessName, details.Address)
+	if err != nil {
+		return ""
 	}
 
-	renderPage(c, "templates/layouts/base.html", "templates/business.html", gin.H{
-		"title":           merchant.BusinessName,
-		"merchant":        merchant,
-		"details":         details,
-		"reviews":         reviews,
-		"cleanPhone":      cleanPhone,
-		"whatsappWebLink": whatsappWebLink,
-		"whatsappAppLink": whatsappAppLink,
-		"googlePlaceID":   googlePlaceID,
-		"wazeURL":         wazeURL,
+	// updated_at is left alone: caching the ID doesn't change the page
+	if _, err := h.db.Exec("UPDATE merchant_details SET google_place_id = $1 WHERE merchant_id = $2",
+		placeID, merchant.ID); err != nil {
+		log.Printf("Failed to cache Place ID for merchant %d: %v", merchant.ID, err)
+	}
+	return placeID
+}
+
+// invalidatePlaceID clears the cached Google Place ID if the business name it
+// was looked up with is changing
+func (h *Handlers) invalidatePlaceID(merchantID int, businessName string) error {
+	_, err := h.db.Exec(`
+		UPDATE merchant_details SET google_place_id = NULL
+		WHERE merchant_id = $1 AND google_place_id IS NOT NULL
+			AND EXISTS (SELECT 1 FROM merchants WHERE id = $1 AND business_name IS DISTINCT FROM $2)
+	`, merchantID, businessName)
+	return err
+}
+
+// GetPublicProfile returns an active merchant's public page data as JSON
+func (h *Handlers) GetPublicProfile(c *gin.Context) {
+	merchant, err := h.getMerchantBySlug(c.Param("slug"))
+	if err != nil || !merchant.IsActive {
+		respondAPIError(c, http.StatusNotFound, "Merchant not found")
+		return
+	}
+
+	details, err := h.getMerchantDetails(merchant.ID)
+	if err != nil {
+		respondAPIError(c, http.StatusInternalServerError, "Failed to load business details")
+		return
+	}
+
+	reviews, err := h.getActiveReviewsByMerchantID(merchant.ID)
+	if err != nil {
+		log.Printf("Failed to fetch reviews for merchant %d: %v", merchant.ID, err)
+		reviews = []Review{}
+	}
+
+	templates := make([]gin.H, 0, len(reviews))
+	for _, review := range reviews {
+		templates = append(templates, gin.H{
+			"id":          review.ID,
+			"platform":    review.Platform,
+			"review_text": review.ReviewText,
+		})
+	}
+
+	details.GooglePlaceID = h.googlePlaceID(merchant, details)
+	c.JSON(http.StatusOK, gin.H{
+		"business_name":    merchant.BusinessName,
+		"slug":             merchant.Slug,
+		"details":          details,
+		"links":            generateBusinessLinks(merchant, details, detectLocale(c)),
+		"review_templates": templates,
 	})
 }
 
+// maxPublicReviewLimit caps the page size of the public review list
+const maxPublicReviewLimit = 50
+
+// GetPublicReviews returns a merchant's publicly visible synced reviews, newest
+// first. ?order=balanced takes turns between platforms instead, so one busy
+// platform doesn't crowd out the others.
+func (h *Handlers) GetPublicReviews(c *gin.Context) {
+	merchant, err := h.getMerchantBySlug(c.Param("slug"))
+	if err != nil || !merchant.IsActive {
+		respondAPIError(c, http.StatusNotFound, "Merchant not found")
+		return
+	}
+
+	opts := socialmedia.PublicReviewOptions{
+		Platform: c.Query("platform"),
+		Limit:    10,
+		Order:    socialmedia.ParseReviewOrder(c.Query("order")),
+	}
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		opts.Limit = l
+	}
+	if opts.Limit > maxPublicReviewLimit {
+		opts.Limit = maxPublicReviewLimit
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		opts.Offset = o
+	}
+	if r, err := strconv.ParseFloat(c.Query("min_rating"), 64); err == nil && r > 0 {
+		opts.MinRating = r
+	}
+
+	reviews, err := socialmedia.NewDB(h.db.DB).GetPublicReviews(merchant.ID, opts)
+	if err != nil {
+		log.Printf("Failed to fetch public reviews for merchant %d: %v", merchant.ID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to load reviews")
+		return
+	}
+
+	items := make([]gin.H, 0, len(reviews))
+	for _, review := range reviews {
+		items = append(items, gin.H{
+			"platform":         review.Platform,
+			"author_name":      review.AuthorName,
+			"author_photo_url": review.AuthorPhotoURL,
+			"rating":           review.Rating,
+			"review_text":      review.ReviewText,
+			"review_reply":     review.ReviewReply,
+			"reviewed_at":      review.ReviewedAt,
+			"rating_only":      review.RatingOnly,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": items,
+		"order":   opts.Order,
+	})
+}
+
+// businessPageVersion returns when anything shown on the merchant's public page
+// last changed and an ETag for it. Review counts are included so deletions,
+// which leave no updated_at behind, still change the tag. The Google rating
+// badge changes outside the database, so the expiry of its cached Places
+// lookup is folded in too, as is the origin the share preview URLs point at.
+// The ETag also keys the page cache.
+func (h *Handlers) businessPageVersion(merchantID int, locale, origin string) (time.Time, string, error) {
+	var lastModified time.Time
+	var manualReviews, syncedReviews int
+	var placeID string
+	err := h.db.QueryRow(`
+		SELECT GREATEST(
+				m.updated_at::timestamptz,
+				COALESCE((SELECT MAX(updated_at)::timestamptz FROM merchant_details WHERE merchant_id = m.id), m.updated_at::timestamptz),
+				COALESCE((SELECT MAX(updated_at)::timestamptz FROM merchant_reviews WHERE merchant_id = m.id), m.updated_at::timestamptz),
+				COALESCE((SELECT MAX(updated_at)::timestamptz FROM synced_reviews WHERE merchant_id = m.id), m.updated_at::timestamptz)
+			),
+			(SELECT COUNT(*) FROM merchant_reviews WHERE merchant_id = m.id),
+			(SELECT COUNT(*) FROM synced_reviews WHERE merchant_id = m.id),
+			COALESCE((SELECT google_place_id FROM merchant_details WHERE merchant_id = m.id LIMIT 1), '')
+		FROM merchants m
+		WHERE m.id = $1
+	`, merchantID).Scan(&lastModified, &manualReviews, &syncedReviews, &placeID)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	ratingCachedUntil := utils.GooglePlaceDetailsCachedUntil(placeID)
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%d|%d|%d|%d|%s|%s", merchantID, lastModified.UnixNano(), manualReviews, syncedReviews,
+		ratingCachedUntil.Unix(), locale, origin)))
+	return lastModified, `W/"` + hex.EncodeToString(sum[:8]) + `"`, nil
+}
+
+// notModified reports whether the request's conditional headers match the current version.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil {
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
 // MerchantPage displays a merchant's page based on ?bn= parameter
 func (h *Handlers) MerchantPage(c *gin.Context) {
 	businessName := c.Query("bn")
@@ -154,9 +440,9 @@ func (h *Handlers) MerchantPage(c *gin.Context) {
 	// Generate WhatsApp link
 	whatsappWebLink := ""
 	whatsappAppLink := ""
-	if details.PhoneNumber != "" && details.WhatsAppPresetText != "" {
-		whatsappWebLink = utils.GenerateWhatsAppWebLink(details.PhoneNumber, details.WhatsAppPresetText)
-		whatsappAppLink = utils.GenerateWhatsAppAppLink(details.PhoneNumber, details.WhatsAppPresetText)
+	if preset := details.whatsAppPresetFor(detectLocale(c)); details.PhoneNumber != "" && preset != "" {
+		whatsappWebLink = utils.GenerateWhatsAppWebLink(details.PhoneNumber, preset)
+		whatsappAppLink = utils.GenerateWhatsAppAppLink(details.PhoneNumber, preset)
 	}
 
 	// Generate Google Review link
@@ -168,6 +454,8 @@ func (h *Handlers) MerchantPage(c *gin.Context) {
 	renderPage(c, "templates/layouts/base.html", "templates/merchant.html", gin.H{
 		"merchant":           merchant,
 		"details":            details,
+		"theme":              themeForDetails(details),
+		"noindex":            details.NoIndex,
 		"whatsappWebLink":    whatsappWebLink, // Add this
 		"whatsappAppLink":    whatsappAppLink, // Add this
 		"google_review_link": googleReviewLink,
@@ -198,8 +486,8 @@ func (h *Handlers) RegisterPage(c *gin.Context) {
 // }
 
 func (h *Handlers) Logout(c *gin.Context) {
-	c.SetCookie("auth_token", "", -1, "/", "", false, true)
-	c.Redirect(http.StatusFound, "/")
+	c.SetCookie("auth_token", "", -1, cookiePath(), "", false, true)
+	c.Redirect(http.StatusFound, appPath("/"))
 }
 
 // Admin handlers
@@ -208,14 +496,14 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 	var totalMerchants, activeMerchants, totalUsers int
 
 	// Count total merchants
-	err := h.db.QueryRow("SELECT COUNT(*) FROM merchants").Scan(&totalMerchants)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM merchants WHERE deleted_at IS NULL").Scan(&totalMerchants)
 	if err != nil {
 		log.Printf("Error counting total merchants: %v", err)
 		totalMerchants = 0
 	}
 
 	// Count active merchants (is_active = true)
-	err = h.db.QueryRow("SELECT COUNT(*) FROM merchants WHERE is_active = true").Scan(&activeMerchants)
+	err = h.db.QueryRow("SELECT COUNT(*) FROM merchants WHERE is_active = true AND deleted_at IS NULL").Scan(&activeMerchants)
 	if err != nil {
 		log.Printf("Error counting active merchants: %v", err)
 		activeMerchants = 0
@@ -228,16 +516,29 @@ func (h *Handlers) AdminDashboard(c *gin.Context) {
 		totalUsers = 0
 	}
 
+	// Engagement across all merchants over the selected period
+	period := selectAnalyticsPeriod(c)
+	since := time.Now().AddDate(0, 0, -period.Days)
+	analytics, err := h.db.getAdminAnalytics(since, adminTopMerchantsLimit)
+	if err != nil {
+		log.Printf("Error loading admin analytics: %v", err)
+		analytics = nil
+	}
+
 	renderPage(c, "templates/layouts/base.html", "templates/admin/dashboard.html", gin.H{
 		"title":           "Admin Dashboard",
 		"totalMerchants":  totalMerchants,
 		"activeMerchants": activeMerchants,
 		"totalUsers":      totalUsers,
+		"analytics":       analytics,
+		"period":          period,
+		"periods":         analyticsPeriods,
 	})
 }
 
 func (h *Handlers) AdminMerchantsList(c *gin.Context) {
-	merchants, err := h.getAllMerchantsWithDetails()
+	showDeleted := c.Query("deleted") == "true"
+	merchants, err := h.getAllMerchantsWithDetails(showDeleted)
 	if err != nil {
 		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
 			"error": "Failed to load merchants",
@@ -246,8 +547,9 @@ func (h *Handlers) AdminMerchantsList(c *gin.Context) {
 	}
 
 	renderPage(c, "templates/layouts/base.html", "templates/admin/merchants.html", gin.H{
-		"title":     "Manage Merchants",
-		"merchants": merchants,
+		"title":       "Manage Merchants",
+		"merchants":   merchants,
+		"showDeleted": showDeleted,
 	})
 }
 
@@ -352,6 +654,30 @@ func (h *Handlers) AdminCreateMerchant(c *gin.Context) {
 	userEmail := c.PostForm("user_email")
 	password := c.PostForm("password")
 
+	// Generate a slug from the business name when none is given, otherwise normalize it
+	var err error
+	if strings.TrimSpace(slug) == "" {
+		slug, err = h.generateUniqueSlug(businessName)
+		if err != nil {
+			log.Printf("Failed to generate slug: %v", err)
+			renderPage(c, "templates/layouts/base.html", "templates/admin/merchant_form.html", gin.H{
+				"title": "Add New Merchant",
+				"error": "Failed to generate slug",
+			})
+			return
+		}
+	} else {
+		slug = utils.Slugify(slug)
+		taken, err := h.slugExists(slug)
+		if slug == "" || err != nil || taken {
+			renderPage(c, "templates/layouts/base.html", "templates/admin/merchant_form.html", gin.H{
+				"title": "Add New Merchant",
+				"error": "Slug is invalid or already in use. Leave it blank to generate one automatically.",
+			})
+			return
+		}
+	}
+
 	// Check if user already exists
 	existingUserID, err := h.getAuthUserByEmail(userEmail)
 
@@ -366,8 +692,9 @@ func (h *Handlers) AdminCreateMerchant(c *gin.Context) {
 		return
 	}
 
-	// User doesn't exist - create new user AND role in one transaction
-	authUserID, err = h.createSupabaseUserWithRole(userEmail, password, "merchant")
+	// User doesn't exist - create the auth user, then its role, merchant and
+	// details in one transaction so a failure leaves nothing half-created
+	authUserID, err = h.createSupabaseUser(userEmail, password)
 	if err != nil {
 		log.Printf("Failed to create user: %v", err)
 		renderPage(c, "templates/layouts/base.html", "templates/admin/merchant_form.html", gin.H{
@@ -379,10 +706,13 @@ func (h *Handlers) AdminCreateMerchant(c *gin.Context) {
 
 	log.Printf("Successfully created user: %s with ID: %s", userEmail, authUserID)
 
-	// Create merchant with auth_user_id
-	merchantID, err := h.createMerchantWithAuthUserID(authUserID, businessName, slug)
+	merchantID, err := h.createMerchantAccount(authUserID, "merchant", businessName, slug)
 	if err != nil {
 		log.Printf("Failed to create merchant: %v", err)
+		// The auth user lives outside the transaction, so remove it by hand
+		if delErr := deleteSupabaseAdminUser(GetSupabaseURL(), GetSupabaseServiceKey(), authUserID); delErr != nil {
+			log.Printf("Failed to remove auth user %s after merchant creation failed: %v", authUserID, delErr)
+		}
 		renderPage(c, "templates/layouts/base.html", "templates/admin/merchant_form.html", gin.H{
 			"title": "Add New Merchant",
 			"error": "Failed to create merchant: " + err.Error(),
@@ -390,12 +720,6 @@ func (h *Handlers) AdminCreateMerchant(c *gin.Context) {
 		return
 	}
 
-	// Create default merchant details
-	err = h.createMerchantDetails(merchantID)
-	if err != nil {
-		log.Printf("Failed to create merchant details: %v", err)
-	}
-
 	log.Printf("Successfully created merchant ID: %d for user: %s", merchantID, userEmail)
 
 	// Log audit event
@@ -406,7 +730,7 @@ func (h *Handlers) AdminCreateMerchant(c *gin.Context) {
 		"auth_user_id":  authUserID,
 	})
 
-	c.Redirect(http.StatusFound, "/admin/merchants")
+	c.Redirect(http.StatusFound, appPath("/admin/merchants"))
 }
 
 func (h *Handlers) AdminEditMerchant(c *gin.Context) {
@@ -455,32 +779,79 @@ func (h *Handlers) AdminUpdateMerchant(c *gin.Context) {
 	slug := c.PostForm("slug")
 	isActive := c.PostForm("is_active") == "true"
 
-	err = h.updateMerchant(id, businessName, slug, isActive)
+	urls, urlErrors := normalizeProfileURLs(c)
+	theme, themeErrors := normalizeThemeFields(c)
+	urlErrors = append(urlErrors, themeErrors...)
+	country, err := normalizeCountry(c.PostForm("country"))
 	if err != nil {
-		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
-			"error": "Failed to update merchant: " + err.Error(),
-		})
-		return
+		urlErrors = append(urlErrors, "Country "+err.Error())
 	}
 
 	// Update merchant details
 	details := &MerchantDetails{
 		MerchantID:         id,
 		Address:            c.PostForm("address"),
+		Country:            country,
 		PhoneNumber:        c.PostForm("phone_number"),
 		WhatsAppPresetText: c.PostForm("whatsapp_preset_text"),
-		FacebookURL:        c.PostForm("facebook_url"),
+		WhatsAppPresets:    whatsAppPresetsFromForm(c),
+		FacebookURL:        urls["facebook_url"],
 		XiaohongshuID:      c.PostForm("xiaohongshu_id"),
-		TiktokURL:          c.PostForm("tiktok_url"),
-		InstagramURL:       c.PostForm("instagram_url"),
-		ThreadsURL:         c.PostForm("threads_url"),
-		WebsiteURL:         c.PostForm("website_url"),
-		GooglePlayURL:      c.PostForm("google_play_url"),
-		AppStoreURL:        c.PostForm("app_store_url"),
-		GoogleMapsURL:      c.PostForm("google_maps_url"),
-		WazeURL:            c.PostForm("waze_url"),
+		TiktokURL:          urls["tiktok_url"],
+		InstagramURL:       urls["instagram_url"],
+		ThreadsURL:         urls["threads_url"],
+		WebsiteURL:         urls["website_url"],
+		GooglePlayURL:      urls["google_play_url"],
+		AppStoreURL:        urls["app_store_url"],
+		GoogleMapsURL:      urls["google_maps_url"],
+		WazeURL:            urls["waze_url"],
 		LogoURL:            c.PostForm("logo_url"),
-		ThemeColor:         c.PostForm("theme_color"),
+		ThemeColor:         theme["theme_color"],
+		SecondaryColor:     theme["secondary_color"],
+		TextColor:          theme["text_color"],
+		FontFamily:         theme["font_family"],
+		NoIndex:            c.PostForm("noindex") == "true",
+	}
+
+	if len(urlErrors) > 0 {
+		merchant, err := h.getMerchantByID(id)
+		if err != nil {
+			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+				"error": "Merchant not found",
+			})
+			return
+		}
+		merchant.BusinessName = businessName
+		merchant.Slug = slug
+		merchant.IsActive = isActive
+
+		renderPage(c, "templates/layouts/base.html", "templates/admin/merchant_edit.html", gin.H{
+			"title":    "Edit Merchant",
+			"merchant": merchant,
+			"details":  details,
+			"error":    strings.Join(urlErrors, ", "),
+		})
+		return
+	}
+
+	err = h.updateMerchant(id, businessName, slug, isActive)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to update merchant: " + err.Error(),
+		})
+		return
+	}
+
+	// Update analytics sampling rate (1 = exact tracking)
+	if sampleRate, err := strconv.Atoi(c.PostForm("analytics_sample_rate")); err == nil && sampleRate >= 1 {
+		if err := h.updateMerchantSampleRate(id, sampleRate); err != nil {
+			log.Printf("Failed to update analytics sample rate for merchant %d: %v", id, err)
+		}
+	}
+
+	// Takes effect from the next sync
+	if err := h.updateMerchantCrossPlatformDedup(id, c.PostForm("cross_platform_dedup") == "true"); err != nil {
+		log.Printf("Failed to update cross-platform dedup for merchant %d: %v", id, err)
 	}
 
 	err = h.updateMerchantDetails(details)
@@ -491,7 +862,7 @@ func (h *Handlers) AdminUpdateMerchant(c *gin.Context) {
 		return
 	}
 
-	c.Redirect(http.StatusFound, "/admin/merchants")
+	c.Redirect(http.StatusFound, appPath("/admin/merchants"))
 }
 
 func (h *Handlers) AdminDeleteMerchant(c *gin.Context) {
@@ -512,15 +883,162 @@ func (h *Handlers) AdminDeleteMerchant(c *gin.Context) {
 		return
 	}
 
-	c.Redirect(http.StatusFound, "/admin/merchants")
+	h.logAuditEvent(c, "merchant_deleted", "merchant", idStr, map[string]interface{}{})
+
+	c.Redirect(http.StatusFound, appPath("/admin/merchants"))
+}
+
+// AdminDuplicateMerchant copies a merchant's profile into a new merchant with a
+// fresh slug, owned by the same user or by the user given in owner_email
+func (h *Handlers) AdminDuplicateMerchant(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Invalid merchant ID",
+		})
+		return
+	}
+
+	source, err := h.getMerchantByID(id)
+	if err != nil || source.DeletedAt != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Merchant not found",
+		})
+		return
+	}
+
+	authUserID := source.AuthUserID
+	if ownerEmail := strings.TrimSpace(c.PostForm("owner_email")); ownerEmail != "" {
+		authUserID, err = h.getAuthUserByEmail(ownerEmail)
+		if err != nil {
+			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+				"error": "No user found with email " + ownerEmail,
+			})
+			return
+		}
+	}
+
+	businessName := strings.TrimSpace(c.PostForm("business_name"))
+	if businessName == "" {
+		businessName = source.BusinessName
+	}
+
+	slug, err := h.generateUniqueSlug(businessName)
+	if err != nil {
+		log.Printf("Failed to generate slug for duplicate of merchant %d: %v", id, err)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to duplicate merchant",
+		})
+		return
+	}
+
+	copyReviews := c.PostForm("copy_reviews") == "true"
+	newID, err := h.duplicateMerchant(id, authUserID, businessName, slug, copyReviews)
+	if err != nil {
+		log.Printf("Failed to duplicate merchant %d: %v", id, err)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to duplicate merchant",
+		})
+		return
+	}
+
+	h.logAuditEvent(c, "merchant_duplicated", "merchant", fmt.Sprintf("%d", newID), map[string]interface{}{
+		"source_id":     id,
+		"business_name": businessName,
+		"slug":          slug,
+		"auth_user_id":  authUserID,
+		"copy_reviews":  copyReviews,
+	})
+
+	c.Redirect(http.StatusFound, appPath(fmt.Sprintf("/admin/merchants/%d/edit", newID)))
+}
+
+// AdminRestoreMerchant brings back a soft-deleted merchant
+func (h *Handlers) AdminRestoreMerchant(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Invalid merchant ID",
+		})
+		return
+	}
+
+	if err := h.restoreMerchant(id); err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to restore merchant",
+		})
+		return
+	}
+
+	h.logAuditEvent(c, "merchant_restored", "merchant", idStr, map[string]interface{}{})
+
+	c.Redirect(http.StatusFound, appPath("/admin/merchants"))
+}
+
+// AdminHardDeleteMerchant permanently deletes a soft-deleted merchant.
+// The admin must confirm by typing the merchant's slug.
+func (h *Handlers) AdminHardDeleteMerchant(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Invalid merchant ID",
+		})
+		return
+	}
+
+	merchant, err := h.getMerchantByID(id)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Merchant not found",
+		})
+		return
+	}
+
+	if merchant.DeletedAt == nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Merchant must be deleted before it can be permanently deleted",
+		})
+		return
+	}
+
+	if c.PostForm("confirm_slug") != merchant.Slug {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Confirmation did not match the merchant slug",
+		})
+		return
+	}
+
+	if err := h.hardDeleteMerchant(id); err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to permanently delete merchant",
+		})
+		return
+	}
+
+	h.logAuditEvent(c, "merchant_hard_deleted", "merchant", idStr, map[string]interface{}{
+		"business_name": merchant.BusinessName,
+		"slug":          merchant.Slug,
+	})
+
+	c.Redirect(http.StatusFound, appPath("/admin/merchants?deleted=true"))
 }
 
 // Merchant handlers
 func (h *Handlers) MerchantDashboard(c *gin.Context) {
 	userID := c.GetString("user_id")
 	log.Printf("Dashboard: Looking for merchants with auth_user_id: %s", userID)
-	merchants, err := h.getMerchantsByAuthUserID(userID)
+	selected, merchants, err := h.selectedMerchant(c)
 	log.Printf("Dashboard: Found %d merchants, error: %v", len(merchants), err)
+	if err == errMerchantNotOwned {
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "You don't have access to that business",
+		})
+		return
+	}
 	if err != nil {
 		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
 			"error": "Failed to load your businesses",
@@ -528,11 +1046,10 @@ func (h *Handlers) MerchantDashboard(c *gin.Context) {
 		return
 	}
 
-	// Get analytics stats for first merchant (most users have one business)
+	// Get analytics stats for the selected merchant (the first unless ?merchant_id= picks another)
 	var stats map[string]interface{}
-	if len(merchants) > 0 {
-		merchantID := merchants[0].ID
-		stats = h.getMerchantStats(merchantID)
+	if selected != nil {
+		stats = h.getMerchantStats(selected.ID)
 	} else {
 		stats = map[string]interface{}{
 			"total_views":       0,
@@ -544,9 +1061,11 @@ func (h *Handlers) MerchantDashboard(c *gin.Context) {
 	}
 
 	renderPage(c, "templates/layouts/base.html", "templates/merchant_dashboard.html", gin.H{
-		"title":     "Dashboard",
-		"merchants": merchants,
-		"stats":     stats,
+		"title":            "Dashboard",
+		"merchants":        merchants,
+		"selectedMerchant": selected,
+		"stats":            stats,
+		"shortLinks":       h.getShortLinks(c, merchants),
 	})
 }
 
@@ -554,26 +1073,30 @@ func (h *Handlers) MerchantDashboard(c *gin.Context) {
 func (h *Handlers) getMerchantStats(merchantID int) map[string]interface{} {
 	stats := make(map[string]interface{})
 
-	// Total page views
+	// Total page views (weighted, since sampled rows stand in for several views).
+	// Bot traffic is excluded from every figure here.
 	var totalViews int
-	h.db.QueryRow("SELECT COUNT(*) FROM page_views WHERE merchant_id = $1", merchantID).Scan(&totalViews)
+	var viewsSampled bool
+	h.db.QueryRow("SELECT COALESCE(SUM(weight), 0), COALESCE(BOOL_OR(weight > 1), false) FROM page_views WHERE merchant_id = $1 AND NOT is_bot", merchantID).
+		Scan(&totalViews, &viewsSampled)
 	stats["total_views"] = totalViews
+	stats["views_sampled"] = viewsSampled
 
 	// Total link clicks
 	var totalClicks int
-	h.db.QueryRow("SELECT COUNT(*) FROM link_clicks WHERE merchant_id = $1", merchantID).Scan(&totalClicks)
+	h.db.QueryRow("SELECT COUNT(*) FROM link_clicks WHERE merchant_id = $1 AND NOT is_bot", merchantID).Scan(&totalClicks)
 	stats["total_clicks"] = totalClicks
 
 	// Active reviews count
 	var reviewsCount int
-	h.db.QueryRow("SELECT COUNT(*) FROM merchant_reviews WHERE merchant_id = $1 AND is_active = true", merchantID).Scan(&reviewsCount)
+	h.db.QueryRow("SELECT COUNT(*) FROM merchant_reviews WHERE merchant_id = $1 AND is_active = true AND deleted_at IS NULL", merchantID).Scan(&reviewsCount)
 	stats["reviews_count"] = reviewsCount
 
 	// Views in last 7 days (for chart)
 	rows, err := h.db.Query(`
-		SELECT DATE(created_at) as date, COUNT(*) as count
+		SELECT DATE(created_at) as date, SUM(weight) as count
 		FROM page_views
-		WHERE merchant_id = $1 AND created_at > NOW() - INTERVAL '7 days'
+		WHERE merchant_id = $1 AND NOT is_bot AND created_at > NOW() - INTERVAL '7 days'
 		GROUP BY DATE(created_at)
 		ORDER BY date
 	`, merchantID)
@@ -597,7 +1120,7 @@ func (h *Handlers) getMerchantStats(merchantID int) map[string]interface{} {
 	clicksRows, err := h.db.Query(`
 		SELECT platform, COUNT(*) as count
 		FROM link_clicks
-		WHERE merchant_id = $1
+		WHERE merchant_id = $1 AND NOT is_bot
 		GROUP BY platform
 		ORDER BY count DESC
 	`, merchantID)
@@ -616,16 +1139,27 @@ func (h *Handlers) getMerchantStats(merchantID int) map[string]interface{} {
 
 	// Unique visitors (based on distinct IP addresses)
 	var uniqueVisitors int
-	h.db.QueryRow("SELECT COUNT(DISTINCT ip_address) FROM page_views WHERE merchant_id = $1", merchantID).Scan(&uniqueVisitors)
+	h.db.QueryRow("SELECT COUNT(DISTINCT ip_address) FROM page_views WHERE merchant_id = $1 AND NOT is_bot", merchantID).Scan(&uniqueVisitors)
 	stats["unique_visitors"] = uniqueVisitors
 
+	// Repeat views suppressed by the dedup window
+	var repeatViews int
+	h.db.QueryRow("SELECT COALESCE(SUM(repeats), 0) FROM page_view_repeats WHERE merchant_id = $1", merchantID).Scan(&repeatViews)
+	stats["repeat_views"] = repeatViews
+
 	return stats
 }
 
 func (h *Handlers) MerchantProfile(c *gin.Context) {
-	userID := c.GetString("user_id")
 	userEmail := c.GetString("user_email")
-	merchants, err := h.getMerchantsByAuthUserID(userID)
+	merchant, merchants, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "You don't have access to that business",
+		})
+		return
+	}
 	if err != nil {
 		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
 			"error": "Failed to load your businesses",
@@ -633,11 +1167,8 @@ func (h *Handlers) MerchantProfile(c *gin.Context) {
 		return
 	}
 
-	var merchant *Merchant
 	var details *MerchantDetails
-
-	if len(merchants) > 0 {
-		merchant = &merchants[0]
+	if merchant != nil {
 		details, _ = h.getMerchantDetails(merchant.ID)
 	}
 
@@ -649,6 +1180,7 @@ func (h *Handlers) MerchantProfile(c *gin.Context) {
 	renderPage(c, "templates/layouts/base.html", "templates/merchant_profile.html", gin.H{
 		"title":     "Profile",
 		"merchant":  merchant,
+		"merchants": merchants,
 		"details":   details,
 		"reviews":   reviews,
 		"userEmail": userEmail,
@@ -671,6 +1203,15 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 		errors = append(errors, "URL Slug is required")
 	}
 
+	urls, urlErrors := normalizeProfileURLs(c)
+	errors = append(errors, urlErrors...)
+	theme, themeErrors := normalizeThemeFields(c)
+	errors = append(errors, themeErrors...)
+	country, countryErr := normalizeCountry(c.PostForm("country"))
+	if countryErr != nil {
+		errors = append(errors, "Country "+countryErr.Error())
+	}
+
 	// If there are validation errors, return them
 	if len(errors) > 0 {
 		// Check if this is an AJAX request
@@ -693,11 +1234,9 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 		}
 
 		// For non-AJAX requests, get existing data and render page with errors
-		merchants, _ := h.getMerchantsByAuthUserID(userID)
-		var merchant *Merchant
+		merchant, _, _ := h.selectedMerchant(c)
 		var details *MerchantDetails
-		if len(merchants) > 0 {
-			merchant = &merchants[0]
+		if merchant != nil {
 			details, _ = h.getMerchantDetails(merchant.ID)
 		}
 
@@ -713,7 +1252,21 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 	}
 
 	// Get or create merchant (your existing logic)
-	merchants, err := h.getMerchantsByAuthUserID(userID)
+	selected, _, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		if c.GetHeader("HX-Request") != "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"errors":  []string{"You don't have access to that business"},
+			})
+			return
+		}
+		c.Status(http.StatusForbidden)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "You don't have access to that business",
+		})
+		return
+	}
 	if err != nil {
 		if c.GetHeader("HX-Request") != "" {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -731,7 +1284,7 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 	var merchantID int
 	var currentDetails *MerchantDetails
 
-	if len(merchants) == 0 {
+	if selected == nil {
 		// Create new merchant
 		merchantID, err = h.createMerchantWithAuthUserID(userID, businessName, slug)
 		if err != nil {
@@ -753,7 +1306,7 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 			log.Printf("Failed to create merchant details: %v", err)
 		}
 	} else {
-		merchantID = merchants[0].ID
+		merchantID = selected.ID
 		// Get current details to preserve existing logo if no new one uploaded
 		currentDetails, _ = h.getMerchantDetails(merchantID)
 
@@ -776,7 +1329,7 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 	}
 
 	// Handle logo upload or URL
-	var logoURL string
+	var logoURL, replacedLogo string
 
 	// Check if a file was uploaded
 	file, header, err := c.Request.FormFile("logo_file")
@@ -795,11 +1348,9 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 				return
 			}
 			// Get existing data for redisplay
-			merchants, _ := h.getMerchantsByAuthUserID(userID)
-			var merchant *Merchant
+			merchant, _ := h.getMerchantByID(merchantID)
 			var details *MerchantDetails
-			if len(merchants) > 0 {
-				merchant = &merchants[0]
+			if merchant != nil {
 				details, _ = h.getMerchantDetails(merchant.ID)
 			}
 
@@ -812,15 +1363,13 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 			return
 		}
 
-		// Upload to Supabase using the function from storage.go
-		logoURL, err = uploadToSupabase(file, header, "logos")
+		// Upload to the configured storage backend
+		logoURL, err = h.storage.Upload(file, header, logoFolder)
 		if err != nil {
 			// Get existing data for redisplay
-			merchants, _ := h.getMerchantsByAuthUserID(userID)
-			var merchant *Merchant
+			merchant, _ := h.getMerchantByID(merchantID)
 			var details *MerchantDetails
-			if len(merchants) > 0 {
-				merchant = &merchants[0]
+			if merchant != nil {
 				details, _ = h.getMerchantDetails(merchant.ID)
 			}
 
@@ -832,6 +1381,10 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 			})
 			return
 		}
+
+		if currentDetails != nil {
+			replacedLogo = currentDetails.LogoURL
+		}
 	} else {
 		// No file uploaded, check URL field
 		urlFromForm := strings.TrimSpace(c.PostForm("logo_url"))
@@ -847,20 +1400,26 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 	details := &MerchantDetails{
 		MerchantID:         merchantID,
 		Address:            c.PostForm("address"),
+		Country:            country,
 		PhoneNumber:        c.PostForm("phone_number"),
 		WhatsAppPresetText: c.PostForm("whatsapp_preset_text"),
-		FacebookURL:        c.PostForm("facebook_url"),
+		WhatsAppPresets:    whatsAppPresetsFromForm(c),
+		FacebookURL:        urls["facebook_url"],
 		XiaohongshuID:      c.PostForm("xiaohongshu_id"),
-		TiktokURL:          c.PostForm("tiktok_url"),
-		InstagramURL:       c.PostForm("instagram_url"),
-		ThreadsURL:         c.PostForm("threads_url"),
-		WebsiteURL:         c.PostForm("website_url"),
-		GooglePlayURL:      c.PostForm("google_play_url"),
-		AppStoreURL:        c.PostForm("app_store_url"),
-		GoogleMapsURL:      c.PostForm("google_maps_url"),
-		WazeURL:            c.PostForm("waze_url"),
+		TiktokURL:          urls["tiktok_url"],
+		InstagramURL:       urls["instagram_url"],
+		ThreadsURL:         urls["threads_url"],
+		WebsiteURL:         urls["website_url"],
+		GooglePlayURL:      urls["google_play_url"],
+		AppStoreURL:        urls["app_store_url"],
+		GoogleMapsURL:      urls["google_maps_url"],
+		WazeURL:            urls["waze_url"],
 		LogoURL:            logoURL, // This will be either uploaded URL or form URL or existing URL
-		ThemeColor:         c.PostForm("theme_color"),
+		ThemeColor:         theme["theme_color"],
+		SecondaryColor:     theme["secondary_color"],
+		TextColor:          theme["text_color"],
+		FontFamily:         theme["font_family"],
+		NoIndex:            c.PostForm("noindex") == "true",
 	}
 
 	err = h.updateMerchantDetails(details)
@@ -871,6 +1430,9 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 		})
 		return
 	}
+	if replacedLogo != "" {
+		h.deleteReplacedLogo(replacedLogo, merchantID)
+	}
 
 	// Handle review updates if present
 	reviewUpdatesJSON := c.PostForm("review_updates")
@@ -906,21 +1468,21 @@ func (h *Handlers) UpdateMerchantProfile(c *gin.Context) {
 		return
 	}
 
-	c.Redirect(http.StatusFound, "/dashboard/profile?success=1")
+	c.Redirect(http.StatusFound, appPath(fmt.Sprintf("/dashboard/profile?merchant_id=%d&success=1", merchantID)))
 }
 
 func (h *Handlers) ToggleMerchantStatus(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merchant ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid merchant ID")
 		return
 	}
 
 	// Get merchant details before toggling for audit log
 	merchant, err := h.getMerchantByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusNotFound, "Merchant not found")
 		return
 	}
 	oldStatus := merchant.IsActive
@@ -928,25 +1490,68 @@ func (h *Handlers) ToggleMerchantStatus(c *gin.Context) {
 	// Toggle status
 	err = h.toggleMerchantStatus(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle status"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to toggle status")
 		return
 	}
 
 	// Get new status
 	newStatus := !oldStatus
 
-	// Log audit event
+	// Log audit event
+	action := "merchant_disabled"
+	if newStatus {
+		action = "merchant_enabled"
+	}
+	h.logAuditEvent(c, action, "merchant", idStr, map[string]interface{}{
+		"business_name": merchant.BusinessName,
+		"old_status":    oldStatus,
+		"new_status":    newStatus,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"status": "toggled"})
+}
+
+// BulkMerchantStatus enables or disables several merchants at once
+func (h *Handlers) BulkMerchantStatus(c *gin.Context) {
+	var req struct {
+		IDs    []int `json:"ids"`
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Active == nil {
+		respondAPIError(c, http.StatusBadRequest, "Expected {\"ids\": [...], \"active\": true|false}")
+		return
+	}
+	if len(req.IDs) == 0 {
+		respondAPIError(c, http.StatusBadRequest, "No merchant IDs given")
+		return
+	}
+	if len(req.IDs) > maxBulkMerchantIDs {
+		respondAPIError(c, http.StatusBadRequest, fmt.Sprintf("At most %d merchants can be updated at once", maxBulkMerchantIDs))
+		return
+	}
+
+	updated, err := h.setMerchantsActive(req.IDs, *req.Active)
+	if err != nil {
+		log.Printf("Failed to bulk update merchant status: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to update merchants")
+		return
+	}
+
 	action := "merchant_disabled"
-	if newStatus {
+	if *req.Active {
 		action = "merchant_enabled"
 	}
-	h.logAuditEvent(c, action, "merchant", idStr, map[string]interface{}{
-		"business_name": merchant.BusinessName,
-		"old_status":    oldStatus,
-		"new_status":    newStatus,
-	})
+	for _, merchant := range updated {
+		h.pageCache.Invalidate(merchant.ID)
+		h.logAuditEvent(c, action, "merchant", strconv.Itoa(merchant.ID), map[string]interface{}{
+			"business_name": merchant.BusinessName,
+			"old_status":    !*req.Active,
+			"new_status":    *req.Active,
+			"bulk":          true,
+		})
+	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "toggled"})
+	c.JSON(http.StatusOK, gin.H{"updated": len(updated)})
 }
 
 func generateGoogleReviewLink(address string) string {
@@ -964,33 +1569,44 @@ type User struct {
 }
 
 type Merchant struct {
-	ID           int       `json:"id"`
-	AuthUserID   string    `json:"auth_user_id"` // UUID from auth.users
-	BusinessName string    `json:"business_name"`
-	Slug         string    `json:"slug"`
-	IsActive     bool      `json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UserEmail    string    `json:"user_email,omitempty"` // For admin views (joined from auth.users)
+	ID                 int        `json:"id"`
+	AuthUserID         string     `json:"auth_user_id"` // UUID from auth.users
+	BusinessName       string     `json:"business_name"`
+	Slug               string     `json:"slug"`
+	IsActive           bool       `json:"is_active"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	SampleRate         int        `json:"analytics_sample_rate"` // Store 1 in N page views
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"`  // Set when soft-deleted
+	CrossPlatformDedup bool       `json:"cross_platform_dedup"`  // Hide reviews duplicated across connected platforms
+	UserEmail          string     `json:"user_email,omitempty"`  // For admin views (joined from auth.users)
 }
 
 type MerchantDetails struct {
-	ID                 int    `json:"id"`
-	MerchantID         int    `json:"merchant_id"`
-	Address            string `json:"address"`
-	PhoneNumber        string `json:"phone_number"`
-	WhatsAppPresetText string `json:"whatsapp_preset_text"`
-	FacebookURL        string `json:"facebook_url"`
-	XiaohongshuID      string `json:"xiaohongshu_id"`
-	TiktokURL          string `json:"tiktok_url"`
-	InstagramURL       string `json:"instagram_url"`
-	ThreadsURL         string `json:"threads_url"`
-	WebsiteURL         string `json:"website_url"`
-	GooglePlayURL      string `json:"google_play_url"`
-	AppStoreURL        string `json:"app_store_url"`
-	GoogleMapsURL      string `json:"google_maps_url"`
-	WazeURL            string `json:"waze_url"`
-	LogoURL            string `json:"logo_url"`
-	ThemeColor         string `json:"theme_color"`
+	ID                 int               `json:"id"`
+	MerchantID         int               `json:"merchant_id"`
+	Address            string            `json:"address"`
+	Country            string            `json:"country"` // ISO 3166-1 alpha-2 code, empty when unknown
+	PhoneNumber        string            `json:"phone_number"`
+	WhatsAppPresetText string            `json:"whatsapp_preset_text"`
+	WhatsAppPresets    map[string]string `json:"whatsapp_presets,omitempty"` // Per-locale overrides of WhatsAppPresetText
+	FacebookURL        string            `json:"facebook_url"`
+	XiaohongshuID      string            `json:"xiaohongshu_id"`
+	TiktokURL          string            `json:"tiktok_url"`
+	InstagramURL       string            `json:"instagram_url"`
+	ThreadsURL         string            `json:"threads_url"`
+	WebsiteURL         string            `json:"website_url"`
+	GooglePlayURL      string            `json:"google_play_url"`
+	AppStoreURL        string            `json:"app_store_url"`
+	GoogleMapsURL      string            `json:"google_maps_url"`
+	WazeURL            string            `json:"waze_url"`
+	LogoURL            string            `json:"logo_url"`
+	ThemeColor         string            `json:"theme_color"`
+	SecondaryColor     string            `json:"secondary_color"`
+	TextColor          string            `json:"text_color"`
+	FontFamily         string            `json:"font_family"`
+	NoIndex            bool              `json:"noindex"` // Ask search engines not to index the business page
+	GooglePlaceID      string            `json:"-"`       // Cached Places API lookup, cleared when the name or address changes
 }
 
 type Review struct {
@@ -1027,222 +1643,289 @@ func (h *Handlers) createMerchant(userID int, businessName, slug string) (int, e
 
 func (h *Handlers) getMerchantByID(id int) (*Merchant, error) {
 	merchant := &Merchant{}
-	err := h.db.QueryRow("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants WHERE id = $1", id).
-		Scan(&merchant.ID, &merchant.AuthUserID, &merchant.BusinessName, &merchant.Slug, &merchant.IsActive, &merchant.CreatedAt)
+	err := h.db.QueryRow("SELECT id, auth_user_id, business_name, slug, is_active, created_at, updated_at, analytics_sample_rate, deleted_at, cross_platform_dedup FROM merchants WHERE id = $1", id).
+		Scan(&merchant.ID, &merchant.AuthUserID, &merchant.BusinessName, &merchant.Slug, &merchant.IsActive, &merchant.CreatedAt, &merchant.UpdatedAt, &merchant.SampleRate, &merchant.DeletedAt, &merchant.CrossPlatformDedup)
 	return merchant, err
 }
 
 func (h *Handlers) updateMerchant(id int, businessName, slug string, isActive bool) error {
+	if err := h.invalidatePlaceID(id, businessName); err != nil {
+		log.Printf("Failed to clear cached Place ID for merchant %d: %v", id, err)
+	}
 	_, err := h.db.Exec("UPDATE merchants SET business_name = $1, slug = $2, is_active = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4",
 		businessName, slug, isActive, id)
 	return err
 }
 
-func (h *Handlers) deleteMerchant(id int) error {
-	_, err := h.db.Exec("DELETE FROM merchants WHERE id = $1", id)
+func (h *Handlers) updateMerchantSampleRate(id, sampleRate int) error {
+	_, err := h.db.Exec("UPDATE merchants SET analytics_sample_rate = $1 WHERE id = $2", sampleRate, id)
 	return err
 }
 
-func (h *Handlers) toggleMerchantStatus(id int) error {
-	_, err := h.db.Exec("UPDATE merchants SET is_active = NOT is_active, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+func (h *Handlers) updateMerchantCrossPlatformDedup(id int, enabled bool) error {
+	_, err := h.db.Exec("UPDATE merchants SET cross_platform_dedup = $1 WHERE id = $2", enabled, id)
 	return err
 }
 
-// Database operations for merchant details
-func (h *Handlers) createMerchantDetails(merchantID int) error {
-	_, err := h.db.Exec("INSERT INTO merchant_details (merchant_id) VALUES ($1)", merchantID)
+// deleteMerchant soft-deletes a merchant so it can be restored later
+func (h *Handlers) deleteMerchant(id int) error {
+	_, err := h.db.Exec("UPDATE merchants SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
+	h.pageCache.Invalidate(id)
 	return err
 }
 
-func (h *Handlers) updateMerchantDetails(details *MerchantDetails) error {
-	_, err := h.db.Exec(`UPDATE merchant_details SET 
-		address = $1, phone_number = $2, whatsapp_preset_text = $3, facebook_url = $4, 
-		xiaohongshu_id = $5, tiktok_url = $6, instagram_url = $7, threads_url = $8,
-		website_url = $9, google_play_url = $10, app_store_url = $11, google_maps_url = $12,
-		waze_url = $13, logo_url = $14, theme_color = $15, updated_at = CURRENT_TIMESTAMP
-		WHERE merchant_id = $16`,
-		details.Address, details.PhoneNumber, details.WhatsAppPresetText, details.FacebookURL,
-		details.XiaohongshuID, details.TiktokURL, details.InstagramURL, details.ThreadsURL,
-		details.WebsiteURL, details.GooglePlayURL, details.AppStoreURL, details.GoogleMapsURL,
-		details.WazeURL, details.LogoURL, details.ThemeColor, details.MerchantID)
+func (h *Handlers) restoreMerchant(id int) error {
+	_, err := h.db.Exec("UPDATE merchants SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
 	return err
 }
 
-// Existing database helper methods
-func (h *Handlers) getUserByEmail(email string) (*User, error) {
-	user := &User{}
-	err := h.db.QueryRow("SELECT id, email, password_hash, role, created_at FROM users WHERE email = $1", email).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.CreatedAt)
-	return user, err
-}
-
-// getAuthUserByEmail gets user from auth.users table
-func (h *Handlers) getAuthUserByEmail(email string) (string, error) {
-	var userID string
-	err := h.db.QueryRow("SELECT id FROM auth.users WHERE email = $1", email).Scan(&userID)
-	return userID, err
+// hardDeleteMerchant permanently removes a merchant, cascading to details, reviews and connections
+func (h *Handlers) hardDeleteMerchant(id int) error {
+	_, err := h.db.Exec("DELETE FROM merchants WHERE id = $1", id)
+	h.pageCache.Invalidate(id)
+	return err
 }
 
-// createSupabaseUserWithRole creates a new user via Supabase Admin API and sets their role
-func (h *Handlers) createSupabaseUserWithRole(email, password, role string) (string, error) {
-	supabaseURL := GetSupabaseURL()
-	serviceRoleKey := GetSupabaseServiceKey()
+// setMerchantsActive sets is_active on the given merchants in one transaction and
+// returns the merchants whose status actually changed
+func (h *Handlers) setMerchantsActive(ids []int, active bool) ([]Merchant, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	log.Printf("Creating Supabase user for email: %s with role: %s", email, role)
-	log.Printf("Supabase URL: %s", supabaseURL)
+	rows, err := tx.Query(`
+		UPDATE merchants SET is_active = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2) AND is_active <> $1 AND deleted_at IS NULL
+		RETURNING id, business_name
+	`, active, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
 
-	// Prepare request body - don't set user_metadata to avoid trigger conflict
-	requestBody := map[string]interface{}{
-		"email":         email,
-		"password":      password,
-		"email_confirm": true, // Auto-confirm email
+	var updated []Merchant
+	for rows.Next() {
+		var merchant Merchant
+		if err := rows.Scan(&merchant.ID, &merchant.BusinessName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		updated = append(updated, merchant)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
+	return updated, nil
+}
 
-	log.Printf("Request body: %s", string(jsonData))
+func (h *Handlers) toggleMerchantStatus(id int) error {
+	_, err := h.db.Exec("UPDATE merchants SET is_active = NOT is_active, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	return err
+}
 
-	// Make HTTP request to Supabase Admin API
-	url := fmt.Sprintf("%s/auth/v1/admin/users", supabaseURL)
-	log.Printf("Making request to: %s", url)
+// logoFolder is the storage folder merchant logo uploads go to
+const logoFolder = "logos"
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// deleteReplacedLogo removes a logo that an upload replaced, if it was uploaded
+// to logoFolder in our bucket and no other merchant (e.g. a duplicated one)
+// still uses it. logo_url can be set by hand, so nothing else is ever deleted.
+func (h *Handlers) deleteReplacedLogo(logoURL string, merchantID int) {
+	objectPath, ok := h.storage.ObjectPath(logoURL)
+	if !ok || !strings.HasPrefix(objectPath, logoFolder+"/") ||
+		path.Clean(objectPath) != objectPath || strings.ContainsAny(objectPath, "%?#\\") {
+		return
 	}
+	if h.logoSharedWithOtherMerchant(logoURL, merchantID) {
+		return
+	}
+	if err := h.storage.Delete(objectPath); err != nil {
+		log.Printf("Failed to delete previous logo %s: %v", objectPath, err)
+	}
+}
 
-	req.Header.Set("apikey", serviceRoleKey)
-	req.Header.Set("Authorization", "Bearer "+serviceRoleKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// Database operations for merchant details
+// logoSharedWithOtherMerchant reports whether another merchant's details reference the logo URL
+func (h *Handlers) logoSharedWithOtherMerchant(logoURL string, merchantID int) bool {
+	if logoURL == "" {
+		return false
+	}
+	var shared bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM merchant_details WHERE logo_url = $1 AND merchant_id <> $2)", logoURL, merchantID).Scan(&shared)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		// Err on the side of keeping the file
+		return true
 	}
-	defer resp.Body.Close()
+	return shared
+}
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("Failed to decode response body, status: %d", resp.StatusCode)
-		return "", fmt.Errorf("failed to decode response: %w", err)
+// duplicateMerchant copies a merchant and its details (and optionally its review
+// templates) into a new merchant in a single transaction
+func (h *Handlers) duplicateMerchant(sourceID int, authUserID, businessName, slug string, copyReviews bool) (int, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	log.Printf("Response status: %d", resp.StatusCode)
-	log.Printf("Response body: %+v", result)
+	var newID int
+	err = tx.QueryRow(`
+		INSERT INTO merchants (auth_user_id, business_name, slug, is_active, analytics_sample_rate, cross_platform_dedup)
+		SELECT $2, $3, $4, is_active, analytics_sample_rate, cross_platform_dedup FROM merchants WHERE id = $1
+		RETURNING id
+	`, sourceID, authUserID, businessName, slug).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO merchant_details (merchant_id, address, country, phone_number, whatsapp_preset_text, whatsapp_presets, facebook_url,
+			xiaohongshu_id, tiktok_url, instagram_url, threads_url, website_url, google_play_url,
+			app_store_url, google_maps_url, waze_url, logo_url, theme_color, secondary_color,
+			text_color, font_family, noindex)
+		SELECT $2, address, country, phone_number, whatsapp_preset_text, whatsapp_presets, facebook_url,
+			xiaohongshu_id, tiktok_url, instagram_url, threads_url, website_url, google_play_url,
+			app_store_url, google_maps_url, waze_url, logo_url, theme_color, secondary_color,
+			text_color, font_family, noindex
+		FROM merchant_details WHERE merchant_id = $1
+	`, sourceID, newID)
+	if err != nil {
+		return 0, err
+	}
+	if copied, _ := result.RowsAffected(); copied == 0 {
+		if _, err := tx.Exec("INSERT INTO merchant_details (merchant_id) VALUES ($1)", newID); err != nil {
+			return 0, err
+		}
+	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		errorMsg := "Unknown error"
-		if msg, ok := result["message"].(string); ok {
-			errorMsg = msg
-		} else if msg, ok := result["error"].(string); ok {
-			errorMsg = msg
-		} else if msg, ok := result["msg"].(string); ok {
-			errorMsg = msg
+	if copyReviews {
+		_, err = tx.Exec(`
+			INSERT INTO merchant_reviews (merchant_id, platform, review_text, is_active)
+			SELECT $2, platform, review_text, is_active FROM merchant_reviews WHERE merchant_id = $1 AND deleted_at IS NULL
+		`, sourceID, newID)
+		if err != nil {
+			return 0, err
 		}
-		log.Printf("API error - Status: %d, Message: %s, Full response: %+v", resp.StatusCode, errorMsg, result)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, errorMsg)
 	}
 
-	// Extract user ID from response
-	userID, ok := result["id"].(string)
-	if !ok {
-		log.Printf("User ID not found in response: %+v", result)
-		return "", fmt.Errorf("user ID not found in response")
+	if err := tx.Commit(); err != nil {
+		return 0, err
 	}
+	return newID, nil
+}
 
-	log.Printf("Successfully created user with ID: %s, now creating role entry", userID)
+// createMerchantAccount gives a newly created auth user its role, a merchant and
+// default merchant details in a single transaction
+func (h *Handlers) createMerchantAccount(authUserID, role, businessName, slug string) (int, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 
-	// Manually insert into user_roles table (bypassing trigger)
-	_, err = h.db.Exec(`
+	// Insert the role directly rather than relying on the signup trigger
+	_, err = tx.Exec(`
 		INSERT INTO public.user_roles (user_id, role)
 		VALUES ($1, $2)
 		ON CONFLICT (user_id) DO UPDATE SET role = $2
-	`, userID, role)
-
+	`, authUserID, role)
 	if err != nil {
-		log.Printf("Failed to create user_roles entry: %v", err)
-		return "", fmt.Errorf("user created but failed to set role: %w", err)
+		return 0, fmt.Errorf("failed to set role: %w", err)
 	}
 
-	log.Printf("Successfully created user_roles entry for user: %s", userID)
-	return userID, nil
-}
-
-// createSupabaseUser creates a new user via Supabase Admin API
-func (h *Handlers) createSupabaseUser(email, password string) (string, error) {
-	supabaseURL := GetSupabaseURL()
-	serviceRoleKey := GetSupabaseServiceKey()
-
-	log.Printf("Creating Supabase user for email: %s", email)
-	log.Printf("Supabase URL: %s", supabaseURL)
-
-	// Prepare request body
-	requestBody := map[string]interface{}{
-		"email":         email,
-		"password":      password,
-		"email_confirm": true, // Auto-confirm email
+	var merchantID int
+	err = tx.QueryRow("INSERT INTO merchants (auth_user_id, business_name, slug) VALUES ($1, $2, $3) RETURNING id",
+		authUserID, businessName, slug).Scan(&merchantID)
+	if err != nil {
+		return 0, err
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	if _, err := tx.Exec("INSERT INTO merchant_details (merchant_id) VALUES ($1)", merchantID); err != nil {
+		return 0, err
 	}
 
-	log.Printf("Request body: %s", string(jsonData))
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return merchantID, nil
+}
 
-	// Make HTTP request to Supabase Admin API
-	url := fmt.Sprintf("%s/auth/v1/admin/users", supabaseURL)
-	log.Printf("Making request to: %s", url)
+func (h *Handlers) createMerchantDetails(merchantID int) error {
+	_, err := h.db.Exec("INSERT INTO merchant_details (merchant_id) VALUES ($1)", merchantID)
+	return err
+}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(jsonData)))
+func (h *Handlers) updateMerchantDetails(details *MerchantDetails) error {
+	_, err := h.db.Exec(`UPDATE merchant_details SET 
+		address = $1, phone_number = $2, whatsapp_preset_text = $3, facebook_url = $4, 
+		xiaohongshu_id = $5, tiktok_url = $6, instagram_url = $7, threads_url = $8,
+		website_url = $9, google_play_url = $10, app_store_url = $11, google_maps_url = $12,
+		waze_url = $13, logo_url = $14, theme_color = $15, secondary_color = NULLIF($16, ''),
+		text_color = NULLIF($17, ''), font_family = NULLIF($18, ''), noindex = $19, whatsapp_presets = $20,
+		google_place_id = CASE WHEN COALESCE(address, '') = $1 THEN google_place_id END, country = $21,
+		updated_at = CURRENT_TIMESTAMP
+		WHERE merchant_id = $22`,
+		details.Address, details.PhoneNumber, details.WhatsAppPresetText, details.FacebookURL,
+		details.XiaohongshuID, details.TiktokURL, details.InstagramURL, details.ThreadsURL,
+		details.WebsiteURL, details.GooglePlayURL, details.AppStoreURL, details.GoogleMapsURL,
+		details.WazeURL, details.LogoURL, details.ThemeColor, details.SecondaryColor,
+		details.TextColor, details.FontFamily, details.NoIndex, encodeWhatsAppPresets(details.WhatsAppPresets),
+		details.Country, details.MerchantID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
+	return h.touchMerchant(details.MerchantID)
+}
 
-	req.Header.Set("apikey", serviceRoleKey)
-	req.Header.Set("Authorization", "Bearer "+serviceRoleKey)
-	req.Header.Set("Content-Type", "application/json")
+// touchMerchant bumps a merchant's updated_at so cached public pages are refreshed
+func (h *Handlers) touchMerchant(merchantID int) error {
+	_, err := h.db.Exec("UPDATE merchants SET updated_at = CURRENT_TIMESTAMP WHERE id = $1", merchantID)
+	h.pageCache.Invalidate(merchantID)
+	return err
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+// touchMerchantByReview bumps updated_at for the merchant owning a review template
+func (h *Handlers) touchMerchantByReview(reviewID int) error {
+	var merchantID int
+	err := h.db.QueryRow(`
+		UPDATE merchants SET updated_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT merchant_id FROM merchant_reviews WHERE id = $1)
+		RETURNING id
+	`, reviewID).Scan(&merchantID)
+	if err == sql.ErrNoRows {
+		return nil
 	}
-	defer resp.Body.Close()
+	h.pageCache.Invalidate(merchantID)
+	return err
+}
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("Failed to decode response body, status: %d", resp.StatusCode)
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
+// Existing database helper methods
+func (h *Handlers) getUserByEmail(email string) (*User, error) {
+	user := &User{}
+	err := h.db.QueryRow("SELECT id, email, password_hash, role, created_at FROM users WHERE email = $1", email).
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	return user, err
+}
 
-	log.Printf("Response status: %d", resp.StatusCode)
-	log.Printf("Response body: %+v", result)
+// getAuthUserByEmail gets user from auth.users table
+func (h *Handlers) getAuthUserByEmail(email string) (string, error) {
+	var userID string
+	err := h.db.QueryRow("SELECT id FROM auth.users WHERE email = $1", email).Scan(&userID)
+	return userID, err
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		errorMsg := "Unknown error"
-		if msg, ok := result["message"].(string); ok {
-			errorMsg = msg
-		} else if msg, ok := result["error"].(string); ok {
-			errorMsg = msg
-		} else if msg, ok := result["msg"].(string); ok {
-			errorMsg = msg
-		}
-		log.Printf("API error - Status: %d, Message: %s, Full response: %+v", resp.StatusCode, errorMsg, result)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, errorMsg)
-	}
+// createSupabaseUser creates a new user via Supabase Admin API
+func (h *Handlers) createSupabaseUser(email, password string) (string, error) {
+	log.Printf("Creating Supabase user for email: %s", email)
 
-	// Extract user ID from response
-	userID, ok := result["id"].(string)
-	if !ok {
-		log.Printf("User ID not found in response: %+v", result)
-		return "", fmt.Errorf("user ID not found in response")
+	userID, err := createSupabaseAdminUser(GetSupabaseURL(), GetSupabaseServiceKey(), email, password)
+	if err != nil {
+		return "", err
 	}
 
 	log.Printf("Successfully created user with ID: %s", userID)
@@ -1258,25 +1941,30 @@ func (h *Handlers) createUser(email, passwordHash, role string) (int, error) {
 
 func (h *Handlers) getMerchantBySlug(slug string) (*Merchant, error) {
 	merchant := &Merchant{}
-	err := h.db.QueryRow("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants WHERE slug = $1 AND is_active = true", slug).
-		Scan(&merchant.ID, &merchant.AuthUserID, &merchant.BusinessName, &merchant.Slug, &merchant.IsActive, &merchant.CreatedAt)
+	err := h.db.QueryRow("SELECT id, auth_user_id, business_name, slug, is_active, created_at, updated_at FROM merchants WHERE slug = $1 AND is_active = true AND deleted_at IS NULL", slug).
+		Scan(&merchant.ID, &merchant.AuthUserID, &merchant.BusinessName, &merchant.Slug, &merchant.IsActive, &merchant.CreatedAt, &merchant.UpdatedAt)
 	return merchant, err
 }
 
 func (h *Handlers) getMerchantDetails(merchantID int) (*MerchantDetails, error) {
 	details := &MerchantDetails{}
+	var presets []byte
 	err := h.db.QueryRow(`SELECT id, merchant_id, COALESCE(address, ''), COALESCE(phone_number, ''), 
 		COALESCE(whatsapp_preset_text, ''), COALESCE(facebook_url, ''), COALESCE(xiaohongshu_id, ''),
 		COALESCE(tiktok_url, ''), COALESCE(instagram_url, ''), COALESCE(threads_url, ''),
 		COALESCE(website_url, ''), COALESCE(google_play_url, ''), COALESCE(app_store_url, ''),
 		COALESCE(google_maps_url, ''), COALESCE(waze_url, ''), COALESCE(logo_url, ''), 
-		COALESCE(theme_color, '#3B82F6')
-		FROM merchant_details WHERE merchant_id = $1`, merchantID).
+		COALESCE(theme_color, $2), COALESCE(secondary_color, ''), COALESCE(text_color, ''),
+		COALESCE(font_family, ''), noindex, whatsapp_presets, COALESCE(google_place_id, ''),
+		COALESCE(country, '')
+		FROM merchant_details WHERE merchant_id = $1`, merchantID, branding.DefaultThemeColor).
 		Scan(&details.ID, &details.MerchantID, &details.Address, &details.PhoneNumber,
 			&details.WhatsAppPresetText, &details.FacebookURL, &details.XiaohongshuID,
 			&details.TiktokURL, &details.InstagramURL, &details.ThreadsURL,
 			&details.WebsiteURL, &details.GooglePlayURL, &details.AppStoreURL,
-			&details.GoogleMapsURL, &details.WazeURL, &details.LogoURL, &details.ThemeColor)
+			&details.GoogleMapsURL, &details.WazeURL, &details.LogoURL, &details.ThemeColor,
+			&details.SecondaryColor, &details.TextColor, &details.FontFamily, &details.NoIndex, &presets,
+			&details.GooglePlaceID, &details.Country)
 
 	if err == sql.ErrNoRows {
 		// Create default details if none exist
@@ -1286,12 +1974,13 @@ func (h *Handlers) getMerchantDetails(merchantID int) (*MerchantDetails, error)
 		}
 		return h.getMerchantDetails(merchantID)
 	}
+	details.WhatsAppPresets = decodeWhatsAppPresets(presets)
 
 	return details, err
 }
 
 func (h *Handlers) getAllMerchants() ([]Merchant, error) {
-	rows, err := h.db.Query("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants ORDER BY created_at DESC")
+	rows, err := h.db.Query("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants WHERE deleted_at IS NULL ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -1308,11 +1997,18 @@ func (h *Handlers) getAllMerchants() ([]Merchant, error) {
 	return merchants, nil
 }
 
-func (h *Handlers) getAllMerchantsWithDetails() ([]Merchant, error) {
+// getAllMerchantsWithDetails lists merchants for the admin, either live or soft-deleted ones
+func (h *Handlers) getAllMerchantsWithDetails(deleted bool) ([]Merchant, error) {
+	condition := "m.deleted_at IS NULL"
+	if deleted {
+		condition = "m.deleted_at IS NOT NULL"
+	}
+
 	rows, err := h.db.Query(`
-		SELECT m.id, m.auth_user_id, m.business_name, m.slug, m.is_active, m.created_at, u.email
+		SELECT m.id, m.auth_user_id, m.business_name, m.slug, m.is_active, m.created_at, u.email, m.deleted_at
 		FROM merchants m
 		LEFT JOIN auth.users u ON m.auth_user_id = u.id
+		WHERE ` + condition + `
 		ORDER BY m.created_at DESC
 	`)
 	if err != nil {
@@ -1324,7 +2020,7 @@ func (h *Handlers) getAllMerchantsWithDetails() ([]Merchant, error) {
 	for rows.Next() {
 		var merchant Merchant
 		if err := rows.Scan(&merchant.ID, &merchant.AuthUserID, &merchant.BusinessName, &merchant.Slug,
-			&merchant.IsActive, &merchant.CreatedAt, &merchant.UserEmail); err != nil {
+			&merchant.IsActive, &merchant.CreatedAt, &merchant.UserEmail, &merchant.DeletedAt); err != nil {
 			return nil, err
 		}
 		merchants = append(merchants, merchant)
@@ -1338,6 +2034,33 @@ func (h *Handlers) getMerchantsByUserID(userID int) ([]Merchant, error) {
 	return []Merchant{}, nil
 }
 
+// slugExists reports whether a merchant already uses the slug
+func (h *Handlers) slugExists(slug string) (bool, error) {
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM merchants WHERE slug = $1)", slug).Scan(&exists)
+	return exists, err
+}
+
+// generateUniqueSlug slugifies the business name and appends -2, -3, etc. until it is unused
+func (h *Handlers) generateUniqueSlug(businessName string) (string, error) {
+	base := utils.Slugify(businessName)
+	if base == "" {
+		base = "merchant"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		exists, err := h.slugExists(slug)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
 // Auth.users UUID-based functions (migrated from auth_user_helpers.go)
 func (h *Handlers) createMerchantWithAuthUserID(authUserID, businessName, slug string) (int, error) {
 	var merchantID int
@@ -1348,7 +2071,7 @@ func (h *Handlers) createMerchantWithAuthUserID(authUserID, businessName, slug s
 
 func (h *Handlers) getMerchantsByAuthUserID(authUserID string) ([]Merchant, error) {
 	log.Printf("getMerchantsByAuthUserID: Querying for auth_user_id = %s", authUserID)
-	rows, err := h.db.Query("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants WHERE auth_user_id = $1 ORDER BY created_at DESC", authUserID)
+	rows, err := h.db.Query("SELECT id, auth_user_id, business_name, slug, is_active, created_at FROM merchants WHERE auth_user_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC", authUserID)
 	if err != nil {
 		return nil, err
 	}
@@ -1370,7 +2093,7 @@ func (h *Handlers) getReviewsByMerchantID(merchantID int) ([]Review, error) {
 	rows, err := h.db.Query(`
 		SELECT id, merchant_id, platform, review_text, is_active, created_at, updated_at
 		FROM merchant_reviews
-		WHERE merchant_id = $1
+		WHERE merchant_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`, merchantID)
 	if err != nil {
@@ -1394,7 +2117,7 @@ func (h *Handlers) getActiveReviewsByMerchantID(merchantID int) ([]Review, error
 	rows, err := h.db.Query(`
 		SELECT id, merchant_id, platform, review_text, is_active, created_at, updated_at
 		FROM merchant_reviews
-		WHERE merchant_id = $1 AND is_active = true
+		WHERE merchant_id = $1 AND is_active = true AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`, merchantID)
 	if err != nil {
@@ -1416,13 +2139,25 @@ func (h *Handlers) getActiveReviewsByMerchantID(merchantID int) ([]Review, error
 
 func (h *Handlers) createReview(merchantID int, platform, reviewText string) error {
 	log.Printf("createReview: Inserting merchantID=%d, platform=%s, reviewText=%s", merchantID, platform, reviewText)
-	_, err := h.db.Exec(`
+	if err := insertReview(h.db, merchantID, platform, reviewText); err != nil {
+		log.Printf("createReview SQL error: %v", err)
+		return err
+	}
+	return h.touchMerchant(merchantID)
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertReview adds an active review template without touching the merchant,
+// so callers inserting several in a transaction can do that once at the end
+func insertReview(db sqlExecer, merchantID int, platform, reviewText string) error {
+	_, err := db.Exec(`
 		INSERT INTO merchant_reviews (merchant_id, platform, review_text, is_active)
 		VALUES ($1, $2, $3, true)
 	`, merchantID, platform, reviewText)
-	if err != nil {
-		log.Printf("createReview SQL error: %v", err)
-	}
 	return err
 }
 
@@ -1430,14 +2165,84 @@ func (h *Handlers) updateReview(reviewID int, platform, reviewText string, isAct
 	_, err := h.db.Exec(`
 		UPDATE merchant_reviews
 		SET platform = $2, review_text = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`, reviewID, platform, reviewText, isActive)
-	return err
+	if err != nil {
+		return err
+	}
+	return h.touchMerchantByReview(reviewID)
 }
 
+// deleteReview soft-deletes a review template; it can be restored within reviewUndoWindow
 func (h *Handlers) deleteReview(reviewID int) error {
-	_, err := h.db.Exec("DELETE FROM merchant_reviews WHERE id = $1", reviewID)
-	return err
+	_, err := h.db.Exec(`
+		UPDATE merchant_reviews
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`, reviewID)
+	if err != nil {
+		return err
+	}
+	if err := h.touchMerchantByReview(reviewID); err != nil {
+		log.Printf("Failed to touch merchant for review %d: %v", reviewID, err)
+	}
+	return nil
+}
+
+func (h *Handlers) getReviewByID(reviewID int) (*Review, error) {
+	review := &Review{}
+	err := h.db.QueryRow(`
+		SELECT id, merchant_id, platform, review_text, is_active, created_at, updated_at
+		FROM merchant_reviews
+		WHERE id = $1 AND deleted_at IS NULL
+	`, reviewID).Scan(&review.ID, &review.MerchantID, &review.Platform,
+		&review.ReviewText, &review.IsActive, &review.CreatedAt, &review.UpdatedAt)
+	return review, err
+}
+
+// reviewTextExists reports whether the merchant already has a template with the same text for a platform
+func (h *Handlers) reviewTextExists(merchantID int, platform, reviewText string) (bool, error) {
+	var exists bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM merchant_reviews
+			WHERE merchant_id = $1 AND platform = $2 AND review_text = $3 AND deleted_at IS NULL
+		)
+	`, merchantID, platform, reviewText).Scan(&exists)
+	return exists, err
+}
+
+// duplicateReview copies a review template's text into a new template for another platform
+func (h *Handlers) duplicateReview(source *Review, platform string) (*Review, error) {
+	review := &Review{
+		MerchantID: source.MerchantID,
+		Platform:   platform,
+		ReviewText: source.ReviewText,
+	}
+	err := h.db.QueryRow(`
+		INSERT INTO merchant_reviews (merchant_id, platform, review_text, is_active)
+		VALUES ($1, $2, $3, true)
+		RETURNING id, is_active, created_at, updated_at
+	`, source.MerchantID, platform, source.ReviewText).
+		Scan(&review.ID, &review.IsActive, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return review, h.touchMerchant(source.MerchantID)
+}
+
+// merchantOwnsReview checks that a review template belongs to one of the user's merchants
+func (h *Handlers) merchantOwnsReview(userID string, review *Review) bool {
+	merchants, err := h.getMerchantsByAuthUserID(userID)
+	if err != nil {
+		return false
+	}
+	for _, merchant := range merchants {
+		if merchant.ID == review.MerchantID {
+			return true
+		}
+	}
+	return false
 }
 
 // API handlers for reviews
@@ -1445,15 +2250,14 @@ func (h *Handlers) AddReview(c *gin.Context) {
 	userID := c.GetString("user_id")
 	log.Printf("AddReview: userID = %s", userID)
 
-	// Get merchant for this user
-	merchants, err := h.getMerchantsByAuthUserID(userID)
-	if err != nil || len(merchants) == 0 {
+	// Get the selected merchant for this user
+	merchantID, err := h.getMerchantIDFromContext(c)
+	if err != nil || merchantID == 0 {
 		log.Printf("AddReview error: No merchant found for user %s, err: %v", userID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No merchant found"})
+		respondAPIError(c, http.StatusBadRequest, "No merchant found")
 		return
 	}
 
-	merchantID := merchants[0].ID
 	platform := c.PostForm("platform")
 	reviewText := c.PostForm("text")
 
@@ -1461,7 +2265,7 @@ func (h *Handlers) AddReview(c *gin.Context) {
 
 	if platform == "" || reviewText == "" {
 		log.Printf("AddReview error: Missing fields - platform=%s, reviewText=%s", platform, reviewText)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Platform and text are required"})
+		respondAPIError(c, http.StatusBadRequest, "Platform and text are required")
 		return
 	}
 
@@ -1469,7 +2273,7 @@ func (h *Handlers) AddReview(c *gin.Context) {
 	err = h.createReview(merchantID, platform, reviewText)
 	if err != nil {
 		log.Printf("AddReview error: Failed to create review - %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to create review")
 		return
 	}
 
@@ -1510,7 +2314,7 @@ func (h *Handlers) AddReview(c *gin.Context) {
 						<span class="ml-2 text-sm text-gray-600">Active</span>
 					</label>
 					<button type="button" class="text-red-600 hover:text-red-800 text-sm"
-							hx-delete="/api/reviews/%d"
+							hx-delete="%s/api/reviews/%d"
 							hx-target="closest .review-item"
 							hx-swap="outerHTML"
 							hx-confirm="Are you sure you want to delete this review template?">Delete</button>
@@ -1533,6 +2337,7 @@ func (h *Handlers) AddReview(c *gin.Context) {
 		func() string { if newReview.Platform == "facebook" { return "selected" } else { return "" } }(),
 		newReview.ID,
 		func() string { if newReview.IsActive { return "checked" } else { return "" } }(),
+		basePath,
 		newReview.ID,
 		newReview.ID,
 		template.JSEscapeString(newReview.ReviewText),
@@ -1546,7 +2351,13 @@ func (h *Handlers) DeleteReview(c *gin.Context) {
 	reviewIDStr := c.Param("id")
 	reviewID, err := strconv.Atoi(reviewIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	review, err := h.getReviewByID(reviewID)
+	if err != nil || !h.merchantOwnsReview(c.GetString("user_id"), review) {
+		respondAPIError(c, http.StatusNotFound, "Review template not found")
 		return
 	}
 
@@ -1563,15 +2374,77 @@ func (h *Handlers) DeleteReview(c *gin.Context) {
 		return
 	}
 
-	// Return empty response with success toast (HTMX will remove the element)
+	// Return empty response with success toast (HTMX will remove the element);
+	// Undo restores the template and reloads the list
 	c.Header("Content-Type", "text/html")
-	c.String(http.StatusOK, `<script>
+	c.String(http.StatusOK, fmt.Sprintf(`<script>
 		iziToast.success({
 			title: 'Template Deleted!',
-			message: 'Review template has been deleted successfully.',
+			message: 'Review template has been deleted. You can undo this for 30 days.',
 			icon: 'fas fa-trash-alt',
+			timeout: 10000,
+			buttons: [
+				['<button><b>Undo</b></button>', function (instance, toast) {
+					instance.hide({}, toast);
+					fetch('%s/api/reviews/%d/restore', {method: 'POST'})
+						.then(response => {
+							if (response.ok) {
+								window.location.reload();
+							} else {
+								iziToast.error({title: 'Error', message: 'Failed to restore review template'});
+							}
+						});
+				}, true],
+			],
 		});
-	</script>`)
+	</script>`, basePath, reviewID))
+}
+
+// DuplicateReview copies a review template to another platform
+func (h *Handlers) DuplicateReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	platform := c.PostForm("platform")
+	if platform != "google" && platform != "facebook" {
+		respondAPIError(c, http.StatusBadRequest, "Platform must be google or facebook")
+		return
+	}
+
+	source, err := h.getReviewByID(reviewID)
+	if err != nil || !h.merchantOwnsReview(c.GetString("user_id"), source) {
+		respondAPIError(c, http.StatusNotFound, "Review template not found")
+		return
+	}
+
+	if source.Platform == platform {
+		respondAPIError(c, http.StatusBadRequest, "Template already belongs to this platform")
+		return
+	}
+
+	// Skip if the same text already exists for the target platform
+	exists, err := h.reviewTextExists(source.MerchantID, platform, source.ReviewText)
+	if err != nil {
+		log.Printf("DuplicateReview error: Failed to check existing templates - %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to duplicate review")
+		return
+	}
+	if exists {
+		respondAPIError(c, http.StatusConflict, "An identical template already exists for this platform")
+		return
+	}
+
+	review, err := h.duplicateReview(source, platform)
+	if err != nil {
+		log.Printf("DuplicateReview error: Failed to create review - %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to duplicate review")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"review": review})
 }
 
 // GetReviewsData returns reviews data as JSON for a specific merchant
@@ -1579,7 +2452,7 @@ func (h *Handlers) GetReviewsData(c *gin.Context) {
 	merchantIDStr := c.Param("merchantId")
 	merchantID, err := strconv.Atoi(merchantIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merchant ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid merchant ID")
 		return
 	}
 
@@ -1701,6 +2574,11 @@ func (h *Handlers) GetReviewModal(c *gin.Context) {
 
 // logAuditEvent logs an admin action to the audit_logs table
 func (h *Handlers) logAuditEvent(c *gin.Context, action, targetType, targetID string, details map[string]interface{}) {
+	h.db.logAuditEvent(c, action, targetType, targetID, details)
+}
+
+// logAuditEvent records the current user's action in the audit_logs table
+func (db *Database) logAuditEvent(c *gin.Context, action, targetType, targetID string, details map[string]interface{}) {
 	// Get admin user info from context (set by middleware)
 	userID, _ := c.Get("user_id")
 	userEmail, _ := c.Get("user_email")
@@ -1719,7 +2597,7 @@ func (h *Handlers) logAuditEvent(c *gin.Context, action, targetType, targetID st
 	}
 
 	// Insert audit log
-	_, err = h.db.Exec(`
+	_, err = db.Exec(`
 		INSERT INTO audit_logs (user_id, user_email, action, target_type, target_id, details, ip_address, user_agent)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`, userID, userEmail, action, targetType, targetID, detailsJSON, ipAddress, userAgent)
@@ -1738,30 +2616,57 @@ func (h *Handlers) logAuditEvent(c *gin.Context, action, targetType, targetID st
 func (h *Handlers) TrackPageView(c *gin.Context) {
 	merchantIDStr := c.Query("merchant_id")
 	if merchantIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "merchant_id required"})
+		respondAPIError(c, http.StatusBadRequest, "merchant_id required")
 		return
 	}
 
 	merchantID, err := strconv.Atoi(merchantIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant_id"})
+		respondAPIError(c, http.StatusBadRequest, "invalid merchant_id")
+		return
+	}
+
+	sampleRate, ok := h.admitTrackingRequest(c, merchantID)
+	if !ok {
 		return
 	}
 
-	// Get tracking data
 	ipAddress := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
+	isBot := isBotUserAgent(userAgent)
+
+	// Reloads and prefetches from a visitor who was just here are counted
+	// separately rather than stored as another view
+	recent, err := h.viewedRecently(merchantID, ipAddress, isBot)
+	if err != nil {
+		log.Printf("Failed to check recent page views: %v", err)
+	} else if recent {
+		if !isBot {
+			if err := h.recordRepeatView(merchantID); err != nil {
+				log.Printf("Failed to count repeat page view: %v", err)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "repeat"})
+		return
+	}
+
+	// Apply the merchant's sampling rate: store 1 in N views, weighted by N
+	if sampleRate > 1 && rand.Intn(sampleRate) != 0 {
+		c.JSON(http.StatusOK, gin.H{"status": "tracked"})
+		return
+	}
+
 	referrer := c.GetHeader("Referer")
 
-	// Insert page view
+	// Insert page view; bot traffic is kept but flagged so dashboards can leave it out
 	_, err = h.db.Exec(`
-		INSERT INTO page_views (merchant_id, ip_address, user_agent, referrer)
-		VALUES ($1, $2, $3, $4)
-	`, merchantID, ipAddress, userAgent, referrer)
+		INSERT INTO page_views (merchant_id, ip_address, user_agent, referrer, weight, is_bot)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, merchantID, ipAddress, userAgent, referrer, sampleRate, isBot)
 
 	if err != nil {
 		log.Printf("Failed to log page view: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to track view"})
+		respondAPIError(c, http.StatusInternalServerError, "failed to track view")
 		return
 	}
 
@@ -1776,13 +2681,17 @@ func (h *Handlers) TrackLinkClick(c *gin.Context) {
 	linkType := c.Query("type")
 
 	if merchantIDStr == "" || platform == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "merchant_id and platform required"})
+		respondAPIError(c, http.StatusBadRequest, "merchant_id and platform required")
 		return
 	}
 
 	merchantID, err := strconv.Atoi(merchantIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant_id"})
+		respondAPIError(c, http.StatusBadRequest, "invalid merchant_id")
+		return
+	}
+
+	if _, ok := h.admitTrackingRequest(c, merchantID); !ok {
 		return
 	}
 
@@ -1791,22 +2700,26 @@ func (h *Handlers) TrackLinkClick(c *gin.Context) {
 		linkType = "social"
 	}
 
-	// Get tracking data
-	ipAddress := c.ClientIP()
-	userAgent := c.GetHeader("User-Agent")
+	if err := h.recordLinkClick(c, merchantID, platform, linkType); err != nil {
+		log.Printf("Failed to log link click: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "failed to track click")
+		return
+	}
 
-	// Insert link click
-	_, err = h.db.Exec(`
-		INSERT INTO link_clicks (merchant_id, platform, link_type, ip_address, user_agent)
-		VALUES ($1, $2, $3, $4, $5)
-	`, merchantID, platform, linkType, ipAddress, userAgent)
+	c.JSON(http.StatusOK, gin.H{"status": "tracked"})
+}
 
+// recordLinkClick inserts a link click with the visitor's IP and user agent, flagging bot traffic
+func (h *Handlers) recordLinkClick(c *gin.Context, merchantID int, platform, linkType string) error {
+	userAgent := c.GetHeader("User-Agent")
+	_, err := h.db.Exec(`
+		INSERT INTO link_clicks (merchant_id, platform, link_type, ip_address, user_agent, is_bot)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, merchantID, platform, linkType, c.ClientIP(), userAgent, isBotUserAgent(userAgent))
 	if err != nil {
-		log.Printf("Failed to log link click: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to track click"})
-		return
+		return err
 	}
 
 	log.Printf("Link click tracked: merchant_id=%d, platform=%s, type=%s", merchantID, platform, linkType)
-	c.JSON(http.StatusOK, gin.H{"status": "tracked"})
+	return nil
 }
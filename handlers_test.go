@@ -0,0 +1,688 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postForm sends a form POST through the router
+func postForm(router *gin.Engine, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// asUser is middleware standing in for SupabaseAuthMiddleware
+func asUser(userID string) gin.HandlerFunc {
+	return func(c *gin.Context) { c.Set("user_id", userID) }
+}
+
+// newTemplateStore backs the review template queries with reviews, all
+// owned by merchant 1 of user-1. Inserted templates are appended. deleted
+// holds the deleted_at of soft-deleted templates; purged ones are removed
+// from reviews.
+func newTemplateStore(t *testing.T, reviews *[]Review, deleted map[int]time.Time) *Handlers {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		now := time.Now()
+		// Honour the soft-delete filter of the list and lookup queries
+		visible := func(r Review) bool {
+			_, isDeleted := deleted[r.ID]
+			return !isDeleted || !strings.Contains(query, "deleted_at IS NULL")
+		}
+		switch {
+		case strings.Contains(query, "FROM merchants WHERE auth_user_id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 6)}
+			if args[0] == "user-1" {
+				res.Rows = [][]driver.Value{{int64(1), "user-1", "Cafe", "cafe", true, now}}
+			}
+			return res, nil
+		case strings.Contains(query, "FROM merchant_reviews\n\t\tWHERE id = $1 AND deleted_at IS NOT NULL"):
+			res := &fakedb.Result{Columns: make([]string, 8)}
+			for _, r := range *reviews {
+				if deletedAt, ok := deleted[r.ID]; ok && int64(r.ID) == args[0] {
+					res.Rows = [][]driver.Value{{int64(r.ID), int64(r.MerchantID), r.Platform, r.ReviewText, true, now, now, deletedAt}}
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "FROM merchant_reviews\n\t\tWHERE id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 7)}
+			for _, r := range *reviews {
+				if int64(r.ID) == args[0] && visible(r) {
+					res.Rows = [][]driver.Value{{int64(r.ID), int64(r.MerchantID), r.Platform, r.ReviewText, true, now, now}}
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "FROM merchant_reviews\n\t\tWHERE merchant_id = $1"):
+			activeOnly := strings.Contains(query, "is_active = true")
+			res := &fakedb.Result{Columns: make([]string, 7)}
+			for _, r := range *reviews {
+				if int64(r.MerchantID) == args[0] && visible(r) && (r.IsActive || !activeOnly) {
+					res.Rows = append(res.Rows, []driver.Value{int64(r.ID), int64(r.MerchantID), r.Platform, r.ReviewText, r.IsActive, now, now})
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "SELECT EXISTS"):
+			exists := false
+			for _, r := range *reviews {
+				exists = exists || (int64(r.MerchantID) == args[0] && r.Platform == args[1] && r.ReviewText == args[2])
+			}
+			return &fakedb.Result{Columns: []string{"exists"}, Rows: [][]driver.Value{{exists}}}, nil
+		case strings.Contains(query, "INSERT INTO merchant_reviews"):
+			id := len(*reviews) + 1
+			*reviews = append(*reviews, Review{ID: id, MerchantID: int(args[0].(int64)), Platform: args[1].(string), ReviewText: args[2].(string)})
+			return &fakedb.Result{Columns: make([]string, 4), Rows: [][]driver.Value{{int64(id), true, now, now}}}, nil
+		case strings.Contains(query, "SET deleted_at = CURRENT_TIMESTAMP"):
+			id := int(args[0].(int64))
+			if _, ok := deleted[id]; ok {
+				return &fakedb.Result{}, nil
+			}
+			deleted[id] = now
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SET deleted_at = NULL"):
+			id := int(args[0].(int64))
+			if deletedAt, ok := deleted[id]; !ok || !deletedAt.After(args[1].(time.Time)) {
+				return &fakedb.Result{}, nil
+			}
+			delete(deleted, id)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "DELETE FROM merchant_reviews WHERE deleted_at < $1"):
+			var kept []Review
+			for _, r := range *reviews {
+				if deletedAt, ok := deleted[r.ID]; ok && deletedAt.Before(args[0].(time.Time)) {
+					delete(deleted, r.ID)
+					continue
+				}
+				kept = append(kept, r)
+			}
+			purged := len(*reviews) - len(kept)
+			*reviews = kept
+			return &fakedb.Result{RowsAffected: int64(purged)}, nil
+		case strings.Contains(query, "UPDATE merchants SET updated_at"):
+			if strings.Contains(query, "RETURNING id") {
+				return &fakedb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(1)}}}, nil
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}}
+}
+
+func TestDuplicateReviewToOtherPlatform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reviews := []Review{{ID: 1, MerchantID: 1, Platform: "google", ReviewText: "Lovely coffee"}}
+	h := newTemplateStore(t, &reviews, nil)
+
+	router := gin.New()
+	router.POST("/api/reviews/:id/duplicate", asUser("user-1"), h.DuplicateReview)
+
+	w := postForm(router, "/api/reviews/1/duplicate", url.Values{"platform": {"facebook"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (body %s)", w.Code, w.Body)
+	}
+	var body struct{ Review Review }
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Review.Platform != "facebook" || body.Review.ReviewText != "Lovely coffee" || body.Review.MerchantID != 1 {
+		t.Errorf("review = %+v, want the text copied to facebook for merchant 1", body.Review)
+	}
+	if len(reviews) != 2 || reviews[0].Platform != "google" {
+		t.Errorf("templates = %+v, want the google original kept alongside the copy", reviews)
+	}
+}
+
+func TestDuplicateReviewRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reviews := []Review{
+		{ID: 1, MerchantID: 1, Platform: "google", ReviewText: "Lovely coffee"},
+		{ID: 2, MerchantID: 1, Platform: "facebook", ReviewText: "Lovely coffee"},
+	}
+	h := newTemplateStore(t, &reviews, nil)
+
+	tests := []struct {
+		name       string
+		user       string
+		path       string
+		platform   string
+		wantStatus int
+	}{
+		{"identical template exists", "user-1", "/api/reviews/1/duplicate", "facebook", http.StatusConflict},
+		{"same platform", "user-1", "/api/reviews/1/duplicate", "google", http.StatusBadRequest},
+		{"unknown platform", "user-1", "/api/reviews/1/duplicate", "yelp", http.StatusBadRequest},
+		{"unknown template", "user-1", "/api/reviews/9/duplicate", "facebook", http.StatusNotFound},
+		{"another user's template", "user-2", "/api/reviews/1/duplicate", "facebook", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/reviews/:id/duplicate", asUser(tt.user), h.DuplicateReview)
+			w := postForm(router, tt.path, url.Values{"platform": {tt.platform}})
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+	if len(reviews) != 2 {
+		t.Errorf("rejected duplicates inserted %d templates", len(reviews)-2)
+	}
+}
+
+// businessPageFixture is the fake database behind the public page of one
+// active merchant, id 1, slug "cafe". Renders counts the detail lookups,
+// which only happen when the page is rendered rather than served from cache.
+type businessPageFixture struct {
+	mu            sync.Mutex
+	name          string
+	updatedAt     time.Time
+	details       MerchantDetails
+	presets       []byte
+	reviews       []Review
+	syncedRatings []float64
+	inactive      bool
+	renders       int
+	clicks        [][]driver.Value // link_clicks insert arguments
+}
+
+func newBusinessPageFixture() *businessPageFixture {
+	return &businessPageFixture{
+		name:      "Cafe",
+		updatedAt: time.Now().Add(-time.Hour).Truncate(time.Second),
+		details:   MerchantDetails{ID: 1, MerchantID: 1, ThemeColor: "#4f46e5"},
+	}
+}
+
+// handlers returns Handlers over the fixture with the given page cache
+func (f *businessPageFixture) handlers(t *testing.T, cache *pageCache) *Handlers {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		d := f.details
+		switch {
+		case strings.Contains(query, "FROM merchants WHERE slug = $1"):
+			res := &fakedb.Result{Columns: make([]string, 7)}
+			if args[0] == "cafe" && !(f.inactive && strings.Contains(query, "is_active = true")) {
+				res.Rows = [][]driver.Value{{int64(1), "user-1", f.name, "cafe", !f.inactive, f.updatedAt, f.updatedAt}}
+			}
+			return res, nil
+		case strings.Contains(query, "FROM merchants WHERE id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 10)}
+			if args[0] == int64(1) {
+				res.Rows = [][]driver.Value{{int64(1), "user-1", f.name, "cafe", !f.inactive, f.updatedAt, f.updatedAt, int64(100), nil, false}}
+			}
+			return res, nil
+		case strings.Contains(query, "INSERT INTO link_clicks"):
+			f.clicks = append(f.clicks, args)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SELECT GREATEST("):
+			return &fakedb.Result{Columns: make([]string, 4), Rows: [][]driver.Value{
+				{f.updatedAt, int64(len(f.reviews)), int64(len(f.syncedRatings)), d.GooglePlaceID},
+			}}, nil
+		case strings.Contains(query, "FROM merchant_details WHERE merchant_id = $1"):
+			f.renders++
+			return &fakedb.Result{Columns: make([]string, 24), Rows: [][]driver.Value{{
+				int64(d.ID), int64(d.MerchantID), d.Address, d.PhoneNumber,
+				d.WhatsAppPresetText, d.FacebookURL, d.XiaohongshuID,
+				d.TiktokURL, d.InstagramURL, d.ThreadsURL,
+				d.WebsiteURL, d.GooglePlayURL, d.AppStoreURL,
+				d.GoogleMapsURL, d.WazeURL, d.LogoURL, d.ThemeColor,
+				d.SecondaryColor, d.TextColor, d.FontFamily, d.NoIndex, f.presets,
+				d.GooglePlaceID, d.Country,
+			}}}, nil
+		case strings.Contains(query, "FROM merchant_reviews"):
+			res := &fakedb.Result{Columns: make([]string, 7)}
+			for _, r := range f.reviews {
+				res.Rows = append(res.Rows, []driver.Value{int64(r.ID), int64(1), r.Platform, r.ReviewText, true, f.updatedAt, f.updatedAt})
+			}
+			return res, nil
+		case strings.Contains(query, "avg_rating"):
+			sum := 0.0
+			for _, r := range f.syncedRatings {
+				sum += r
+			}
+			avg := 0.0
+			if n := len(f.syncedRatings); n > 0 {
+				avg = sum / float64(n)
+			}
+			n := int64(len(f.syncedRatings))
+			return &fakedb.Result{Columns: make([]string, 5), Rows: [][]driver.Value{{n, int64(1), n, avg, nil}}}, nil
+		case strings.Contains(query, "FROM synced_reviews"):
+			// Per-platform and sentiment breakdowns
+			return &fakedb.Result{Columns: make([]string, 3)}, nil
+		case strings.Contains(query, "SET google_place_id = NULL"):
+			f.details.GooglePlaceID = ""
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SET google_place_id = $1"):
+			f.details.GooglePlaceID = args[0].(string)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "UPDATE merchant_details SET"):
+			f.details.Address = args[0].(string)
+			f.updatedAt = f.updatedAt.Add(time.Minute)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "UPDATE merchants SET updated_at"):
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "UPDATE merchants SET business_name"):
+			f.name = args[0].(string)
+			f.updatedAt = f.updatedAt.Add(time.Minute)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}, pageCache: cache}
+}
+
+// getBusinessPage requests the public page with the given query string and extra headers
+func getBusinessPage(h *Handlers, query string, header http.Header) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", h.Home)
+
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if host := header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBusinessPageCache(t *testing.T) {
+	f := newBusinessPageFixture()
+	h := f.handlers(t, &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)})
+
+	first := getBusinessPage(h, "id=cafe", nil)
+	if first.Code != http.StatusOK || first.Header().Get("X-Page-Cache") != "MISS" {
+		t.Fatalf("first view: status %d, cache %q; want a rendered page", first.Code, first.Header().Get("X-Page-Cache"))
+	}
+
+	second := getBusinessPage(h, "id=cafe", nil)
+	if second.Header().Get("X-Page-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Errorf("second view: cache %q; want the first page served from cache", second.Header().Get("X-Page-Cache"))
+	}
+	if f.renders != 1 {
+		t.Errorf("rendered %d times, want once", f.renders)
+	}
+
+	if err := h.updateMerchant(1, "Cafe Two", "cafe", true); err != nil {
+		t.Fatal(err)
+	}
+	third := getBusinessPage(h, "id=cafe", nil)
+	if third.Header().Get("X-Page-Cache") != "MISS" || !strings.Contains(third.Body.String(), "Cafe Two") {
+		t.Errorf("after update: cache %q; want a fresh page with the new name", third.Header().Get("X-Page-Cache"))
+	}
+}
+
+func TestBusinessPageCacheAfterSync(t *testing.T) {
+	loadTranslations()
+	f := newBusinessPageFixture()
+	h := f.handlers(t, &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)})
+
+	first := getBusinessPage(h, "id=cafe", nil)
+	if first.Header().Get("X-Page-Cache") != "MISS" {
+		t.Fatalf("first view: cache %q, want MISS", first.Header().Get("X-Page-Cache"))
+	}
+
+	// A sync writes synced_reviews only; merchants.updated_at is untouched
+	f.mu.Lock()
+	f.syncedRatings = []float64{5, 4, 4}
+	f.mu.Unlock()
+
+	w := getBusinessPage(h, "id=cafe", nil)
+	if w.Header().Get("X-Page-Cache") != "MISS" || !strings.Contains(w.Body.String(), "4.3 from 3 reviews") {
+		t.Errorf("after sync: cache %q; want a fresh page with the synced rating", w.Header().Get("X-Page-Cache"))
+	}
+	if w.Header().Get("ETag") == first.Header().Get("ETag") {
+		t.Error("after sync: ETag unchanged")
+	}
+}
+
+func TestBusinessPageCacheSkipsSignedInUsers(t *testing.T) {
+	f := newBusinessPageFixture()
+	h := f.handlers(t, &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)})
+
+	signedIn := http.Header{"Cookie": {"sb_access_token=token"}}
+	getBusinessPage(h, "id=cafe", signedIn)
+	if w := getBusinessPage(h, "id=cafe", signedIn); w.Header().Get("X-Page-Cache") != "" || f.renders != 2 {
+		t.Errorf("cache %q after %d renders; want signed-in views rendered every time", w.Header().Get("X-Page-Cache"), f.renders)
+	}
+}
+
+func TestBusinessPageConditionalGet(t *testing.T) {
+	f := newBusinessPageFixture()
+	h := f.handlers(t, nil)
+
+	first := getBusinessPage(h, "id=cafe", nil)
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("first view: status %d, ETag %q, Last-Modified %q", first.Code, etag, lastModified)
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"matching ETag", http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"ETag in a list", http.Header{"If-None-Match": {`"other", ` + etag}}, http.StatusNotModified},
+		{"stale ETag", http.Header{"If-None-Match": {`W/"stale"`}}, http.StatusOK},
+		{"not modified since", http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"stale ETag wins over date", http.Header{"If-None-Match": {`W/"stale"`}, "If-Modified-Since": {lastModified}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		renders := f.renders
+		w := getBusinessPage(h, "id=cafe", tt.header)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if tt.want == http.StatusNotModified && (f.renders != renders || w.Body.Len() != 0) {
+			t.Errorf("%s: rendered the page for a 304", tt.name)
+		}
+	}
+
+	if err := h.updateMerchant(1, "Cafe Two", "cafe", true); err != nil {
+		t.Fatal(err)
+	}
+	w := getBusinessPage(h, "id=cafe", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after update: status = %d, ETag %q; want the new version", w.Code, w.Header().Get("ETag"))
+	}
+
+	signedIn := getBusinessPage(h, "id=cafe", http.Header{"Cookie": {"sb_access_token=token"}})
+	if signedIn.Header().Get("ETag") != "" {
+		t.Error("signed-in view has an ETag, want it left uncached")
+	}
+}
+
+func TestBusinessPageShowsRatingSummary(t *testing.T) {
+	loadTranslations()
+	f := newBusinessPageFixture()
+	h := f.handlers(t, nil)
+
+	if w := getBusinessPage(h, "id=cafe", nil); strings.Contains(w.Body.String(), "★") {
+		t.Errorf("no synced reviews: page shows a rating")
+	}
+
+	f.syncedRatings = []float64{5, 4, 4}
+	w := getBusinessPage(h, "id=cafe", nil)
+	if !strings.Contains(w.Body.String(), "4.3 from 3 reviews") {
+		t.Errorf("page missing the rating summary \"4.3 from 3 reviews\":\n%s", w.Body)
+	}
+}
+
+func TestGetPublicProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newBusinessPageFixture()
+	f.details.Address = "1 Jalan Ampang"
+	f.reviews = []Review{{ID: 3, Platform: "google", ReviewText: "Great coffee"}}
+	h := f.handlers(t, nil)
+
+	router := gin.New()
+	router.GET("/api/public/merchants/:slug", h.GetPublicProfile)
+	get := func(slug string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/public/merchants/"+slug, nil))
+		return w
+	}
+
+	w := get("cafe")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+	var profile struct {
+		BusinessName    string          `json:"business_name"`
+		Slug            string          `json:"slug"`
+		Details         MerchantDetails `json:"details"`
+		ReviewTemplates []struct {
+			ID         int    `json:"id"`
+			ReviewText string `json:"review_text"`
+		} `json:"review_templates"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatal(err)
+	}
+	if profile.BusinessName != "Cafe" || profile.Slug != "cafe" || profile.Details.Address != "1 Jalan Ampang" {
+		t.Errorf("profile = %+v, want Cafe's details", profile)
+	}
+	if len(profile.ReviewTemplates) != 1 || profile.ReviewTemplates[0].ReviewText != "Great coffee" {
+		t.Errorf("review templates = %+v, want the one active template", profile.ReviewTemplates)
+	}
+
+	if w := get("nope"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: status = %d, want 404", w.Code)
+	}
+	f.inactive = true
+	if w := get("cafe"); w.Code != http.StatusNotFound {
+		t.Errorf("inactive merchant: status = %d, want 404", w.Code)
+	}
+}
+
+func TestTrackPageViewSampling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRACKING_IP_LIMIT", "100000")
+	t.Setenv("TRACKING_MERCHANT_LIMIT", "100000")
+	loadTrackingLimits()
+
+	// Merchant 1 stores one in ten page views
+	const sampleRate = 10
+	var stored, weighted int64
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT analytics_sample_rate"):
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{int64(sampleRate)}}}, nil
+		case strings.Contains(query, "SELECT EXISTS"):
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{false}}}, nil
+		case strings.Contains(query, "INSERT INTO page_views"):
+			stored++
+			weighted += args[4].(int64)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/api/track/view", h.TrackPageView)
+
+	const views = 2000
+	for i := 0; i < views; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/track/view?merchant_id=1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("view %d: status = %d, want 200", i+1, w.Code)
+		}
+	}
+
+	// About a tenth of the views are stored, each standing in for ten. The
+	// bounds are several standard deviations wide so the test doesn't flake.
+	if stored < views/sampleRate*7/10 || stored > views/sampleRate*13/10 {
+		t.Errorf("stored %d rows for %d views, want about %d", stored, views, views/sampleRate)
+	}
+	if weighted != stored*sampleRate {
+		t.Errorf("stored weight %d, want %d per row", weighted, sampleRate)
+	}
+	if weighted < views*7/10 || weighted > views*13/10 {
+		t.Errorf("estimated %d views, want about %d", weighted, views)
+	}
+}
+
+func TestGenerateUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"cafe": true, "cafe-2": true, "merchant": true}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if !strings.Contains(query, "SELECT EXISTS(SELECT 1 FROM merchants WHERE slug = $1)") {
+			t.Fatalf("unexpected query: %s", query)
+		}
+		return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{taken[args[0].(string)]}}}, nil
+	})
+	defer conn.Close()
+	h := &Handlers{db: &Database{DB: conn}}
+
+	for name, want := range map[string]string{
+		"Bakery":    "bakery",
+		"Café!":     "caf",
+		"Cafe":      "cafe-3",
+		"  CAFE  ":  "cafe-3",
+		"面包店":       "merchant-2",
+		"Cafe 2":    "cafe-2-2",
+		"Best Cafe": "best-cafe",
+	} {
+		got, err := h.generateUniqueSlug(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("generateUniqueSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAdminDuplicateMerchantCopiesDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type merchantRow struct{ name, slug string }
+	merchants := map[int64]merchantRow{1: {"Cafe", "cafe"}}
+	addresses := map[int64]string{1: "1 Jalan Ampang, Kuala Lumpur"}
+	var audited []string
+
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		now := time.Now()
+		switch {
+		case strings.Contains(query, "INSERT INTO merchants"):
+			id := int64(len(merchants) + 1)
+			merchants[id] = merchantRow{args[2].(string), args[3].(string)}
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{id}}}, nil
+		case strings.Contains(query, "FROM merchants WHERE id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 10)}
+			if m, ok := merchants[args[0].(int64)]; ok {
+				res.Rows = [][]driver.Value{{args[0], "user-1", m.name, m.slug, true, now, now, int64(1), nil, false}}
+			}
+			return res, nil
+		case strings.Contains(query, "SELECT EXISTS(SELECT 1 FROM merchants WHERE slug = $1)"):
+			taken := false
+			for _, m := range merchants {
+				taken = taken || m.slug == args[0]
+			}
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{taken}}}, nil
+		case strings.Contains(query, "INSERT INTO merchant_details") && strings.Contains(query, "SELECT $2"):
+			address, ok := addresses[args[0].(int64)]
+			if !ok {
+				return &fakedb.Result{}, nil
+			}
+			addresses[args[1].(int64)] = address
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "INSERT INTO audit_logs"):
+			audited = append(audited, args[2].(string))
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+	h := &Handlers{db: &Database{DB: conn}}
+
+	router := gin.New()
+	router.POST("/admin/merchants/:id/duplicate", h.AdminDuplicateMerchant)
+
+	w := postForm(router, "/admin/merchants/1/duplicate", url.Values{})
+	if w.Code != http.StatusFound || w.Header().Get("Location") != appPath("/admin/merchants/2/edit") {
+		t.Fatalf("status = %d, location %q; want a redirect to the copy's edit page", w.Code, w.Header().Get("Location"))
+	}
+	if copy := merchants[2]; copy.name != "Cafe" || copy.slug != "cafe-2" {
+		t.Errorf("copy = %+v, want Cafe with the distinct slug cafe-2", copy)
+	}
+	if addresses[2] != addresses[1] {
+		t.Errorf("copy's address = %q, want the source's details copied", addresses[2])
+	}
+	if len(audited) != 1 || audited[0] != "merchant_duplicated" {
+		t.Errorf("audit log = %v, want merchant_duplicated", audited)
+	}
+}
+
+func TestBulkMerchantStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	active := map[int64]bool{1: true, 2: true, 3: false}
+	var audited []string
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "UPDATE merchants SET is_active = $1"):
+			// pq.Array sends the ids as a Postgres array literal
+			res := &fakedb.Result{Columns: []string{"id", "business_name"}}
+			for _, field := range strings.Split(strings.Trim(args[1].(string), "{}"), ",") {
+				for id := range active {
+					if field == strconv.FormatInt(id, 10) && active[id] != args[0].(bool) {
+						active[id] = args[0].(bool)
+						res.Rows = append(res.Rows, []driver.Value{id, "Merchant " + field})
+					}
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "INSERT INTO audit_logs"):
+			audited = append(audited, args[2].(string)+" "+args[4].(string))
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+	h := &Handlers{db: &Database{DB: conn}, pageCache: newPageCacheFromEnv()}
+
+	router := gin.New()
+	router.POST("/admin/merchants/bulk-status", h.BulkMerchantStatus)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/merchants/bulk-status", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Merchant 3 is already disabled, so only 1 and 2 change
+	w := post(`{"ids": [1, 2, 3], "active": false}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"updated":2`) {
+		t.Fatalf("status = %d, body %s; want 2 updated", w.Code, w.Body)
+	}
+	if active[1] || active[2] || active[3] {
+		t.Errorf("active = %v, want every merchant disabled", active)
+	}
+	if len(audited) != 2 || audited[0] != "merchant_disabled 1" || audited[1] != "merchant_disabled 2" {
+		t.Errorf("audit log = %v, want merchant_disabled for 1 and 2", audited)
+	}
+
+	for name, body := range map[string]string{
+		"empty list":     `{"ids": [], "active": true}`,
+		"no active flag": `{"ids": [1]}`,
+		"not JSON":       `ids=1`,
+	} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+	if w := post(`{"ids": [1], "active": true}`); !active[1] || w.Code != http.StatusOK {
+		t.Errorf("re-enable: status = %d, active = %v", w.Code, active[1])
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	socialmedia "auto-gbp-review/social_media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds how long the health check waits on the database
+const healthCheckTimeout = 2 * time.Second
+
+// checkConnection verifies the database is reachable and can answer a trivial query
+func (db *Database) checkConnection(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+	var one int
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// lastSuccessfulSync returns when the most recent sync completed, or nil if none has
+func (db *Database) lastSuccessfulSync(ctx context.Context) (*time.Time, error) {
+	var completedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `SELECT MAX(completed_at) FROM sync_logs WHERE status = 'completed'`).Scan(&completedAt)
+	if err != nil || !completedAt.Valid {
+		return nil, err
+	}
+	return &completedAt.Time, nil
+}
+
+// HealthCheck reports unhealthy (503) when the database can't be reached so
+// the platform's health checks restart or route around the instance
+func HealthCheck(db *Database, scheduler *socialmedia.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		defer cancel()
+
+		timestamp := time.Now().Format(time.RFC3339)
+
+		if err := db.checkConnection(ctx); err != nil {
+			log.Printf("Health check failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "unhealthy",
+				"db":        "down",
+				"timestamp": timestamp,
+			})
+			return
+		}
+
+		response := gin.H{
+			"status":    "healthy",
+			"db":        "up",
+			"timestamp": timestamp,
+		}
+		if scheduler != nil {
+			response["scheduler_running"] = scheduler.IsRunning()
+		}
+		if lastSync, err := db.lastSuccessfulSync(ctx); err == nil && lastSync != nil {
+			response["last_successful_sync"] = lastSync.Format(time.RFC3339)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// Livez is the liveness probe: it answers 200 whenever the process can serve
+// requests, regardless of dependencies. The keep-alive pinger uses it so a
+// database blip doesn't also let the instance spin down.
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "alive",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readyz is the readiness probe: 200 only once migrations have run and the
+// database is reachable. Point Render's health check path at /readyz so
+// deploys are only promoted when the instance can actually serve traffic.
+func Readyz(db *Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timestamp := time.Now().Format(time.RFC3339)
+
+		if !db.migrated {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":     "not ready",
+				"migrations": "pending",
+				"timestamp":  timestamp,
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		defer cancel()
+
+		if err := db.checkConnection(ctx); err != nil {
+			log.Printf("Readiness check failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "not ready",
+				"db":        "down",
+				"timestamp": timestamp,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ready",
+			"timestamp": timestamp,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthDB is a reachable database whose last completed sync was at lastSync
+func healthDB(t *testing.T, lastSync time.Time) *Database {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if strings.Contains(query, "FROM sync_logs") {
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{lastSync}}}, nil
+		}
+		return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Database{DB: conn}
+}
+
+func probe(handler gin.HandlerFunc, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(path, handler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHealthCheck(t *testing.T) {
+	lastSync := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	db := healthDB(t, lastSync)
+
+	w := probe(HealthCheck(db, nil), "/health")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"db":"up"`) {
+		t.Fatalf("status = %d, body %s; want healthy", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), `"last_successful_sync":"2026-10-01T12:00:00Z"`) {
+		t.Errorf("body = %s, want the last successful sync", w.Body)
+	}
+
+	db.Close()
+	w = probe(HealthCheck(db, nil), "/health")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"db":"down"`) {
+		t.Errorf("closed database: status = %d, body %s; want 503 unhealthy", w.Code, w.Body)
+	}
+}
+
+func TestLivezAndReadyz(t *testing.T) {
+	db := healthDB(t, time.Now())
+
+	db.migrated = false
+	if w := probe(Readyz(db), "/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"migrations":"pending"`) {
+		t.Errorf("before migrations: readyz status = %d, body %s", w.Code, w.Body)
+	}
+
+	db.migrated = true
+	if w := probe(Readyz(db), "/readyz"); w.Code != http.StatusOK {
+		t.Errorf("database up: readyz status = %d, want 200", w.Code)
+	}
+	if w := probe(Livez, "/livez"); w.Code != http.StatusOK {
+		t.Errorf("database up: livez status = %d, want 200", w.Code)
+	}
+
+	db.Close()
+	if w := probe(Readyz(db), "/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"db":"down"`) {
+		t.Errorf("database down: readyz status = %d, body %s", w.Code, w.Body)
+	}
+	if w := probe(Livez, "/livez"); w.Code != http.StatusOK {
+		t.Errorf("database down: livez status = %d, want 200 regardless of the database", w.Code)
+	}
+}
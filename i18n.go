@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLocale is used when the request doesn't ask for a supported locale
+const defaultLocale = "en"
+
+// supportedLocales lists the locales we ship message bundles for
+var supportedLocales = []string{"en", "ms", "zh"}
+
+// translations maps locale -> message key -> translated string
+var translations = map[string]map[string]string{}
+
+// loadTranslations reads the per-locale JSON bundles from the locales directory
+func loadTranslations() {
+	loaded := make(map[string]map[string]string)
+	for _, locale := range supportedLocales {
+		path := filepath.Join("locales", locale+".json")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Failed to read locale bundle %s: %v", path, err)
+			continue
+		}
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Printf("Failed to parse locale bundle %s: %v", path, err)
+			continue
+		}
+		loaded[locale] = messages
+	}
+	translations = loaded
+}
+
+// translate looks up a key for the locale, falling back to English and then the key itself
+func translate(locale string, key string) string {
+	if msg, ok := translations[locale][key]; ok {
+		return msg
+	}
+	if msg, ok := translations[defaultLocale][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// isSupportedLocale reports whether we have a bundle for the locale
+func isSupportedLocale(locale string) bool {
+	for _, l := range supportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// detectLocale picks the request locale from ?lang= or the Accept-Language header
+func detectLocale(c *gin.Context) string {
+	if lang := strings.ToLower(strings.TrimSpace(c.Query("lang"))); isSupportedLocale(lang) {
+		return lang
+	}
+
+	// Accept-Language: ms-MY,ms;q=0.9,en;q=0.8 - take the first supported language
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if isSupportedLocale(lang) {
+			return lang
+		}
+	}
+
+	return defaultLocale
+}
+
+// templateFuncs returns the template functions bound to a locale
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string) string {
+			return translate(locale, key)
+		},
+	}
+}
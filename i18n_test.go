@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDetectLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		{"", "", "en"},
+		{"lang=ms", "", "ms"},
+		{"lang=ZH", "ms-MY", "zh"},
+		{"lang=fr", "ms-MY,ms;q=0.9,en;q=0.8", "ms"},
+		{"", "fr-FR,zh-CN;q=0.8", "zh"},
+		{"", "fr-FR", "en"},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+		if got := detectLocale(c); got != tt.want {
+			t.Errorf("detectLocale(?%s, %q) = %q, want %q", tt.query, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateFallsBack(t *testing.T) {
+	orig := translations
+	defer func() { translations = orig }()
+	translations = map[string]map[string]string{
+		"en": {"greeting": "Hello", "farewell": "Goodbye"},
+		"ms": {"greeting": "Selamat datang"},
+	}
+
+	for _, tt := range []struct{ locale, key, want string }{
+		{"ms", "greeting", "Selamat datang"},
+		{"ms", "farewell", "Goodbye"},
+		{"zh", "greeting", "Hello"},
+		{"ms", "missing.key", "missing.key"},
+	} {
+		if got := translate(tt.locale, tt.key); got != tt.want {
+			t.Errorf("translate(%q, %q) = %q, want %q", tt.locale, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBusinessPageRendersRequestedLocale(t *testing.T) {
+	loadTranslations()
+	h := newBusinessPageFixture().handlers(t, nil)
+
+	w := getBusinessPage(h, "id=cafe&lang=ms", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Hubungi Kami") || strings.Contains(body, "Connect With Us") {
+		t.Error("?lang=ms didn't render the Malay business.connect_title")
+	}
+}
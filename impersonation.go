@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// impersonationCookie holds the signed "view as merchant" context
+const impersonationCookie = "sb_impersonate"
+
+// impersonationTTL bounds how long an admin can view as a merchant before starting again
+const impersonationTTL = time.Hour
+
+// errImpersonationDisabled is returned when there is no secret to sign the context with
+var errImpersonationDisabled = errors.New("impersonation requires JWT_SECRET to be set")
+
+// impersonation is the context an admin sets to see the dashboard as a
+// merchant's owner. Subject is the owner's auth user id.
+type impersonation struct {
+	AdminID      string `json:"admin_id"`
+	MerchantID   int    `json:"merchant_id"`
+	BusinessName string `json:"business_name"`
+	jwt.RegisteredClaims
+}
+
+// impersonationSecret is the key impersonation cookies are signed with
+func impersonationSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// signImpersonation signs the context for adminID viewing merchant as its owner
+func signImpersonation(adminID string, merchant *Merchant, secret []byte, now time.Time) (string, error) {
+	if len(secret) == 0 {
+		return "", errImpersonationDisabled
+	}
+	claims := &impersonation{
+		AdminID:      adminID,
+		MerchantID:   merchant.ID,
+		BusinessName: merchant.BusinessName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   merchant.AuthUserID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(impersonationTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// parseImpersonation verifies an impersonation cookie's signature and expiry
+func parseImpersonation(value string, secret []byte) (*impersonation, error) {
+	if len(secret) == 0 {
+		return nil, errImpersonationDisabled
+	}
+	claims := &impersonation{}
+	_, err := jwt.ParseWithClaims(value, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if claims.AdminID == "" || claims.Subject == "" {
+		return nil, errors.New("incomplete impersonation context")
+	}
+	return claims, nil
+}
+
+// activeImpersonation returns the impersonation context for the logged-in
+// session, or nil. A context started by someone else, by a user who is no
+// longer an admin, or that fails verification is cleared.
+func activeImpersonation(c *gin.Context, session *authSession) *impersonation {
+	value, err := c.Cookie(impersonationCookie)
+	if err != nil || value == "" {
+		return nil
+	}
+
+	imp, err := parseImpersonation(value, impersonationSecret())
+	if err != nil || imp.AdminID != session.UserID || !hasRequiredRole(session.Role, "admin") {
+		clearImpersonationCookie(c)
+		return nil
+	}
+	return imp
+}
+
+func clearImpersonationCookie(c *gin.Context) {
+	c.SetCookie(impersonationCookie, "", -1, cookiePath(), "", false, true)
+}
+
+// impersonationReadOnly reports whether the request may run while impersonating.
+// Support staff can look around but anything that changes data is refused.
+func impersonationReadOnly(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// denyWhileImpersonating answers a request an impersonating admin isn't allowed to make
+func denyWhileImpersonating(c *gin.Context) {
+	if isAPIRequest(c) {
+		respondAPIError(c, http.StatusForbidden, "Not available while viewing as a merchant")
+		return
+	}
+	c.Status(http.StatusForbidden)
+	renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+		"error": "You're viewing as a merchant. Stop impersonating to make changes.",
+	})
+	c.Abort()
+}
+
+// BlockImpersonation refuses GET routes with side effects (such as starting an
+// OAuth connection) while an admin is viewing as a merchant
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonation"); ok {
+			denyWhileImpersonating(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminImpersonateMerchant lets an admin view the dashboard as a merchant's owner
+func (h *Handlers) AdminImpersonateMerchant(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Invalid merchant ID",
+		})
+		return
+	}
+
+	merchant, err := h.getMerchantByID(id)
+	if err != nil || merchant.DeletedAt != nil || merchant.AuthUserID == "" {
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Merchant not found",
+		})
+		return
+	}
+
+	value, err := signImpersonation(c.GetString("user_id"), merchant, impersonationSecret(), time.Now())
+	if err != nil {
+		log.Printf("Failed to start impersonation of merchant %d: %v", id, err)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Viewing as a merchant is not configured",
+		})
+		return
+	}
+
+	c.SetCookie(impersonationCookie, value, int(impersonationTTL.Seconds()), cookiePath(), "", false, true)
+
+	h.logAuditEvent(c, "impersonation_started", "merchant", idStr, map[string]interface{}{
+		"business_name": merchant.BusinessName,
+		"auth_user_id":  merchant.AuthUserID,
+	})
+
+	c.Redirect(http.StatusFound, appPath(fmt.Sprintf("/dashboard/?merchant_id=%d", merchant.ID)))
+}
+
+// AdminStopImpersonating ends a "view as merchant" session
+func (h *Handlers) AdminStopImpersonating(c *gin.Context) {
+	if value, err := c.Cookie(impersonationCookie); err == nil && value != "" {
+		if imp, err := parseImpersonation(value, impersonationSecret()); err == nil && imp.AdminID == c.GetString("user_id") {
+			h.logAuditEvent(c, "impersonation_stopped", "merchant", strconv.Itoa(imp.MerchantID), map[string]interface{}{
+				"business_name": imp.BusinessName,
+				"auth_user_id":  imp.Subject,
+			})
+		}
+	}
+
+	clearImpersonationCookie(c)
+	c.Redirect(http.StatusFound, appPath("/admin/merchants"))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImpersonationRoundTrip(t *testing.T) {
+	secret := []byte("jwt-secret")
+	merchant := &Merchant{ID: 7, BusinessName: "Cafe", AuthUserID: "owner-1"}
+	now := time.Now()
+
+	value, err := signImpersonation("admin-1", merchant, secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp, err := parseImpersonation(value, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imp.AdminID != "admin-1" || imp.MerchantID != 7 || imp.BusinessName != "Cafe" || imp.Subject != "owner-1" {
+		t.Errorf("impersonation = %+v", imp)
+	}
+
+	if _, err := parseImpersonation(value, []byte("other-secret")); err == nil {
+		t.Error("accepted a context signed with another secret")
+	}
+	expired, _ := signImpersonation("admin-1", merchant, secret, now.Add(-impersonationTTL-time.Minute))
+	if _, err := parseImpersonation(expired, secret); err == nil {
+		t.Error("accepted an expired context")
+	}
+	if _, err := signImpersonation("admin-1", merchant, nil, now); err != errImpersonationDisabled {
+		t.Errorf("sign without a secret: err = %v, want errImpersonationDisabled", err)
+	}
+	if _, err := parseImpersonation(value, nil); err != errImpersonationDisabled {
+		t.Errorf("parse without a secret: err = %v, want errImpersonationDisabled", err)
+	}
+}
+
+func TestActiveImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	value, err := signImpersonation("admin-1", &Merchant{ID: 7, AuthUserID: "owner-1"}, impersonationSecret(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cookie  string
+		session authSession
+		active  bool
+	}{
+		{"the admin who started it", value, authSession{UserID: "admin-1", Role: "admin"}, true},
+		{"another admin", value, authSession{UserID: "admin-2", Role: "admin"}, false},
+		{"no longer an admin", value, authSession{UserID: "admin-1", Role: "merchant"}, false},
+		{"tampered cookie", value + "x", authSession{UserID: "admin-1", Role: "admin"}, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/dashboard/", nil)
+		c.Request.AddCookie(&http.Cookie{Name: impersonationCookie, Value: tt.cookie})
+
+		imp := activeImpersonation(c, &tt.session)
+		if (imp != nil) != tt.active {
+			t.Errorf("%s: active = %v, want %v", tt.name, imp != nil, tt.active)
+		}
+		cleared := strings.Contains(w.Header().Get("Set-Cookie"), impersonationCookie+"=;")
+		if cleared == tt.active {
+			t.Errorf("%s: cookie cleared = %v", tt.name, cleared)
+		}
+	}
+}
+
+func TestImpersonationIsReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for method, allowed := range map[string]bool{
+		http.MethodGet:    true,
+		http.MethodHead:   true,
+		http.MethodPost:   false,
+		http.MethodPut:    false,
+		http.MethodDelete: false,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(method, "/dashboard/profile", nil)
+		if got := impersonationReadOnly(c); got != allowed {
+			t.Errorf("%s allowed = %v, want %v", method, got, allowed)
+		}
+	}
+}
+
+func TestBlockImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.Query("impersonating") != "" {
+			c.Set("impersonation", &impersonation{})
+		}
+	})
+	router.GET("/api/social-media/connect/:platform", BlockImpersonation(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for query, want := range map[string]int{"": http.StatusOK, "?impersonating=1": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/social-media/connect/google"+query, nil))
+		if w.Code != want {
+			t.Errorf("GET with %q: status %d, want %d", query, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package fakedb is an in-memory database/sql driver for tests. Every
+// statement is handed to a Handler, which decides what it returns, so code
+// written against *sql.DB can be exercised without a Postgres server.
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Result is what a Handler returns for one statement: rows for queries,
+// RowsAffected for everything else
+type Result struct {
+	Columns      []string
+	Rows         [][]driver.Value
+	RowsAffected int64
+}
+
+// Handler answers a statement. Returning an error fails the statement with it.
+type Handler func(query string, args []driver.Value) (*Result, error)
+
+var (
+	registerOnce sync.Once
+	handlers     sync.Map // dsn -> Handler
+	nextDSN      atomic.Int64
+)
+
+// Open returns a *sql.DB whose statements are all answered by h
+func Open(h Handler) *sql.DB {
+	registerOnce.Do(func() { sql.Register("fakedb", fakeDriver{}) })
+
+	dsn := fmt.Sprintf("fakedb-%d", nextDSN.Add(1))
+	handlers.Store(dsn, h)
+	db, err := sql.Open("fakedb", dsn)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	h, ok := handlers.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unknown dsn %q", dsn)
+	}
+	return &conn{handler: h.(Handler)}, nil
+}
+
+type conn struct {
+	handler Handler
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) { return tx{}, nil }
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.handler(query, values(args))
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = &Result{}
+	}
+	return driver.RowsAffected(res.RowsAffected), nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.handler(query, values(args))
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = &Result{}
+	}
+	return &rows{columns: res.Columns, rows: res.Rows}, nil
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, named(args))
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	nv := make([]driver.NamedValue, len(args))
+	for i, a := range args {
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: a}
+	}
+	return nv
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type rows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,347 @@
+package fakedb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table is an in-memory table for Handlers that should answer a query the way
+// Postgres would rather than by recognising its text. Match evaluates the
+// query's WHERE clause against Rows, so a test can check that a filter is
+// applied by looking at what comes back.
+//
+// The WHERE clause is split into conditions joined by AND, and parenthesised
+// groups joined by OR. Each condition is one of
+//
+//	col = true, col = false, col IS NULL, col IS NOT NULL, 1=1
+//	col op $n       (op is =, <>, !=, <, <=, > or >=)
+//	col LIKE $n, col ILIKE $n
+//
+// or a condition listed in Conditions. Table aliases (m.slug) are ignored.
+// Any other condition makes Match fail, so a query the table can't evaluate
+// never passes by accident.
+type Table struct {
+	Columns []string
+	Rows    [][]driver.Value
+
+	// Conditions evaluates conditions the grammar above doesn't cover, keyed
+	// by their text with whitespace collapsed
+	Conditions map[string]func(row Row, args []driver.Value) bool
+}
+
+// Row gives a condition the values of one table row by column name
+type Row map[string]driver.Value
+
+var (
+	whereClause   = regexp.MustCompile(`(?is)\bWHERE\s+(.*?)(?:\s+ORDER\s+BY\b|\s+GROUP\s+BY\b|\s+LIMIT\b|\s+OFFSET\b|\s+RETURNING\b|$)`)
+	limitClause   = regexp.MustCompile(`(?i)\bLIMIT\s+(\$\d+|\d+)`)
+	offsetClause  = regexp.MustCompile(`(?i)\bOFFSET\s+(\$\d+|\d+)`)
+	boolCondition = regexp.MustCompile(`(?i)^([\w.]+)\s*=\s*(true|false)$`)
+	nullCondition = regexp.MustCompile(`(?i)^([\w.]+)\s+IS\s+(NOT\s+)?NULL$`)
+	likeCondition = regexp.MustCompile(`(?i)^([\w.]+)\s+(I?LIKE)\s+\$(\d+)$`)
+	cmpCondition  = regexp.MustCompile(`^([\w.]+)\s*(=|<>|!=|<=|>=|<|>)\s*\$(\d+)$`)
+)
+
+// Match returns the rows that satisfy query's WHERE clause, in table order,
+// paged by its LIMIT and OFFSET
+func (t *Table) Match(query string, args []driver.Value) ([][]driver.Value, error) {
+	query = strings.Join(strings.Fields(query), " ")
+
+	var where string
+	if m := whereClause.FindStringSubmatch(query); m != nil {
+		where = m[1]
+	}
+
+	var matched [][]driver.Value
+	for _, values := range t.Rows {
+		row := make(Row, len(t.Columns))
+		for i, col := range t.Columns {
+			row[col] = values[i]
+		}
+		ok := true
+		if where != "" {
+			var err error
+			if ok, err = t.eval(where, row, args); err != nil {
+				return nil, err
+			}
+		}
+		if ok {
+			matched = append(matched, values)
+		}
+	}
+
+	offset, err := clauseValue(offsetClause, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= 0 {
+		if offset > len(matched) {
+			offset = len(matched)
+		}
+		matched = matched[offset:]
+	}
+	limit, err := clauseValue(limitClause, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+// Count is Match for SELECT COUNT(*): a single row holding the number of matches
+func (t *Table) Count(query string, args []driver.Value) (*Result, error) {
+	rows, err := t.Match(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(len(rows))}}}, nil
+}
+
+// clauseValue returns the number of a LIMIT or OFFSET clause, or -1 without one
+func clauseValue(clause *regexp.Regexp, query string, args []driver.Value) (int, error) {
+	m := clause.FindStringSubmatch(query)
+	if m == nil {
+		return -1, nil
+	}
+	var v driver.Value = m[1]
+	if strings.HasPrefix(m[1], "$") {
+		var err error
+		if v, err = arg(m[1][1:], args); err != nil {
+			return 0, err
+		}
+	}
+	switch n := v.(type) {
+	case int64:
+		return int(n), nil
+	case string:
+		return strconv.Atoi(n)
+	}
+	return 0, fmt.Errorf("fakedb: %v is not a row count", v)
+}
+
+func (t *Table) eval(cond string, row Row, args []driver.Value) (bool, error) {
+	if parts := splitTopLevel(cond, " AND "); len(parts) > 1 {
+		for _, part := range parts {
+			if ok, err := t.eval(part, row, args); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if parts := splitTopLevel(cond, " OR "); len(parts) > 1 {
+		for _, part := range parts {
+			if ok, err := t.eval(part, row, args); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	if f, ok := t.Conditions[cond]; ok {
+		return f(row, args), nil
+	}
+	if inner, ok := unwrap(cond); ok {
+		return t.eval(inner, row, args)
+	}
+
+	if cond == "1=1" {
+		return true, nil
+	}
+	if m := boolCondition.FindStringSubmatch(cond); m != nil {
+		v, err := column(row, m[1])
+		if err != nil {
+			return false, err
+		}
+		return v == strings.EqualFold(m[2], "true"), nil
+	}
+	if m := nullCondition.FindStringSubmatch(cond); m != nil {
+		v, err := column(row, m[1])
+		if err != nil {
+			return false, err
+		}
+		return (v == nil) == (m[2] == ""), nil
+	}
+	if m := likeCondition.FindStringSubmatch(cond); m != nil {
+		v, err := column(row, m[1])
+		if err != nil {
+			return false, err
+		}
+		pattern, err := arg(m[3], args)
+		if err != nil {
+			return false, err
+		}
+		s, ok := v.(string)
+		p, _ := pattern.(string)
+		return ok && Like(p, s, strings.EqualFold(m[2], "ILIKE")), nil
+	}
+	if m := cmpCondition.FindStringSubmatch(cond); m != nil {
+		v, err := column(row, m[1])
+		if err != nil {
+			return false, err
+		}
+		want, err := arg(m[3], args)
+		if err != nil {
+			return false, err
+		}
+		return compare(v, m[2], want)
+	}
+	return false, fmt.Errorf("fakedb: can't evaluate condition %q", cond)
+}
+
+// splitTopLevel splits s on sep where it isn't inside parentheses or quotes
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && i+len(sep) <= len(s) && strings.EqualFold(s[i:i+len(sep)], sep):
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unwrap strips one pair of parentheses enclosing all of s
+func unwrap(s string) (string, bool) {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return "", false
+	}
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			return "", false
+		}
+	}
+	return s[1 : len(s)-1], true
+}
+
+func column(row Row, name string) (driver.Value, error) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	v, ok := row[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: no column %q", name)
+	}
+	return v, nil
+}
+
+func arg(n string, args []driver.Value) (driver.Value, error) {
+	i, err := strconv.Atoi(strings.TrimPrefix(n, "$"))
+	if err != nil || i < 1 || i > len(args) {
+		return nil, fmt.Errorf("fakedb: no argument $%s", n)
+	}
+	return args[i-1], nil
+}
+
+// compare applies a SQL comparison; as in SQL, nothing compares true with NULL
+func compare(v driver.Value, op string, want driver.Value) (bool, error) {
+	if v == nil || want == nil {
+		return false, nil
+	}
+
+	var c int
+	switch a := v.(type) {
+	case int64, float64:
+		x, y := number(a), number(want)
+		switch {
+		case x < y:
+			c = -1
+		case x > y:
+			c = 1
+		}
+	case string:
+		b, ok := want.(string)
+		if !ok {
+			return false, fmt.Errorf("fakedb: can't compare %q with %v", a, want)
+		}
+		c = strings.Compare(a, b)
+	case bool:
+		if b, ok := want.(bool); !ok || (op != "=" && op != "<>" && op != "!=") {
+			return false, fmt.Errorf("fakedb: can't compare %v %s %v", a, op, want)
+		} else if a != b {
+			c = 1
+		}
+	case time.Time:
+		b, ok := want.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("fakedb: can't compare %v with %v", a, want)
+		}
+		c = a.Compare(b)
+	default:
+		return false, fmt.Errorf("fakedb: can't compare %T values", v)
+	}
+
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "<>", "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func number(v driver.Value) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// Like reports whether s matches a SQL LIKE pattern, where % is any run of
+// characters, _ is one character and a backslash escapes the next character
+func Like(pattern, s string, caseInsensitive bool) bool {
+	var re strings.Builder
+	re.WriteString("(?s)^")
+	if caseInsensitive {
+		re.WriteString("(?i)")
+	}
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			re.WriteString(".*")
+		case c == '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String()).MatchString(s)
+}
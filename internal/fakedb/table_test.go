@@ -0,0 +1,79 @@
+package fakedb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTableMatch(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	table := &Table{
+		Columns: []string{"id", "platform", "rating", "review_text", "is_visible", "deleted_at"},
+		Rows: [][]driver.Value{
+			{int64(1), "google", 5.0, "Great coffee", true, nil},
+			{int64(2), "facebook", 2.0, "Slow service", true, nil},
+			{int64(3), "google", 4.0, "", false, day},
+			{int64(4), "google", nil, "50% off today", true, nil},
+		},
+		Conditions: map[string]func(Row, []driver.Value) bool{
+			"COALESCE(TRIM(review_text), '') <> ''": func(row Row, _ []driver.Value) bool {
+				return strings.TrimSpace(row["review_text"].(string)) != ""
+			},
+		},
+	}
+
+	tests := []struct {
+		query string
+		args  []driver.Value
+		want  string
+	}{
+		{"SELECT * FROM t", nil, "[1 2 3 4]"},
+		{"SELECT * FROM t WHERE is_visible = true", nil, "[1 2 4]"},
+		{"SELECT * FROM t t1 WHERE t1.deleted_at IS NOT NULL", nil, "[3]"},
+		{"SELECT * FROM t WHERE platform = $1 AND rating >= $2", []driver.Value{"google", int64(4)}, "[1 3]"},
+		{"SELECT * FROM t WHERE rating < $1", []driver.Value{5.0}, "[2 3]"},
+		{"SELECT * FROM t WHERE 1=1 AND COALESCE(TRIM(review_text), '') <> ''", nil, "[1 2 4]"},
+		{"SELECT * FROM t WHERE (platform = $1 OR review_text ILIKE $2) AND is_visible = true", []driver.Value{"facebook", `%50\% off%`}, "[2 4]"},
+		{"SELECT * FROM t WHERE\n\t\tplatform = $1\n\t\tORDER BY id LIMIT $2 OFFSET $3", []driver.Value{"google", int64(1), int64(1)}, "[3]"},
+	}
+	for _, tt := range tests {
+		rows, err := table.Match(tt.query, tt.args)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		var ids []driver.Value
+		for _, row := range rows {
+			ids = append(ids, row[0])
+		}
+		if got := fmt.Sprint(ids); got != tt.want {
+			t.Errorf("%q matched %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := table.Match("SELECT * FROM t WHERE rating BETWEEN 1 AND 3", nil); err == nil {
+		t.Error("a condition the table can't evaluate matched without an error")
+	}
+}
+
+func TestLike(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ci, want   bool
+	}{
+		{"%coffee%", "Great coffee here", false, true},
+		{"%COFFEE%", "Great coffee here", true, true},
+		{"%COFFEE%", "Great coffee here", false, false},
+		{`%50\% off%`, "50% off today", false, true},
+		{`%50\% off%`, "500 off today", false, false},
+		{"caf_", "café", false, true},
+	}
+	for _, tt := range tests {
+		if got := Like(tt.pattern, tt.s, tt.ci); got != tt.want {
+			t.Errorf("Like(%q, %q, %v) = %v, want %v", tt.pattern, tt.s, tt.ci, got, tt.want)
+		}
+	}
+}
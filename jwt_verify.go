@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Ways SupabaseAuthMiddleware can check an access token
+const (
+	authVerifyRemote = "remote" // Ask Supabase's /auth/v1/user on every cache miss (default)
+	authVerifyLocal  = "local"  // Verify the JWT signature with SUPABASE_JWT_SECRET, falling back to Supabase
+)
+
+// supabaseJWTSecret verifies access tokens locally; nil means every token goes to Supabase
+var supabaseJWTSecret []byte
+
+// loadAuthVerifyMode reads AUTH_VERIFY_MODE and, in local mode, SUPABASE_JWT_SECRET
+func loadAuthVerifyMode() {
+	supabaseJWTSecret = nil
+
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("AUTH_VERIFY_MODE")))
+	switch mode {
+	case "", authVerifyRemote:
+		return
+	case authVerifyLocal:
+		secret := os.Getenv("SUPABASE_JWT_SECRET")
+		if secret == "" {
+			log.Printf("AUTH_VERIFY_MODE=local but SUPABASE_JWT_SECRET is not set, verifying tokens with Supabase")
+			return
+		}
+		supabaseJWTSecret = []byte(secret)
+	default:
+		log.Printf("Invalid AUTH_VERIFY_MODE %q, verifying tokens with Supabase", mode)
+	}
+}
+
+// verifySupabaseJWT checks the token's HS256 signature and expiry against secret
+// and returns the session it describes. Any error means the caller should fall
+// back to Supabase, which can also refresh an expired token.
+func verifySupabaseJWT(tokenString string, secret []byte) (*authSession, error) {
+	claims := &supabaseJWTClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("verify JWT: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("verify JWT: missing sub claim")
+	}
+
+	role := claims.UserRole
+	if role == "" {
+		role = "merchant"
+	}
+	return &authSession{UserID: claims.Subject, Email: claims.Email, Role: role}, nil
+}
+
+// supabaseJWTClaims are the access token claims SupabaseAuthMiddleware relies on
+type supabaseJWTClaims struct {
+	Email    string `json:"email"`
+	UserRole string `json:"user_role"`
+	jwt.RegisteredClaims
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signTestJWT signs claims with HS256 and secret
+func signTestJWT(t *testing.T, claims supabaseJWTClaims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifySupabaseJWT(t *testing.T) {
+	secret := []byte("jwt-secret")
+	valid := supabaseJWTClaims{
+		Email:            "owner@example.com",
+		UserRole:         "admin",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+
+	session, err := verifySupabaseJWT(signTestJWT(t, valid, "jwt-secret"), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *session != (authSession{UserID: "user-1", Email: "owner@example.com", Role: "admin"}) {
+		t.Errorf("session = %+v", session)
+	}
+
+	noRole := valid
+	noRole.UserRole = ""
+	if session, err := verifySupabaseJWT(signTestJWT(t, noRole, "jwt-secret"), secret); err != nil || session.Role != "merchant" {
+		t.Errorf("no user_role claim: session %+v, err %v; want the merchant role", session, err)
+	}
+
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+	noSubject := valid
+	noSubject.Subject = ""
+
+	rejected := map[string]string{
+		"wrong secret": signTestJWT(t, valid, "other-secret"),
+		"expired":      signTestJWT(t, expired, "jwt-secret"),
+		"no expiry":    signTestJWT(t, noExpiry, "jwt-secret"),
+		"no subject":   signTestJWT(t, noSubject, "jwt-secret"),
+		"not a JWT":    "garbage",
+	}
+	for name, token := range rejected {
+		if _, err := verifySupabaseJWT(token, secret); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+
+	// Only HS256 is accepted, so a token can't pick a weaker algorithm
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := verifySupabaseJWT(none, secret); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
+
+func TestLoadAuthVerifyMode(t *testing.T) {
+	t.Cleanup(func() { supabaseJWTSecret = nil })
+
+	tests := []struct {
+		mode, secret string
+		wantLocal    bool
+	}{
+		{"", "jwt-secret", false},
+		{"remote", "jwt-secret", false},
+		{"LOCAL", "jwt-secret", true},
+		{"local", "", false},
+		{"sometimes", "jwt-secret", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("AUTH_VERIFY_MODE", tt.mode)
+		t.Setenv("SUPABASE_JWT_SECRET", tt.secret)
+		loadAuthVerifyMode()
+		if local := supabaseJWTSecret != nil; local != tt.wantLocal {
+			t.Errorf("AUTH_VERIFY_MODE=%q, secret %q: local verification %v, want %v", tt.mode, tt.secret, local, tt.wantLocal)
+		}
+	}
+}
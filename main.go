@@ -1,33 +1,39 @@
 package main
 
 import (
-	"html/template"
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	socialmedia "auto-gbp-review/social_media"
+
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 // renderPage renders a page with a specific layout
 func renderPage(c *gin.Context, layout string, content string, data gin.H) {
-	tmpl, err := template.ParseFiles(layout, content)
+	locale := detectLocale(c)
+	tmpl, err := templates.Get(layout, content, locale)
 	if err != nil {
 		log.Printf("Template parsing error: %v", err)
 		c.String(http.StatusInternalServerError, "Template parsing error: %s", err.Error())
 		return
 	}
 
-	// Set default title if not provided
-	if data == nil {
-		data = gin.H{}
-	}
-	if _, exists := data["title"]; !exists {
-		data["title"] = "ViralEngine"
+	// Set default title and branding if not provided
+	data = applyPageDefaults(data, locale)
+	if imp, ok := c.Get("impersonation"); ok {
+		data["impersonation"] = imp
 	}
 
 	c.Header("Content-Type", "text/html; charset=utf-8")
@@ -38,12 +44,46 @@ func renderPage(c *gin.Context, layout string, content string, data gin.H) {
 	}
 }
 
+// renderPageHTML renders a page with a specific layout and locale into a byte slice
+func renderPageHTML(layout string, content string, locale string, data gin.H) ([]byte, error) {
+	tmpl, err := templates.Get(layout, content, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	data = applyPageDefaults(data, locale)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Load branding, translations and parse templates once up front (parsing skipped in DEV_MODE)
+	loadBasePath()
+	loadPasswordResetLimits()
+	loadTrackingLimits()
+	loadPageViewDedup()
+	loadBotFilter()
+	loadSitemapCache()
+	loadUploadLimits()
+	loadCORSConfig()
+	loadSessionCache()
+	loadAuthVerifyMode()
+	loadBranding()
+	loadTranslations()
+	if err := validateTemplates(pageTemplates); err != nil {
+		log.Fatal("Template check failed: ", err)
+	}
+	initTemplateCache()
+
 	// Initialize Supabase client
 	if err := InitSupabase(); err != nil {
 		log.Fatal("Failed to initialize Supabase client:", err)
@@ -58,12 +98,21 @@ func main() {
 
 	// Initialize Gin router
 	router := gin.Default()
+	if err := configureTrustedProxies(router); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
-	// Serve static files
-	router.Static("/static", "./static")
+	// Unknown routes: JSON error envelope under /api, HTML error page elsewhere
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NoRouteHandler)
+	router.NoMethod(NoMethodHandler)
+
+	// Post-sync work (dedup, alerts) runs on background workers
+	jobs := socialmedia.JobQueueFromEnv()
+	jobs.Start()
 
 	// Initialize routes
-	InitRoutes(router, db)
+	scheduler := InitRoutes(router, db, jobs)
 
 	// Get port from environment or default
 	port := os.Getenv("PORT")
@@ -74,110 +123,178 @@ func main() {
 	// Start the keep-alive pinger to prevent Render.com spin down
 	go startKeepAlivePinger()
 
+	// Stop on SIGINT/SIGTERM (Render sends SIGTERM on deploy) and let in-flight requests finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+
 	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	err = runServer(ctx, srv, shutdownTimeout, func(shutdownCtx context.Context) {
+		// Let a running sync finish before the database is closed
+		if err := scheduler.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Scheduler did not stop in time: %v", err)
+		}
+
+		// Drain jobs queued by the last syncs. The scheduler's Shutdown waited for
+		// every sync it and the OAuth callbacks started, so no more are coming.
+		if err := jobs.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Job queue did not drain in time: %v", err)
+		}
+	})
+	if err != nil {
+		scheduler.Stop()
+		log.Fatal("Server error:", err)
 	}
+	log.Println("Server stopped")
 }
 
-// InitRoutes sets up all application routes
-func InitRoutes(router *gin.Engine, db *Database) {
+// InitRoutes sets up all application routes and returns the review sync
+// scheduler it started, so main can stop it on shutdown
+func InitRoutes(router *gin.Engine, db *Database, jobs *socialmedia.JobQueue) *socialmedia.Scheduler {
 	// Create handlers
 	handlers := NewHandlers(db)
-	socialMediaHandlers := NewSocialMediaHandlers(db)
+	socialMediaHandlers := NewSocialMediaHandlers(db, jobs)
+
+	// Purge review templates deleted more than 30 days ago
+	handlers.startReviewPurge()
+
+	// Weekly digest emails (only when SMTP is configured)
+	if digest := newWeeklyDigestFromEnv(handlers); digest != nil {
+		digest.Start()
+	}
+
+	// Mount everything under BASE_PATH so the app can sit behind a sub-path proxy
+	root := router.Group(basePath)
+
+	// Serve static files
+	root.Static("/static", "./static")
 
 	// Public routes
-	router.GET("/", handlers.Home)
-	router.GET("/merchant", handlers.MerchantPage) // ?bn=businessname
+	root.GET("/", handlers.Home)
+	root.GET("/merchant", handlers.MerchantPage) // ?bn=businessname
+	root.GET("/sitemap.xml", handlers.Sitemap)
+	root.GET("/s/:code", handlers.ShortLinkRedirect)
+	root.GET("/go/:merchantId/:platform", handlers.ClickThrough)
 
 	// Auth routes (redirect if already logged in)
-	router.GET("/login", SupabaseRedirectIfAuthenticated(), handlers.LoginPage)
-	router.POST("/login", SupabaseLogin)
-	router.GET("/register", SupabaseRedirectIfAuthenticated(), handlers.RegisterPage)
-	router.POST("/register", SupabaseRegister)
-	router.POST("/logout", SupabaseLogout)
+	root.GET("/login", SupabaseRedirectIfAuthenticated(), handlers.LoginPage)
+	root.POST("/login", SupabaseLogin)
+	root.GET("/register", SupabaseRedirectIfAuthenticated(), handlers.RegisterPage)
+	root.POST("/register", SupabaseRegister)
+	root.POST("/logout", SupabaseLogout)
 
 	// Supabase auth callback routes (server-side handling)
-	router.GET("/auth/callback", HandleSupabaseAuthCallback)
-	router.POST("/auth/reset-password", ResetPasswordCallback)
+	root.GET("/auth/callback", HandleSupabaseAuthCallback)
+	root.POST("/auth/reset-password", ResetPasswordCallback)
 
 	// Password reset routes (Supabase Auth only)
-	router.GET("/forgot-password", SupabaseRedirectIfAuthenticated(), ForgotPasswordPage)
-	router.POST("/forgot-password", ForgotPassword)
-	router.GET("/reset-password", ResetPasswordPage)
-	router.POST("/api/reset-password", ResetPassword)
+	root.GET("/forgot-password", SupabaseRedirectIfAuthenticated(), ForgotPasswordPage)
+	root.POST("/forgot-password", ForgotPassword)
+	root.GET("/reset-password", ResetPasswordPage)
+	root.POST("/api/reset-password", ResetPassword)
 
 	// Admin routes (protected)
-	admin := router.Group("/admin")
+	admin := root.Group("/admin")
 	admin.Use(SupabaseAuthMiddleware("admin"))
 	{
 		admin.GET("/", handlers.AdminDashboard)
 		admin.GET("/merchants", handlers.AdminMerchantsList)
 		admin.GET("/merchants/new", handlers.AdminMerchantForm)
-		admin.POST("/merchants", handlers.AdminCreateMerchant)
+		admin.POST("/merchants", LimitUploadSize(), handlers.AdminCreateMerchant)
 		admin.GET("/merchants/:id/edit", handlers.AdminEditMerchant)
-		admin.POST("/merchants/:id/update", handlers.AdminUpdateMerchant) // Changed from PUT to POST
-		admin.POST("/merchants/:id/delete", handlers.AdminDeleteMerchant) // Changed from DELETE to POST
+		admin.POST("/merchants/:id/update", LimitUploadSize(), handlers.AdminUpdateMerchant) // Changed from PUT to POST
+		admin.POST("/merchants/:id/delete", handlers.AdminDeleteMerchant)                    // Changed from DELETE to POST
+		admin.POST("/merchants/:id/restore", handlers.AdminRestoreMerchant)
+		admin.POST("/merchants/:id/duplicate", handlers.AdminDuplicateMerchant)
+		admin.POST("/merchants/:id/hard-delete", handlers.AdminHardDeleteMerchant)
 		admin.GET("/audit-logs", handlers.AdminAuditLogs)
+		admin.GET("/connections", socialMediaHandlers.AdminConnectionsPage)
+		admin.POST("/merchants/:id/impersonate", handlers.AdminImpersonateMerchant)
+		admin.POST("/stop-impersonating", handlers.AdminStopImpersonating)
 	}
 
 	// Merchant routes (protected)
-	merchant := router.Group("/dashboard")
+	merchant := root.Group("/dashboard")
 	merchant.Use(SupabaseAuthMiddleware("merchant"))
 	{
 		merchant.GET("/", handlers.MerchantDashboard)
 		merchant.GET("/profile", handlers.MerchantProfile)
-		merchant.POST("/profile", handlers.UpdateMerchantProfile) // Changed from PUT to POST
+		merchant.POST("/profile", LimitUploadSize(), handlers.UpdateMerchantProfile) // Changed from PUT to POST
+		merchant.GET("/export", BlockImpersonation(), handlers.ExportMerchantData)
+		merchant.POST("/short-link", handlers.CreateShortLink)
+		merchant.GET("/api-keys", handlers.ListAPIKeys)
+		merchant.POST("/api-keys", handlers.CreateAPIKey)
+		merchant.POST("/api-keys/:id/revoke", handlers.RevokeAPIKey)
+		merchant.POST("/delete-account", socialMediaHandlers.DeleteAccount)
 
 		// Social media integrations
-		merchant.GET("/integrations", socialMediaHandlers.IntegrationsPage)
+		merchant.GET("/integrations", handlers.SelectedMerchantMiddleware(), socialMediaHandlers.IntegrationsPage)
 	}
 
 	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "healthy",
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	})
+	root.GET("/health", HealthCheck(db, socialMediaHandlers.scheduler))
+
+	// Deployment probes: /livez for liveness and keep-alive, /readyz for Render's health check
+	root.GET("/livez", Livez)
+	root.GET("/readyz", Readyz(db))
 
 	// API routes for HTMX
-	api := router.Group("/api")
+	api := root.Group("/api")
 	{
 		// Admin-only API routes
 		adminAPI := api.Group("")
 		adminAPI.Use(SupabaseAuthMiddleware("admin"))
 		{
 			adminAPI.POST("/merchants/:id/toggle-status", handlers.ToggleMerchantStatus)
+			adminAPI.POST("/merchants/bulk-status", handlers.BulkMerchantStatus)
+			adminAPI.GET("/admin/scheduler/status", socialMediaHandlers.AdminSchedulerStatus)
 		}
 
-		// Public API for reviews data
-		api.GET("/reviews/data/:merchantId", handlers.GetReviewsData)
-		api.GET("/reviews/modal/:merchantId/:platform", handlers.GetReviewModal)
+		// Public routes, readable cross-origin by widgets embedded on merchant sites
+		publicAPI := api.Group("")
+		publicAPI.Use(PublicCORS())
+		{
+			// Public merchant profile for third parties and apps
+			publicGET(publicAPI, "/merchants/:slug", handlers.GetPublicProfile)
+			publicGET(publicAPI, "/merchants/:slug/reviews", handlers.GetPublicReviews)
 
-		// Public API for analytics tracking
-		api.GET("/track/view", handlers.TrackPageView)
-		api.GET("/track/click", handlers.TrackLinkClick)
+			// Public API for reviews data
+			publicGET(publicAPI, "/reviews/data/:merchantId", handlers.GetReviewsData)
+			publicGET(publicAPI, "/reviews/modal/:merchantId/:platform", handlers.GetReviewModal)
+
+			// Public API for analytics tracking
+			publicGET(publicAPI, "/track/view", handlers.TrackPageView)
+			publicGET(publicAPI, "/track/click", handlers.TrackLinkClick)
+		}
 
 		// Review routes (protected)
 		reviewsAPI := api.Group("/reviews")
 		reviewsAPI.Use(SupabaseAuthMiddleware("merchant"))
 		{
 			reviewsAPI.POST("/add", handlers.AddReview)
+			reviewsAPI.POST("/import", LimitUploadSize(), handlers.ImportReviews)
 			reviewsAPI.DELETE("/:id", handlers.DeleteReview)
+			reviewsAPI.POST("/:id/restore", handlers.RestoreReview)
+			reviewsAPI.POST("/:id/duplicate", handlers.DuplicateReview)
 		}
 
 		// Social media API routes (protected)
 		socialMedia := api.Group("/social-media")
-		socialMedia.Use(SupabaseAuthMiddleware("merchant"))
+		socialMedia.Use(SupabaseAuthMiddleware("merchant"), handlers.SelectedMerchantMiddleware())
 		{
 			// OAuth routes
-			socialMedia.GET("/connect/:platform", socialMediaHandlers.ConnectPlatform)
-			socialMedia.GET("/callback/:platform", socialMediaHandlers.OAuthCallback)
+			socialMedia.GET("/connect/:platform", BlockImpersonation(), socialMediaHandlers.ConnectPlatform)
+			socialMedia.GET("/callback/:platform", BlockImpersonation(), socialMediaHandlers.OAuthCallback)
+
+			// Platform availability
+			socialMedia.GET("/platforms", socialMediaHandlers.GetPlatforms)
 
 			// Connection management
 			socialMedia.GET("/connections", socialMediaHandlers.GetConnections)
 			socialMedia.DELETE("/connections/:id", socialMediaHandlers.DisconnectPlatform)
+			socialMedia.POST("/connections/:id/clear-error", socialMediaHandlers.ClearConnectionError)
+			socialMedia.GET("/connections/:id/status", socialMediaHandlers.GetConnectionStatus)
 
 			// Sync operations
 			socialMedia.POST("/connections/:id/sync", socialMediaHandlers.TriggerSync)
@@ -185,45 +302,108 @@ func InitRoutes(router *gin.Engine, db *Database) {
 
 			// Synced reviews
 			socialMedia.GET("/reviews", socialMediaHandlers.GetSyncedReviews)
+			socialMedia.GET("/reviews/search", socialMediaHandlers.SearchSyncedReviews)
+			socialMedia.GET("/reviews/:id/history", socialMediaHandlers.GetReviewHistory)
+
+			// Notification settings
+			socialMedia.POST("/settings/notifications", socialMediaHandlers.UpdateNotificationSettings)
+		}
+
+		// Read-only API for merchants' own integrations, authenticated by API key
+		v1 := api.Group("/v1")
+		v1.Use(handlers.APIKeyMiddleware())
+		{
+			v1.GET("/reviews", socialMediaHandlers.GetSyncedReviews)
+			v1.GET("/stats", handlers.GetAPIStats)
+			v1.GET("/connections", socialMediaHandlers.GetConnections)
 		}
 
 		// Admin social media routes
 		adminSocialMedia := api.Group("/admin/social-media")
 		adminSocialMedia.Use(SupabaseAuthMiddleware("admin"))
 		{
-			adminSocialMedia.GET("/connections", socialMediaHandlers.AdminConnectionsPage)
+			adminSocialMedia.GET("/connections", socialMediaHandlers.AdminGetConnections)
+			adminSocialMedia.GET("/connections/failing", socialMediaHandlers.AdminFailingConnections)
+			adminSocialMedia.POST("/connections/:id/notes", socialMediaHandlers.UpdateConnectionNotes)
+			adminSocialMedia.POST("/connections/:id/sync", socialMediaHandlers.AdminForceSync)
+			adminSocialMedia.POST("/sync-logs/cleanup", socialMediaHandlers.AdminCleanupSyncLogs)
+			adminSocialMedia.POST("/sync-logs/:id/retry", socialMediaHandlers.AdminRetrySyncLog)
+			adminSocialMedia.POST("/reencrypt-tokens", socialMediaHandlers.AdminReencryptTokens)
 		}
 	}
+
+	return socialMediaHandlers.scheduler
 }
 
-// startKeepAlivePinger starts a goroutine that pings the health endpoint every 14 minutes
-// to prevent Render.com free tier from spinning down due to inactivity
-func startKeepAlivePinger() {
-	// Only run keep-alive in production (when deployed to Render.com)
-	if os.Getenv("RENDER") != "true" {
-		log.Println("Keep-alive pinger disabled (not running on Render.com)")
-		return
+// Keep-alive defaults: ping every 14 minutes, never more often than once a minute
+const (
+	defaultKeepAliveInterval = 14 * time.Minute
+	minKeepAliveInterval     = time.Minute
+)
+
+// parseKeepAliveInterval parses KEEPALIVE_INTERVAL (a Go duration such as "10m"),
+// falling back to the default when unset or invalid and clamping to the minimum
+func parseKeepAliveInterval(value string) time.Duration {
+	if value == "" {
+		return defaultKeepAliveInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("Invalid KEEPALIVE_INTERVAL %q, using %s", value, defaultKeepAliveInterval)
+		return defaultKeepAliveInterval
+	}
+	if interval < minKeepAliveInterval {
+		log.Printf("KEEPALIVE_INTERVAL %s is below the minimum, using %s", interval, minKeepAliveInterval)
+		return minKeepAliveInterval
+	}
+	return interval
+}
+
+// keepAliveEnabled honors KEEPALIVE_ENABLED when set and otherwise only
+// enables the pinger on Render.com, as before
+func keepAliveEnabled() bool {
+	if enabled, err := strconv.ParseBool(os.Getenv("KEEPALIVE_ENABLED")); err == nil {
+		return enabled
+	}
+	return os.Getenv("RENDER") == "true"
+}
+
+// keepAliveURL returns KEEPALIVE_URL if set, otherwise BASE_URL with the liveness
+// path; /livez doesn't depend on the database, so a DB outage won't stop the
+// pings that keep the instance awake
+func keepAliveURL() (string, error) {
+	if target := os.Getenv("KEEPALIVE_URL"); target != "" {
+		return target, nil
 	}
 
-	// Get base URL from environment variable
 	baseURL := os.Getenv("BASE_URL")
 	if baseURL == "" {
-		log.Println("BASE_URL environment variable not set, skipping keep-alive pinger")
-		return
+		return "", fmt.Errorf("neither KEEPALIVE_URL nor BASE_URL is set")
 	}
 
-	// Parse base URL and add health path
 	parsedURL, err := url.Parse(baseURL)
 	if err != nil {
-		log.Printf("Invalid BASE_URL: %v, skipping keep-alive pinger", err)
+		return "", fmt.Errorf("invalid BASE_URL: %v", err)
+	}
+	parsedURL.Path = appPath("/livez")
+	return parsedURL.String(), nil
+}
+
+// startKeepAlivePinger periodically pings the app's own liveness endpoint
+// to prevent Render.com free tier from spinning down due to inactivity
+func startKeepAlivePinger() {
+	if !keepAliveEnabled() {
+		log.Println("Keep-alive pinger disabled (set KEEPALIVE_ENABLED=true to enable outside Render.com)")
+		return
+	}
+
+	healthURL, err := keepAliveURL()
+	if err != nil {
+		log.Printf("Skipping keep-alive pinger: %v", err)
 		return
 	}
-	parsedURL.Path = "/health"
-	healthURL := parsedURL.String()
 
-	// Ping every 5 seconds for testing (switch back to 14 minutes for production)
-	interval := 14 * time.Minute // Production: 14 minutes
-	// interval := 5 * time.Second // Testing: 5 seconds
+	interval := parseKeepAliveInterval(os.Getenv("KEEPALIVE_INTERVAL"))
 
 	log.Printf("Starting keep-alive pinger - will ping %s every %s", healthURL, interval)
 
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseKeepAliveInterval(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":     defaultKeepAliveInterval,
+		"10m":  10 * time.Minute,
+		"2h":   2 * time.Hour,
+		"5s":   minKeepAliveInterval,
+		"-1m":  defaultKeepAliveInterval,
+		"0":    defaultKeepAliveInterval,
+		"soon": defaultKeepAliveInterval,
+	} {
+		if got := parseKeepAliveInterval(value); got != want {
+			t.Errorf("parseKeepAliveInterval(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestKeepAliveEnabled(t *testing.T) {
+	tests := []struct {
+		enabled, render string
+		want            bool
+	}{
+		{"", "", false},
+		{"", "true", true},
+		{"false", "true", false},
+		{"true", "", true},
+		{"maybe", "true", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("KEEPALIVE_ENABLED", tt.enabled)
+		t.Setenv("RENDER", tt.render)
+		if got := keepAliveEnabled(); got != tt.want {
+			t.Errorf("KEEPALIVE_ENABLED=%q RENDER=%q: enabled = %v, want %v", tt.enabled, tt.render, got, tt.want)
+		}
+	}
+}
+
+func TestKeepAliveURL(t *testing.T) {
+	withBasePath(t, "/reviews")
+
+	t.Setenv("KEEPALIVE_URL", "")
+	t.Setenv("BASE_URL", "")
+	if _, err := keepAliveURL(); err == nil {
+		t.Error("no URL configured: want an error")
+	}
+
+	t.Setenv("BASE_URL", "https://app.example.com/ignored")
+	if got, err := keepAliveURL(); err != nil || got != "https://app.example.com/reviews/livez" {
+		t.Errorf("from BASE_URL: %q, %v; want the liveness path under the base path", got, err)
+	}
+
+	t.Setenv("KEEPALIVE_URL", "https://ping.example.com/up")
+	if got, _ := keepAliveURL(); got != "https://ping.example.com/up" {
+		t.Errorf("KEEPALIVE_URL set: %q, want it used as is", got)
+	}
+}
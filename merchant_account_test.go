@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// newMerchantAccountHandlers records the statements createMerchantAccount runs,
+// failing the one containing failOn when it is set
+func newMerchantAccountHandlers(t *testing.T, failOn string) (*Handlers, *[]string) {
+	t.Helper()
+	var statements []string
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		statements = append(statements, query)
+		if failOn != "" && strings.Contains(query, failOn) {
+			return nil, errors.New("insert failed")
+		}
+		switch {
+		case strings.Contains(query, "INSERT INTO public.user_roles"):
+			if args[0] != "user-1" || args[1] != "merchant" {
+				t.Errorf("role args = %v", args)
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "INSERT INTO merchants"):
+			return &fakedb.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(42)}}}, nil
+		case strings.Contains(query, "INSERT INTO merchant_details"):
+			if args[0] != int64(42) {
+				t.Errorf("details for merchant %v, want 42", args[0])
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}}, &statements
+}
+
+func TestCreateMerchantAccount(t *testing.T) {
+	h, statements := newMerchantAccountHandlers(t, "")
+
+	merchantID, err := h.createMerchantAccount("user-1", "merchant", "Kopi Corner", "kopi-corner")
+	if err != nil || merchantID != 42 {
+		t.Fatalf("createMerchantAccount = %d, %v, want 42", merchantID, err)
+	}
+	if len(*statements) != 3 {
+		t.Errorf("ran %d statements, want the role, merchant and details inserts", len(*statements))
+	}
+}
+
+func TestCreateMerchantAccountFails(t *testing.T) {
+	for _, failOn := range []string{"user_roles", "INSERT INTO merchants", "merchant_details"} {
+		h, statements := newMerchantAccountHandlers(t, failOn)
+
+		if _, err := h.createMerchantAccount("user-1", "merchant", "Kopi Corner", "kopi-corner"); err == nil {
+			t.Errorf("%s insert failed: createMerchantAccount succeeded", failOn)
+		}
+		if last := (*statements)[len(*statements)-1]; !strings.Contains(last, failOn) {
+			t.Errorf("%s insert failed: kept going with %s", failOn, last)
+		}
+	}
+}
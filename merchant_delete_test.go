@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// newSoftDeleteHandlers backs deleteMerchant, restoreMerchant and
+// getMerchantBySlug with one in-memory active merchant, id 1, slug "cafe"
+func newSoftDeleteHandlers(t *testing.T) *Handlers {
+	t.Helper()
+	deleted := false
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SET deleted_at = CURRENT_TIMESTAMP"):
+			deleted = true
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SET deleted_at = NULL"):
+			deleted = false
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "FROM merchants WHERE slug = $1"):
+			res := &fakedb.Result{Columns: make([]string, 7)}
+			if args[0] == "cafe" && !(deleted && strings.Contains(query, "deleted_at IS NULL")) {
+				now := time.Now()
+				res.Rows = [][]driver.Value{{int64(1), "user-1", "Cafe", "cafe", true, now, now}}
+			}
+			return res, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}, pageCache: newPageCacheFromEnv()}
+}
+
+func TestSoftDeletedMerchantIsHiddenButRestorable(t *testing.T) {
+	h := newSoftDeleteHandlers(t)
+
+	if _, err := h.getMerchantBySlug("cafe"); err != nil {
+		t.Fatalf("before delete: %v", err)
+	}
+
+	if err := h.deleteMerchant(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.getMerchantBySlug("cafe"); err != sql.ErrNoRows {
+		t.Errorf("after delete: err = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := h.restoreMerchant(1); err != nil {
+		t.Fatal(err)
+	}
+	merchant, err := h.getMerchantBySlug("cafe")
+	if err != nil || merchant.ID != 1 {
+		t.Errorf("after restore: %v, %v; want merchant 1", merchant, err)
+	}
+}
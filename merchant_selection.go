@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errMerchantNotOwned is returned when the requested merchant_id isn't one of the user's businesses
+var errMerchantNotOwned = errors.New("merchant does not belong to this user")
+
+// selectMerchant picks the business a request acts on from the user's merchants.
+// An explicit merchant_id (query string or form field) must be one of them;
+// without one the first business is used. Returns nil if the user has none.
+func selectMerchant(c *gin.Context, merchants []Merchant) (*Merchant, error) {
+	idStr := c.Query("merchant_id")
+	if idStr == "" {
+		idStr = c.PostForm("merchant_id")
+	}
+
+	if idStr == "" {
+		if len(merchants) == 0 {
+			return nil, nil
+		}
+		return &merchants[0], nil
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, errMerchantNotOwned
+	}
+	for i := range merchants {
+		if merchants[i].ID == id {
+			return &merchants[i], nil
+		}
+	}
+	return nil, errMerchantNotOwned
+}
+
+// selectedMerchant loads the logged-in user's merchants and returns the selected one alongside them
+func (h *Handlers) selectedMerchant(c *gin.Context) (*Merchant, []Merchant, error) {
+	merchants, err := h.getMerchantsByAuthUserID(c.GetString("user_id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	merchant, err := selectMerchant(c, merchants)
+	return merchant, merchants, err
+}
+
+// getMerchantIDFromContext returns the id of the selected merchant, or 0 if the user has none yet
+func (h *Handlers) getMerchantIDFromContext(c *gin.Context) (int, error) {
+	merchant, _, err := h.selectedMerchant(c)
+	if err != nil || merchant == nil {
+		return 0, err
+	}
+	return merchant.ID, nil
+}
+
+// SelectedMerchantMiddleware stores the selected merchant's id as "merchant_id" for handlers
+// that act on a single business. Must run after SupabaseAuthMiddleware.
+func (h *Handlers) SelectedMerchantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		merchantID, err := h.getMerchantIDFromContext(c)
+		if err == errMerchantNotOwned {
+			if isAPIRequest(c) {
+				respondAPIError(c, http.StatusForbidden, "Merchant not found")
+				return
+			}
+			c.Status(http.StatusForbidden)
+			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+				"error": "You don't have access to that business",
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			respondAPIError(c, http.StatusInternalServerError, "Failed to load your businesses")
+			return
+		}
+
+		c.Set("merchant_id", merchantID)
+		c.Next()
+	}
+}
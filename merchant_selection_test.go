@@ -0,0 +1,83 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// merchantSelectionRouter echoes the merchant SelectedMerchantMiddleware picks.
+// user-1 owns merchants 1 and 2 (1 listed first), user-2 owns merchant 3.
+func merchantSelectionRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	owned := map[string][]int64{"user-1": {1, 2}, "user-2": {3}}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if !strings.Contains(query, "FROM merchants WHERE auth_user_id = $1") {
+			t.Fatalf("unexpected query: %s", query)
+		}
+		res := &fakedb.Result{Columns: make([]string, 6)}
+		for _, id := range owned[args[0].(string)] {
+			res.Rows = append(res.Rows, []driver.Value{id, args[0], "Shop", "shop", true, time.Now()})
+		}
+		return res, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	h := &Handlers{db: &Database{DB: conn}}
+
+	router := gin.New()
+	echo := func(c *gin.Context) { c.String(http.StatusOK, strconv.Itoa(c.GetInt("merchant_id"))) }
+	for _, user := range []string{"user-1", "user-2", "user-3"} {
+		router.Any("/api/"+user+"/connections", asUser(user), h.SelectedMerchantMiddleware(), echo)
+	}
+	return router
+}
+
+func TestSelectedMerchantMiddleware(t *testing.T) {
+	router := merchantSelectionRouter(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		form       url.Values
+		wantStatus int
+		wantID     string
+	}{
+		{"default to the first business", "/api/user-1/connections", nil, http.StatusOK, "1"},
+		{"query selects the second", "/api/user-1/connections?merchant_id=2", nil, http.StatusOK, "2"},
+		{"form field selects the second", "/api/user-1/connections", url.Values{"merchant_id": {"2"}}, http.StatusOK, "2"},
+		{"another user's business", "/api/user-1/connections?merchant_id=3", nil, http.StatusForbidden, ""},
+		{"not a number", "/api/user-1/connections?merchant_id=two", nil, http.StatusForbidden, ""},
+		{"single business", "/api/user-2/connections", nil, http.StatusOK, "3"},
+		{"no business yet", "/api/user-3/connections", nil, http.StatusOK, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w *httptest.ResponseRecorder
+			if tt.form != nil {
+				w = postForm(router, tt.path, tt.form)
+			} else {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantID {
+				t.Errorf("selected merchant %s, want %s", w.Body, tt.wantID)
+			}
+			if tt.wantStatus == http.StatusForbidden && !strings.Contains(w.Body.String(), `"code":"forbidden"`) {
+				t.Errorf("body = %s, want the API error envelope", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxOGDescription keeps share previews to what WhatsApp and Facebook display
+const maxOGDescription = 160
+
+// openGraph is the share preview of a page, rendered as og: and twitter: tags
+// by the base layout. Pages without one get the site-wide defaults.
+type openGraph struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+}
+
+// publicOrigin is the absolute URL of the app root: BASE_URL when configured,
+// otherwise the origin the request came in on
+func publicOrigin(c *gin.Context) string {
+	if baseURL := strings.TrimRight(os.Getenv("BASE_URL"), "/"); baseURL != "" {
+		return baseURL + appPath("")
+	}
+	return requestOrigin(c)
+}
+
+// businessOpenGraph builds the share preview of a merchant's business page.
+// The description is the first review, then the address, then a generic line;
+// the image is the logo, then the app logo.
+func businessOpenGraph(origin string, merchant *Merchant, details *MerchantDetails, reviews []Review) openGraph {
+	og := openGraph{
+		Title: merchant.BusinessName,
+		URL:   origin + "/?id=" + url.QueryEscape(merchant.Slug),
+	}
+
+	for _, review := range reviews {
+		if text := strings.TrimSpace(review.ReviewText); text != "" {
+			og.Description = truncateText(text, maxOGDescription)
+			break
+		}
+	}
+	if og.Description == "" && details != nil && strings.TrimSpace(details.Address) != "" {
+		og.Description = truncateText(strings.TrimSpace(details.Address), maxOGDescription)
+	}
+	if og.Description == "" {
+		og.Description = "See what customers say about " + merchant.BusinessName + " and leave a review."
+	}
+
+	if details != nil && details.LogoURL != "" {
+		og.Image = absoluteURL(origin, details.LogoURL)
+	} else if branding.LogoURL != "" {
+		og.Image = absoluteURL(origin, branding.LogoURL)
+	}
+
+	return og
+}
+
+// absoluteURL resolves a root-relative path (such as an uploaded logo served
+// from /static) against origin; absolute URLs are returned unchanged
+func absoluteURL(origin, ref string) string {
+	if strings.Contains(ref, "://") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "//") {
+		return "https:" + ref
+	}
+	// origin already ends in BASE_PATH, so don't repeat it
+	if basePath != "" && strings.HasPrefix(ref, basePath+"/") {
+		ref = strings.TrimPrefix(ref, basePath)
+	}
+	return origin + "/" + strings.TrimPrefix(ref, "/")
+}
+
+// truncateText shortens s to at most max runes, ending with an ellipsis when cut
+func truncateText(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
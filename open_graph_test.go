@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBusinessOpenGraph(t *testing.T) {
+	origBranding := branding
+	t.Cleanup(func() { branding = origBranding })
+	branding.LogoURL = "/static/images/logo.png"
+
+	const origin = "https://reviews.example.com"
+	merchant := &Merchant{BusinessName: "Kopi & Co", Slug: "kopi co"}
+
+	og := businessOpenGraph(origin, merchant, &MerchantDetails{Address: " 1 Jalan Ampang ", LogoURL: "https://cdn.example.com/logo.png"},
+		[]Review{{ReviewText: "  "}, {ReviewText: "Best kopi in town"}})
+	want := openGraph{
+		Title:       "Kopi & Co",
+		Description: "Best kopi in town",
+		Image:       "https://cdn.example.com/logo.png",
+		URL:         "https://reviews.example.com/?id=kopi+co",
+	}
+	if og != want {
+		t.Errorf("og = %+v, want %+v", og, want)
+	}
+
+	// Without reviews or a logo: the address and the app logo
+	og = businessOpenGraph(origin, merchant, &MerchantDetails{Address: " 1 Jalan Ampang "}, nil)
+	if og.Description != "1 Jalan Ampang" || og.Image != "https://reviews.example.com/static/images/logo.png" {
+		t.Errorf("og = %+v, want the address and the absolute app logo", og)
+	}
+
+	og = businessOpenGraph(origin, merchant, nil, nil)
+	if !strings.Contains(og.Description, "Kopi & Co") {
+		t.Errorf("description = %q, want the generic line", og.Description)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	origBasePath := basePath
+	t.Cleanup(func() { basePath = origBasePath })
+	basePath = "/reviews"
+
+	const origin = "https://example.com/reviews"
+	tests := map[string]string{
+		"https://cdn.example.com/a.png": "https://cdn.example.com/a.png",
+		"//cdn.example.com/a.png":       "https://cdn.example.com/a.png",
+		"/static/a.png":                 "https://example.com/reviews/static/a.png",
+		"/reviews/static/a.png":         "https://example.com/reviews/static/a.png",
+		"static/a.png":                  "https://example.com/reviews/static/a.png",
+	}
+	for ref, want := range tests {
+		if got := absoluteURL(origin, ref); got != want {
+			t.Errorf("absoluteURL(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("short", 10); got != "short" {
+		t.Errorf("truncateText kept %q, want it unchanged", got)
+	}
+	got := truncateText(strings.Repeat("é", 200), maxOGDescription)
+	if utf8.RuneCountInString(got) != maxOGDescription || !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+		t.Errorf("truncateText = %d runes %q, want %d ending in an ellipsis", utf8.RuneCountInString(got), got, maxOGDescription)
+	}
+}
+
+func TestPublicOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Host = "internal:8080"
+
+	t.Setenv("BASE_URL", "https://reviews.example.com/")
+	if got := publicOrigin(c); got != "https://reviews.example.com"+appPath("") {
+		t.Errorf("with BASE_URL: origin = %q", got)
+	}
+	t.Setenv("BASE_URL", "")
+	if got := publicOrigin(c); got != "http://internal:8080"+appPath("") {
+		t.Errorf("without BASE_URL: origin = %q, want the request origin", got)
+	}
+}
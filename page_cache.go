@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pageCache holds rendered business page HTML for anonymous visitors.
+// Entries are keyed by merchant ID, locale and public origin (share preview
+// URLs are absolute), and only served while the page version (its ETag, see
+// businessPageVersion) matches, so any profile, details, review or synced
+// review change and any Google rating refetch busts them.
+type pageCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[int]map[string]pageCacheEntry
+}
+
+type pageCacheEntry struct {
+	version   string
+	html      []byte
+	expiresAt time.Time
+}
+
+// newPageCacheFromEnv creates a page cache using PAGE_CACHE_TTL_SECONDS.
+// Returns nil (caching disabled) when the TTL is unset or zero.
+func newPageCacheFromEnv() *pageCache {
+	ttlSeconds, err := strconv.Atoi(os.Getenv("PAGE_CACHE_TTL_SECONDS"))
+	if err != nil || ttlSeconds <= 0 {
+		return nil
+	}
+
+	log.Printf("Business page cache enabled with TTL %ds", ttlSeconds)
+	return &pageCache{
+		ttl:     time.Duration(ttlSeconds) * time.Second,
+		entries: make(map[int]map[string]pageCacheEntry),
+	}
+}
+
+// maxPageCacheVariants bounds the locale and origin pairs kept per merchant,
+// since without BASE_URL the origin comes from the client-controlled Host header
+const maxPageCacheVariants = 16
+
+// pageCacheVariant is the key of one rendering of a merchant's page
+func pageCacheVariant(locale, origin string) string {
+	return locale + " " + origin
+}
+
+// Get returns cached HTML for the merchant, locale and origin if it is fresh
+// and matches version
+func (pc *pageCache) Get(merchantID int, locale, origin, version string) ([]byte, bool) {
+	if pc == nil {
+		return nil, false
+	}
+
+	pc.mu.RLock()
+	entry, ok := pc.entries[merchantID][pageCacheVariant(locale, origin)]
+	pc.mu.RUnlock()
+
+	if !ok || entry.version != version || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.html, true
+}
+
+// Set stores rendered HTML for the merchant, locale and origin at version
+func (pc *pageCache) Set(merchantID int, locale, origin, version string, html []byte) {
+	if pc == nil {
+		return
+	}
+
+	pc.mu.Lock()
+	if len(pc.entries[merchantID]) >= maxPageCacheVariants {
+		pc.entries[merchantID] = nil
+	}
+	if pc.entries[merchantID] == nil {
+		pc.entries[merchantID] = make(map[string]pageCacheEntry)
+	}
+	pc.entries[merchantID][pageCacheVariant(locale, origin)] = pageCacheEntry{
+		version:   version,
+		html:      html,
+		expiresAt: time.Now().Add(pc.ttl),
+	}
+	pc.mu.Unlock()
+}
+
+// Invalidate drops any cached pages for the merchant in every locale
+func (pc *pageCache) Invalidate(merchantID int) {
+	if pc == nil {
+		return
+	}
+
+	pc.mu.Lock()
+	delete(pc.entries, merchantID)
+	pc.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageCacheMatchesVersions(t *testing.T) {
+	pc := &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)}
+	pc.Set(1, "en", "https://a.example", `W/"one"`, []byte("page"))
+
+	if html, ok := pc.Get(1, "en", "https://a.example", `W/"one"`); !ok || string(html) != "page" {
+		t.Fatalf("Get = %q, %v; want the cached page", html, ok)
+	}
+
+	tests := []struct {
+		name    string
+		locale  string
+		origin  string
+		version string
+	}{
+		{"other locale", "ms", "https://a.example", `W/"one"`},
+		{"other origin", "en", "https://b.example", `W/"one"`},
+		{"new version", "en", "https://a.example", `W/"two"`},
+		{"no version", "en", "https://a.example", ""},
+	}
+	for _, tt := range tests {
+		if _, ok := pc.Get(1, tt.locale, tt.origin, tt.version); ok {
+			t.Errorf("%s: got a cached page", tt.name)
+		}
+	}
+
+	pc.Invalidate(1)
+	if _, ok := pc.Get(1, "en", "https://a.example", `W/"one"`); ok {
+		t.Error("got a cached page after Invalidate")
+	}
+}
+
+func TestPageCacheExpires(t *testing.T) {
+	pc := &pageCache{ttl: -time.Second, entries: make(map[int]map[string]pageCacheEntry)}
+	pc.Set(1, "en", "", "v", []byte("page"))
+	if _, ok := pc.Get(1, "en", "", "v"); ok {
+		t.Error("got an expired page")
+	}
+
+	var disabled *pageCache
+	disabled.Set(1, "en", "", "v", []byte("page"))
+	if _, ok := disabled.Get(1, "en", "", "v"); ok {
+		t.Error("nil cache returned a page")
+	}
+}
+
+func TestPageCacheBoundsVariants(t *testing.T) {
+	pc := &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)}
+	for i := 0; i < maxPageCacheVariants+1; i++ {
+		pc.Set(1, "en", fmt.Sprintf("http://host%d", i), "v", []byte("page"))
+	}
+	if n := len(pc.entries[1]); n > maxPageCacheVariants {
+		t.Errorf("kept %d variants, want at most %d", n, maxPageCacheVariants)
+	}
+}
+
+func TestBusinessPageCacheKeysOnHost(t *testing.T) {
+	t.Setenv("BASE_URL", "")
+	f := newBusinessPageFixture()
+	f.details.LogoURL = "/static/uploads/logo.png"
+	h := f.handlers(t, &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)})
+
+	evil := getBusinessPage(h, "id=cafe", http.Header{"Host": {"evil.example"}})
+	if !strings.Contains(evil.Body.String(), "http://evil.example/") {
+		t.Fatalf("page for evil.example has no evil.example share URLs")
+	}
+
+	w := getBusinessPage(h, "id=cafe", http.Header{"Host": {"reviews.example.com"}})
+	if w.Header().Get("X-Page-Cache") != "MISS" || strings.Contains(w.Body.String(), "evil.example") {
+		t.Errorf("second host: cache %q; want its own page without the first host's URLs", w.Header().Get("X-Page-Cache"))
+	}
+	if !strings.Contains(w.Body.String(), `content="http://reviews.example.com/static/uploads/logo.png"`) {
+		t.Error("second host: og:image does not use its own origin")
+	}
+	if w.Header().Get("ETag") == evil.Header().Get("ETag") {
+		t.Error("both hosts got the same ETag")
+	}
+
+	if again := getBusinessPage(h, "id=cafe", http.Header{"Host": {"evil.example"}}); again.Header().Get("X-Page-Cache") != "HIT" {
+		t.Errorf("repeat view on the first host: cache %q, want HIT", again.Header().Get("X-Page-Cache"))
+	}
+}
+
+func TestBusinessPageCacheProfileUpdate(t *testing.T) {
+	f := newBusinessPageFixture()
+	cache := &pageCache{ttl: time.Minute, entries: make(map[int]map[string]pageCacheEntry)}
+	h := f.handlers(t, cache)
+
+	getBusinessPage(h, "id=cafe", nil)
+	if w := getBusinessPage(h, "id=cafe", nil); w.Header().Get("X-Page-Cache") != "HIT" || f.renders != 1 {
+		t.Fatalf("second view: cache %q after %d renders; want it served from cache", w.Header().Get("X-Page-Cache"), f.renders)
+	}
+
+	if err := h.updateMerchantDetails(&MerchantDetails{MerchantID: 1, Address: "1 Jalan Ampang", ThemeColor: "#4f46e5"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.entries[1]; ok {
+		t.Error("profile update left the merchant's cached pages")
+	}
+	w := getBusinessPage(h, "id=cafe", nil)
+	if w.Header().Get("X-Page-Cache") != "MISS" || f.renders != 2 || !strings.Contains(w.Body.String(), "1 Jalan Ampang") {
+		t.Errorf("after profile update: cache %q after %d renders; want a fresh page with the new address", w.Header().Get("X-Page-Cache"), f.renders)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+)
+
+// pageViewDedupWindow is how long after a stored view further views from the
+// same IP count as repeats rather than new rows. Set by loadPageViewDedup.
+var pageViewDedupWindow = 30 * time.Minute
+
+// loadPageViewDedup reads the dedup window from env (in minutes)
+func loadPageViewDedup() {
+	pageViewDedupWindow = time.Duration(envInt("PAGE_VIEW_DEDUP_MINUTES", 30)) * time.Minute
+}
+
+// viewedRecently reports whether ip already has a stored view of the merchant's
+// page within the dedup window. Bot and human views are deduplicated separately.
+func (h *Handlers) viewedRecently(merchantID int, ip string, isBot bool) (bool, error) {
+	var recent bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM page_views
+			WHERE merchant_id = $1 AND ip_address = $2 AND is_bot = $4
+				AND created_at > NOW() - make_interval(secs => $3)
+		)
+	`, merchantID, ip, pageViewDedupWindow.Seconds(), isBot).Scan(&recent)
+	return recent, err
+}
+
+// recordRepeatView counts a suppressed view in today's repeat total for the merchant
+func (h *Handlers) recordRepeatView(merchantID int) error {
+	_, err := h.db.Exec(`
+		INSERT INTO page_view_repeats (merchant_id, day, repeats)
+		VALUES ($1, CURRENT_DATE, 1)
+		ON CONFLICT (merchant_id, day) DO UPDATE SET repeats = page_view_repeats.repeats + 1
+	`, merchantID)
+	return err
+}
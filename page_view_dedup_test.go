@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoadPageViewDedup(t *testing.T) {
+	t.Cleanup(func() { pageViewDedupWindow = 30 * time.Minute })
+
+	t.Setenv("PAGE_VIEW_DEDUP_MINUTES", "")
+	loadPageViewDedup()
+	if pageViewDedupWindow != 30*time.Minute {
+		t.Errorf("default window = %s, want 30m", pageViewDedupWindow)
+	}
+	t.Setenv("PAGE_VIEW_DEDUP_MINUTES", "5")
+	loadPageViewDedup()
+	if pageViewDedupWindow != 5*time.Minute {
+		t.Errorf("window = %s, want 5m", pageViewDedupWindow)
+	}
+}
+
+func TestTrackPageViewDeduplicatesRepeats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRACKING_IP_LIMIT", "100")
+	t.Setenv("TRACKING_MERCHANT_LIMIT", "100")
+	loadTrackingLimits()
+
+	// stored holds "ip bot?" for every page view row inserted
+	stored := map[string]bool{}
+	repeats := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT analytics_sample_rate"):
+			return &fakedb.Result{Columns: []string{"analytics_sample_rate"}, Rows: [][]driver.Value{{int64(1)}}}, nil
+		case strings.Contains(query, "FROM page_views"):
+			if args[2] != pageViewDedupWindow.Seconds() {
+				t.Errorf("dedup window arg = %v, want %v seconds", args[2], pageViewDedupWindow.Seconds())
+			}
+			recent := stored[fmt.Sprint(args[1], " ", args[3])]
+			return &fakedb.Result{Columns: []string{"exists"}, Rows: [][]driver.Value{{recent}}}, nil
+		case strings.Contains(query, "INSERT INTO page_views"):
+			stored[fmt.Sprint(args[1], " ", args[5])] = true
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "INSERT INTO page_view_repeats"):
+			repeats++
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/api/track/view", h.TrackPageView)
+
+	view := func(ip, userAgent string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/track/view?merchant_id=1", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body struct{ Status string }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Status
+	}
+
+	browser := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Safari/604.1"
+	crawler := "Googlebot/2.1 (+http://www.google.com/bot.html)"
+	steps := []struct {
+		ip, userAgent, want string
+	}{
+		{"203.0.113.1", browser, "tracked"},
+		{"203.0.113.1", browser, "repeat"},
+		{"203.0.113.2", browser, "tracked"},
+		// Bots are deduplicated on their own and never counted as repeats
+		{"203.0.113.1", crawler, "tracked"},
+		{"203.0.113.1", crawler, "repeat"},
+	}
+	for i, step := range steps {
+		if got := view(step.ip, step.userAgent); got != step.want {
+			t.Errorf("view %d from %s: status %q, want %q", i+1, step.ip, got, step.want)
+		}
+	}
+	if len(stored) != 3 || repeats != 1 {
+		t.Errorf("stored %v with %d repeats, want 3 views and 1 human repeat", stored, repeats)
+	}
+}
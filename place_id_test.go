@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// recordExecs returns Handlers over a database that accepts every statement and
+// records it with its arguments
+func recordExecs(t *testing.T) (*Handlers, *[]string, *[][]driver.Value) {
+	t.Helper()
+	var queries []string
+	var args [][]driver.Value
+	conn := fakedb.Open(func(query string, a []driver.Value) (*fakedb.Result, error) {
+		queries = append(queries, query)
+		args = append(args, a)
+		return &fakedb.Result{RowsAffected: 1}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}}, &queries, &args
+}
+
+func TestGooglePlaceIDUsesCachedID(t *testing.T) {
+	h, queries, _ := recordExecs(t)
+	merchant := &Merchant{ID: 7, BusinessName: "Kopi Corner"}
+
+	if got := h.googlePlaceID(merchant, &MerchantDetails{Address: "1 Jalan Ampang", GooglePlaceID: "place-1"}); got != "place-1" {
+		t.Errorf("cached: Place ID %q, want place-1", got)
+	}
+	if got := h.googlePlaceID(merchant, &MerchantDetails{}); got != "" {
+		t.Errorf("no address: Place ID %q, want none", got)
+	}
+
+	// A failed lookup isn't cached, so the next request tries again
+	t.Setenv("GOOGLE_PLACES_API_KEY", "")
+	if got := h.googlePlaceID(merchant, &MerchantDetails{Address: "1 Jalan Ampang"}); got != "" {
+		t.Errorf("failed lookup: Place ID %q, want none", got)
+	}
+	if len(*queries) != 0 {
+		t.Errorf("ran %v, want no writes", *queries)
+	}
+}
+
+func TestPlaceIDIsClearedOnNameOrAddressChange(t *testing.T) {
+	h, queries, args := recordExecs(t)
+
+	if err := h.invalidatePlaceID(7, "Kopi Corner 2"); err != nil {
+		t.Fatal(err)
+	}
+	if q := (*queries)[0]; !strings.Contains(q, "SET google_place_id = NULL") || !strings.Contains(q, "business_name IS DISTINCT FROM $2") {
+		t.Errorf("invalidate query = %s", q)
+	}
+	if a := (*args)[0]; a[0] != int64(7) || a[1] != "Kopi Corner 2" {
+		t.Errorf("invalidate args = %v", a)
+	}
+
+	if err := h.updateMerchantDetails(&MerchantDetails{MerchantID: 7, Address: "2 Jalan Ampang"}); err != nil {
+		t.Fatal(err)
+	}
+	if q := (*queries)[1]; !strings.Contains(q, "google_place_id = CASE WHEN COALESCE(address, '') = $1 THEN google_place_id END") {
+		t.Errorf("details update doesn't clear the Place ID on an address change: %s", q)
+	}
+}
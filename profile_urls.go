@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// profileURLField is a link on the merchant profile form. Domains, when set,
+// limits the host to those domains and their subdomains.
+type profileURLField struct {
+	Name    string
+	Label   string
+	Domains []string
+}
+
+// profileURLFields lists the profile form fields that hold links
+var profileURLFields = []profileURLField{
+	{Name: "facebook_url", Label: "Facebook URL", Domains: []string{"facebook.com", "fb.com", "fb.me"}},
+	{Name: "tiktok_url", Label: "TikTok URL", Domains: []string{"tiktok.com"}},
+	{Name: "instagram_url", Label: "Instagram URL", Domains: []string{"instagram.com"}},
+	{Name: "threads_url", Label: "Threads URL", Domains: []string{"threads.net", "threads.com"}},
+	{Name: "website_url", Label: "Website URL"},
+	{Name: "google_play_url", Label: "Google Play URL", Domains: []string{"play.google.com"}},
+	{Name: "app_store_url", Label: "App Store URL", Domains: []string{"apps.apple.com", "itunes.apple.com"}},
+	{Name: "google_maps_url", Label: "Google Maps URL", Domains: []string{"google.com", "google.com.my", "goo.gl", "g.page"}},
+	{Name: "waze_url", Label: "Waze URL", Domains: []string{"waze.com"}},
+}
+
+// normalizeURL trims raw, adds https:// when the scheme is missing and checks
+// the host looks real (and, if domains are given, belongs to one of them).
+// Empty input is returned as-is.
+func normalizeURL(raw string, domains ...string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + strings.TrimPrefix(raw, "//")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.New("is not a valid URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("must start with http:// or https://")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !validHostname(host) {
+		return "", errors.New("is not a valid URL")
+	}
+
+	if len(domains) > 0 && !hostInDomains(host, domains) {
+		return "", fmt.Errorf("must be a %s link", domains[0])
+	}
+
+	return u.String(), nil
+}
+
+// validHostname rejects hosts without a dot or with characters a domain can't contain
+func validHostname(host string) bool {
+	if host == "" || !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hostInDomains reports whether host is one of domains or a subdomain of one
+func hostInDomains(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeProfileURLs reads and normalizes the link fields of a profile form.
+// It returns the cleaned values by field name and one message per invalid field.
+func normalizeProfileURLs(c *gin.Context) (map[string]string, []string) {
+	values := make(map[string]string, len(profileURLFields))
+	var problems []string
+
+	for _, field := range profileURLFields {
+		raw := c.PostForm(field.Name)
+		normalized, err := normalizeURL(raw, field.Domains...)
+		if err != nil {
+			problems = append(problems, field.Label+" "+err.Error())
+			values[field.Name] = strings.TrimSpace(raw)
+			continue
+		}
+		values[field.Name] = normalized
+	}
+
+	return values, problems
+}
+
+// normalizeCountry upper-cases a two-letter ISO 3166-1 country code. Empty
+// input is allowed and means the country is unknown.
+func normalizeCountry(raw string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if code == "" {
+		return "", nil
+	}
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", errors.New("must be a two-letter country code like MY")
+	}
+	return code, nil
+}
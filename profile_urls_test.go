@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	facebook := []string{"facebook.com", "fb.com"}
+	tests := []struct {
+		raw     string
+		domains []string
+		want    string
+		wantErr bool
+	}{
+		{"", nil, "", false},
+		{"  example.com/menu  ", nil, "https://example.com/menu", false},
+		{"//example.com", nil, "https://example.com", false},
+		{"http://example.com", nil, "http://example.com", false},
+		{"facebook.com/cafe", facebook, "https://facebook.com/cafe", false},
+		{"https://m.facebook.com/cafe", facebook, "https://m.facebook.com/cafe", false},
+		{"https://notfacebook.com/cafe", facebook, "", true},
+		{"https://facebook.com.evil.io/cafe", facebook, "", true},
+		{"javascript:alert(1)", nil, "", true},
+		{"ftp://example.com", nil, "", true},
+		{"localhost", nil, "", true},
+		{"https://exa_mple.com", nil, "", true},
+		{"https://-bad.com", nil, "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.raw, tt.domains...)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeURL(%q, %v) = %q, %v; want %q, error %v", tt.raw, tt.domains, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeProfileURLs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	form := url.Values{
+		"instagram_url": {"instagram.com/cafe"},
+		"waze_url":      {"https://maps.example.com/cafe"},
+		"website_url":   {" cafe.my "},
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/dashboard/profile", strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	values, problems := normalizeProfileURLs(c)
+	if values["instagram_url"] != "https://instagram.com/cafe" || values["website_url"] != "https://cafe.my" || values["tiktok_url"] != "" {
+		t.Errorf("values = %v, want normalized links", values)
+	}
+	if len(problems) != 1 || problems[0] != "Waze URL must be a waze.com link" {
+		t.Errorf("problems = %v, want only the Waze link rejected", problems)
+	}
+	if values["waze_url"] != "https://maps.example.com/cafe" {
+		t.Errorf("waze_url = %q, want the rejected input kept for the form", values["waze_url"])
+	}
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	for raw, want := range map[string]string{"my": "MY", " sg ": "SG", "": ""} {
+		if got, err := normalizeCountry(raw); err != nil || got != want {
+			t.Errorf("normalizeCountry(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"MYS", "M", "M1", "Malaysia"} {
+		if _, err := normalizeCountry(raw); err == nil {
+			t.Errorf("normalizeCountry(%q) accepted", raw)
+		}
+	}
+}
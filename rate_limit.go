@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is an in-memory sliding-window limiter keyed by an arbitrary
+// string (IP, email, ...). State is per process, which is enough for the
+// single-instance deployment.
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string][]time.Time
+	lastPrune time.Time
+}
+
+// newRateLimiter allows up to limit events per key within window
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit
+func (l *rateLimiter) Allow(key string) bool {
+	return l.allowAt(key, time.Now())
+}
+
+// allowAt is Allow at a given time
+func (l *rateLimiter) allowAt(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+
+	recent := l.hits[key][:0]
+	for _, t := range l.hits[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.hits[key] = recent
+		return false
+	}
+
+	l.hits[key] = append(recent, now)
+
+	// Sweeping every key is O(keys), so do it at most once per window
+	if now.Sub(l.lastPrune) >= l.window {
+		l.prune(cutoff)
+		l.lastPrune = now
+	}
+	return true
+}
+
+// prune drops keys with no events inside the window so the map doesn't grow unbounded
+func (l *rateLimiter) prune(cutoff time.Time) {
+	for key, times := range l.hits {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(l.hits, key)
+		}
+	}
+}
+
+// tokenBucketLimiter is an in-memory token bucket keyed by an arbitrary
+// string. Each key holds up to burst tokens, refilled evenly over window, and
+// every event spends one. It keeps one small struct per key instead of a
+// timestamp per event, so it suits high-volume keys like tracking requests.
+type tokenBucketLimiter struct {
+	mu        sync.Mutex
+	burst     float64
+	perSecond float64
+	window    time.Duration
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+// tokenBucket is one key's remaining tokens as of updated
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newTokenBucketLimiter allows bursts of up to limit events per key, refilling
+// limit tokens per window
+func newTokenBucketLimiter(limit int, window time.Duration) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		burst:     float64(limit),
+		perSecond: float64(limit) / window.Seconds(),
+		window:    window,
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// Allow spends a token for key and reports whether one was available
+func (l *tokenBucketLimiter) Allow(key string) bool {
+	return l.allowAt(key, time.Now())
+}
+
+// allowAt is Allow at a given time
+func (l *tokenBucketLimiter) allowAt(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A bucket untouched for a whole window is full again, the same as a new one
+	if now.Sub(l.lastPrune) >= l.window {
+		for k, b := range l.buckets {
+			if now.Sub(b.updated) >= l.window {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.perSecond)
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(2, time.Minute)
+	start := time.Now()
+
+	if !l.allowAt("a", start) || !l.allowAt("a", start.Add(time.Second)) {
+		t.Fatal("first two events should be allowed")
+	}
+	if l.allowAt("a", start.Add(2*time.Second)) {
+		t.Error("third event within the window should be refused")
+	}
+	if !l.allowAt("b", start.Add(2*time.Second)) {
+		t.Error("keys are limited independently")
+	}
+	if !l.allowAt("a", start.Add(time.Minute+time.Second)) {
+		t.Error("first event left the window, so one more is allowed")
+	}
+}
+
+func TestRateLimiterPrunesOncePerWindow(t *testing.T) {
+	l := newRateLimiter(5, time.Minute)
+	start := time.Now()
+
+	l.allowAt("stale", start)
+	l.allowAt("fresh", start.Add(30*time.Second))
+	if len(l.hits) != 2 {
+		t.Fatalf("tracking %d keys, want 2", len(l.hits))
+	}
+
+	// The last sweep was at start, so nothing is swept until a window later
+	l.allowAt("fresh", start.Add(50*time.Second))
+	if _, ok := l.hits["stale"]; !ok {
+		t.Error("pruned before a window had passed since the last sweep")
+	}
+
+	l.allowAt("fresh", start.Add(61*time.Second))
+	if _, ok := l.hits["stale"]; ok {
+		t.Error("stale key kept after a window passed")
+	}
+	if _, ok := l.hits["fresh"]; !ok {
+		t.Error("key with recent events was pruned")
+	}
+}
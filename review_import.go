@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxReviewImportRows caps how many templates a single CSV import may contain
+	maxReviewImportRows = 500
+	// maxReviewImportBytes caps the size of an imported CSV file
+	maxReviewImportBytes = 256 << 10
+)
+
+// reviewTemplatePlatforms are the platforms a review template can belong to
+var reviewTemplatePlatforms = map[string]bool{"google": true, "facebook": true}
+
+var (
+	errReviewImportEmpty   = errors.New("the CSV file has no rows")
+	errReviewImportTooMany = fmt.Errorf("the CSV file has more than %d rows", maxReviewImportRows)
+)
+
+// reviewImportRow is a valid template read from an import file
+type reviewImportRow struct {
+	Platform string
+	Text     string
+}
+
+// reviewImportSkip explains why a row of an import file wasn't imported
+type reviewImportSkip struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// parseReviewImport reads platform,text rows from a CSV file. A leading
+// header row is ignored. Invalid rows are reported by line number rather than
+// failing the whole file; only an unreadable, empty or oversized file is an error.
+func parseReviewImport(r io.Reader) ([]reviewImportRow, []reviewImportSkip, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []reviewImportRow
+	var skipped []reviewImportSkip
+	line := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				skipped = append(skipped, reviewImportSkip{Row: line, Reason: "Could not be parsed"})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if line == 1 && len(record) >= 2 &&
+			strings.EqualFold(strings.TrimSpace(record[0]), "platform") &&
+			strings.EqualFold(strings.TrimSpace(record[1]), "text") {
+			continue
+		}
+
+		if len(rows)+len(skipped) >= maxReviewImportRows {
+			return nil, nil, errReviewImportTooMany
+		}
+
+		if len(record) != 2 {
+			skipped = append(skipped, reviewImportSkip{Row: line, Reason: "Expected 2 columns: platform,text"})
+			continue
+		}
+
+		platform := strings.ToLower(strings.TrimSpace(record[0]))
+		text := strings.TrimSpace(record[1])
+		switch {
+		case !reviewTemplatePlatforms[platform]:
+			skipped = append(skipped, reviewImportSkip{Row: line, Reason: "Platform must be google or facebook"})
+		case text == "":
+			skipped = append(skipped, reviewImportSkip{Row: line, Reason: "Text is required"})
+		default:
+			rows = append(rows, reviewImportRow{Platform: platform, Text: text})
+		}
+	}
+
+	if len(rows) == 0 && len(skipped) == 0 {
+		return nil, nil, errReviewImportEmpty
+	}
+	return rows, skipped, nil
+}
+
+// ImportReviews adds review templates for the selected merchant from an
+// uploaded CSV file with platform,text columns. Valid rows are inserted
+// together in one transaction; invalid rows are skipped and reported.
+func (h *Handlers) ImportReviews(c *gin.Context) {
+	merchantID, err := h.getMerchantIDFromContext(c)
+	if err != nil || merchantID == 0 {
+		respondAPIError(c, http.StatusBadRequest, "No merchant found")
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Upload a CSV file in the \"file\" field")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxReviewImportBytes {
+		respondAPIError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("CSV file too large. Maximum size is %dKB", maxReviewImportBytes>>10))
+		return
+	}
+
+	rows, skipped, err := parseReviewImport(io.LimitReader(file, maxReviewImportBytes))
+	if err != nil {
+		if errors.Is(err, errReviewImportEmpty) || errors.Is(err, errReviewImportTooMany) {
+			respondAPIError(c, http.StatusBadRequest, "Import failed: "+err.Error())
+			return
+		}
+		respondAPIError(c, http.StatusBadRequest, "Could not read the CSV file")
+		return
+	}
+
+	if len(rows) > 0 {
+		if err := h.importReviews(merchantID, rows); err != nil {
+			log.Printf("ImportReviews error: Failed to import templates for merchant %d - %v", merchantID, err)
+			respondAPIError(c, http.StatusInternalServerError, "Failed to import review templates")
+			return
+		}
+	}
+
+	if skipped == nil {
+		skipped = []reviewImportSkip{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"imported": len(rows),
+		"skipped":  len(skipped),
+		"errors":   skipped,
+	})
+}
+
+// importReviews inserts all rows for the merchant, or none if any insert fails
+func (h *Handlers) importReviews(merchantID int, rows []reviewImportRow) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		if err := insertReview(tx, merchantID, row.Platform, row.Text); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return h.touchMerchant(merchantID)
+}
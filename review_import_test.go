@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseReviewImport(t *testing.T) {
+	input := "Platform,Text\n" +
+		"google,Great coffee!\n" +
+		"FACEBOOK,  \"Friendly staff, fast service\"\n" +
+		"yelp,Nice\n" +
+		"google,\n" +
+		"google\n" +
+		"facebook,Cosy,extra\n"
+
+	rows, skipped, err := parseReviewImport(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0] != (reviewImportRow{"google", "Great coffee!"}) || rows[1] != (reviewImportRow{"facebook", "Friendly staff, fast service"}) {
+		t.Errorf("rows = %+v", rows)
+	}
+
+	want := []reviewImportSkip{
+		{4, "Platform must be google or facebook"},
+		{5, "Text is required"},
+		{6, "Expected 2 columns: platform,text"},
+		{7, "Expected 2 columns: platform,text"},
+	}
+	if fmt.Sprint(skipped) != fmt.Sprint(want) {
+		t.Errorf("skipped = %+v, want %+v", skipped, want)
+	}
+}
+
+func TestParseReviewImportWithoutHeader(t *testing.T) {
+	rows, skipped, err := parseReviewImport(strings.NewReader("google,Lovely\n"))
+	if err != nil || len(rows) != 1 || len(skipped) != 0 {
+		t.Errorf("got %d rows, %d skipped, err %v; want the first row imported", len(rows), len(skipped), err)
+	}
+}
+
+func TestParseReviewImportErrors(t *testing.T) {
+	if _, _, err := parseReviewImport(strings.NewReader("")); err != errReviewImportEmpty {
+		t.Errorf("empty file: err = %v, want errReviewImportEmpty", err)
+	}
+	if _, _, err := parseReviewImport(strings.NewReader("platform,text\n")); err != errReviewImportEmpty {
+		t.Errorf("header only: err = %v, want errReviewImportEmpty", err)
+	}
+
+	tooMany := strings.Repeat("google,Nice\n", maxReviewImportRows+1)
+	if _, _, err := parseReviewImport(strings.NewReader(tooMany)); err != errReviewImportTooMany {
+		t.Errorf("%d rows: err = %v, want errReviewImportTooMany", maxReviewImportRows+1, err)
+	}
+	atLimit := "platform,text\n" + strings.Repeat("google,Nice\n", maxReviewImportRows)
+	if rows, _, err := parseReviewImport(strings.NewReader(atLimit)); err != nil || len(rows) != maxReviewImportRows {
+		t.Errorf("%d rows and a header: got %d rows, err %v", maxReviewImportRows, len(rows), err)
+	}
+}
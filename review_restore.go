@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewUndoWindow is how long a deleted review template can be restored before it is purged
+const reviewUndoWindow = 30 * 24 * time.Hour
+
+// reviewPurgeInterval is how often expired deleted templates are purged
+const reviewPurgeInterval = 24 * time.Hour
+
+// getDeletedReviewByID loads a soft-deleted review template and when it was deleted
+func (h *Handlers) getDeletedReviewByID(reviewID int) (*Review, time.Time, error) {
+	review := &Review{}
+	var deletedAt time.Time
+	err := h.db.QueryRow(`
+		SELECT id, merchant_id, platform, review_text, is_active, created_at, updated_at, deleted_at
+		FROM merchant_reviews
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, reviewID).Scan(&review.ID, &review.MerchantID, &review.Platform,
+		&review.ReviewText, &review.IsActive, &review.CreatedAt, &review.UpdatedAt, &deletedAt)
+	return review, deletedAt, err
+}
+
+// restoreReview undeletes a review template deleted within reviewUndoWindow.
+// Returns false if there was nothing restorable.
+func (h *Handlers) restoreReview(review *Review) (bool, error) {
+	result, err := h.db.Exec(`
+		UPDATE merchant_reviews
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at > $2
+	`, review.ID, time.Now().Add(-reviewUndoWindow))
+	if err != nil {
+		return false, err
+	}
+	restored, err := result.RowsAffected()
+	if err != nil || restored == 0 {
+		return false, err
+	}
+	return true, h.touchMerchant(review.MerchantID)
+}
+
+// purgeDeletedReviews hard-deletes templates deleted before cutoff and returns how many were removed
+func (h *Handlers) purgeDeletedReviews(cutoff time.Time) (int64, error) {
+	result, err := h.db.Exec("DELETE FROM merchant_reviews WHERE deleted_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// startReviewPurge purges deleted templates past the undo window now and then once a day
+func (h *Handlers) startReviewPurge() {
+	go func() {
+		ticker := time.NewTicker(reviewPurgeInterval)
+		defer ticker.Stop()
+		for {
+			if purged, err := h.purgeDeletedReviews(time.Now().Add(-reviewUndoWindow)); err != nil {
+				log.Printf("Failed to purge deleted review templates: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d deleted review template(s)", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RestoreReview brings back a review template deleted within the last 30 days
+func (h *Handlers) RestoreReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	review, deletedAt, err := h.getDeletedReviewByID(reviewID)
+	if err == sql.ErrNoRows || (err == nil && !h.merchantOwnsReview(c.GetString("user_id"), review)) {
+		respondAPIError(c, http.StatusNotFound, "Deleted review template not found")
+		return
+	}
+	if err != nil {
+		log.Printf("RestoreReview error: Failed to load review %d - %v", reviewID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to restore review template")
+		return
+	}
+
+	if time.Since(deletedAt) >= reviewUndoWindow {
+		respondAPIError(c, http.StatusGone, "This template was deleted more than 30 days ago and can't be restored")
+		return
+	}
+
+	restored, err := h.restoreReview(review)
+	if err != nil {
+		log.Printf("RestoreReview error: Failed to restore review %d - %v", reviewID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to restore review template")
+		return
+	}
+	if !restored {
+		respondAPIError(c, http.StatusGone, "This template can no longer be restored")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"review": review})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRestoreReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	reviews := []Review{
+		{ID: 1, MerchantID: 1, Platform: "google", ReviewText: "Lovely"},
+		{ID: 2, MerchantID: 1, Platform: "google", ReviewText: "Great"},
+	}
+	deleted := map[int]time.Time{
+		1: now.Add(-time.Hour),
+		2: now.Add(-reviewUndoWindow - time.Hour),
+	}
+	h := newTemplateStore(t, &reviews, deleted)
+
+	tests := []struct {
+		name       string
+		user       string
+		path       string
+		wantStatus int
+	}{
+		{"another user's template", "user-2", "/api/reviews/1/restore", http.StatusNotFound},
+		{"deleted an hour ago", "user-1", "/api/reviews/1/restore", http.StatusOK},
+		{"past the undo window", "user-1", "/api/reviews/2/restore", http.StatusGone},
+		{"not deleted or unknown", "user-1", "/api/reviews/3/restore", http.StatusNotFound},
+		{"invalid id", "user-1", "/api/reviews/abc/restore", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.POST("/api/reviews/:id/restore", asUser(tt.user), h.RestoreReview)
+		if w := postForm(router, tt.path, nil); w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d (body %s)", tt.name, w.Code, tt.wantStatus, w.Body)
+		}
+	}
+	if _, ok := deleted[1]; ok {
+		t.Error("template 1 is still deleted")
+	}
+	if _, ok := deleted[2]; !ok {
+		t.Error("template 2 was restored past the undo window")
+	}
+}
+
+func TestDeleteReviewHidesTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reviews := []Review{
+		{ID: 1, MerchantID: 1, Platform: "google", ReviewText: "Lovely", IsActive: true},
+		{ID: 2, MerchantID: 1, Platform: "facebook", ReviewText: "Great", IsActive: true},
+	}
+	deleted := map[int]time.Time{}
+	h := newTemplateStore(t, &reviews, deleted)
+
+	router := gin.New()
+	router.DELETE("/api/reviews/:id", asUser("user-1"), h.DeleteReview)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/reviews/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+
+	all, err := h.getReviewsByMerchantID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := h.getActiveReviewsByMerchantID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, list := range map[string][]Review{"all templates": all, "active templates": active} {
+		if len(list) != 1 || list[0].ID != 2 {
+			t.Errorf("%s = %+v, want only template 2", name, list)
+		}
+	}
+
+	if _, _, err := h.getDeletedReviewByID(1); err != nil {
+		t.Errorf("deleted template can't be loaded for restore: %v", err)
+	}
+}
+
+func TestPurgeDeletedReviews(t *testing.T) {
+	now := time.Now()
+	reviews := []Review{
+		{ID: 1, MerchantID: 1, Platform: "google", ReviewText: "Old"},
+		{ID: 2, MerchantID: 1, Platform: "google", ReviewText: "Recent"},
+		{ID: 3, MerchantID: 1, Platform: "google", ReviewText: "Kept"},
+	}
+	deleted := map[int]time.Time{
+		1: now.Add(-reviewUndoWindow - time.Hour),
+		2: now.Add(-time.Hour),
+	}
+	h := newTemplateStore(t, &reviews, deleted)
+
+	purged, err := h.purgeDeletedReviews(now.Add(-reviewUndoWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d templates, want 1", purged)
+	}
+	if len(reviews) != 2 || reviews[0].ID != 2 || reviews[1].ID != 3 {
+		t.Errorf("templates left = %+v, want 2 (still restorable) and 3 (not deleted)", reviews)
+	}
+	if _, ok := deleted[2]; !ok {
+		t.Error("template 2 is no longer deleted")
+	}
+}
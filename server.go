@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout bounds the whole shutdown once a signal arrives: in-flight
+// requests, then the running sync and queued jobs. Render waits 30s after
+// SIGTERM before killing the process.
+const shutdownTimeout = 20 * time.Second
+
+// runServer serves srv until ctx is cancelled, then stops accepting connections
+// and waits for in-flight requests to complete. One deadline, timeout from the
+// cancellation, covers that wait and then stop, which gets what is left of it
+// to shut down everything else. stop may be nil. It returns nil after a clean
+// shutdown.
+func runServer(ctx context.Context, srv *http.Server, timeout time.Duration, stop func(context.Context)) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests and background work", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if stop != nil {
+		stop(shutdownCtx)
+	}
+	return err
+}
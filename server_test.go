@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunServerFinishesInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	addr := freeAddr(t)
+	srv := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	var stopDeadline time.Time
+	go func() {
+		stopped <- runServer(ctx, srv, 5*time.Second, func(shutdownCtx context.Context) {
+			stopDeadline, _ = shutdownCtx.Deadline()
+		})
+	}()
+
+	response := make(chan string, 1)
+	go func() {
+		var resp *http.Response
+		var err error
+		// The listener may not be up yet
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-stopped:
+		t.Fatalf("runServer returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	released := time.Now()
+	close(release)
+	if got := <-response; got != "done" {
+		t.Errorf("in-flight request got %q, want it completed", got)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("runServer = %v, want a clean shutdown", err)
+	}
+	// The rest of the shutdown shares the deadline set at cancel instead of
+	// getting a fresh one after the in-flight request
+	if stopDeadline.IsZero() || !stopDeadline.Before(released.Add(5*time.Second)) {
+		t.Errorf("stop got deadline %v, want the one set before the request was released at %v", stopDeadline, released)
+	}
+}
+
+func TestRunServerReportsListenErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	srv := &http.Server{Addr: l.Addr().String(), Handler: http.NotFoundHandler()}
+	if err := runServer(context.Background(), srv, time.Second, nil); err == nil {
+		t.Error("runServer on a taken port returned nil")
+	}
+}
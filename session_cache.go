@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// authSession is what SupabaseAuthMiddleware needs to know about a validated access token
+type authSession struct {
+	UserID string
+	Email  string
+	Role   string
+}
+
+// sessionCache remembers validated sessions so the middleware can skip the
+// Supabase round-trip. Behind an interface so it can be replaced by local
+// JWT verification later.
+type sessionCache interface {
+	Get(accessToken string) (*authSession, bool)
+	Set(accessToken string, session *authSession, tokenExpiry time.Time)
+	Delete(accessToken string)
+}
+
+// defaultSessionCacheTTL is how long a validated session is trusted without asking Supabase again
+const defaultSessionCacheTTL = 60 * time.Second
+
+// memorySessionCache is a per-process sessionCache keyed by a hash of the
+// access token, so raw tokens aren't kept in memory longer than needed
+type memorySessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]sessionCacheEntry
+}
+
+type sessionCacheEntry struct {
+	session   *authSession
+	expiresAt time.Time
+}
+
+// supabaseSessions is the cache used by SupabaseAuthMiddleware; nil disables caching
+var supabaseSessions sessionCache
+
+// loadSessionCache sets up supabaseSessions from the environment
+func loadSessionCache() {
+	supabaseSessions = newSessionCacheFromEnv()
+}
+
+// newSessionCacheFromEnv reads SESSION_CACHE_TTL_SECONDS (default 60, 0 disables caching)
+func newSessionCacheFromEnv() sessionCache {
+	ttl := defaultSessionCacheTTL
+	if value := os.Getenv("SESSION_CACHE_TTL_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			log.Printf("Invalid SESSION_CACHE_TTL_SECONDS %q, using %s", value, defaultSessionCacheTTL)
+		} else if seconds == 0 {
+			return nil
+		} else {
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	return newMemorySessionCache(ttl)
+}
+
+func newMemorySessionCache(ttl time.Duration) *memorySessionCache {
+	return &memorySessionCache{
+		ttl:     ttl,
+		entries: make(map[string]sessionCacheEntry),
+	}
+}
+
+func sessionCacheKey(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached session for the token if it hasn't expired
+func (sc *memorySessionCache) Get(accessToken string) (*authSession, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	key := sessionCacheKey(accessToken)
+	entry, ok := sc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(sc.entries, key)
+		return nil, false
+	}
+	return entry.session, true
+}
+
+// Set caches a session for the TTL, or until the token itself expires if that's sooner
+func (sc *memorySessionCache) Set(accessToken string, session *authSession, tokenExpiry time.Time) {
+	now := time.Now()
+	expiresAt := now.Add(sc.ttl)
+	if !tokenExpiry.IsZero() && tokenExpiry.Before(expiresAt) {
+		expiresAt = tokenExpiry
+	}
+	if !expiresAt.After(now) {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	// Drop expired entries so the map doesn't grow unbounded
+	for key, entry := range sc.entries {
+		if now.After(entry.expiresAt) {
+			delete(sc.entries, key)
+		}
+	}
+	sc.entries[sessionCacheKey(accessToken)] = sessionCacheEntry{session: session, expiresAt: expiresAt}
+}
+
+// Delete forgets the token's session, e.g. on logout
+func (sc *memorySessionCache) Delete(accessToken string) {
+	sc.mu.Lock()
+	delete(sc.entries, sessionCacheKey(accessToken))
+	sc.mu.Unlock()
+}
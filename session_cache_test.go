@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemorySessionCache(t *testing.T) {
+	cache := newMemorySessionCache(time.Minute)
+	session := &authSession{UserID: "user-1", Role: "merchant"}
+
+	cache.Set("token", session, time.Time{})
+	if got, ok := cache.Get("token"); !ok || got != session {
+		t.Fatalf("Get = %v, %v, want the cached session", got, ok)
+	}
+	if _, ok := cache.Get("other"); ok {
+		t.Error("Get found a session for an unknown token")
+	}
+
+	cache.Delete("token")
+	if _, ok := cache.Get("token"); ok {
+		t.Error("Get found a deleted session")
+	}
+
+	// A token that has already expired is never cached
+	cache.Set("stale", session, time.Now().Add(-time.Second))
+	if _, ok := cache.Get("stale"); ok {
+		t.Error("cached a session for an expired token")
+	}
+
+	// Entries don't outlive the token itself
+	cache.Set("short", session, time.Now().Add(20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("session outlived its token")
+	}
+
+	for key := range cache.entries {
+		if key == "token" || key == "short" {
+			t.Errorf("raw token %q used as a cache key", key)
+		}
+	}
+}
+
+func TestNewSessionCacheFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration // 0 means caching is disabled
+	}{
+		{"", defaultSessionCacheTTL},
+		{"300", 5 * time.Minute},
+		{"0", 0},
+		{"-5", defaultSessionCacheTTL},
+		{"soon", defaultSessionCacheTTL},
+	}
+	for _, tt := range tests {
+		t.Setenv("SESSION_CACHE_TTL_SECONDS", tt.value)
+		cache := newSessionCacheFromEnv()
+		if tt.want == 0 {
+			if cache != nil {
+				t.Errorf("SESSION_CACHE_TTL_SECONDS=%q: caching enabled, want disabled", tt.value)
+			}
+			continue
+		}
+		if mem, ok := cache.(*memorySessionCache); !ok || mem.ttl != tt.want {
+			t.Errorf("SESSION_CACHE_TTL_SECONDS=%q: cache = %+v, want a TTL of %s", tt.value, cache, tt.want)
+		}
+	}
+}
+
+func TestResolveSessionUsesCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/dashboard/", nil)
+
+	// Supabase would reject the token, so only the cache can resolve it
+	auth := &fakeSessionAuth{validToken: "other"}
+	cache := newMemorySessionCache(time.Minute)
+	cached := &authSession{UserID: "user-1", Role: "admin"}
+	cache.Set("token", cached, time.Time{})
+
+	if got, ok := resolveSession(c, auth, cache, "token"); !ok || got != cached {
+		t.Errorf("resolveSession = %+v, %v, want the cached session", got, ok)
+	}
+	if _, ok := resolveSession(c, auth, cache, "uncached"); ok {
+		t.Error("resolveSession accepted a token Supabase rejects")
+	}
+}
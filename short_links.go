@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Short codes use letters and digits without look-alikes (0/O, 1/l/I) so
+// they survive being read off print and typed back in
+const (
+	shortCodeAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	shortCodeLength   = 7
+	shortCodeAttempts = 5
+)
+
+// errShortCodeExhausted is returned when every generated code was already taken
+var errShortCodeExhausted = errors.New("could not find an unused short code")
+
+// ShortLink maps a short code to a merchant's business page
+type ShortLink struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"short_url"`
+	Clicks    int       `json:"clicks"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// generateShortCode returns a random code drawn from shortCodeAlphabet
+func generateShortCode() (string, error) {
+	max := big.NewInt(int64(len(shortCodeAlphabet)))
+	code := make([]byte, shortCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = shortCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// shortLinkURL is the public address of a short code
+func shortLinkURL(c *gin.Context, code string) string {
+	return requestOrigin(c) + "/s/" + code
+}
+
+// CreateShortLink returns the selected business's short link, creating it on
+// first use. Only the logged-in owner's own businesses can be selected.
+func (h *Handlers) CreateShortLink(c *gin.Context) {
+	merchant, _, err := h.selectedMerchant(c)
+	if err == errMerchantNotOwned {
+		respondAPIError(c, http.StatusForbidden, "Merchant not found")
+		return
+	}
+	if err != nil || merchant == nil {
+		respondAPIError(c, http.StatusNotFound, "No business to link to")
+		return
+	}
+
+	link, created, err := h.ensureShortLink(merchant.ID)
+	if err != nil {
+		log.Printf("CreateShortLink error: Failed to create short link for merchant %d - %v", merchant.ID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to create short link")
+		return
+	}
+	link.URL = shortLinkURL(c, link.Code)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, link)
+}
+
+// ensureShortLink returns the merchant's short link, inserting one with a
+// fresh code if it has none. created reports whether a new link was made.
+func (h *Handlers) ensureShortLink(merchantID int) (link *ShortLink, created bool, err error) {
+	link, err = h.getShortLink(merchantID)
+	if err == nil {
+		return link, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	for attempt := 0; attempt < shortCodeAttempts; attempt++ {
+		code, err := generateShortCode()
+		if err != nil {
+			return nil, false, err
+		}
+
+		// A taken code, or a link created concurrently for the same merchant,
+		// inserts nothing; the lookup below tells the two apart
+		result, err := h.db.Exec(`
+			INSERT INTO short_links (code, merchant_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, code, merchantID)
+		if err != nil {
+			return nil, false, err
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 1 {
+			link, err = h.getShortLink(merchantID)
+			return link, true, err
+		}
+		if link, err = h.getShortLink(merchantID); err == nil {
+			return link, false, nil
+		}
+	}
+	return nil, false, errShortCodeExhausted
+}
+
+// getShortLink loads a merchant's short link
+func (h *Handlers) getShortLink(merchantID int) (*ShortLink, error) {
+	link := &ShortLink{}
+	err := h.db.QueryRow("SELECT code, clicks, created_at FROM short_links WHERE merchant_id = $1", merchantID).
+		Scan(&link.Code, &link.Clicks, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// getShortLinks loads the short links of several merchants, keyed by merchant id
+func (h *Handlers) getShortLinks(c *gin.Context, merchants []Merchant) map[int]*ShortLink {
+	links := make(map[int]*ShortLink, len(merchants))
+	for _, m := range merchants {
+		link, err := h.getShortLink(m.ID)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("Failed to load short link for merchant %d: %v", m.ID, err)
+			}
+			continue
+		}
+		link.URL = shortLinkURL(c, link.Code)
+		links[m.ID] = link
+	}
+	return links
+}
+
+// ShortLinkRedirect counts a click on a short link and sends the visitor to the
+// business page. The redirect is permanent but not cacheable, so repeat visits
+// still reach the server and are counted.
+func (h *Handlers) ShortLinkRedirect(c *gin.Context) {
+	var slug string
+	err := h.db.QueryRow(`
+		UPDATE short_links s SET clicks = clicks + 1
+		FROM merchants m
+		WHERE s.code = $1 AND m.id = s.merchant_id AND m.is_active = true AND m.deleted_at IS NULL
+		RETURNING m.slug
+	`, c.Param("code")).Scan(&slug)
+	if err == sql.ErrNoRows {
+		NoRouteHandler(c)
+		return
+	}
+	if err != nil {
+		log.Printf("ShortLinkRedirect error: Failed to resolve %q - %v", c.Param("code"), err)
+		c.Status(http.StatusInternalServerError)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to open this link",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusMovedPermanently, appPath("/?id="+url.QueryEscape(slug)))
+}
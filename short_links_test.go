@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGenerateShortCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		code, err := generateShortCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != shortCodeLength || strings.Trim(code, shortCodeAlphabet) != "" {
+			t.Fatalf("code %q isn't %d characters from the alphabet", code, shortCodeLength)
+		}
+		if strings.ContainsAny(code, "0O1lI") {
+			t.Fatalf("code %q has a look-alike character", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("%d distinct codes out of 100", len(seen))
+	}
+}
+
+// newShortLinkStore backs the short link queries with one table row per merchant;
+// inserts succeed only while accept returns true
+func newShortLinkStore(t *testing.T, accept func() bool) (*Handlers, map[int64]string, *int) {
+	t.Helper()
+	links := map[int64]string{}
+	inserts := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "FROM short_links WHERE merchant_id = $1"):
+			res := &fakedb.Result{Columns: []string{"code", "clicks", "created_at"}}
+			if code, ok := links[args[0].(int64)]; ok {
+				res.Rows = [][]driver.Value{{code, int64(0), time.Now()}}
+			}
+			return res, nil
+		case strings.Contains(query, "INSERT INTO short_links"):
+			inserts++
+			if !accept() {
+				return &fakedb.Result{RowsAffected: 0}, nil
+			}
+			links[args[1].(int64)] = args[0].(string)
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &Handlers{db: &Database{DB: conn}}, links, &inserts
+}
+
+func TestEnsureShortLink(t *testing.T) {
+	h, links, inserts := newShortLinkStore(t, func() bool { return true })
+
+	link, created, err := h.ensureShortLink(7)
+	if err != nil || !created || link.Code == "" || links[7] != link.Code {
+		t.Fatalf("first call = %+v, %v, %v; want a new link", link, created, err)
+	}
+	again, created, err := h.ensureShortLink(7)
+	if err != nil || created || again.Code != link.Code || *inserts != 1 {
+		t.Errorf("second call = %+v, %v, %v after %d inserts; want the same link", again, created, err, *inserts)
+	}
+}
+
+func TestEnsureShortLinkRetriesTakenCodes(t *testing.T) {
+	taken := 2
+	h, _, inserts := newShortLinkStore(t, func() bool { taken--; return taken < 0 })
+	if _, created, err := h.ensureShortLink(7); err != nil || !created || *inserts != 3 {
+		t.Errorf("created %v, %v after %d inserts; want a link on the third try", created, err, *inserts)
+	}
+
+	h, _, inserts = newShortLinkStore(t, func() bool { return false })
+	if _, _, err := h.ensureShortLink(7); err != errShortCodeExhausted || *inserts != shortCodeAttempts {
+		t.Errorf("err = %v after %d inserts, want errShortCodeExhausted after %d", err, *inserts, shortCodeAttempts)
+	}
+}
+
+func TestShortLinkRedirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clicks := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if !strings.Contains(query, "SET clicks = clicks + 1") || !strings.Contains(query, "m.deleted_at IS NULL") {
+			t.Fatalf("unexpected query: %s", query)
+		}
+		res := &fakedb.Result{Columns: []string{"slug"}}
+		if args[0] == "abc2345" {
+			clicks++
+			res.Rows = [][]driver.Value{{"kopi & co"}}
+		}
+		return res, nil
+	})
+	defer conn.Close()
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/s/:code", h.ShortLinkRedirect)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s/abc2345", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != appPath("/?id=kopi+%26+co") {
+		t.Errorf("status %d to %q, want a 301 to the business page", w.Code, w.Header().Get("Location"))
+	}
+	if w.Header().Get("Cache-Control") != "no-store" || clicks != 1 {
+		t.Errorf("Cache-Control %q with %d clicks, want an uncached, counted redirect", w.Header().Get("Cache-Control"), clicks)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown code: status %d, want 404", w.Code)
+	}
+}
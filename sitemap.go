@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sitemapCacheTTL is how long a generated sitemap is served before the
+// merchant list is queried again. Set by loadSitemapCache.
+var sitemapCacheTTL = 5 * time.Minute
+
+// loadSitemapCache reads the sitemap cache TTL from env (in seconds)
+func loadSitemapCache() {
+	sitemapCacheTTL = time.Duration(envInt("SITEMAP_CACHE_SECONDS", 300)) * time.Second
+}
+
+// sitemapCache holds generated sitemaps keyed by the absolute origin they were
+// built for, since the same deployment can be reached on several hosts
+type sitemapCache struct {
+	mu      sync.Mutex
+	entries map[string]sitemapCacheEntry
+}
+
+type sitemapCacheEntry struct {
+	xml       []byte
+	expiresAt time.Time
+}
+
+var sitemaps = &sitemapCache{entries: make(map[string]sitemapCacheEntry)}
+
+func (sc *sitemapCache) get(origin string) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	entry, ok := sc.entries[origin]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.xml, true
+}
+
+// maxSitemapOrigins bounds the cache, since the Host header is client-controlled
+const maxSitemapOrigins = 16
+
+func (sc *sitemapCache) set(origin string, body []byte) {
+	sc.mu.Lock()
+	if len(sc.entries) >= maxSitemapOrigins {
+		sc.entries = make(map[string]sitemapCacheEntry)
+	}
+	sc.entries[origin] = sitemapCacheEntry{xml: body, expiresAt: time.Now().Add(sitemapCacheTTL)}
+	sc.mu.Unlock()
+}
+
+// requestOrigin is the scheme and host the client used, honouring the proxy's
+// X-Forwarded-Proto, plus BASE_PATH
+func requestOrigin(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + appPath("")
+}
+
+// Sitemap serves an XML sitemap of every active merchant's business page,
+// leaving out merchants that opted out of indexing.
+// On a cache miss the document is streamed to the client while it is built.
+func (h *Handlers) Sitemap(c *gin.Context) {
+	origin := requestOrigin(c)
+
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	if body, ok := sitemaps.get(origin); ok {
+		c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT m.slug, m.updated_at FROM merchants m
+		LEFT JOIN merchant_details d ON d.merchant_id = m.id
+		WHERE m.is_active = true AND m.deleted_at IS NULL AND m.slug <> ''
+			AND NOT COALESCE(d.noindex, false)
+		ORDER BY m.id
+	`)
+	if err != nil {
+		log.Printf("Failed to load merchants for sitemap: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	c.Status(http.StatusOK)
+	if err := writeSitemap(io.MultiWriter(c.Writer, &buf), origin, func() (string, time.Time, bool) {
+		for rows.Next() {
+			var slug string
+			var updatedAt time.Time
+			if err := rows.Scan(&slug, &updatedAt); err != nil {
+				log.Printf("Failed to scan merchant for sitemap: %v", err)
+				continue
+			}
+			return slug, updatedAt, true
+		}
+		return "", time.Time{}, false
+	}); err != nil {
+		log.Printf("Failed to write sitemap: %v", err)
+		return
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to read merchants for sitemap: %v", err)
+		return
+	}
+	sitemaps.set(origin, buf.Bytes())
+}
+
+// writeSitemap writes a sitemap with one <url> per merchant returned by next
+// until it reports false. Pages live at origin/?id=<slug>.
+func writeSitemap(w io.Writer, origin string, next func() (slug string, updatedAt time.Time, ok bool)) error {
+	if _, err := io.WriteString(w, xml.Header+`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+"\n"); err != nil {
+		return err
+	}
+
+	for {
+		slug, updatedAt, ok := next()
+		if !ok {
+			break
+		}
+		var loc bytes.Buffer
+		xml.EscapeText(&loc, []byte(origin+"/?id="+url.QueryEscape(slug)))
+		if _, err := fmt.Fprintf(w, "  <url><loc>%s</loc><lastmod>%s</lastmod></url>\n",
+			loc.String(), updatedAt.UTC().Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "</urlset>\n")
+	return err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSitemap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sitemaps = &sitemapCache{entries: make(map[string]sitemapCacheEntry)}
+	t.Cleanup(func() { sitemaps = &sitemapCache{entries: make(map[string]sitemapCacheEntry)} })
+
+	queries := 0
+	updated := time.Date(2026, 10, 1, 22, 0, 0, 0, time.FixedZone("MYT", 8*3600))
+	// merchants joined with merchant_details, as the sitemap query reads them
+	merchants := &fakedb.Table{
+		Columns: []string{"slug", "updated_at", "is_active", "deleted_at", "noindex"},
+		Rows: [][]driver.Value{
+			{"kopi-corner", updated, true, nil, false},
+			{"ben & co", updated, true, nil, nil},
+			{"private-salon", updated, true, nil, true},
+			{"closed-shop", updated, false, nil, false},
+			{"gone-cafe", updated, true, updated, false},
+			{"", updated, true, nil, false},
+		},
+		Conditions: map[string]func(fakedb.Row, []driver.Value) bool{
+			"m.slug <> ''": func(row fakedb.Row, _ []driver.Value) bool { return row["slug"] != "" },
+			"NOT COALESCE(d.noindex, false)": func(row fakedb.Row, _ []driver.Value) bool {
+				return row["noindex"] != true
+			},
+		},
+	}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		queries++
+		rows, err := merchants.Match(query, args)
+		res := &fakedb.Result{Columns: []string{"slug", "updated_at"}}
+		for _, row := range rows {
+			res.Rows = append(res.Rows, row[:2])
+		}
+		return res, err
+	})
+	defer conn.Close()
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/sitemap.xml", h.Sitemap)
+
+	get := func(host string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil)
+		req.Host = host
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("reviews.example.com")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var doc struct {
+		URLs []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("sitemap isn't valid XML: %v\n%s", err, w.Body)
+	}
+	// Only listed, active, undeleted merchants with a slug are in the sitemap
+	if len(doc.URLs) != 2 ||
+		doc.URLs[0].Loc != "https://reviews.example.com/?id=kopi-corner" ||
+		doc.URLs[1].Loc != "https://reviews.example.com/?id=ben+%26+co" ||
+		doc.URLs[0].LastMod != "2026-10-01" {
+		t.Errorf("urls = %+v", doc.URLs)
+	}
+
+	// Served from the cache for the same origin, rebuilt for another
+	if again := get("reviews.example.com"); again.Body.String() != w.Body.String() || queries != 1 {
+		t.Errorf("second request ran %d queries, want the cached sitemap", queries)
+	}
+	if other := get("other.example.com"); !strings.Contains(other.Body.String(), "https://other.example.com/?id=") || queries != 2 {
+		t.Errorf("other host: %d queries, body:\n%s", queries, other.Body)
+	}
+}
+
+func TestSitemapCacheIsBounded(t *testing.T) {
+	sc := &sitemapCache{entries: make(map[string]sitemapCacheEntry)}
+	for i := 0; i < maxSitemapOrigins*3; i++ {
+		sc.set(strings.Repeat("x", i), []byte("<urlset/>"))
+	}
+	if len(sc.entries) > maxSitemapOrigins {
+		t.Errorf("cache holds %d origins, want at most %d", len(sc.entries), maxSitemapOrigins)
+	}
+}
@@ -0,0 +1,114 @@
+package socialmedia
+
+import (
+	"log"
+	"strings"
+	"unicode"
+)
+
+// crossPlatformDedupScanLimit caps how many visible reviews one dedup pass compares
+const crossPlatformDedupScanLimit = 5000
+
+// platformSignal ranks platforms when the same review exists on several;
+// the highest one stays visible. Google reviews always carry a star rating.
+var platformSignal = map[string]int{
+	PlatformGoogleBusiness: 3,
+	PlatformFacebook:       2,
+	PlatformInstagram:      1,
+	PlatformThreads:        0,
+}
+
+// crossPlatformKey identifies a review independent of the platform it came
+// from: author, normalized text and UTC day. Reviews without an author or
+// text return "" and are never collapsed, since they can't be told apart.
+func crossPlatformKey(review *SyncedReview) string {
+	author := strings.ToLower(strings.TrimSpace(review.AuthorName))
+	text := normalizeReviewText(review.ReviewText)
+	if author == "" || text == "" {
+		return ""
+	}
+	return author + "|" + reviewDay(review.ReviewedAt).Format("2006-01-02") + "|" + text
+}
+
+// normalizeReviewText lowercases text and reduces it to words, so punctuation,
+// emoji and whitespace differences between platforms don't matter
+func normalizeReviewText(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// crossPlatformDuplicates returns the reviews to hide: for each group sharing a
+// crossPlatformKey, every review from a different platform than the one kept.
+// Same-platform repeats are left to the per-platform dedup strategy.
+func crossPlatformDuplicates(reviews []*SyncedReview) []*SyncedReview {
+	keep := make(map[string]*SyncedReview)
+	for _, review := range reviews {
+		key := crossPlatformKey(review)
+		if key == "" {
+			continue
+		}
+		current, ok := keep[key]
+		if !ok || platformSignal[review.Platform] > platformSignal[current.Platform] ||
+			(review.Platform == current.Platform && review.ID < current.ID) {
+			keep[key] = review
+		}
+	}
+
+	var duplicates []*SyncedReview
+	for _, review := range reviews {
+		key := crossPlatformKey(review)
+		if key == "" {
+			continue
+		}
+		if kept := keep[key]; kept.Platform != review.Platform {
+			duplicates = append(duplicates, review)
+		}
+	}
+	return duplicates
+}
+
+// DedupCrossPlatform hides a merchant's visible reviews that duplicate one on a
+// higher-signal platform and returns how many were hidden. Callers decide whether
+// the merchant opted in; see dedupAfterSync.
+func (s *SyncService) DedupCrossPlatform(merchantID int) (int, error) {
+	reviews, err := s.db.GetVisibleSyncedReviewsByMerchant(merchantID, crossPlatformDedupScanLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	duplicates := crossPlatformDuplicates(reviews)
+	if len(duplicates) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int, len(duplicates))
+	for i, review := range duplicates {
+		ids[i] = review.ID
+	}
+	hidden, err := s.db.HideSyncedReviews(ids)
+	return int(hidden), err
+}
+
+// dedupAfterSync runs the cross-platform pass for merchants who enabled it.
+// A sync re-shows every review it touches, so this runs after each one.
+func (s *SyncService) dedupAfterSync(merchantID int) {
+	enabled, err := s.db.GetCrossPlatformDedup(merchantID)
+	if err != nil {
+		log.Printf("Failed to read cross-platform dedup setting for merchant %d: %v", merchantID, err)
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	hidden, err := s.DedupCrossPlatform(merchantID)
+	if err != nil {
+		log.Printf("Cross-platform dedup failed for merchant %d: %v", merchantID, err)
+		return
+	}
+	if hidden > 0 {
+		log.Printf("Hid %d cross-platform duplicate reviews for merchant %d", hidden, merchantID)
+	}
+}
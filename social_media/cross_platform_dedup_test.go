@@ -0,0 +1,85 @@
+package socialmedia
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCrossPlatformKey(t *testing.T) {
+	day := time.Date(2026, 10, 1, 23, 0, 0, 0, time.UTC)
+	base := crossPlatformKey(&SyncedReview{AuthorName: "Aina", ReviewText: "Great coffee!", ReviewedAt: day})
+
+	same := []*SyncedReview{
+		{AuthorName: " aina ", ReviewText: "great   coffee 😊", ReviewedAt: day},
+		// 07:00 the next day in Kuala Lumpur is still the same UTC day
+		{AuthorName: "AINA", ReviewText: "Great, coffee.", ReviewedAt: day.In(time.FixedZone("MYT", 8*3600))},
+	}
+	for _, review := range same {
+		if got := crossPlatformKey(review); got != base {
+			t.Errorf("key(%q, %q) = %q, want %q", review.AuthorName, review.ReviewText, got, base)
+		}
+	}
+
+	different := []*SyncedReview{
+		{AuthorName: "Ben", ReviewText: "Great coffee!", ReviewedAt: day},
+		{AuthorName: "Aina", ReviewText: "Great tea!", ReviewedAt: day},
+		{AuthorName: "Aina", ReviewText: "Great coffee!", ReviewedAt: day.Add(2 * time.Hour)},
+	}
+	for _, review := range different {
+		if got := crossPlatformKey(review); got == base {
+			t.Errorf("key(%q, %q, %v) matches, want a different key", review.AuthorName, review.ReviewText, review.ReviewedAt)
+		}
+	}
+
+	for _, review := range []*SyncedReview{{ReviewText: "Great coffee!", ReviewedAt: day}, {AuthorName: "Aina", ReviewText: "!!", ReviewedAt: day}} {
+		if got := crossPlatformKey(review); got != "" {
+			t.Errorf("key(%q, %q) = %q, want none without an author or text", review.AuthorName, review.ReviewText, got)
+		}
+	}
+}
+
+func TestCrossPlatformDuplicates(t *testing.T) {
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	review := func(id int, platform, author, text string) *SyncedReview {
+		return &SyncedReview{ID: id, MerchantID: 7, Platform: platform, AuthorName: author, ReviewText: text, ReviewedAt: day, IsVisible: true}
+	}
+	reviews := []*SyncedReview{
+		review(1, PlatformInstagram, "Aina", "Lovely place"),
+		review(2, PlatformFacebook, "Aina", "Lovely place!"),
+		review(3, PlatformGoogleBusiness, "aina", "lovely place"),
+		// Only on the lower-signal platforms: Facebook wins
+		review(4, PlatformInstagram, "Ben", "Slow service"),
+		review(5, PlatformFacebook, "Ben", "Slow service"),
+		// Same-platform repeats are left alone
+		review(6, PlatformFacebook, "Chen", "Nice"),
+		review(7, PlatformFacebook, "Chen", "Nice"),
+		// No author: never collapsed
+		review(8, PlatformInstagram, "", "Lovely place"),
+		review(9, PlatformGoogleBusiness, "", "Lovely place"),
+	}
+
+	var ids []int
+	for _, duplicate := range crossPlatformDuplicates(reviews) {
+		ids = append(ids, duplicate.ID)
+	}
+	sort.Ints(ids)
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 4 {
+		t.Errorf("duplicates = %v, want [1 2 4]", ids)
+	}
+}
+
+func TestDedupAfterSyncOnlyForOptedInMerchants(t *testing.T) {
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for _, optedIn := range []bool{false, true} {
+		db := newMemDB()
+		db.dedup = optedIn
+		db.reviews[1] = &SyncedReview{ID: 1, MerchantID: 7, Platform: PlatformGoogleBusiness, AuthorName: "Aina", ReviewText: "Lovely", ReviewedAt: day, IsVisible: true}
+		db.reviews[2] = &SyncedReview{ID: 2, MerchantID: 7, Platform: PlatformFacebook, AuthorName: "Aina", ReviewText: "Lovely", ReviewedAt: day, IsVisible: true}
+
+		newTestSyncService(db, &fakeProvider{platform: PlatformGoogleBusiness}).dedupAfterSync(7)
+		if !db.reviews[1].IsVisible || db.reviews[2].IsVisible == optedIn {
+			t.Errorf("opted in %v: visible = %v, %v", optedIn, db.reviews[1].IsVisible, db.reviews[2].IsVisible)
+		}
+	}
+}
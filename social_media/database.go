@@ -4,6 +4,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 // DB wraps a sql.DB to implement SocialMediaDB interface
@@ -22,14 +26,14 @@ func (db *DB) CreateAPIConnection(conn *APIConnection) error {
 	query := `
 		INSERT INTO api_connections (
 			merchant_id, platform, platform_account_id, platform_account_name,
-			access_token, refresh_token, token_expires_at, is_active
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			account_avatar_url, access_token, refresh_token, token_expires_at, is_active
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
 	return db.conn.QueryRow(
 		query,
 		conn.MerchantID, conn.Platform, conn.PlatformAccountID, conn.PlatformAccountName,
-		conn.AccessToken, conn.RefreshToken, conn.TokenExpiresAt, conn.IsActive,
+		conn.AccountAvatarURL, conn.AccessToken, conn.RefreshToken, conn.TokenExpiresAt, conn.IsActive,
 	).Scan(&conn.ID, &conn.CreatedAt, &conn.UpdatedAt)
 }
 
@@ -40,14 +44,14 @@ func (db *DB) GetAPIConnection(id int) (*APIConnection, error) {
 	query := `
 		SELECT id, merchant_id, platform, platform_account_id, platform_account_name,
 			access_token, refresh_token, token_expires_at, is_active, last_sync_at,
-			sync_status, error_message, created_at, updated_at
+			sync_status, error_message, COALESCE(admin_notes, ''), COALESCE(account_avatar_url, ''), created_at, updated_at
 		FROM api_connections
 		WHERE id = $1
 	`
 	err := db.conn.QueryRow(query, id).Scan(
 		&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
 		&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
-		&conn.SyncStatus, &conn.ErrorMessage, &conn.CreatedAt, &conn.UpdatedAt,
+		&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -64,7 +68,7 @@ func (db *DB) GetAPIConnectionsByMerchant(merchantID int) ([]*APIConnection, err
 	query := `
 		SELECT id, merchant_id, platform, platform_account_id, platform_account_name,
 			access_token, refresh_token, token_expires_at, is_active, last_sync_at,
-			sync_status, error_message, created_at, updated_at
+			sync_status, error_message, COALESCE(admin_notes, ''), COALESCE(account_avatar_url, ''), created_at, updated_at
 		FROM api_connections
 		WHERE merchant_id = $1
 		ORDER BY created_at DESC
@@ -83,7 +87,7 @@ func (db *DB) GetAPIConnectionsByMerchant(merchantID int) ([]*APIConnection, err
 		err := rows.Scan(
 			&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
 			&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
-			&conn.SyncStatus, &conn.ErrorMessage, &conn.CreatedAt, &conn.UpdatedAt,
+			&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -106,7 +110,7 @@ func (db *DB) GetAPIConnectionByPlatform(merchantID int, platform string) (*APIC
 	query := `
 		SELECT id, merchant_id, platform, platform_account_id, platform_account_name,
 			access_token, refresh_token, token_expires_at, is_active, last_sync_at,
-			sync_status, error_message, created_at, updated_at
+			sync_status, error_message, COALESCE(admin_notes, ''), COALESCE(account_avatar_url, ''), created_at, updated_at
 		FROM api_connections
 		WHERE merchant_id = $1 AND platform = $2
 		LIMIT 1
@@ -114,7 +118,35 @@ func (db *DB) GetAPIConnectionByPlatform(merchantID int, platform string) (*APIC
 	err := db.conn.QueryRow(query, merchantID, platform).Scan(
 		&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
 		&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
-		&conn.SyncStatus, &conn.ErrorMessage, &conn.CreatedAt, &conn.UpdatedAt,
+		&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSyncAt.Valid {
+		conn.LastSyncAt = &lastSyncAt.Time
+	}
+
+	return conn, nil
+}
+
+// GetAPIConnectionByAccount finds the merchant's connection to a specific platform account
+func (db *DB) GetAPIConnectionByAccount(merchantID int, platform, platformAccountID string) (*APIConnection, error) {
+	conn := &APIConnection{}
+	var lastSyncAt sql.NullTime
+
+	query := `
+		SELECT id, merchant_id, platform, platform_account_id, platform_account_name,
+			access_token, refresh_token, token_expires_at, is_active, last_sync_at,
+			sync_status, error_message, COALESCE(admin_notes, ''), COALESCE(account_avatar_url, ''), created_at, updated_at
+		FROM api_connections
+		WHERE merchant_id = $1 AND platform = $2 AND platform_account_id = $3
+	`
+	err := db.conn.QueryRow(query, merchantID, platform, platformAccountID).Scan(
+		&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
+		&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
+		&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -132,32 +164,184 @@ func (db *DB) UpdateAPIConnection(conn *APIConnection) error {
 		UPDATE api_connections
 		SET platform_account_id = $1, platform_account_name = $2, access_token = $3,
 			refresh_token = $4, token_expires_at = $5, is_active = $6, last_sync_at = $7,
-			sync_status = $8, error_message = $9, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $10
+			sync_status = $8, error_message = $9, account_avatar_url = NULLIF($10, ''),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $11
 	`
 	_, err := db.conn.Exec(
 		query,
 		conn.PlatformAccountID, conn.PlatformAccountName, conn.AccessToken,
 		conn.RefreshToken, conn.TokenExpiresAt, conn.IsActive, conn.LastSyncAt,
-		conn.SyncStatus, conn.ErrorMessage, conn.ID,
+		conn.SyncStatus, conn.ErrorMessage, conn.AccountAvatarURL, conn.ID,
 	)
 	return err
 }
 
-func (db *DB) DeleteAPIConnection(id int) error {
-	query := `DELETE FROM api_connections WHERE id = $1`
+func (db *DB) UpdateAdminNotes(id int, notes string) error {
+	query := `UPDATE api_connections SET admin_notes = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err := db.conn.Exec(query, notes, id)
+	return err
+}
+
+// ClearConnectionError drops a stale sync error without touching anything else on the connection
+func (db *DB) ClearConnectionError(id int) error {
+	query := `UPDATE api_connections SET error_message = '', updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 	_, err := db.conn.Exec(query, id)
 	return err
 }
 
-func (db *DB) GetActiveConnections() ([]*APIConnection, error) {
+// UpdateConnectionTokens stores a refreshed token pair and its expiry without
+// touching the rest of the connection, which may have changed since it was
+// loaded. An empty refreshToken keeps the stored one.
+func (db *DB) UpdateConnectionTokens(id int, accessToken, refreshToken string, expiresAt time.Time) error {
+	query := `
+		UPDATE api_connections
+		SET access_token = $1, refresh_token = COALESCE(NULLIF($2, ''), refresh_token),
+			token_expires_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+	_, err := db.conn.Exec(query, accessToken, refreshToken, expiresAt, id)
+	return err
+}
+
+// ReplaceConnectionTokens stores re-encrypted tokens for a connection, but only
+// while it still holds the old values, so a concurrent token refresh wins.
+// It reports whether the row was updated.
+func (db *DB) ReplaceConnectionTokens(id int, oldAccess, newAccess, oldRefresh, newRefresh string) (bool, error) {
+	query := `
+		UPDATE api_connections
+		SET access_token = $1, refresh_token = $2
+		WHERE id = $3 AND access_token = $4 AND COALESCE(refresh_token, '') = $5
+	`
+	result, err := db.conn.Exec(query, newAccess, newRefresh, id, oldAccess, oldRefresh)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+func (db *DB) GetAllAPIConnections() ([]*APIConnection, error) {
 	query := `
 		SELECT id, merchant_id, platform, platform_account_id, platform_account_name,
 			access_token, refresh_token, token_expires_at, is_active, last_sync_at,
-			sync_status, error_message, created_at, updated_at
+			sync_status, error_message, COALESCE(admin_notes, ''), COALESCE(account_avatar_url, ''), created_at, updated_at
 		FROM api_connections
-		WHERE is_active = true
-		ORDER BY last_sync_at ASC NULLS FIRST
+		ORDER BY created_at DESC
+	`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connections []*APIConnection
+	for rows.Next() {
+		conn := &APIConnection{}
+		var lastSyncAt sql.NullTime
+
+		err := rows.Scan(
+			&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
+			&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
+			&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if lastSyncAt.Valid {
+			conn.LastSyncAt = &lastSyncAt.Time
+		}
+
+		connections = append(connections, conn)
+	}
+
+	return connections, nil
+}
+
+// GetAllAPIConnectionsWithMerchant lists connections across merchants for admin
+// monitoring, newest first, with the merchant's name and owner email. It also
+// returns the total number of matching connections for pagination.
+func (db *DB) GetAllAPIConnectionsWithMerchant(filter ConnectionFilter, limit, offset int) ([]*AdminAPIConnection, int, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.Platform != "" {
+		args = append(args, filter.Platform)
+		where += fmt.Sprintf(" AND ac.platform = $%d", len(args))
+	}
+	if filter.SyncStatus != "" {
+		args = append(args, filter.SyncStatus)
+		where += fmt.Sprintf(" AND ac.sync_status = $%d", len(args))
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM api_connections ac` + where
+	if err := db.conn.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ac.id, ac.merchant_id, ac.platform, ac.platform_account_id, ac.platform_account_name,
+			ac.token_expires_at, ac.is_active, ac.last_sync_at,
+			ac.sync_status, COALESCE(ac.error_message, ''), COALESCE(ac.admin_notes, ''), COALESCE(ac.account_avatar_url, ''),
+			ac.created_at, ac.updated_at, m.business_name, COALESCE(u.email, '')
+		FROM api_connections ac
+		JOIN merchants m ON m.id = ac.merchant_id
+		LEFT JOIN auth.users u ON u.id = m.auth_user_id` + where +
+		fmt.Sprintf(" ORDER BY ac.created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	rows, err := db.conn.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var connections []*AdminAPIConnection
+	for rows.Next() {
+		conn := &APIConnection{}
+		admin := &AdminAPIConnection{APIConnection: conn}
+		var lastSyncAt sql.NullTime
+
+		err := rows.Scan(
+			&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
+			&conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
+			&conn.SyncStatus, &conn.ErrorMessage, &admin.AdminNotes, &conn.AccountAvatarURL,
+			&conn.CreatedAt, &conn.UpdatedAt, &admin.BusinessName, &admin.OwnerEmail,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if lastSyncAt.Valid {
+			conn.LastSyncAt = &lastSyncAt.Time
+		}
+		conn.AdminNotes = admin.AdminNotes
+
+		connections = append(connections, admin)
+	}
+
+	return connections, total, nil
+}
+
+func (db *DB) DeleteAPIConnection(id int) error {
+	query := `DELETE FROM api_connections WHERE id = $1`
+	_, err := db.conn.Exec(query, id)
+	return err
+}
+
+// GetActiveConnections lists the active connections the scheduler syncs and
+// refreshes. Connections of soft-deleted merchants are left out so a deleted
+// business stops calling the platforms and emailing its owner.
+func (db *DB) GetActiveConnections() ([]*APIConnection, error) {
+	query := `
+		SELECT ac.id, ac.merchant_id, ac.platform, ac.platform_account_id, ac.platform_account_name,
+			ac.access_token, ac.refresh_token, ac.token_expires_at, ac.is_active, ac.last_sync_at,
+			ac.sync_status, ac.error_message, COALESCE(ac.admin_notes, ''), COALESCE(ac.account_avatar_url, ''), ac.created_at, ac.updated_at
+		FROM api_connections ac
+		JOIN merchants m ON m.id = ac.merchant_id
+		WHERE ac.is_active = true AND m.deleted_at IS NULL
+		ORDER BY ac.last_sync_at ASC NULLS FIRST
 	`
 	rows, err := db.conn.Query(query)
 	if err != nil {
@@ -173,7 +357,7 @@ func (db *DB) GetActiveConnections() ([]*APIConnection, error) {
 		err := rows.Scan(
 			&conn.ID, &conn.MerchantID, &conn.Platform, &conn.PlatformAccountID, &conn.PlatformAccountName,
 			&conn.AccessToken, &conn.RefreshToken, &conn.TokenExpiresAt, &conn.IsActive, &lastSyncAt,
-			&conn.SyncStatus, &conn.ErrorMessage, &conn.CreatedAt, &conn.UpdatedAt,
+			&conn.SyncStatus, &conn.ErrorMessage, &conn.AdminNotes, &conn.AccountAvatarURL, &conn.CreatedAt, &conn.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -189,9 +373,53 @@ func (db *DB) GetActiveConnections() ([]*APIConnection, error) {
 	return connections, nil
 }
 
+// GetFailingConnections lists active connections whose last minFailures or more
+// syncs failed, counting failures since their most recent completed sync
+func (db *DB) GetFailingConnections(minFailures int) ([]*FailingConnection, error) {
+	rows, err := db.conn.Query(`
+		SELECT ac.id, ac.merchant_id, m.business_name, ac.platform, ac.platform_account_name,
+			COALESCE(ac.error_message, ''), f.failures, f.last_failed_at, ac.last_sync_at
+		FROM api_connections ac
+		JOIN merchants m ON m.id = ac.merchant_id
+		JOIN LATERAL (
+			SELECT COUNT(*) AS failures, MAX(sl.started_at) AS last_failed_at
+			FROM sync_logs sl
+			WHERE sl.api_connection_id = ac.id AND sl.status = 'failed'
+				AND sl.started_at > COALESCE((
+					SELECT MAX(ok.started_at) FROM sync_logs ok
+					WHERE ok.api_connection_id = ac.id AND ok.status = 'completed'
+				), '-infinity')
+		) f ON true
+		WHERE ac.is_active = true AND m.deleted_at IS NULL AND f.failures >= $1
+		ORDER BY f.failures DESC, f.last_failed_at DESC
+	`, minFailures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failing := []*FailingConnection{}
+	for rows.Next() {
+		fc := &FailingConnection{}
+		var lastSyncAt sql.NullTime
+		if err := rows.Scan(&fc.ConnectionID, &fc.MerchantID, &fc.BusinessName, &fc.Platform, &fc.PlatformAccountName,
+			&fc.ErrorMessage, &fc.ConsecutiveFailures, &fc.LastFailedAt, &lastSyncAt); err != nil {
+			return nil, err
+		}
+		if lastSyncAt.Valid {
+			fc.LastSyncAt = &lastSyncAt.Time
+		}
+		failing = append(failing, fc)
+	}
+	return failing, rows.Err()
+}
+
 // Synced Reviews
 
-func (db *DB) CreateSyncedReview(review *SyncedReview) error {
+// CreateSyncedReview inserts a synced review and reports whether it did. When
+// a concurrent sync already stored the same platform review nothing is written
+// and it returns false, so the caller can update that row with its history.
+func (db *DB) CreateSyncedReview(review *SyncedReview) (bool, error) {
 	metadataJSON, err := json.Marshal(review.Metadata)
 	if err != nil {
 		metadataJSON = []byte("{}")
@@ -201,16 +429,21 @@ func (db *DB) CreateSyncedReview(review *SyncedReview) error {
 		INSERT INTO synced_reviews (
 			merchant_id, api_connection_id, platform, platform_review_id,
 			author_name, author_photo_url, rating, review_text, review_reply,
-			reviewed_at, is_visible, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			reviewed_at, is_visible, metadata, sentiment, sentiment_score
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (platform, platform_review_id) DO NOTHING
 		RETURNING id, synced_at, created_at, updated_at
 	`
-	return db.conn.QueryRow(
+	err = db.conn.QueryRow(
 		query,
 		review.MerchantID, review.APIConnectionID, review.Platform, review.PlatformReviewID,
 		review.AuthorName, review.AuthorPhotoURL, review.Rating, review.ReviewText, review.ReviewReply,
-		review.ReviewedAt, review.IsVisible, metadataJSON,
+		review.ReviewedAt, review.IsVisible, metadataJSON, nullString(review.Sentiment), review.SentimentScore,
 	).Scan(&review.ID, &review.SyncedAt, &review.CreatedAt, &review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 func (db *DB) GetSyncedReview(id int) (*SyncedReview, error) {
@@ -289,33 +522,147 @@ func (db *DB) GetSyncedReviewByPlatformID(platform, platformReviewID string) (*S
 	return review, nil
 }
 
+// FindSyncedReviewByAuthorDay finds a merchant's review on a platform by author and UTC day
+func (db *DB) FindSyncedReviewByAuthorDay(merchantID int, platform, authorName string, reviewedAt time.Time) (*SyncedReview, error) {
+	day := reviewDay(reviewedAt)
+	reviews, err := db.querySyncedReviews(
+		"merchant_id = $1 AND platform = $2 AND LOWER(author_name) = LOWER($3) AND reviewed_at >= $4 AND reviewed_at < $5",
+		1, 0, merchantID, platform, strings.TrimSpace(authorName), day, day.Add(24*time.Hour),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return reviews[0], nil
+}
+
+// GetSyncedReviewsByMerchant returns the merchant's publicly visible reviews
 func (db *DB) GetSyncedReviewsByMerchant(merchantID int, limit, offset int) ([]*SyncedReview, error) {
-	query := `
-		SELECT id, merchant_id, api_connection_id, platform, platform_review_id,
+	return db.GetPublicReviews(merchantID, PublicReviewOptions{Limit: limit, Offset: offset})
+}
+
+// GetPublicReviews is the single query behind every public review surface.
+// It applies the public visibility rules plus any caller filters, newest first.
+func (db *DB) GetPublicReviews(merchantID int, opts PublicReviewOptions) ([]*SyncedReview, error) {
+	where := "merchant_id = $1 AND " + publicVisibilityCondition
+	args := []interface{}{merchantID}
+
+	if opts.Platform != "" {
+		args = append(args, opts.Platform)
+		where += fmt.Sprintf(" AND platform = $%d", len(args))
+	}
+	if opts.MinRating > 0 {
+		args = append(args, opts.MinRating)
+		where += fmt.Sprintf(" AND rating >= $%d", len(args))
+	}
+
+	policy := opts.TextlessPolicy
+	if policy == "" {
+		policy = TextlessReviewPolicyFromEnv()
+	}
+	if policy == TextlessHide {
+		where += " AND " + textlessCondition
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var reviews []*SyncedReview
+	var err error
+	if opts.Order == ReviewOrderBalanced && opts.Platform == "" {
+		reviews, err = db.queryBalancedReviews(where, limit, opts.Offset, args...)
+	} else {
+		reviews, err = db.querySyncedReviews(where, limit, opts.Offset, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if policy == TextlessRatingOnly {
+		for _, review := range reviews {
+			review.RatingOnly = strings.TrimSpace(review.ReviewText) == ""
+		}
+	}
+	return reviews, nil
+}
+
+// GetAllSyncedReviewsByMerchant returns every synced review, including hidden ones, for the dashboard
+func (db *DB) GetAllSyncedReviewsByMerchant(merchantID int, limit, offset int) ([]*SyncedReview, error) {
+	return db.querySyncedReviews("merchant_id = $1", limit, offset, merchantID)
+}
+
+// GetSyncedReviewsFiltered returns a page of the merchant's synced reviews,
+// hidden ones included, that match filter, along with the number of matches
+func (db *DB) GetSyncedReviewsFiltered(merchantID int, filter ReviewFilter, limit, offset int) ([]*SyncedReview, int, error) {
+	where := "merchant_id = $1"
+	args := []interface{}{merchantID}
+
+	if filter.Platform != "" {
+		args = append(args, filter.Platform)
+		where += fmt.Sprintf(" AND platform = $%d", len(args))
+	}
+	if filter.MinRating != nil {
+		args = append(args, *filter.MinRating)
+		where += fmt.Sprintf(" AND rating >= $%d", len(args))
+	}
+	if filter.MaxRating != nil {
+		args = append(args, *filter.MaxRating)
+		where += fmt.Sprintf(" AND rating <= $%d", len(args))
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM synced_reviews WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	reviews, err := db.querySyncedReviews(where, limit, offset, args...)
+	return reviews, total, err
+}
+
+// syncedReviewColumns is the column list scanSyncedReviews expects
+const syncedReviewColumns = `id, merchant_id, api_connection_id, platform, platform_review_id,
 			author_name, author_photo_url, rating, review_text, review_reply,
-			reviewed_at, synced_at, is_visible, metadata, created_at, updated_at
+			reviewed_at, synced_at, is_visible, metadata, created_at, updated_at,
+			sentiment, sentiment_score`
+
+func (db *DB) querySyncedReviews(where string, limit, offset int, args ...interface{}) ([]*SyncedReview, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM synced_reviews
-		WHERE merchant_id = $1 AND is_visible = true
+		WHERE %s
 		ORDER BY reviewed_at DESC
-		LIMIT $2 OFFSET $3
-	`
-	rows, err := db.conn.Query(query, merchantID, limit, offset)
+		LIMIT $%d OFFSET $%d
+	`, syncedReviewColumns, where, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanSyncedReviews(rows)
+}
+
+// scanSyncedReviews reads rows selecting syncedReviewColumns
+func scanSyncedReviews(rows *sql.Rows) ([]*SyncedReview, error) {
 	var reviews []*SyncedReview
 	for rows.Next() {
 		review := &SyncedReview{}
 		var metadataJSON []byte
 		var apiConnectionID sql.NullInt64
 		var rating sql.NullFloat64
+		var sentiment sql.NullString
+		var sentimentScore sql.NullFloat64
 
 		err := rows.Scan(
 			&review.ID, &review.MerchantID, &apiConnectionID, &review.Platform, &review.PlatformReviewID,
 			&review.AuthorName, &review.AuthorPhotoURL, &rating, &review.ReviewText, &review.ReviewReply,
 			&review.ReviewedAt, &review.SyncedAt, &review.IsVisible, &metadataJSON, &review.CreatedAt, &review.UpdatedAt,
+			&sentiment, &sentimentScore,
 		)
 		if err != nil {
 			return nil, err
@@ -334,6 +681,11 @@ func (db *DB) GetSyncedReviewsByMerchant(merchantID int, limit, offset int) ([]*
 			json.Unmarshal(metadataJSON, &review.Metadata)
 		}
 
+		review.Sentiment = sentiment.String
+		if sentimentScore.Valid {
+			review.SentimentScore = &sentimentScore.Float64
+		}
+
 		reviews = append(reviews, review)
 	}
 
@@ -349,53 +701,181 @@ func (db *DB) UpdateSyncedReview(review *SyncedReview) error {
 	query := `
 		UPDATE synced_reviews
 		SET author_name = $1, author_photo_url = $2, rating = $3, review_text = $4,
-			review_reply = $5, is_visible = $6, metadata = $7, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $8
+			review_reply = $5, is_visible = $6, metadata = $7, sentiment = $8, sentiment_score = $9,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
 	`
 	_, err = db.conn.Exec(
 		query,
 		review.AuthorName, review.AuthorPhotoURL, review.Rating, review.ReviewText,
-		review.ReviewReply, review.IsVisible, metadataJSON, review.ID,
+		review.ReviewReply, review.IsVisible, metadataJSON, nullString(review.Sentiment), review.SentimentScore, review.ID,
 	)
 	return err
 }
 
+// CreateSyncedReviewRevision records the previous version of an edited review
+func (db *DB) CreateSyncedReviewRevision(rev *SyncedReviewRevision) error {
+	query := `
+		INSERT INTO synced_review_revisions (synced_review_id, rating, review_text, review_reply)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return db.conn.QueryRow(query, rev.SyncedReviewID, rev.Rating, rev.ReviewText, rev.ReviewReply).
+		Scan(&rev.ID, &rev.CreatedAt)
+}
+
+// GetSyncedReviewRevisions lists a review's previous versions, newest first
+func (db *DB) GetSyncedReviewRevisions(reviewID int) ([]*SyncedReviewRevision, error) {
+	query := `
+		SELECT id, synced_review_id, rating, COALESCE(review_text, ''), COALESCE(review_reply, ''), created_at
+		FROM synced_review_revisions
+		WHERE synced_review_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := db.conn.Query(query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*SyncedReviewRevision{}
+	for rows.Next() {
+		rev := &SyncedReviewRevision{}
+		var rating sql.NullFloat64
+		if err := rows.Scan(&rev.ID, &rev.SyncedReviewID, &rating, &rev.ReviewText, &rev.ReviewReply, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if rating.Valid {
+			rev.Rating = &rating.Float64
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
 func (db *DB) DeleteSyncedReview(id int) error {
 	query := `DELETE FROM synced_reviews WHERE id = $1`
 	_, err := db.conn.Exec(query, id)
 	return err
 }
 
+// DeleteSyncedReviewsByConnection removes every review imported through a connection
+func (db *DB) DeleteSyncedReviewsByConnection(connectionID int) (int64, error) {
+	query := `DELETE FROM synced_reviews WHERE api_connection_id = $1`
+	result, err := db.conn.Exec(query, connectionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// HideSyncedReviewsByConnection takes every review imported through a connection off the public page
+func (db *DB) HideSyncedReviewsByConnection(connectionID int) (int64, error) {
+	query := `UPDATE synced_reviews SET is_visible = false, updated_at = CURRENT_TIMESTAMP WHERE api_connection_id = $1`
+	result, err := db.conn.Exec(query, connectionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetVisibleSyncedReviewsByMerchant returns a merchant's visible reviews, newest first
+func (db *DB) GetVisibleSyncedReviewsByMerchant(merchantID int, limit int) ([]*SyncedReview, error) {
+	return db.querySyncedReviews("merchant_id = $1 AND is_visible = true", limit, 0, merchantID)
+}
+
+// HideSyncedReviews marks the given reviews as not visible
+func (db *DB) HideSyncedReviews(ids []int) (int64, error) {
+	query := `UPDATE synced_reviews SET is_visible = false, updated_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`
+	result, err := db.conn.Exec(query, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Merchant settings
+
+// GetCrossPlatformDedup reports whether the merchant opted into cross-platform review dedup
+func (db *DB) GetCrossPlatformDedup(merchantID int) (bool, error) {
+	var enabled bool
+	err := db.conn.QueryRow(`SELECT cross_platform_dedup FROM merchants WHERE id = $1`, merchantID).Scan(&enabled)
+	return enabled, err
+}
+
+// GetNotificationSettings returns the merchant's alert preference with the owner's email
+func (db *DB) GetNotificationSettings(merchantID int) (*NotificationSettings, error) {
+	settings := &NotificationSettings{}
+	var email sql.NullString
+	query := `
+		SELECT m.notify_on_negative, m.weekly_digest, m.business_name, u.email
+		FROM merchants m
+		LEFT JOIN auth.users u ON u.id = m.auth_user_id
+		WHERE m.id = $1`
+	err := db.conn.QueryRow(query, merchantID).Scan(&settings.NotifyOnNegative, &settings.WeeklyDigest, &settings.BusinessName, &email)
+	if err != nil {
+		return nil, err
+	}
+	settings.Email = email.String
+	return settings, nil
+}
+
+// SetNotificationSettings turns the merchant's negative review alerts and weekly digest on or off
+func (db *DB) SetNotificationSettings(merchantID int, notifyOnNegative, weeklyDigest bool) error {
+	query := `UPDATE merchants SET notify_on_negative = $1, weekly_digest = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	_, err := db.conn.Exec(query, notifyOnNegative, weeklyDigest, merchantID)
+	return err
+}
+
+// GetReviewActivitySince counts visible reviews posted since the cutoff and
+// averages the rated ones from before it, so callers can show the rating change
+func (db *DB) GetReviewActivitySince(merchantID int, since time.Time) (*ReviewActivity, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE reviewed_at >= $2),
+			COUNT(rating) FILTER (WHERE reviewed_at < $2),
+			COALESCE(AVG(rating) FILTER (WHERE reviewed_at < $2), 0)
+		FROM synced_reviews
+		WHERE merchant_id = $1 AND ` + publicVisibilityCondition
+	activity := &ReviewActivity{}
+	err := db.conn.QueryRow(query, merchantID, since).Scan(&activity.NewReviews, &activity.PreviousRated, &activity.PreviousAvgRating)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
 // Sync Logs
 
 func (db *DB) CreateSyncLog(log *SyncLog) error {
 	query := `
 		INSERT INTO sync_logs (
 			api_connection_id, sync_type, status, reviews_fetched,
-			reviews_added, reviews_updated, error_message
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			reviews_added, reviews_updated, error_message, retry_of
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, started_at
 	`
 	return db.conn.QueryRow(
 		query,
 		log.APIConnectionID, log.SyncType, log.Status, log.ReviewsFetched,
-		log.ReviewsAdded, log.ReviewsUpdated, log.ErrorMessage,
+		log.ReviewsAdded, log.ReviewsUpdated, log.ErrorMessage, log.RetryOf,
 	).Scan(&log.ID, &log.StartedAt)
 }
 
 func (db *DB) GetSyncLog(id int) (*SyncLog, error) {
 	log := &SyncLog{}
 	var completedAt sql.NullTime
+	var retryOf sql.NullInt64
 
 	query := `
 		SELECT id, api_connection_id, sync_type, status, reviews_fetched,
-			reviews_added, reviews_updated, error_message, started_at, completed_at
+			reviews_added, reviews_updated, error_message, started_at, completed_at, retry_of
 		FROM sync_logs
 		WHERE id = $1
 	`
 	err := db.conn.QueryRow(query, id).Scan(
 		&log.ID, &log.APIConnectionID, &log.SyncType, &log.Status, &log.ReviewsFetched,
-		&log.ReviewsAdded, &log.ReviewsUpdated, &log.ErrorMessage, &log.StartedAt, &completedAt,
+		&log.ReviewsAdded, &log.ReviewsUpdated, &log.ErrorMessage, &log.StartedAt, &completedAt, &retryOf,
 	)
 	if err != nil {
 		return nil, err
@@ -404,6 +884,10 @@ func (db *DB) GetSyncLog(id int) (*SyncLog, error) {
 	if completedAt.Valid {
 		log.CompletedAt = &completedAt.Time
 	}
+	if retryOf.Valid {
+		id := int(retryOf.Int64)
+		log.RetryOf = &id
+	}
 
 	return log, nil
 }
@@ -411,7 +895,7 @@ func (db *DB) GetSyncLog(id int) (*SyncLog, error) {
 func (db *DB) GetSyncLogsByConnection(connectionID int, limit int) ([]*SyncLog, error) {
 	query := `
 		SELECT id, api_connection_id, sync_type, status, reviews_fetched,
-			reviews_added, reviews_updated, error_message, started_at, completed_at
+			reviews_added, reviews_updated, error_message, started_at, completed_at, retry_of
 		FROM sync_logs
 		WHERE api_connection_id = $1
 		ORDER BY started_at DESC
@@ -427,10 +911,11 @@ func (db *DB) GetSyncLogsByConnection(connectionID int, limit int) ([]*SyncLog,
 	for rows.Next() {
 		log := &SyncLog{}
 		var completedAt sql.NullTime
+		var retryOf sql.NullInt64
 
 		err := rows.Scan(
 			&log.ID, &log.APIConnectionID, &log.SyncType, &log.Status, &log.ReviewsFetched,
-			&log.ReviewsAdded, &log.ReviewsUpdated, &log.ErrorMessage, &log.StartedAt, &completedAt,
+			&log.ReviewsAdded, &log.ReviewsUpdated, &log.ErrorMessage, &log.StartedAt, &completedAt, &retryOf,
 		)
 		if err != nil {
 			return nil, err
@@ -439,6 +924,10 @@ func (db *DB) GetSyncLogsByConnection(connectionID int, limit int) ([]*SyncLog,
 		if completedAt.Valid {
 			log.CompletedAt = &completedAt.Time
 		}
+		if retryOf.Valid {
+			id := int(retryOf.Int64)
+			log.RetryOf = &id
+		}
 
 		logs = append(logs, log)
 	}
@@ -461,6 +950,118 @@ func (db *DB) UpdateSyncLog(log *SyncLog) error {
 	return err
 }
 
+// OAuth States
+
+// CreateOAuthState records a pending authorization for the user who started it
+// and clears out expired ones
+func (db *DB) CreateOAuthState(state string, merchantID int, authUserID, platform string, expiresAt time.Time) error {
+	if _, err := db.conn.Exec(`DELETE FROM oauth_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oauth_states (state, merchant_id, auth_user_id, platform, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.conn.Exec(query, state, merchantID, authUserID, platform, expiresAt)
+	return err
+}
+
+// ConsumeOAuthState deletes a state and returns the merchant it was issued to.
+// Deleting first makes each state single-use even if two callbacks race.
+// Returns ErrInvalidOAuthState if the state is unknown, expired, for another
+// platform, or was issued to a different user than the one completing it.
+func (db *DB) ConsumeOAuthState(state, platform, authUserID string) (int, error) {
+	var merchantID int
+	var stateUserID, statePlatform string
+	var expiresAt time.Time
+
+	query := `DELETE FROM oauth_states WHERE state = $1 RETURNING merchant_id, auth_user_id, platform, expires_at`
+	err := db.conn.QueryRow(query, state).Scan(&merchantID, &stateUserID, &statePlatform, &expiresAt)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if statePlatform != platform || stateUserID != authUserID || time.Now().After(expiresAt) {
+		return 0, ErrInvalidOAuthState
+	}
+	return merchantID, nil
+}
+
+// DeleteSyncLogsOlderThan removes sync logs started before cutoff in batches
+// and returns the total number deleted
+func (db *DB) DeleteSyncLogsOlderThan(cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM sync_logs
+		WHERE id IN (
+			SELECT id FROM sync_logs
+			WHERE started_at < $1
+			LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		result, err := db.conn.Exec(query, cutoff, syncLogDeleteBatchSize)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < syncLogDeleteBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Token refresh log operations
+
+func (db *DB) CreateTokenRefreshLog(log *TokenRefreshLog) error {
+	query := `
+		INSERT INTO token_refresh_logs (api_connection_id, status, error_message, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	var expiresAt *time.Time
+	if !log.ExpiresAt.IsZero() {
+		expiresAt = &log.ExpiresAt
+	}
+	return db.conn.QueryRow(query, log.APIConnectionID, log.Status, nullString(log.ErrorMessage), expiresAt).
+		Scan(&log.ID, &log.CreatedAt)
+}
+
+// DeleteTokenRefreshLogsOlderThan removes token refresh logs created before
+// cutoff in batches and returns the total number deleted
+func (db *DB) DeleteTokenRefreshLogsOlderThan(cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM token_refresh_logs
+		WHERE id IN (
+			SELECT id FROM token_refresh_logs
+			WHERE created_at < $1
+			LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		result, err := db.conn.Exec(query, cutoff, syncLogDeleteBatchSize)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < syncLogDeleteBatchSize {
+			return total, nil
+		}
+	}
+}
+
 // Transaction helpers
 
 func (db *DB) Begin() (*sql.Tx, error) {
@@ -481,18 +1082,19 @@ func (db *DB) GetMerchantReviewStats(merchantID int) (map[string]interface{}, er
 		SELECT
 			COUNT(*) as total_reviews,
 			COUNT(DISTINCT platform) as platforms_connected,
-			AVG(CASE WHEN rating IS NOT NULL THEN rating ELSE 0 END) as avg_rating,
+			COUNT(rating) as rated_reviews,
+			COALESCE(AVG(rating), 0) as avg_rating,
 			MAX(reviewed_at) as latest_review_date
 		FROM synced_reviews
-		WHERE merchant_id = $1 AND is_visible = true
+		WHERE merchant_id = $1 AND ` + publicVisibilityCondition + `
 	`
 
-	var totalReviews, platformsConnected int
+	var totalReviews, platformsConnected, ratedReviews int
 	var avgRating float64
 	var latestReviewDate sql.NullTime
 
 	err := db.conn.QueryRow(query, merchantID).Scan(
-		&totalReviews, &platformsConnected, &avgRating, &latestReviewDate,
+		&totalReviews, &platformsConnected, &ratedReviews, &avgRating, &latestReviewDate,
 	)
 	if err != nil {
 		return nil, err
@@ -501,6 +1103,7 @@ func (db *DB) GetMerchantReviewStats(merchantID int) (map[string]interface{}, er
 	stats := map[string]interface{}{
 		"total_reviews":       totalReviews,
 		"platforms_connected": platformsConnected,
+		"rated_reviews":       ratedReviews,
 		"avg_rating":          fmt.Sprintf("%.1f", avgRating),
 	}
 
@@ -508,5 +1111,92 @@ func (db *DB) GetMerchantReviewStats(merchantID int) (map[string]interface{}, er
 		stats["latest_review_date"] = latestReviewDate.Time
 	}
 
+	platforms, err := db.getPlatformRatingStats(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	stats["platforms"] = platforms
+
+	sentiment, err := db.getSentimentBreakdown(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	stats["sentiment_breakdown"] = sentiment
+
 	return stats, nil
 }
+
+// getSentimentBreakdown counts visible reviews per sentiment label; reviews
+// without text (and so without a label) aren't counted
+func (db *DB) getSentimentBreakdown(merchantID int) (map[string]int, error) {
+	query := `
+		SELECT sentiment, COUNT(*)
+		FROM synced_reviews
+		WHERE merchant_id = $1 AND sentiment IS NOT NULL AND ` + publicVisibilityCondition + `
+		GROUP BY sentiment
+	`
+	rows, err := db.conn.Query(query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdown := map[string]int{
+		SentimentPositive: 0,
+		SentimentNeutral:  0,
+		SentimentNegative: 0,
+	}
+	for rows.Next() {
+		var label string
+		var count int
+		if err := rows.Scan(&label, &count); err != nil {
+			return nil, err
+		}
+		breakdown[label] = count
+	}
+
+	return breakdown, rows.Err()
+}
+
+// PlatformRatingStats is the rating breakdown for one platform
+type PlatformRatingStats struct {
+	Platform     string `json:"platform"`
+	Name         string `json:"name"`
+	TotalReviews int    `json:"total_reviews"`
+	AvgRating    string `json:"avg_rating"`
+}
+
+// getPlatformRatingStats returns per-platform averages for platforms with rated reviews
+func (db *DB) getPlatformRatingStats(merchantID int) ([]PlatformRatingStats, error) {
+	query := `
+		SELECT platform, COUNT(rating), AVG(rating)
+		FROM synced_reviews
+		WHERE merchant_id = $1 AND rating IS NOT NULL AND ` + publicVisibilityCondition + `
+		GROUP BY platform
+		ORDER BY COUNT(rating) DESC
+	`
+	rows, err := db.conn.Query(query, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var platforms []PlatformRatingStats
+	for rows.Next() {
+		var stat PlatformRatingStats
+		var avgRating float64
+		if err := rows.Scan(&stat.Platform, &stat.TotalReviews, &avgRating); err != nil {
+			return nil, err
+		}
+		stat.Name = PlatformDisplayName(stat.Platform)
+		stat.AvgRating = fmt.Sprintf("%.1f", avgRating)
+		platforms = append(platforms, stat)
+	}
+
+	return platforms, rows.Err()
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
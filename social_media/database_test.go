@@ -0,0 +1,225 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+func TestGetActiveConnectionsSkipsDeletedMerchants(t *testing.T) {
+	// Connection 1 belongs to a live merchant, connection 2 to a soft-deleted one
+	deletedMerchant := map[int64]bool{1: false, 2: true}
+
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		res := &fakedb.Result{Columns: make([]string, 16)}
+		now := time.Now()
+		for id, deleted := range deletedMerchant {
+			if deleted && strings.Contains(query, "m.deleted_at IS NULL") {
+				continue
+			}
+			res.Rows = append(res.Rows, []driver.Value{
+				id, id, PlatformGoogleBusiness, "account", "Account",
+				"token", "", now, true, nil,
+				SyncStatusCompleted, "", "", "", now, now,
+			})
+		}
+		return res, nil
+	})
+	defer conn.Close()
+
+	connections, err := NewDB(conn).GetActiveConnections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(connections) != 1 || connections[0].MerchantID != 1 {
+		t.Errorf("got %d connections, want only the live merchant's", len(connections))
+	}
+}
+
+// publicReviewFixture is merchant 7's reviews, newest first, with one of
+// merchant 8's mixed in
+func publicReviewFixture() []*SyncedReview {
+	now := time.Now()
+	review := func(id, merchantID int, platform string, rating float64, text string, visible bool) *SyncedReview {
+		return &SyncedReview{ID: id, MerchantID: merchantID, Platform: platform, Rating: &rating, ReviewText: text,
+			IsVisible: visible, ReviewedAt: now.Add(-time.Duration(id) * time.Hour)}
+	}
+	return []*SyncedReview{
+		review(1, 7, PlatformFacebook, 5, "Great", true),
+		review(2, 7, PlatformFacebook, 4, " ", true),
+		review(3, 7, PlatformGoogleBusiness, 5, "Nice", true),
+		review(4, 7, PlatformFacebook, 2, "Meh", true),
+		review(5, 7, PlatformFacebook, 5, "Hidden", false),
+		review(6, 8, PlatformFacebook, 5, "Another merchant", true),
+		review(7, 7, PlatformFacebook, 4, "Also good", true),
+	}
+}
+
+func TestGetPublicReviewsCombinesFilters(t *testing.T) {
+	db := tableDB(t, syncedReviewTable(publicReviewFixture()...))
+	opts := PublicReviewOptions{
+		Platform:       PlatformFacebook,
+		MinRating:      4,
+		TextlessPolicy: TextlessHide,
+	}
+
+	reviews, err := db.GetPublicReviews(7, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[1 7]" {
+		t.Errorf("got reviews %s, want the visible written Facebook reviews rated 4 or more: [1 7]", got)
+	}
+
+	opts.Limit, opts.Offset = 1, 1
+	if reviews, err = db.GetPublicReviews(7, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[7]" {
+		t.Errorf("second page = %s, want [7]", got)
+	}
+}
+
+func TestGetPublicReviewsDefaults(t *testing.T) {
+	t.Setenv("TEXTLESS_REVIEW_POLICY", "")
+	db := tableDB(t, syncedReviewTable(publicReviewFixture()...))
+
+	reviews, err := db.GetPublicReviews(7, PublicReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[1 2 3 4 7]" {
+		t.Errorf("got reviews %s, want every visible review of the merchant: [1 2 3 4 7]", got)
+	}
+	for _, review := range reviews {
+		if review.RatingOnly != (review.ID == 2) {
+			t.Errorf("review %d: RatingOnly = %v, want it only on the textless review", review.ID, review.RatingOnly)
+		}
+	}
+
+	var many []*SyncedReview
+	for i := 1; i <= 60; i++ {
+		rating := 5.0
+		many = append(many, &SyncedReview{ID: i, MerchantID: 7, Rating: &rating, ReviewText: "Good", IsVisible: true})
+	}
+	db = tableDB(t, syncedReviewTable(many...))
+	if reviews, err = db.GetPublicReviews(7, PublicReviewOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 50 {
+		t.Errorf("got %d reviews, want the default limit of 50", len(reviews))
+	}
+}
+
+func TestAPIConnectionAvatarRoundTrip(t *testing.T) {
+	var stored driver.Value
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		now := time.Now()
+		switch {
+		case strings.Contains(query, "INSERT INTO api_connections"):
+			stored = args[4]
+			return &fakedb.Result{Columns: make([]string, 3), Rows: [][]driver.Value{{int64(1), now, now}}}, nil
+		case strings.Contains(query, "FROM api_connections"):
+			return &fakedb.Result{Columns: make([]string, 16), Rows: [][]driver.Value{{
+				int64(1), int64(7), PlatformGoogleBusiness, "accounts/1", "Cafe",
+				"token", "", now, true, nil,
+				SyncStatusPending, "", "", stored, now, now,
+			}}}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	defer conn.Close()
+	db := NewDB(conn)
+
+	created := &APIConnection{MerchantID: 7, Platform: PlatformGoogleBusiness, AccountAvatarURL: "https://example.com/avatar.png"}
+	if err := db.CreateAPIConnection(created); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetAPIConnection(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccountAvatarURL != "https://example.com/avatar.png" {
+		t.Errorf("AccountAvatarURL = %q, want the stored avatar", got.AccountAvatarURL)
+	}
+}
+
+func TestGetAllAPIConnectionsWithMerchant(t *testing.T) {
+	now := time.Now()
+	row := func(id int64, platform, status, notes, business string) []driver.Value {
+		return []driver.Value{
+			id, id + 6, platform, "page-1", "Cafe Page",
+			now, true, nil,
+			status, "token expired", notes, "",
+			now, now, business, "owner@example.com",
+		}
+	}
+	// api_connections joined with the merchant and its owner
+	table := &fakedb.Table{
+		Columns: []string{"id", "merchant_id", "platform", "platform_account_id", "platform_account_name",
+			"token_expires_at", "is_active", "last_sync_at",
+			"sync_status", "error_message", "admin_notes", "account_avatar_url",
+			"created_at", "updated_at", "business_name", "email"},
+		Rows: [][]driver.Value{
+			row(1, PlatformFacebook, SyncStatusFailed, "Called the owner", "Cafe"),
+			row(2, PlatformFacebook, SyncStatusCompleted, "", "Bakery"),
+			row(3, PlatformGoogleBusiness, SyncStatusFailed, "", "Salon"),
+			row(4, PlatformFacebook, SyncStatusFailed, "", "Diner"),
+		},
+	}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return table.Count(query, args)
+		}
+		rows, err := table.Match(query, args)
+		return &fakedb.Result{Columns: table.Columns, Rows: rows}, err
+	})
+	defer conn.Close()
+	db := NewDB(conn)
+	filter := ConnectionFilter{Platform: PlatformFacebook, SyncStatus: SyncStatusFailed}
+
+	connections, total, err := db.GetAllAPIConnectionsWithMerchant(filter, 20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(connections) != 2 || connections[0].ID != 1 || connections[1].ID != 4 {
+		t.Fatalf("got %d connections of %d, want the failed Facebook connections 1 and 4", len(connections), total)
+	}
+	if got := connections[0]; got.BusinessName != "Cafe" || got.OwnerEmail != "owner@example.com" || got.AdminNotes != "Called the owner" || got.MerchantID != 7 {
+		t.Errorf("connection = %+v, want merchant 7's with its name, owner and notes", got)
+	}
+
+	if connections, total, err = db.GetAllAPIConnectionsWithMerchant(filter, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(connections) != 1 || connections[0].ID != 4 {
+		t.Errorf("second page: got %d connections of %d, want connection 4 of 2", len(connections), total)
+	}
+}
+
+func TestCreateSyncedReviewSkipsConflicts(t *testing.T) {
+	for _, inserted := range []bool{true, false} {
+		now := time.Now()
+		conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+			if !strings.Contains(query, "ON CONFLICT (platform, platform_review_id) DO NOTHING") {
+				t.Fatalf("unexpected query: %s", query)
+			}
+			res := &fakedb.Result{Columns: make([]string, 4)}
+			if inserted {
+				res.Rows = [][]driver.Value{{int64(9), now, now, now}}
+			}
+			return res, nil
+		})
+
+		review := &SyncedReview{MerchantID: 7, Platform: PlatformGoogleBusiness, PlatformReviewID: "r1", ReviewedAt: now}
+		created, err := NewDB(conn).CreateSyncedReview(review)
+		if err != nil || created != inserted || (review.ID == 9) != inserted {
+			t.Errorf("inserted=%v: CreateSyncedReview = %v, %v with id %d", inserted, created, err, review.ID)
+		}
+		conn.Close()
+	}
+}
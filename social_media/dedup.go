@@ -0,0 +1,123 @@
+package socialmedia
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// DedupStrategy decides when a fetched review is the same as a stored one
+type DedupStrategy string
+
+const (
+	// DedupStrictID matches on (platform, platform_review_id) only. Use it for
+	// platforms whose review ids are stable and unique.
+	DedupStrictID DedupStrategy = "id"
+
+	// DedupIDAuthorDay also requires the author and review day to agree. An id
+	// match with a different author/day is a different review (stored under a
+	// disambiguated id), and a review whose id changed between syncs is still
+	// matched by author and day.
+	DedupIDAuthorDay DedupStrategy = "id_author_day"
+)
+
+// defaultDedupStrategies documents the choice per provider:
+//   - google_business: reviewId is stable and unique
+//   - facebook: the id falls back to created_time when open_graph_story is
+//     missing, so ids can collide and can change once the story appears
+//   - instagram: comment ids are stable and unique
+//   - threads: reply and mention ids are stable and unique
+var defaultDedupStrategies = map[string]DedupStrategy{
+	PlatformGoogleBusiness: DedupStrictID,
+	PlatformFacebook:       DedupIDAuthorDay,
+	PlatformInstagram:      DedupStrictID,
+	PlatformThreads:        DedupStrictID,
+}
+
+// dedupStrategiesFromEnv applies REVIEW_DEDUP_STRATEGIES overrides, e.g.
+// "facebook=id_author_day,google_business=id", on top of the defaults
+func dedupStrategiesFromEnv() map[string]DedupStrategy {
+	strategies := make(map[string]DedupStrategy, len(defaultDedupStrategies))
+	for platform, strategy := range defaultDedupStrategies {
+		strategies[platform] = strategy
+	}
+
+	for _, pair := range strings.Split(os.Getenv("REVIEW_DEDUP_STRATEGIES"), ",") {
+		platform, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		switch strategy := DedupStrategy(strings.TrimSpace(value)); strategy {
+		case DedupStrictID, DedupIDAuthorDay:
+			strategies[strings.TrimSpace(platform)] = strategy
+		default:
+			log.Printf("Ignoring unknown dedup strategy %q for %s", value, platform)
+		}
+	}
+	return strategies
+}
+
+// dedupStrategy returns the strategy for a platform, defaulting to strict id matching
+func (s *SyncService) dedupStrategy(platform string) DedupStrategy {
+	if strategy, ok := s.dedupStrategies[platform]; ok {
+		return strategy
+	}
+	return DedupStrictID
+}
+
+// findExistingReview looks up the stored copy of a fetched review using the
+// platform's dedup strategy. It may rewrite review.PlatformReviewID when the
+// platform id collides with a different review. Returns nil for a new review.
+func (s *SyncService) findExistingReview(conn *APIConnection, review *Review) *SyncedReview {
+	existing, err := s.db.GetSyncedReviewByPlatformID(conn.Platform, review.PlatformReviewID)
+	if err != nil {
+		existing = nil
+	}
+
+	if s.dedupStrategy(conn.Platform) == DedupStrictID {
+		return existing
+	}
+
+	if existing != nil {
+		if sameAuthorDay(existing.AuthorName, existing.ReviewedAt, review) {
+			return existing
+		}
+		// Same id, different review: store it under an id that won't collide
+		review.PlatformReviewID = disambiguatedReviewID(review)
+		if existing, err := s.db.GetSyncedReviewByPlatformID(conn.Platform, review.PlatformReviewID); err == nil {
+			return existing
+		}
+		return nil
+	}
+
+	// The id may have changed since the last sync; fall back to author and day.
+	// Anonymous reviews can't be told apart this way, so they stay new.
+	if strings.TrimSpace(review.AuthorName) == "" {
+		return nil
+	}
+	existing, err = s.db.FindSyncedReviewByAuthorDay(conn.MerchantID, conn.Platform, review.AuthorName, review.ReviewedAt)
+	if err != nil {
+		return nil
+	}
+	return existing
+}
+
+// sameAuthorDay reports whether a stored review and a fetched one share author and UTC day
+func sameAuthorDay(authorName string, reviewedAt time.Time, review *Review) bool {
+	return strings.EqualFold(strings.TrimSpace(authorName), strings.TrimSpace(review.AuthorName)) &&
+		reviewDay(reviewedAt).Equal(reviewDay(review.ReviewedAt))
+}
+
+// reviewDay truncates a review time to its UTC day
+func reviewDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// disambiguatedReviewID appends a short author/day hash to a colliding platform id
+func disambiguatedReviewID(review *Review) string {
+	sum := sha1.Sum([]byte(strings.ToLower(strings.TrimSpace(review.AuthorName)) + "|" + reviewDay(review.ReviewedAt).Format("2006-01-02")))
+	return review.PlatformReviewID + "#" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,81 @@
+package socialmedia
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupStrategiesFromEnv(t *testing.T) {
+	t.Setenv("REVIEW_DEDUP_STRATEGIES", " google_business = id_author_day ,facebook=fuzzy,instagram")
+
+	strategies := dedupStrategiesFromEnv()
+	if strategies[PlatformGoogleBusiness] != DedupIDAuthorDay {
+		t.Errorf("google_business = %s, want the override", strategies[PlatformGoogleBusiness])
+	}
+	if strategies[PlatformFacebook] != DedupIDAuthorDay || strategies[PlatformInstagram] != DedupStrictID {
+		t.Errorf("strategies = %v, want invalid entries to keep the defaults", strategies)
+	}
+}
+
+func TestFindExistingReview(t *testing.T) {
+	t.Setenv("REVIEW_DEDUP_STRATEGIES", "")
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	stored := func(platform string) *memDB {
+		db := newMemDB()
+		db.reviews[1] = &SyncedReview{ID: 1, MerchantID: 7, Platform: platform, PlatformReviewID: "r1", AuthorName: "Aina", ReviewedAt: day}
+		return db
+	}
+
+	tests := []struct {
+		name      string
+		platform  string
+		review    Review
+		wantFound bool
+		wantID    string // PlatformReviewID after the lookup
+	}{
+		{"strict: same id", PlatformGoogleBusiness, Review{PlatformReviewID: "r1", AuthorName: "Ben", ReviewedAt: day.AddDate(0, 0, 3)}, true, "r1"},
+		{"strict: new id", PlatformGoogleBusiness, Review{PlatformReviewID: "r2", AuthorName: "Aina", ReviewedAt: day}, false, "r2"},
+		{"author/day: same id and author", PlatformFacebook, Review{PlatformReviewID: "r1", AuthorName: " aina ", ReviewedAt: day.Add(5 * time.Hour)}, true, "r1"},
+		{"author/day: id changed", PlatformFacebook, Review{PlatformReviewID: "r9", AuthorName: "Aina", ReviewedAt: day}, true, "r9"},
+		{"author/day: anonymous", PlatformFacebook, Review{PlatformReviewID: "r9", ReviewedAt: day}, false, "r9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSyncService(stored(tt.platform), &fakeProvider{platform: tt.platform})
+			review := tt.review
+			existing := s.findExistingReview(&APIConnection{MerchantID: 7, Platform: tt.platform}, &review)
+			if (existing != nil) != tt.wantFound {
+				t.Errorf("found = %v, want %v", existing != nil, tt.wantFound)
+			}
+			if review.PlatformReviewID != tt.wantID {
+				t.Errorf("PlatformReviewID = %q, want %q", review.PlatformReviewID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestFindExistingReviewDisambiguatesCollidingIDs(t *testing.T) {
+	t.Setenv("REVIEW_DEDUP_STRATEGIES", "")
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	db := newMemDB()
+	db.reviews[1] = &SyncedReview{ID: 1, MerchantID: 7, Platform: PlatformFacebook, PlatformReviewID: "r1", AuthorName: "Aina", ReviewedAt: day}
+	s := newTestSyncService(db, &fakeProvider{platform: PlatformFacebook})
+	conn := &APIConnection{MerchantID: 7, Platform: PlatformFacebook}
+
+	// Same id, different author: a different review
+	review := Review{PlatformReviewID: "r1", AuthorName: "Ben", ReviewedAt: day}
+	if existing := s.findExistingReview(conn, &review); existing != nil {
+		t.Fatalf("matched stored review %d, want a new review", existing.ID)
+	}
+	if !strings.HasPrefix(review.PlatformReviewID, "r1#") {
+		t.Fatalf("PlatformReviewID = %q, want r1 disambiguated", review.PlatformReviewID)
+	}
+
+	// Once stored under that id, the next sync finds it again
+	db.reviews[2] = &SyncedReview{ID: 2, MerchantID: 7, Platform: PlatformFacebook, PlatformReviewID: review.PlatformReviewID, AuthorName: "Ben", ReviewedAt: day}
+	again := Review{PlatformReviewID: "r1", AuthorName: "Ben", ReviewedAt: day}
+	if existing := s.findExistingReview(conn, &again); existing == nil || existing.ID != 2 {
+		t.Errorf("second sync matched %v, want stored review 2", existing)
+	}
+}
@@ -4,14 +4,37 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+)
+
+// MinEncryptionKeyLength is the shortest ENCRYPTION_KEY accepted
+const MinEncryptionKeyLength = 32
+
+// DefaultKeyID identifies the primary key when ENCRYPTION_KEY_ID isn't set.
+// Ciphertext is stored as "<key id>:<base64>"; ciphertext with no key id
+// predates key derivation and was encrypted with the legacy padded key.
+const DefaultKeyID = "v2"
+
+var (
+	ErrMissingEncryptionKey = errors.New("ENCRYPTION_KEY is not set")
+	ErrWeakEncryptionKey    = fmt.Errorf("ENCRYPTION_KEY must be at least %d characters", MinEncryptionKeyLength)
+	ErrUnknownKeyID         = errors.New("ciphertext was encrypted with an unknown key id")
 )
 
 // AESEncryptor implements TokenEncryptor using AES-256-GCM encryption
 type AESEncryptor struct {
 	key []byte
+	// keyID tags new ciphertext; empty for a raw-key encryptor, which writes no tag
+	keyID string
+	// keys holds every key that can decrypt, by id, including the primary
+	keys map[string][]byte
+	// legacyKeys decrypt untagged tokens stored before keys were derived
+	legacyKeys [][]byte
 }
 
 // NewAESEncryptor creates a new AES encryptor with the given key
@@ -23,14 +46,150 @@ func NewAESEncryptor(key []byte) (*AESEncryptor, error) {
 	return &AESEncryptor{key: key}, nil
 }
 
+// NewAESEncryptorFromSecret creates an encryptor whose key is derived from
+// secret. Tokens encrypted before key derivation was introduced can still be
+// decrypted with the old padded form of the same secret.
+func NewAESEncryptorFromSecret(secret string) (*AESEncryptor, error) {
+	return NewVersionedAESEncryptor(DefaultKeyID, secret, nil)
+}
+
+// NewVersionedAESEncryptor creates an encryptor that encrypts with the key
+// derived from secret, tagged with keyID, and can still decrypt anything
+// tagged with one of the retired keys in oldSecrets (key id to secret).
+// Untagged legacy tokens are tried against every secret's padded form.
+// Retired secrets shorter than MinEncryptionKeyLength are only used that way.
+func NewVersionedAESEncryptor(keyID, secret string, oldSecrets map[string]string) (*AESEncryptor, error) {
+	if !validKeyID(keyID) {
+		return nil, fmt.Errorf("invalid encryption key id %q: use letters and digits only", keyID)
+	}
+	key, err := DeriveEncryptionKey(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &AESEncryptor{
+		key:        key,
+		keyID:      keyID,
+		keys:       map[string][]byte{keyID: key},
+		legacyKeys: [][]byte{EncryptionKeyFromString(secret)},
+	}
+	for id, oldSecret := range oldSecrets {
+		if id == keyID {
+			return nil, fmt.Errorf("retired encryption key id %q is the same as the primary key id", id)
+		}
+		if !validKeyID(id) {
+			return nil, fmt.Errorf("invalid encryption key id %q: use letters and digits only", id)
+		}
+		oldKey, err := DeriveEncryptionKey(oldSecret)
+		switch {
+		case err == ErrWeakEncryptionKey:
+			// Short secrets predate the length check, so nothing was ever
+			// tagged with them; keep them for untagged legacy tokens only
+		case err != nil:
+			return nil, fmt.Errorf("retired encryption key %q: %w", id, err)
+		default:
+			e.keys[id] = oldKey
+		}
+		e.legacyKeys = append(e.legacyKeys, EncryptionKeyFromString(oldSecret))
+	}
+	return e, nil
+}
+
+// ParseRetiredKeys parses "id:secret,id:secret" as used by ENCRYPTION_KEYS_RETIRED
+func ParseRetiredKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, errors.New("retired encryption keys must be written as id:secret")
+		}
+		keys[id] = secret
+	}
+	return keys, nil
+}
+
+// validKeyID reports whether id can tag ciphertext. Base64 never contains ':',
+// so any alphanumeric id before the first ':' is unambiguous.
+func validKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyID returns the id new ciphertext is tagged with
+func (e *AESEncryptor) KeyID() string {
+	return e.keyID
+}
+
+// IsCurrent reports whether ciphertext is already encrypted with the primary key
+func (e *AESEncryptor) IsCurrent(ciphertext string) bool {
+	if ciphertext == "" {
+		return true
+	}
+	if e.keyID == "" {
+		return !strings.Contains(ciphertext, ":")
+	}
+	return strings.HasPrefix(ciphertext, e.keyID+":")
+}
+
 // Encrypt encrypts plaintext using AES-256-GCM
 func (e *AESEncryptor) Encrypt(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", nil
 	}
 
+	sealed, err := sealAESGCM(e.key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	if e.keyID == "" {
+		return sealed, nil
+	}
+	return e.keyID + ":" + sealed, nil
+}
+
+// Decrypt decrypts ciphertext using AES-256-GCM
+func (e *AESEncryptor) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	if keyID, rest, ok := strings.Cut(ciphertext, ":"); ok {
+		key, known := e.keys[keyID]
+		if !known {
+			return "", ErrUnknownKeyID
+		}
+		return openAESGCM(key, rest)
+	}
+
+	if len(e.legacyKeys) == 0 {
+		return openAESGCM(e.key, ciphertext)
+	}
+	// GCM authenticates, so only the key that encrypted the token opens it
+	var err error
+	for _, key := range e.legacyKeys {
+		var plaintext string
+		if plaintext, err = openAESGCM(key, ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
+
+// sealAESGCM encrypts plaintext with key, returning base64 of nonce+ciphertext
+func sealAESGCM(key []byte, plaintext string) (string, error) {
 	// Create cipher block
-	block, err := aes.NewCipher(e.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -54,12 +213,8 @@ func (e *AESEncryptor) Encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt decrypts ciphertext using AES-256-GCM
-func (e *AESEncryptor) Decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
+// openAESGCM reverses sealAESGCM
+func openAESGCM(key []byte, ciphertext string) (string, error) {
 	// Decode from base64
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
@@ -67,7 +222,7 @@ func (e *AESEncryptor) Decrypt(ciphertext string) (string, error) {
 	}
 
 	// Create cipher block
-	block, err := aes.NewCipher(e.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -104,8 +259,22 @@ func GenerateEncryptionKey() ([]byte, error) {
 	return key, nil
 }
 
+// DeriveEncryptionKey turns a secret into a 32-byte AES-256 key with SHA-256.
+// Secrets that are empty or shorter than MinEncryptionKeyLength are rejected.
+func DeriveEncryptionKey(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, ErrMissingEncryptionKey
+	}
+	if len(secret) < MinEncryptionKeyLength {
+		return nil, ErrWeakEncryptionKey
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:], nil
+}
+
 // EncryptionKeyFromString converts a string to a 32-byte key
-// If the string is shorter, it's padded; if longer, it's truncated
+// If the string is shorter, it's padded; if longer, it's truncated.
+// Only used to decrypt tokens stored before DeriveEncryptionKey.
 func EncryptionKeyFromString(keyStr string) []byte {
 	key := make([]byte, 32)
 	copy(key, []byte(keyStr))
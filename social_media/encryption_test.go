@@ -0,0 +1,84 @@
+package socialmedia
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestDeriveEncryptionKey(t *testing.T) {
+	if _, err := DeriveEncryptionKey(""); err != ErrMissingEncryptionKey {
+		t.Errorf("empty secret: err = %v, want ErrMissingEncryptionKey", err)
+	}
+	if _, err := DeriveEncryptionKey(strings.Repeat("a", MinEncryptionKeyLength-1)); err != ErrWeakEncryptionKey {
+		t.Errorf("short secret: err = %v, want ErrWeakEncryptionKey", err)
+	}
+
+	key, err := DeriveEncryptionKey(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("key is %d bytes, want 32", len(key))
+	}
+	// The derived key is not the padded secret, so a short secret can't be guessed from it
+	if bytes.Equal(key, EncryptionKeyFromString(testSecret)) {
+		t.Error("derived key equals the raw secret")
+	}
+	other, _ := DeriveEncryptionKey(testSecret + "x")
+	if bytes.Equal(key, other) {
+		t.Error("different secrets derived the same key")
+	}
+}
+
+func TestAESEncryptorFromSecretRoundTrip(t *testing.T) {
+	e, err := NewAESEncryptorFromSecret(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := e.Encrypt("access-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ciphertext, DefaultKeyID+":") || !e.IsCurrent(ciphertext) {
+		t.Errorf("ciphertext %q isn't tagged with %q", ciphertext, DefaultKeyID)
+	}
+	if plaintext, err := e.Decrypt(ciphertext); err != nil || plaintext != "access-token" {
+		t.Errorf("Decrypt = %q, %v", plaintext, err)
+	}
+
+	if ciphertext, err := e.Encrypt(""); err != nil || ciphertext != "" {
+		t.Errorf("empty token encrypted to %q, %v; want it left empty", ciphertext, err)
+	}
+	if _, err := NewAESEncryptorFromSecret("short"); err != ErrWeakEncryptionKey {
+		t.Errorf("short secret: err = %v, want ErrWeakEncryptionKey", err)
+	}
+}
+
+func TestAESEncryptorDecryptsLegacyTokens(t *testing.T) {
+	// Tokens stored before key derivation were encrypted with the padded secret and no tag
+	legacy, err := NewAESEncryptor(EncryptionKeyFromString(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := legacy.Encrypt("old-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := NewAESEncryptorFromSecret(testSecret)
+	if plaintext, err := e.Decrypt(stored); err != nil || plaintext != "old-token" {
+		t.Errorf("legacy Decrypt = %q, %v", plaintext, err)
+	}
+	if e.IsCurrent(stored) {
+		t.Error("legacy token reported as current")
+	}
+
+	other, _ := NewAESEncryptorFromSecret(strings.Repeat("z", MinEncryptionKeyLength))
+	if _, err := other.Decrypt(stored); err == nil {
+		t.Error("legacy token decrypted with another secret")
+	}
+}
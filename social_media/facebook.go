@@ -3,9 +3,9 @@ package socialmedia
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -14,6 +14,7 @@ type FacebookProvider struct {
 	appID       string
 	appSecret   string
 	redirectURI string
+	scopes      string
 	httpClient  *http.Client
 }
 
@@ -23,6 +24,7 @@ func NewFacebookProvider(appID, appSecret, redirectURI string) *FacebookProvider
 		appID:       appID,
 		appSecret:   appSecret,
 		redirectURI: redirectURI,
+		scopes:      strings.Join(scopesFromEnv("FACEBOOK_SCOPES", defaultFacebookScopes), ","),
 		httpClient:  &http.Client{Timeout: 30 * time.Second},
 	}
 }
@@ -39,7 +41,7 @@ func (p *FacebookProvider) GetAuthorizationURL(state string) string {
 	params.Add("client_id", p.appID)
 	params.Add("redirect_uri", p.redirectURI)
 	params.Add("state", state)
-	params.Add("scope", "pages_show_list,pages_read_engagement,pages_manage_metadata")
+	params.Add("scope", p.scopes)
 
 	return fmt.Sprintf("%s?%s", baseURL, params.Encode())
 }
@@ -60,8 +62,7 @@ func (p *FacebookProvider) ExchangeCodeForToken(code string) (*TokenResponse, er
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token exchange failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformFacebook, "token exchange failed", resp)
 	}
 
 	var result struct {
@@ -109,8 +110,7 @@ func (p *FacebookProvider) getLongLivedToken(shortLivedToken string) (*struct {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("long-lived token exchange failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformFacebook, "long-lived token exchange failed", resp)
 	}
 
 	var result struct {
@@ -171,6 +171,27 @@ func (p *FacebookProvider) ValidateToken(accessToken string) (bool, error) {
 	return result.Data.IsValid && result.Data.ExpiresAt > time.Now().Unix(), nil
 }
 
+// RevokeToken removes the app's permissions from the user's Facebook account
+func (p *FacebookProvider) RevokeToken(accessToken string) error {
+	revokeURL := fmt.Sprintf("https://graph.facebook.com/v18.0/me/permissions?access_token=%s", accessToken)
+
+	req, err := http.NewRequest("DELETE", revokeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newProviderError(PlatformFacebook, "token revocation failed", resp)
+	}
+	return nil
+}
+
 // GetAccountInfo retrieves Facebook Page information
 func (p *FacebookProvider) GetAccountInfo(accessToken string) (*AccountInfo, error) {
 	// Get user's pages
@@ -183,8 +204,7 @@ func (p *FacebookProvider) GetAccountInfo(accessToken string) (*AccountInfo, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get pages: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformFacebook, "failed to get pages", resp)
 	}
 
 	var result struct {
@@ -213,22 +233,25 @@ func (p *FacebookProvider) GetAccountInfo(accessToken string) (*AccountInfo, err
 }
 
 // FetchReviews fetches reviews from Facebook Page
-func (p *FacebookProvider) FetchReviews(accessToken string, since time.Time) ([]*Review, error) {
-	// Get account info to get page ID
-	accountInfo, err := p.GetAccountInfo(accessToken)
-	if err != nil {
-		return nil, err
+func (p *FacebookProvider) FetchReviews(accessToken, pageID string, since time.Time) ([]*Review, error) {
+	// Use the page stored at connect time, falling back to the first page
+	if pageID == "" {
+		accountInfo, err := p.GetAccountInfo(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		pageID = accountInfo.AccountID
 	}
 
 	// Get page access token
-	pageToken, err := p.getPageAccessToken(accessToken, accountInfo.AccountID)
+	pageToken, err := p.getPageAccessToken(accessToken, pageID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fetch ratings and reviews
 	reviewsURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/ratings?fields=reviewer,created_time,rating,review_text,recommendation_type,open_graph_story&access_token=%s",
-		accountInfo.AccountID, pageToken)
+		pageID, pageToken)
 
 	// Add since parameter if provided
 	if !since.IsZero() {
@@ -242,8 +265,7 @@ func (p *FacebookProvider) FetchReviews(accessToken string, since time.Time) ([]
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch reviews: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformFacebook, "failed to fetch reviews", resp)
 	}
 
 	var result struct {
@@ -296,7 +318,7 @@ func (p *FacebookProvider) FetchReviews(accessToken string, since time.Time) ([]
 			Metadata: map[string]interface{}{
 				"reviewer_id":         fbReview.Reviewer.ID,
 				"recommendation_type": fbReview.RecommendationType,
-				"page_id":             accountInfo.AccountID,
+				"page_id":             pageID,
 			},
 		}
 
@@ -320,8 +342,7 @@ func (p *FacebookProvider) getPageAccessToken(userAccessToken, pageID string) (s
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to get page token: %s - %s", resp.Status, string(body))
+		return "", newProviderError(PlatformFacebook, "failed to get page token", resp)
 	}
 
 	var result struct {
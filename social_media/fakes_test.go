@@ -0,0 +1,356 @@
+package socialmedia
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// memDB is an in-memory SocialMediaDB for SyncService tests. Methods a test
+// doesn't need fall through to the embedded nil interface and panic.
+type memDB struct {
+	SocialMediaDB
+
+	mu          sync.Mutex
+	connections map[int]*APIConnection
+	reviews     map[int]*SyncedReview
+	syncLogs    []*SyncLog
+	refreshLogs []*TokenRefreshLog
+	dedup       bool // cross_platform_dedup for every merchant
+	writes      int  // calls that would change the database
+}
+
+func newMemDB(connections ...*APIConnection) *memDB {
+	db := &memDB{connections: map[int]*APIConnection{}, reviews: map[int]*SyncedReview{}}
+	for _, conn := range connections {
+		db.connections[conn.ID] = conn
+	}
+	return db
+}
+
+func (db *memDB) GetAPIConnection(id int) (*APIConnection, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	conn, ok := db.connections[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copy := *conn
+	return &copy, nil
+}
+
+func (db *memDB) GetActiveConnections() ([]*APIConnection, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var active []*APIConnection
+	for _, conn := range db.connections {
+		if conn.IsActive {
+			copy := *conn
+			active = append(active, &copy)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active, nil
+}
+
+func (db *memDB) UpdateAPIConnection(conn *APIConnection) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	copy := *conn
+	db.connections[conn.ID] = &copy
+	return nil
+}
+
+func (db *memDB) UpdateConnectionTokens(id int, accessToken, refreshToken string, expiresAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	if conn, ok := db.connections[id]; ok {
+		conn.AccessToken = accessToken
+		if refreshToken != "" {
+			conn.RefreshToken = refreshToken
+		}
+		conn.TokenExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (db *memDB) CreateSyncLog(log *SyncLog) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	log.ID = len(db.syncLogs) + 1
+	copy := *log
+	db.syncLogs = append(db.syncLogs, &copy)
+	return nil
+}
+
+func (db *memDB) UpdateSyncLog(log *SyncLog) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	copy := *log
+	db.syncLogs[log.ID-1] = &copy
+	return nil
+}
+
+func (db *memDB) GetSyncLog(id int) (*SyncLog, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if id < 1 || id > len(db.syncLogs) {
+		return nil, sql.ErrNoRows
+	}
+	copy := *db.syncLogs[id-1]
+	return &copy, nil
+}
+
+func (db *memDB) CreateTokenRefreshLog(log *TokenRefreshLog) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	copy := *log
+	db.refreshLogs = append(db.refreshLogs, &copy)
+	return nil
+}
+
+func (db *memDB) GetSyncedReviewByPlatformID(platform, platformReviewID string) (*SyncedReview, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, review := range db.reviews {
+		if review.Platform == platform && review.PlatformReviewID == platformReviewID {
+			copy := *review
+			return &copy, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (db *memDB) FindSyncedReviewByAuthorDay(merchantID int, platform, authorName string, reviewedAt time.Time) (*SyncedReview, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, review := range db.reviews {
+		if review.MerchantID == merchantID && review.Platform == platform &&
+			review.AuthorName == authorName && reviewDay(review.ReviewedAt).Equal(reviewDay(reviewedAt)) {
+			copy := *review
+			return &copy, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (db *memDB) CreateSyncedReview(review *SyncedReview) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	// Like the unique index, a review already stored under its platform id wins
+	for _, stored := range db.reviews {
+		if stored.Platform == review.Platform && stored.PlatformReviewID == review.PlatformReviewID {
+			return false, nil
+		}
+	}
+	review.ID = len(db.reviews) + 1
+	copy := *review
+	db.reviews[review.ID] = &copy
+	return true, nil
+}
+
+func (db *memDB) UpdateSyncedReview(review *SyncedReview) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	copy := *review
+	db.reviews[review.ID] = &copy
+	return nil
+}
+
+func (db *memDB) GetVisibleSyncedReviewsByMerchant(merchantID int, limit int) ([]*SyncedReview, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var visible []*SyncedReview
+	for _, review := range db.reviews {
+		if review.MerchantID == merchantID && review.IsVisible {
+			copy := *review
+			visible = append(visible, &copy)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].ID < visible[j].ID })
+	if len(visible) > limit {
+		visible = visible[:limit]
+	}
+	return visible, nil
+}
+
+func (db *memDB) HideSyncedReviews(ids []int) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.writes++
+	var hidden int64
+	for _, id := range ids {
+		if review, ok := db.reviews[id]; ok && review.IsVisible {
+			review.IsVisible = false
+			hidden++
+		}
+	}
+	return hidden, nil
+}
+
+func (db *memDB) GetCrossPlatformDedup(merchantID int) (bool, error) {
+	return db.dedup, nil
+}
+
+// fakeProvider is a SocialMediaProvider serving fixed reviews
+type fakeProvider struct {
+	platform string
+	reviews  []*Review
+	invalid  bool // ValidateToken reports the token as expired
+
+	mu         sync.Mutex
+	fetches    []string // account IDs FetchReviews was called with
+	refreshed  []string // refresh tokens RefreshToken was called with
+	refreshErr error
+	onRefresh  func() // runs while RefreshToken is in flight
+}
+
+func (p *fakeProvider) GetAuthorizationURL(state string) string {
+	return "https://example.com/auth?state=" + state
+}
+func (p *fakeProvider) ExchangeCodeForToken(code string) (*TokenResponse, error) {
+	return &TokenResponse{AccessToken: "access-" + code, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+func (p *fakeProvider) GetAccountInfo(accessToken string) (*AccountInfo, error) {
+	return &AccountInfo{AccountID: "accounts/1", AccountName: "Cafe"}, nil
+}
+func (p *fakeProvider) GetPlatformName() string                        { return p.platform }
+func (p *fakeProvider) ValidateToken(accessToken string) (bool, error) { return !p.invalid, nil }
+func (p *fakeProvider) RevokeToken(accessToken string) error           { return nil }
+
+func (p *fakeProvider) RefreshToken(refreshToken string) (*TokenResponse, error) {
+	if p.onRefresh != nil {
+		p.onRefresh()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshed = append(p.refreshed, refreshToken)
+	if p.refreshErr != nil {
+		return nil, p.refreshErr
+	}
+	return &TokenResponse{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresAt: time.Now().Add(60 * 24 * time.Hour)}, nil
+}
+
+func (p *fakeProvider) FetchReviews(accessToken, accountID string, since time.Time) ([]*Review, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches = append(p.fetches, accountID)
+	return p.reviews, nil
+}
+
+// plainEncryptor stores tokens as they are
+type plainEncryptor struct{}
+
+func (plainEncryptor) Encrypt(plaintext string) (string, error)  { return plaintext, nil }
+func (plainEncryptor) Decrypt(ciphertext string) (string, error) { return ciphertext, nil }
+
+// newTestSyncService returns a SyncService over db with provider registered
+func newTestSyncService(db SocialMediaDB, provider SocialMediaProvider) *SyncService {
+	s := NewSyncService(db, plainEncryptor{})
+	s.RegisterProvider(provider)
+	return s
+}
+
+// testAPIConnection is an active Google connection of merchant 7 that has never synced
+func testAPIConnection(id int) *APIConnection {
+	return &APIConnection{
+		ID:                id,
+		MerchantID:        7,
+		Platform:          PlatformGoogleBusiness,
+		PlatformAccountID: "accounts/1",
+		AccessToken:       "access",
+		RefreshToken:      "refresh",
+		TokenExpiresAt:    time.Now().Add(time.Hour),
+		IsActive:          true,
+		SyncStatus:        SyncStatusPending,
+	}
+}
+
+// mockPlatformAPI serves handler for every request a provider makes through
+// the returned client, whatever host the provider addresses. Handlers see the
+// original host in r.Host and the original path in r.URL.Path.
+func mockPlatformAPI(t *testing.T, handler http.HandlerFunc) *http.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Host = req.URL.Host
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// syncedReviewTable holds reviews as synced_reviews rows for a DB from
+// tableDB. Rows keep the order given, which stands in for ORDER BY.
+func syncedReviewTable(reviews ...*SyncedReview) *fakedb.Table {
+	table := &fakedb.Table{
+		Conditions: map[string]func(fakedb.Row, []driver.Value) bool{
+			textlessCondition: func(row fakedb.Row, _ []driver.Value) bool {
+				return strings.TrimSpace(row["review_text"].(string)) != ""
+			},
+		},
+	}
+	for _, col := range strings.Split(syncedReviewColumns, ",") {
+		table.Columns = append(table.Columns, strings.TrimSpace(col))
+	}
+	for _, r := range reviews {
+		var rating driver.Value
+		if r.Rating != nil {
+			rating = *r.Rating
+		}
+		table.Rows = append(table.Rows, []driver.Value{
+			int64(r.ID), int64(r.MerchantID), nil, r.Platform, r.PlatformReviewID,
+			r.AuthorName, "", rating, r.ReviewText, "",
+			r.ReviewedAt, r.SyncedAt, r.IsVisible, []byte("{}"), r.CreatedAt, r.UpdatedAt,
+			nil, nil,
+		})
+	}
+	return table
+}
+
+// tableDB returns a DB that answers every query from table
+func tableDB(t *testing.T, table *fakedb.Table) *DB {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return table.Count(query, args)
+		}
+		rows, err := table.Match(query, args)
+		return &fakedb.Result{Columns: table.Columns, Rows: rows}, err
+	})
+	t.Cleanup(func() { conn.Close() })
+	return NewDB(conn)
+}
+
+// reviewIDs lists the IDs of reviews, for comparing results
+func reviewIDs(reviews []*SyncedReview) string {
+	ids := make([]int, len(reviews))
+	for i, r := range reviews {
+		ids[i] = r.ID
+	}
+	return fmt.Sprint(ids)
+}
@@ -0,0 +1,49 @@
+package socialmedia
+
+import "time"
+
+// staleAfterIntervals is how many sync intervals an active connection may go
+// without syncing before it is flagged as stale
+const staleAfterIntervals = 2
+
+// FailingConnection is an active connection whose most recent syncs all failed
+type FailingConnection struct {
+	ConnectionID        int        `json:"connection_id"`
+	MerchantID          int        `json:"merchant_id"`
+	BusinessName        string     `json:"business_name"`
+	Platform            string     `json:"platform"`
+	PlatformAccountName string     `json:"platform_account_name"`
+	ErrorMessage        string     `json:"error_message,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"` // Failed syncs since the last completed one
+	LastFailedAt        time.Time  `json:"last_failed_at"`
+	LastSyncAt          *time.Time `json:"last_sync_at"`
+}
+
+// MarkStale sets IsStale on active connections that haven't synced in
+// staleAfterIntervals sync intervals. A connection that never synced counts
+// from when it was created. A non-positive interval flags nothing.
+func MarkStale(connections []*APIConnection, interval time.Duration, now time.Time) {
+	if interval <= 0 {
+		return
+	}
+	cutoff := now.Add(-staleAfterIntervals * interval)
+
+	for _, conn := range connections {
+		lastSync := conn.CreatedAt
+		if conn.LastSyncAt != nil {
+			lastSync = *conn.LastSyncAt
+		}
+		conn.IsStale = conn.IsActive && lastSync.Before(cutoff)
+	}
+}
+
+// FailureAlertThreshold is how many consecutive failed syncs put a connection
+// on the admin alert list (SYNC_FAILURE_ALERT_THRESHOLD)
+func (s *SyncService) FailureAlertThreshold() int {
+	return s.failureAlertThreshold
+}
+
+// Interval returns how often scheduled syncs run
+func (s *Scheduler) Interval() time.Duration {
+	return s.interval
+}
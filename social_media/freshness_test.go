@@ -0,0 +1,71 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+func TestMarkStale(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(hoursAgo int) *time.Time {
+		t := now.Add(-time.Duration(hoursAgo) * time.Hour)
+		return &t
+	}
+
+	recent := &APIConnection{IsActive: true, LastSyncAt: at(11)}
+	old := &APIConnection{IsActive: true, LastSyncAt: at(13)}
+	inactive := &APIConnection{IsActive: false, LastSyncAt: at(48)}
+	neverSyncedNew := &APIConnection{IsActive: true, CreatedAt: *at(1)}
+	neverSyncedOld := &APIConnection{IsActive: true, CreatedAt: *at(24)}
+	connections := []*APIConnection{recent, old, inactive, neverSyncedNew, neverSyncedOld}
+
+	MarkStale(connections, 6*time.Hour, now)
+	for i, want := range []bool{false, true, false, false, true} {
+		if connections[i].IsStale != want {
+			t.Errorf("connection %d: stale = %v, want %v", i, connections[i].IsStale, want)
+		}
+	}
+
+	old.IsStale = false
+	MarkStale(connections, 0, now)
+	if old.IsStale {
+		t.Error("flagged stale without a sync interval")
+	}
+}
+
+func TestFailureAlertThreshold(t *testing.T) {
+	for env, want := range map[string]int{"": 3, "5": 5, "0": 3, "many": 3} {
+		t.Setenv("SYNC_FAILURE_ALERT_THRESHOLD", env)
+		if got := NewSyncService(newMemDB(), plainEncryptor{}).FailureAlertThreshold(); got != want {
+			t.Errorf("SYNC_FAILURE_ALERT_THRESHOLD=%q: threshold %d, want %d", env, got, want)
+		}
+	}
+}
+
+func TestGetFailingConnections(t *testing.T) {
+	failedAt := time.Now()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		if !strings.Contains(query, "f.failures >= $1") || args[0] != int64(3) {
+			t.Fatalf("unexpected query %s with %v", query, args)
+		}
+		return &fakedb.Result{Columns: make([]string, 9), Rows: [][]driver.Value{
+			{int64(4), int64(7), "Kopi Corner", PlatformFacebook, "Kopi Page", "token expired", int64(5), failedAt, nil},
+		}}, nil
+	})
+	defer conn.Close()
+
+	failing, err := NewDB(conn).GetFailingConnections(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failing) != 1 {
+		t.Fatalf("got %d failing connections, want 1", len(failing))
+	}
+	if fc := failing[0]; fc.ConnectionID != 4 || fc.ConsecutiveFailures != 5 || fc.BusinessName != "Kopi Corner" || fc.LastSyncAt != nil {
+		t.Errorf("failing connection = %+v", fc)
+	}
+}
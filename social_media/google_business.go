@@ -3,7 +3,6 @@ package socialmedia
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -15,6 +14,7 @@ type GoogleBusinessProvider struct {
 	clientID     string
 	clientSecret string
 	redirectURI  string
+	scopes       string
 	httpClient   *http.Client
 }
 
@@ -24,6 +24,7 @@ func NewGoogleBusinessProvider(clientID, clientSecret, redirectURI string) *Goog
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		redirectURI:  redirectURI,
+		scopes:       strings.Join(scopesFromEnv("GOOGLE_BUSINESS_SCOPES", defaultGoogleBusinessScopes), " "),
 		httpClient:   &http.Client{Timeout: 30 * time.Second},
 	}
 }
@@ -40,7 +41,7 @@ func (p *GoogleBusinessProvider) GetAuthorizationURL(state string) string {
 	params.Add("client_id", p.clientID)
 	params.Add("redirect_uri", p.redirectURI)
 	params.Add("response_type", "code")
-	params.Add("scope", "https://www.googleapis.com/auth/business.manage")
+	params.Add("scope", p.scopes)
 	params.Add("access_type", "offline")
 	params.Add("prompt", "consent")
 	params.Add("state", state)
@@ -70,8 +71,7 @@ func (p *GoogleBusinessProvider) ExchangeCodeForToken(code string) (*TokenRespon
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token exchange failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformGoogleBusiness, "token exchange failed", resp)
 	}
 
 	var result struct {
@@ -115,8 +115,7 @@ func (p *GoogleBusinessProvider) RefreshToken(refreshToken string) (*TokenRespon
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token refresh failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformGoogleBusiness, "token refresh failed", resp)
 	}
 
 	var result struct {
@@ -157,6 +156,29 @@ func (p *GoogleBusinessProvider) ValidateToken(accessToken string) (bool, error)
 	return resp.StatusCode == http.StatusOK, nil
 }
 
+// RevokeToken revokes the grant through Google's OAuth revoke endpoint
+func (p *GoogleBusinessProvider) RevokeToken(accessToken string) error {
+	data := url.Values{}
+	data.Set("token", accessToken)
+
+	req, err := http.NewRequest("POST", "https://oauth2.googleapis.com/revoke", strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newProviderError(PlatformGoogleBusiness, "token revocation failed", resp)
+	}
+	return nil
+}
+
 // GetAccountInfo retrieves account information
 func (p *GoogleBusinessProvider) GetAccountInfo(accessToken string) (*AccountInfo, error) {
 	// First, get the list of accounts
@@ -173,8 +195,7 @@ func (p *GoogleBusinessProvider) GetAccountInfo(accessToken string) (*AccountInf
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get accounts: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformGoogleBusiness, "failed to get accounts", resp)
 	}
 
 	var result struct {
@@ -207,15 +228,19 @@ func (p *GoogleBusinessProvider) GetAccountInfo(accessToken string) (*AccountInf
 }
 
 // FetchReviews fetches reviews from Google Business Profile
-func (p *GoogleBusinessProvider) FetchReviews(accessToken string, since time.Time) ([]*Review, error) {
-	// First get the account
-	accountInfo, err := p.GetAccountInfo(accessToken)
-	if err != nil {
-		return nil, err
+func (p *GoogleBusinessProvider) FetchReviews(accessToken, accountID string, since time.Time) ([]*Review, error) {
+	// Use the account chosen at connect time; re-picking would return whichever
+	// account Google lists first when the user has several
+	if accountID == "" {
+		accountInfo, err := p.GetAccountInfo(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		accountID = accountInfo.AccountID
 	}
 
 	// Get list of locations for this account
-	locationsURL := fmt.Sprintf("https://mybusinessbusinessinformation.googleapis.com/v1/accounts/%s/locations", accountInfo.AccountID)
+	locationsURL := fmt.Sprintf("https://mybusinessbusinessinformation.googleapis.com/v1/%s/locations", googleAccountResourceName(accountID))
 	req, err := http.NewRequest("GET", locationsURL, nil)
 	if err != nil {
 		return nil, err
@@ -229,8 +254,7 @@ func (p *GoogleBusinessProvider) FetchReviews(accessToken string, since time.Tim
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get locations: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformGoogleBusiness, "failed to get locations", resp)
 	}
 
 	var locationsResult struct {
@@ -325,6 +349,14 @@ func (p *GoogleBusinessProvider) FetchReviews(accessToken string, since time.Tim
 	return allReviews, nil
 }
 
+// googleAccountResourceName returns the "accounts/{id}" resource name for a stored account ID
+func googleAccountResourceName(accountID string) string {
+	if strings.HasPrefix(accountID, "accounts/") {
+		return accountID
+	}
+	return "accounts/" + accountID
+}
+
 // convertStarRating converts Google's star rating string to numeric value
 func (p *GoogleBusinessProvider) convertStarRating(starRating string) float64 {
 	switch starRating {
@@ -0,0 +1,50 @@
+package socialmedia
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGoogleFetchReviewsUsesStoredAccount(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	p := NewGoogleBusinessProvider("id", "secret", "https://example.com/callback")
+	p.httpClient = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/accounts":
+			fmt.Fprint(w, `{"accounts": [{"name": "accounts/1", "accountName": "First"}]}`)
+		case "/v1/accounts/2/locations":
+			fmt.Fprint(w, `{"locations": [{"name": "accounts/2/locations/9"}]}`)
+		case "/v4/accounts/2/locations/9/reviews":
+			fmt.Fprint(w, `{"reviews": [{"reviewId": "r1", "starRating": "FOUR", "createTime": "2026-10-01T12:00:00Z"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	for _, accountID := range []string{"accounts/2", "2"} {
+		reviews, err := p.FetchReviews("token", accountID, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		requested := paths
+		paths = nil
+		mu.Unlock()
+
+		if len(reviews) != 1 || *reviews[0].Rating != 4 {
+			t.Errorf("account %q: got %d reviews, want the stored account's one 4-star review", accountID, len(reviews))
+		}
+		for _, path := range requested {
+			if path == "/v1/accounts" {
+				t.Errorf("account %q: listed accounts instead of using the stored one", accountID)
+			}
+		}
+	}
+}
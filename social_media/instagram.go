@@ -3,9 +3,9 @@ package socialmedia
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -15,6 +15,7 @@ type InstagramProvider struct {
 	appID       string
 	appSecret   string
 	redirectURI string
+	scopes      string
 	httpClient  *http.Client
 }
 
@@ -25,6 +26,7 @@ func NewInstagramProvider(appID, appSecret, redirectURI string) *InstagramProvid
 		appSecret:   appSecret,
 		redirectURI: redirectURI,
 		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		scopes:      strings.Join(scopesFromEnv("INSTAGRAM_SCOPES", defaultInstagramScopes), ","),
 	}
 }
 
@@ -41,7 +43,7 @@ func (p *InstagramProvider) GetAuthorizationURL(state string) string {
 	params.Add("redirect_uri", p.redirectURI)
 	params.Add("state", state)
 	// Request Instagram-specific permissions
-	params.Add("scope", "instagram_basic,instagram_manage_comments,instagram_manage_insights,pages_show_list")
+	params.Add("scope", p.scopes)
 
 	return fmt.Sprintf("%s?%s", baseURL, params.Encode())
 }
@@ -62,8 +64,7 @@ func (p *InstagramProvider) ExchangeCodeForToken(code string) (*TokenResponse, e
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("token exchange failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformInstagram, "token exchange failed", resp)
 	}
 
 	var result struct {
@@ -110,8 +111,7 @@ func (p *InstagramProvider) getLongLivedToken(shortLivedToken string) (*struct {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("long-lived token exchange failed: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformInstagram, "long-lived token exchange failed", resp)
 	}
 
 	var result struct {
@@ -171,6 +171,27 @@ func (p *InstagramProvider) ValidateToken(accessToken string) (bool, error) {
 	return result.Data.IsValid && result.Data.ExpiresAt > time.Now().Unix(), nil
 }
 
+// RevokeToken removes the app's permissions from the user's Facebook account
+func (p *InstagramProvider) RevokeToken(accessToken string) error {
+	revokeURL := fmt.Sprintf("https://graph.facebook.com/v18.0/me/permissions?access_token=%s", accessToken)
+
+	req, err := http.NewRequest("DELETE", revokeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newProviderError(PlatformInstagram, "token revocation failed", resp)
+	}
+	return nil
+}
+
 // GetAccountInfo retrieves Instagram Business Account information
 func (p *InstagramProvider) GetAccountInfo(accessToken string) (*AccountInfo, error) {
 	// Get user's pages first
@@ -183,8 +204,7 @@ func (p *InstagramProvider) GetAccountInfo(accessToken string) (*AccountInfo, er
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get pages: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformInstagram, "failed to get pages", resp)
 	}
 
 	var pagesResult struct {
@@ -217,8 +237,7 @@ func (p *InstagramProvider) GetAccountInfo(accessToken string) (*AccountInfo, er
 	defer resp2.Body.Close()
 
 	if resp2.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp2.Body)
-		return nil, fmt.Errorf("failed to get Instagram account: %s - %s", resp2.Status, string(body))
+		return nil, newProviderError(PlatformInstagram, "failed to get Instagram account", resp2)
 	}
 
 	var igResult struct {
@@ -246,7 +265,7 @@ func (p *InstagramProvider) GetAccountInfo(accessToken string) (*AccountInfo, er
 	defer resp3.Body.Close()
 
 	if resp3.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get Instagram details")
+		return nil, newProviderError(PlatformInstagram, "failed to get Instagram details", resp3)
 	}
 
 	var detailsResult struct {
@@ -267,11 +286,14 @@ func (p *InstagramProvider) GetAccountInfo(accessToken string) (*AccountInfo, er
 
 // FetchReviews fetches mentions and comments from Instagram
 // Note: Instagram doesn't have a traditional review system, so we fetch mentions and comments
-func (p *InstagramProvider) FetchReviews(accessToken string, since time.Time) ([]*Review, error) {
-	// Get account info
-	accountInfo, err := p.GetAccountInfo(accessToken)
-	if err != nil {
-		return nil, err
+func (p *InstagramProvider) FetchReviews(accessToken, accountID string, since time.Time) ([]*Review, error) {
+	// Use the Instagram account stored at connect time, falling back to the first one
+	if accountID == "" {
+		accountInfo, err := p.GetAccountInfo(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		accountID = accountInfo.AccountID
 	}
 
 	// Get page access token
@@ -284,7 +306,7 @@ func (p *InstagramProvider) FetchReviews(accessToken string, since time.Time) ([
 
 	// Fetch media (posts) with comments
 	mediaURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/media?fields=id,caption,timestamp,comments_count,like_count&access_token=%s",
-		accountInfo.AccountID, pageToken)
+		accountID, pageToken)
 
 	if !since.IsZero() {
 		mediaURL += fmt.Sprintf("&since=%d", since.Unix())
@@ -297,8 +319,7 @@ func (p *InstagramProvider) FetchReviews(accessToken string, since time.Time) ([
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch media: %s - %s", resp.Status, string(body))
+		return nil, newProviderError(PlatformInstagram, "failed to fetch media", resp)
 	}
 
 	var mediaResult struct {
@@ -384,8 +405,7 @@ func (p *InstagramProvider) getPageAccessToken(userAccessToken string) (string,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to get page token: %s - %s", resp.Status, string(body))
+		return "", newProviderError(PlatformInstagram, "failed to get page token", resp)
 	}
 
 	var result struct {
@@ -0,0 +1,179 @@
+package socialmedia
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrJobQueueClosed is returned by Submit once Shutdown has been called
+	ErrJobQueueClosed = errors.New("job queue is closed")
+	// ErrJobQueueFull is returned by Submit when every buffer slot is taken
+	ErrJobQueueFull = errors.New("job queue is full")
+)
+
+// Job is a unit of follow-up work run by a JobQueue worker
+type Job interface {
+	// Name identifies the job in logs
+	Name() string
+	Run()
+}
+
+// NotifyNegativeReviewsJob emails a merchant about the negative reviews one sync added
+type NotifyNegativeReviewsJob struct {
+	service *SyncService
+	Conn    *APIConnection
+	Reviews []*SyncedReview
+}
+
+// Name implements Job
+func (j *NotifyNegativeReviewsJob) Name() string { return "notify_negative_reviews" }
+
+// Run implements Job
+func (j *NotifyNegativeReviewsJob) Run() { j.service.notifyNegativeReviews(j.Conn, j.Reviews) }
+
+// CrossPlatformDedupJob runs the cross-platform dedup pass for a merchant after a sync
+type CrossPlatformDedupJob struct {
+	service    *SyncService
+	MerchantID int
+}
+
+// Name implements Job
+func (j *CrossPlatformDedupJob) Name() string { return "cross_platform_dedup" }
+
+// Run implements Job
+func (j *CrossPlatformDedupJob) Run() { j.service.dedupAfterSync(j.MerchantID) }
+
+// JobQueue runs submitted jobs on a fixed pool of worker goroutines, so a sync
+// can hand off its follow-up work and return
+type JobQueue struct {
+	jobs    chan Job
+	workers int
+	wg      sync.WaitGroup
+
+	// mu guards closed so Submit never sends on a closed channel
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewJobQueue creates a queue buffering up to size jobs for the given number of workers
+func NewJobQueue(size, workers int) *JobQueue {
+	if size < 1 {
+		size = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &JobQueue{
+		jobs:    make(chan Job, size),
+		workers: workers,
+	}
+}
+
+// JobQueueFromEnv creates a queue sized from JOB_QUEUE_SIZE (default 100) and
+// JOB_QUEUE_WORKERS (default 2)
+func JobQueueFromEnv() *JobQueue {
+	size := 100
+	if envSize := os.Getenv("JOB_QUEUE_SIZE"); envSize != "" {
+		if parsed, err := strconv.Atoi(envSize); err == nil && parsed > 0 {
+			size = parsed
+		}
+	}
+
+	workers := 2
+	if envWorkers := os.Getenv("JOB_QUEUE_WORKERS"); envWorkers != "" {
+		if parsed, err := strconv.Atoi(envWorkers); err == nil && parsed > 0 {
+			workers = parsed
+		}
+	}
+
+	return NewJobQueue(size, workers)
+}
+
+// Start launches the worker goroutines
+func (q *JobQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for job := range q.jobs {
+				q.run(job)
+			}
+		}()
+	}
+	log.Printf("[Jobs] Started %d workers (queue size %d)", q.workers, cap(q.jobs))
+}
+
+// run executes one job, keeping a panicking job from taking its worker down
+func (q *JobQueue) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Jobs] %s panicked: %v", job.Name(), r)
+		}
+	}()
+	job.Run()
+}
+
+// Submit queues a job without blocking. It fails when the queue is full or
+// shut down; callers can then run the job themselves.
+func (q *JobQueue) Submit(job Job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrJobQueueClosed
+	}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrJobQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs and waits for the workers to finish the ones
+// already queued, or for ctx to be done, whichever comes first
+func (q *JobQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetJobQueue makes syncs hand their follow-up work (cross-platform dedup and
+// negative review alerts) to the queue instead of running it before returning
+func (s *SyncService) SetJobQueue(jobs *JobQueue) {
+	s.jobs = jobs
+}
+
+// submitJob queues a sync's follow-up job, running it inline when there is no
+// queue or it can't take the job
+func (s *SyncService) submitJob(job Job) {
+	if s.jobs == nil {
+		job.Run()
+		return
+	}
+	if err := s.jobs.Submit(job); err != nil {
+		log.Printf("[Jobs] Running %s inline: %v", job.Name(), err)
+		job.Run()
+	}
+}
@@ -0,0 +1,131 @@
+package socialmedia
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// funcJob is a Job that runs fn
+type funcJob struct {
+	fn func()
+}
+
+func (j funcJob) Name() string { return "func" }
+func (j funcJob) Run()         { j.fn() }
+
+func TestJobQueueProcessesSubmittedJobs(t *testing.T) {
+	q := NewJobQueue(50, 3)
+	q.Start()
+
+	var ran atomic.Int32
+	for i := 0; i < 50; i++ {
+		if err := q.Submit(funcJob{func() { ran.Add(1) }}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+
+	// Shutdown drains what was queued before returning
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := ran.Load(); got != 50 {
+		t.Errorf("ran %d jobs, want 50", got)
+	}
+}
+
+func TestJobQueueSubmitErrors(t *testing.T) {
+	// Not started, so the one buffer slot stays taken
+	q := NewJobQueue(1, 1)
+	if err := q.Submit(funcJob{func() {}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Submit(funcJob{func() {}}); err != ErrJobQueueFull {
+		t.Errorf("full queue: err = %v, want ErrJobQueueFull", err)
+	}
+
+	q.Start()
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Submit(funcJob{func() {}}); err != ErrJobQueueClosed {
+		t.Errorf("after Shutdown: err = %v, want ErrJobQueueClosed", err)
+	}
+}
+
+func TestJobQueueSurvivesPanickingJob(t *testing.T) {
+	q := NewJobQueue(2, 1)
+	q.Start()
+
+	ran := false
+	q.Submit(funcJob{func() { panic("boom") }})
+	q.Submit(funcJob{func() { ran = true }})
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("job after a panicking one didn't run")
+	}
+}
+
+func TestJobQueueShutdownTimesOut(t *testing.T) {
+	q := NewJobQueue(1, 1)
+	q.Start()
+
+	release := make(chan struct{})
+	defer close(release)
+	q.Submit(funcJob{func() { <-release }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSubmitJobRunsInlineWithoutQueue(t *testing.T) {
+	closed := NewJobQueue(1, 1)
+	closed.Start()
+	closed.Shutdown(context.Background())
+
+	for name, jobs := range map[string]*JobQueue{"no queue": nil, "closed queue": closed} {
+		s := &SyncService{jobs: jobs}
+		ran := false
+		s.submitJob(funcJob{func() { ran = true }})
+		if !ran {
+			t.Errorf("%s: job didn't run inline", name)
+		}
+	}
+}
+
+func TestWaitForBackgroundSyncs(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		// Hold the sync at its first query until the test lets it go
+		<-release
+		return nil, errors.New("connection not found")
+	})
+	defer conn.Close()
+	defer once.Do(func() { close(release) })
+
+	s := &SyncService{db: NewDB(conn)}
+	s.SyncInBackground(1, SyncTypeManual)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.WaitForBackgroundSyncs(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("running sync: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	once.Do(func() { close(release) })
+	if err := s.WaitForBackgroundSyncs(context.Background()); err != nil {
+		t.Errorf("finished sync: err = %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package socialmedia
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const rotatedSecret = "fedcba9876543210fedcba9876543210"
+
+func TestVersionedAESEncryptorRotation(t *testing.T) {
+	before, _ := NewAESEncryptorFromSecret(testSecret)
+	oldToken, _ := before.Encrypt("old-token")
+
+	after, err := NewVersionedAESEncryptor("v3", rotatedSecret, map[string]string{DefaultKeyID: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := after.Decrypt(oldToken); err != nil || plaintext != "old-token" {
+		t.Errorf("token from the retired key: Decrypt = %q, %v", plaintext, err)
+	}
+	if after.IsCurrent(oldToken) {
+		t.Error("token from the retired key reported as current")
+	}
+
+	newToken, _ := after.Encrypt("new-token")
+	if !strings.HasPrefix(newToken, "v3:") || !after.IsCurrent(newToken) {
+		t.Errorf("new ciphertext %q isn't tagged with the primary key id", newToken)
+	}
+	if _, err := before.Decrypt(newToken); err != ErrUnknownKeyID {
+		t.Errorf("old encryptor on a v3 token: err = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestNewVersionedAESEncryptorRejects(t *testing.T) {
+	tests := map[string]struct {
+		keyID   string
+		retired map[string]string
+	}{
+		"key id with a colon":    {"v:3", nil},
+		"empty key id":           {"", nil},
+		"retired id reused":      {"v3", map[string]string{"v3": testSecret}},
+		"empty retired secret":   {"v3", map[string]string{"v2": ""}},
+		"invalid retired key id": {"v3", map[string]string{"v-2": testSecret}},
+	}
+	for name, tt := range tests {
+		if _, err := NewVersionedAESEncryptor(tt.keyID, rotatedSecret, tt.retired); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestVersionedAESEncryptorShortLegacySecret(t *testing.T) {
+	// Untagged tokens from before derivation used the padded secret, however short
+	legacy, _ := NewAESEncryptor(EncryptionKeyFromString("short"))
+	oldToken, _ := legacy.Encrypt("old-token")
+
+	e, err := NewVersionedAESEncryptor("v3", rotatedSecret, map[string]string{"v1": "short"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := e.Decrypt(oldToken); err != nil || plaintext != "old-token" {
+		t.Errorf("legacy token: Decrypt = %q, %v", plaintext, err)
+	}
+	if _, err := e.Decrypt("v1:" + oldToken); err != ErrUnknownKeyID {
+		t.Errorf("token tagged with the short key: err = %v, want ErrUnknownKeyID", err)
+	}
+	if _, err := NewVersionedAESEncryptor("v3", "short", nil); err != ErrWeakEncryptionKey {
+		t.Errorf("short primary secret: err = %v, want ErrWeakEncryptionKey", err)
+	}
+}
+
+func TestParseRetiredKeys(t *testing.T) {
+	keys, err := ParseRetiredKeys(" v1:first-secret , v2:second:secret,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys["v1"] != "first-secret" || keys["v2"] != "second:secret" {
+		t.Errorf("keys = %v", keys)
+	}
+	for _, bad := range []string{"v1", "v1:", ":secret"} {
+		if _, err := ParseRetiredKeys(bad); err == nil {
+			t.Errorf("ParseRetiredKeys(%q) accepted", bad)
+		}
+	}
+}
+
+// rotationDB is a memDB that lists every connection and replaces tokens only
+// when the stored ones still match, like the real compare-and-swap update
+type rotationDB struct {
+	*memDB
+}
+
+func (db *rotationDB) GetAllAPIConnections() ([]*APIConnection, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var all []*APIConnection
+	for _, conn := range db.connections {
+		copy := *conn
+		all = append(all, &copy)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all, nil
+}
+
+func (db *rotationDB) ReplaceConnectionTokens(id int, oldAccess, newAccess, oldRefresh, newRefresh string) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	conn := db.connections[id]
+	if conn.AccessToken != oldAccess || conn.RefreshToken != oldRefresh {
+		return false, nil
+	}
+	conn.AccessToken, conn.RefreshToken = newAccess, newRefresh
+	return true, nil
+}
+
+func TestReencryptTokens(t *testing.T) {
+	before, _ := NewAESEncryptorFromSecret(testSecret)
+	after, _ := NewVersionedAESEncryptor("v3", rotatedSecret, map[string]string{DefaultKeyID: testSecret})
+
+	old := testAPIConnection(1)
+	old.AccessToken, _ = before.Encrypt("access-1")
+	old.RefreshToken, _ = before.Encrypt("refresh-1")
+	current := testAPIConnection(2)
+	current.AccessToken, _ = after.Encrypt("access-2")
+	current.RefreshToken = ""
+	broken := testAPIConnection(3)
+	broken.AccessToken = "v9:unknown"
+
+	db := &rotationDB{newMemDB(old, current, broken)}
+	s := NewSyncService(db, after)
+	currentAccess := current.AccessToken
+
+	result, err := s.ReencryptTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result != (ReencryptResult{Connections: 3, Reencrypted: 1, Failed: 1}) {
+		t.Errorf("result = %+v, want 3 checked, 1 re-encrypted, 1 failed", *result)
+	}
+
+	conn := db.connections[1]
+	if !after.IsCurrent(conn.AccessToken) || !after.IsCurrent(conn.RefreshToken) {
+		t.Errorf("connection 1 tokens %q, %q aren't on the new key", conn.AccessToken, conn.RefreshToken)
+	}
+	if access, _ := after.Decrypt(conn.AccessToken); access != "access-1" {
+		t.Errorf("connection 1 access token decrypts to %q", access)
+	}
+	if db.connections[2].AccessToken != currentAccess {
+		t.Error("connection already on the primary key was rewritten")
+	}
+
+	// A second run has nothing left to do but the broken row
+	if result, _ := s.ReencryptTokens(); result.Reencrypted != 0 || result.Failed != 1 {
+		t.Errorf("second run = %+v, want nothing re-encrypted", *result)
+	}
+}
@@ -12,6 +12,7 @@ type APIConnection struct {
 	Platform            string    `json:"platform"` // 'google_business', 'facebook', 'instagram'
 	PlatformAccountID   string    `json:"platform_account_id"`
 	PlatformAccountName string    `json:"platform_account_name"`
+	AccountAvatarURL    string    `json:"account_avatar_url,omitempty"`
 	AccessToken         string    `json:"-"` // Don't serialize to JSON
 	RefreshToken        string    `json:"-"` // Don't serialize to JSON
 	TokenExpiresAt      time.Time `json:"token_expires_at"`
@@ -19,10 +20,44 @@ type APIConnection struct {
 	LastSyncAt          *time.Time `json:"last_sync_at"`
 	SyncStatus          string    `json:"sync_status"` // 'pending', 'syncing', 'completed', 'failed'
 	ErrorMessage        string    `json:"error_message,omitempty"`
+	AdminNotes          string    `json:"-"` // Admin-only, exposed via AdminAPIConnection
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
+
+	// Computed, not stored: the connection hasn't synced for longer than expected
+	IsStale bool `json:"is_stale"`
+}
+
+// AdminAPIConnection is the admin view of a connection, including internal notes
+type AdminAPIConnection struct {
+	*APIConnection
+	AdminNotes   string `json:"admin_notes"`
+	BusinessName string `json:"business_name,omitempty"` // Joined from merchants
+	OwnerEmail   string `json:"owner_email,omitempty"`   // Joined from auth.users
+}
+
+// ConnectionFilter narrows the admin connection list; empty fields match everything
+type ConnectionFilter struct {
+	Platform   string
+	SyncStatus string
+}
+
+// ReviewFilter narrows a merchant's synced review list; empty fields match everything.
+// A rating bound leaves out reviews without a rating (comments).
+type ReviewFilter struct {
+	Platform  string
+	MinRating *float64
+	MaxRating *float64
 }
 
+// NewAdminAPIConnection wraps a connection for admin responses
+func NewAdminAPIConnection(conn *APIConnection) *AdminAPIConnection {
+	return &AdminAPIConnection{APIConnection: conn, AdminNotes: conn.AdminNotes}
+}
+
+// MaxAdminNotesLength is the maximum number of characters allowed in admin notes
+const MaxAdminNotesLength = 2000
+
 // SyncedReview represents a review synced from a social media platform
 type SyncedReview struct {
 	ID               int            `json:"id"`
@@ -39,8 +74,33 @@ type SyncedReview struct {
 	SyncedAt         time.Time      `json:"synced_at"`
 	IsVisible        bool           `json:"is_visible"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	Sentiment        string         `json:"sentiment,omitempty"`       // positive, neutral or negative; empty if unclassified
+	SentimentScore   *float64       `json:"sentiment_score,omitempty"` // -1 to 1
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
+
+	// Computed, not stored: whether the review passes every public filter
+	PubliclyVisible bool   `json:"publicly_visible"`
+	HiddenReason    string `json:"hidden_reason,omitempty"`
+	// Computed for public lists: render as a compact rating with no text card
+	RatingOnly bool `json:"rating_only,omitempty"`
+}
+
+// SyncedReviewRevision is what a synced review said before a sync found it edited
+type SyncedReviewRevision struct {
+	ID             int       `json:"id"`
+	SyncedReviewID int       `json:"synced_review_id"`
+	Rating         *float64  `json:"rating"`
+	ReviewText     string    `json:"review_text"`
+	ReviewReply    string    `json:"review_reply,omitempty"`
+	CreatedAt      time.Time `json:"created_at"` // When the edit was detected
+}
+
+// ReviewActivity compares a merchant's recent visible reviews with the ones before
+type ReviewActivity struct {
+	NewReviews        int     // Reviewed since the cutoff
+	PreviousRated     int     // Rated reviews from before the cutoff
+	PreviousAvgRating float64 // Average of those, 0 if none
 }
 
 // SyncLog represents a log entry for a sync operation
@@ -55,6 +115,17 @@ type SyncLog struct {
 	ErrorMessage    string    `json:"error_message,omitempty"`
 	StartedAt       time.Time `json:"started_at"`
 	CompletedAt     *time.Time `json:"completed_at"`
+	RetryOf         *int       `json:"retry_of,omitempty"` // The failed sync log this one retried
+}
+
+// TokenRefreshLog records one scheduled token refresh attempt
+type TokenRefreshLog struct {
+	ID              int       `json:"id"`
+	APIConnectionID int       `json:"api_connection_id"`
+	Status          string    `json:"status"` // 'completed', 'failed'
+	ErrorMessage    string    `json:"error_message,omitempty"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 // TokenResponse represents an OAuth token response
@@ -98,8 +169,24 @@ const (
 	PlatformGoogleBusiness = "google_business"
 	PlatformFacebook       = "facebook"
 	PlatformInstagram      = "instagram"
+	PlatformThreads        = "threads"
 )
 
+// PlatformDisplayName returns the human-readable name for a platform
+func PlatformDisplayName(platform string) string {
+	switch platform {
+	case PlatformGoogleBusiness:
+		return "Google"
+	case PlatformFacebook:
+		return "Facebook"
+	case PlatformInstagram:
+		return "Instagram"
+	case PlatformThreads:
+		return "Threads"
+	}
+	return platform
+}
+
 // Sync status constants
 const (
 	SyncStatusPending   = "pending"
@@ -113,6 +200,7 @@ const (
 	SyncTypeManual    = "manual"
 	SyncTypeScheduled = "scheduled"
 	SyncTypeWebhook   = "webhook"
+	SyncTypeDryRun    = "dry_run" // Fetch and compare only; never stored as a sync log
 )
 
 // Database interface for social media operations
@@ -122,23 +210,57 @@ type SocialMediaDB interface {
 	GetAPIConnection(id int) (*APIConnection, error)
 	GetAPIConnectionsByMerchant(merchantID int) ([]*APIConnection, error)
 	GetAPIConnectionByPlatform(merchantID int, platform string) (*APIConnection, error)
+	GetAPIConnectionByAccount(merchantID int, platform, platformAccountID string) (*APIConnection, error)
 	UpdateAPIConnection(conn *APIConnection) error
+	UpdateAdminNotes(id int, notes string) error
+	ClearConnectionError(id int) error
 	DeleteAPIConnection(id int) error
 	GetActiveConnections() ([]*APIConnection, error)
+	GetAllAPIConnections() ([]*APIConnection, error)
+	UpdateConnectionTokens(id int, accessToken, refreshToken string, expiresAt time.Time) error
+	ReplaceConnectionTokens(id int, oldAccess, newAccess, oldRefresh, newRefresh string) (bool, error)
+	GetAllAPIConnectionsWithMerchant(filter ConnectionFilter, limit, offset int) ([]*AdminAPIConnection, int, error)
+	GetFailingConnections(minFailures int) ([]*FailingConnection, error)
 
 	// Synced Reviews
-	CreateSyncedReview(review *SyncedReview) error
+	CreateSyncedReview(review *SyncedReview) (bool, error)
 	GetSyncedReview(id int) (*SyncedReview, error)
 	GetSyncedReviewByPlatformID(platform, platformReviewID string) (*SyncedReview, error)
+	FindSyncedReviewByAuthorDay(merchantID int, platform, authorName string, reviewedAt time.Time) (*SyncedReview, error)
 	GetSyncedReviewsByMerchant(merchantID int, limit, offset int) ([]*SyncedReview, error)
+	GetPublicReviews(merchantID int, opts PublicReviewOptions) ([]*SyncedReview, error)
+	GetAllSyncedReviewsByMerchant(merchantID int, limit, offset int) ([]*SyncedReview, error)
+	GetSyncedReviewsFiltered(merchantID int, filter ReviewFilter, limit, offset int) ([]*SyncedReview, int, error)
+	SearchSyncedReviews(merchantID int, q string, limit, offset int) ([]*SyncedReview, int, error)
 	UpdateSyncedReview(review *SyncedReview) error
+	CreateSyncedReviewRevision(rev *SyncedReviewRevision) error
+	GetSyncedReviewRevisions(reviewID int) ([]*SyncedReviewRevision, error)
 	DeleteSyncedReview(id int) error
+	DeleteSyncedReviewsByConnection(connectionID int) (int64, error)
+	HideSyncedReviewsByConnection(connectionID int) (int64, error)
+	GetVisibleSyncedReviewsByMerchant(merchantID int, limit int) ([]*SyncedReview, error)
+	HideSyncedReviews(ids []int) (int64, error)
+
+	// Merchant settings
+	GetCrossPlatformDedup(merchantID int) (bool, error)
+	GetNotificationSettings(merchantID int) (*NotificationSettings, error)
+	SetNotificationSettings(merchantID int, notifyOnNegative, weeklyDigest bool) error
+	GetReviewActivitySince(merchantID int, since time.Time) (*ReviewActivity, error)
 
 	// Sync Logs
 	CreateSyncLog(log *SyncLog) error
 	GetSyncLog(id int) (*SyncLog, error)
 	GetSyncLogsByConnection(connectionID int, limit int) ([]*SyncLog, error)
 	UpdateSyncLog(log *SyncLog) error
+	DeleteSyncLogsOlderThan(cutoff time.Time) (int64, error)
+
+	// Token Refresh Logs
+	CreateTokenRefreshLog(log *TokenRefreshLog) error
+	DeleteTokenRefreshLogsOlderThan(cutoff time.Time) (int64, error)
+
+	// OAuth States
+	CreateOAuthState(state string, merchantID int, authUserID, platform string, expiresAt time.Time) error
+	ConsumeOAuthState(state, platform, authUserID string) (int, error)
 
 	// Helper methods
 	Begin() (*sql.Tx, error)
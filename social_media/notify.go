@@ -0,0 +1,140 @@
+package socialmedia
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Emailer sends plain-text email. Tests can substitute a recorder.
+type Emailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPEmailer sends mail through an SMTP server with PLAIN auth
+type SMTPEmailer struct {
+	Addr     string // host:port
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+// EmailerFromEnv builds an SMTPEmailer from SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. Returns nil when SMTP_HOST is unset.
+func EmailerFromEnv() Emailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+	return &SMTPEmailer{
+		Addr:     host + ":" + port,
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
+
+// Send implements Emailer
+func (e *SMTPEmailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	return smtp.SendMail(e.Addr, auth, e.From, []string{to}, []byte(composeMessage(e.From, to, subject, body)))
+}
+
+// headerLineBreaks strips CR and LF from header values. Subjects include the
+// merchant's business name, which must not be able to add headers.
+var headerLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// composeMessage builds a plain-text email. The subject is Q-encoded when it
+// isn't plain ASCII, since business names often aren't.
+func composeMessage(from, to, subject, body string) string {
+	return "From: " + headerLineBreaks.Replace(from) + "\r\n" +
+		"To: " + headerLineBreaks.Replace(to) + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", headerLineBreaks.Replace(subject)) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+}
+
+// NotificationSettings holds a merchant's email preferences and where to send them
+type NotificationSettings struct {
+	NotifyOnNegative bool
+	WeeklyDigest     bool
+	Email            string // Owner's email from auth.users
+	BusinessName     string
+}
+
+// SetEmailer enables negative review alerts; dashboardURL is linked from the email and may be empty
+func (s *SyncService) SetEmailer(emailer Emailer, dashboardURL string) {
+	s.emailer = emailer
+	s.dashboardURL = dashboardURL
+}
+
+// isNegative reports whether a review is at or below the alert threshold. Unrated reviews never are.
+func (s *SyncService) isNegative(review *SyncedReview) bool {
+	return review.Rating != nil && *review.Rating <= s.negativeThreshold
+}
+
+// notifyNegativeReviews emails the merchant about the negative reviews one sync
+// added, in a single message. Failures are logged; they never fail the sync.
+func (s *SyncService) notifyNegativeReviews(conn *APIConnection, reviews []*SyncedReview) {
+	if s.emailer == nil || len(reviews) == 0 {
+		return
+	}
+
+	settings, err := s.db.GetNotificationSettings(conn.MerchantID)
+	if err != nil {
+		log.Printf("Failed to load notification settings for merchant %d: %v", conn.MerchantID, err)
+		return
+	}
+	if !settings.NotifyOnNegative || settings.Email == "" {
+		return
+	}
+
+	subject, body := negativeReviewEmail(settings.BusinessName, conn.Platform, reviews, s.dashboardURL)
+	if err := s.emailer.Send(settings.Email, subject, body); err != nil {
+		log.Printf("Failed to send negative review alert for merchant %d: %v", conn.MerchantID, err)
+	}
+}
+
+// negativeReviewEmail builds the subject and body of a negative review alert
+func negativeReviewEmail(businessName, platform string, reviews []*SyncedReview, dashboardURL string) (string, string) {
+	subject := fmt.Sprintf("New low-rated %s review for %s", PlatformDisplayName(platform), businessName)
+	if len(reviews) > 1 {
+		subject = fmt.Sprintf("%d new low-rated %s reviews for %s", len(reviews), PlatformDisplayName(platform), businessName)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s received %d new low-rated review(s) on %s:\n\n", businessName, len(reviews), PlatformDisplayName(platform))
+	for _, review := range reviews {
+		author := review.AuthorName
+		if author == "" {
+			author = "Anonymous"
+		}
+		fmt.Fprintf(&b, "%.0f/5 from %s on %s\n", *review.Rating, author, review.ReviewedAt.Format("2 Jan 2006"))
+		if text := strings.TrimSpace(review.ReviewText); text != "" {
+			fmt.Fprintf(&b, "%s\n", text)
+		}
+		b.WriteString("\n")
+	}
+	if dashboardURL != "" {
+		fmt.Fprintf(&b, "Review them on your dashboard: %s\n", dashboardURL)
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,123 @@
+package socialmedia
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingEmailer collects sent mail instead of sending it
+type recordingEmailer struct {
+	sent []sentEmail
+}
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+func (e *recordingEmailer) Send(to, subject, body string) error {
+	e.sent = append(e.sent, sentEmail{to, subject, body})
+	return nil
+}
+
+// notifyDB is a memDB whose merchants all have the given notification settings
+type notifyDB struct {
+	*memDB
+	settings NotificationSettings
+}
+
+func (db *notifyDB) GetNotificationSettings(merchantID int) (*NotificationSettings, error) {
+	settings := db.settings
+	return &settings, nil
+}
+
+func TestNegativeReviewEmail(t *testing.T) {
+	one, two := 1.0, 2.0
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	reviews := []*SyncedReview{
+		{Rating: &one, AuthorName: "Ben", ReviewText: " Cold food ", ReviewedAt: day},
+		{Rating: &two, ReviewedAt: day},
+	}
+
+	subject, body := negativeReviewEmail("Cafe", PlatformGoogleBusiness, reviews, "https://example.com/dashboard")
+	if subject != "2 new low-rated Google reviews for Cafe" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{
+		"Cafe received 2 new low-rated review(s) on Google",
+		"1/5 from Ben on 1 Oct 2026\nCold food\n",
+		"2/5 from Anonymous on 1 Oct 2026\n\n",
+		"Review them on your dashboard: https://example.com/dashboard",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+
+	subject, body = negativeReviewEmail("Cafe", PlatformGoogleBusiness, reviews[:1], "")
+	if subject != "New low-rated Google review for Cafe" || strings.Contains(body, "dashboard") {
+		t.Errorf("single review without a dashboard URL: subject %q, body:\n%s", subject, body)
+	}
+}
+
+func TestComposeMessageHeaders(t *testing.T) {
+	one := 1.0
+	reviews := []*SyncedReview{{Rating: &one, ReviewedAt: time.Now()}}
+	subject, _ := negativeReviewEmail("Cafe\r\nBcc: victim@example.com", PlatformGoogleBusiness, reviews, "")
+	msg := composeMessage("alerts@example.com", "owner@example.com", subject, "body")
+	headers := strings.SplitN(msg, "\r\n\r\n", 2)[0]
+	if strings.Contains(headers, "\r\nBcc:") || strings.Count(headers, "\r\n") != 4 {
+		t.Errorf("business name added a header:\n%s", headers)
+	}
+
+	msg = composeMessage("alerts@example.com", "owner@example.com", "New review for Kafé Ümit", "body")
+	if !strings.Contains(msg, "\r\nSubject: =?utf-8?q?New_review_for_Kaf=C3=A9_=C3=9Cmit?=\r\n") {
+		t.Errorf("non-ASCII subject not Q-encoded:\n%s", msg)
+	}
+	if !strings.Contains(composeMessage("a@example.com", "b@example.com", "Plain", "body"), "\r\nSubject: Plain\r\n") {
+		t.Error("ASCII subject was encoded")
+	}
+}
+
+func TestIsNegative(t *testing.T) {
+	s := newTestSyncService(newMemDB(), &fakeProvider{platform: PlatformGoogleBusiness})
+	s.negativeThreshold = 2
+	for _, tt := range []struct {
+		rating *float64
+		want   bool
+	}{{nil, false}, {floatPtr(1.0), true}, {floatPtr(2.0), true}, {floatPtr(3.0), false}} {
+		if got := s.isNegative(&SyncedReview{Rating: tt.rating}); got != tt.want {
+			t.Errorf("isNegative(%v) = %v, want %v", tt.rating, got, tt.want)
+		}
+	}
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestNotifyNegativeReviewsRespectsSettings(t *testing.T) {
+	one := 1.0
+	reviews := []*SyncedReview{{Rating: &one, ReviewText: "Bad", ReviewedAt: time.Now()}}
+
+	tests := []struct {
+		name     string
+		settings NotificationSettings
+		want     int
+	}{
+		{"opted in", NotificationSettings{NotifyOnNegative: true, Email: "owner@example.com", BusinessName: "Cafe"}, 1},
+		{"opted out", NotificationSettings{Email: "owner@example.com", BusinessName: "Cafe"}, 0},
+		{"no owner email", NotificationSettings{NotifyOnNegative: true, BusinessName: "Cafe"}, 0},
+	}
+	for _, tt := range tests {
+		emailer := &recordingEmailer{}
+		s := newTestSyncService(&notifyDB{newMemDB(), tt.settings}, &fakeProvider{platform: PlatformGoogleBusiness})
+		s.SetEmailer(emailer, "")
+
+		s.notifyNegativeReviews(testAPIConnection(1), reviews)
+		if len(emailer.sent) != tt.want {
+			t.Errorf("%s: sent %d emails, want %d", tt.name, len(emailer.sent), tt.want)
+		}
+		if tt.want == 1 && emailer.sent[0].to != "owner@example.com" {
+			t.Errorf("%s: sent to %q, want the owner", tt.name, emailer.sent[0].to)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// oauthStateRow is one row of the fake oauth_states table
+type oauthStateRow struct {
+	merchantID int64
+	userID     string
+	platform   string
+	expiresAt  time.Time
+}
+
+// newOAuthStateDB backs CreateOAuthState and ConsumeOAuthState with an in-memory table
+func newOAuthStateDB(t *testing.T) *DB {
+	t.Helper()
+	states := map[string]oauthStateRow{}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.HasPrefix(query, "DELETE FROM oauth_states WHERE expires_at"):
+			return &fakedb.Result{}, nil
+		case strings.HasPrefix(query, "INSERT INTO oauth_states"):
+			states[args[0].(string)] = oauthStateRow{
+				merchantID: args[1].(int64),
+				userID:     args[2].(string),
+				platform:   args[3].(string),
+				expiresAt:  args[4].(time.Time),
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.HasPrefix(query, "DELETE FROM oauth_states WHERE state"):
+			res := &fakedb.Result{Columns: []string{"merchant_id", "auth_user_id", "platform", "expires_at"}}
+			if row, ok := states[args[0].(string)]; ok {
+				delete(states, args[0].(string))
+				res.Rows = [][]driver.Value{{row.merchantID, row.userID, row.platform, row.expiresAt}}
+			}
+			return res, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return NewDB(conn)
+}
+
+func TestConsumeOAuthState(t *testing.T) {
+	db := newOAuthStateDB(t)
+	if err := db.CreateOAuthState("state-1", 7, "user-a", PlatformGoogleBusiness, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	merchantID, err := db.ConsumeOAuthState("state-1", PlatformGoogleBusiness, "user-a")
+	if err != nil || merchantID != 7 {
+		t.Fatalf("ConsumeOAuthState = %d, %v; want 7, nil", merchantID, err)
+	}
+
+	// Single use: the same state can't complete a second callback
+	if _, err := db.ConsumeOAuthState("state-1", PlatformGoogleBusiness, "user-a"); err != ErrInvalidOAuthState {
+		t.Errorf("reused state: err = %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestConsumeOAuthStateRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		platform  string
+		userID    string
+	}{
+		{"expired", time.Now().Add(-time.Second), PlatformGoogleBusiness, "user-a"},
+		{"other platform", time.Now().Add(time.Minute), PlatformFacebook, "user-a"},
+		{"other user", time.Now().Add(time.Minute), PlatformGoogleBusiness, "user-b"},
+		{"unknown state", time.Time{}, PlatformGoogleBusiness, "user-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newOAuthStateDB(t)
+			if !tt.expiresAt.IsZero() {
+				if err := db.CreateOAuthState("state-1", 7, "user-a", PlatformGoogleBusiness, tt.expiresAt); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := db.ConsumeOAuthState("state-1", tt.platform, tt.userID); err != ErrInvalidOAuthState {
+				t.Errorf("err = %v, want ErrInvalidOAuthState", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package socialmedia
+
+// SupportedPlatforms lists every platform the app can integrate with, in display order
+var SupportedPlatforms = []string{PlatformGoogleBusiness, PlatformFacebook, PlatformInstagram, PlatformThreads}
+
+// IsSupportedPlatform reports whether platform is one of SupportedPlatforms
+func IsSupportedPlatform(platform string) bool {
+	for _, supported := range SupportedPlatforms {
+		if platform == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities a platform integration can offer
+const (
+	CapabilityReviews  = "reviews"
+	CapabilityRatings  = "ratings"
+	CapabilityComments = "comments"
+)
+
+// platformCapabilities describes what is synced from each platform
+var platformCapabilities = map[string][]string{
+	PlatformGoogleBusiness: {CapabilityReviews, CapabilityRatings},
+	PlatformFacebook:       {CapabilityReviews, CapabilityRatings},
+	PlatformInstagram:      {CapabilityComments},
+	PlatformThreads:        {CapabilityComments},
+}
+
+// PlatformStatus describes a platform's availability for one merchant
+type PlatformStatus struct {
+	Platform     string   `json:"platform"`
+	DisplayName  string   `json:"display_name"`
+	Configured   bool     `json:"configured"`  // Credentials for the platform are set
+	Connected    bool     `json:"connected"`   // The merchant has an active connection
+	Connectable  bool     `json:"connectable"` // The merchant can start a new connection
+	Capabilities []string `json:"capabilities"`
+}
+
+// BuildPlatformStatuses combines which platforms are configured with the
+// merchant's connections into one status per supported platform
+func BuildPlatformStatuses(configured map[string]bool, connections []*APIConnection) []PlatformStatus {
+	connected := make(map[string]bool)
+	for _, conn := range connections {
+		if conn.IsActive {
+			connected[conn.Platform] = true
+		}
+	}
+
+	statuses := make([]PlatformStatus, 0, len(SupportedPlatforms))
+	for _, platform := range SupportedPlatforms {
+		statuses = append(statuses, PlatformStatus{
+			Platform:     platform,
+			DisplayName:  PlatformDisplayName(platform),
+			Configured:   configured[platform],
+			Connected:    connected[platform],
+			Connectable:  configured[platform] && !connected[platform],
+			Capabilities: platformCapabilities[platform],
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,42 @@
+package socialmedia
+
+import "testing"
+
+func TestBuildPlatformStatuses(t *testing.T) {
+	inactive := &APIConnection{Platform: PlatformFacebook, IsActive: false}
+	active := &APIConnection{Platform: PlatformGoogleBusiness, IsActive: true}
+	configured := map[string]bool{PlatformGoogleBusiness: true, PlatformFacebook: true}
+
+	statuses := BuildPlatformStatuses(configured, []*APIConnection{active, inactive})
+	if len(statuses) != len(SupportedPlatforms) {
+		t.Fatalf("got %d statuses, want one per supported platform", len(statuses))
+	}
+
+	byPlatform := make(map[string]PlatformStatus)
+	for i, status := range statuses {
+		if status.Platform != SupportedPlatforms[i] {
+			t.Errorf("status %d is %s, want display order %v", i, status.Platform, SupportedPlatforms)
+		}
+		byPlatform[status.Platform] = status
+	}
+
+	tests := []struct {
+		platform                           string
+		configured, connected, connectable bool
+	}{
+		{PlatformGoogleBusiness, true, true, false},
+		// Configured but only an inactive connection: can be (re)connected
+		{PlatformFacebook, true, false, true},
+		{PlatformInstagram, false, false, false},
+	}
+	for _, tt := range tests {
+		got := byPlatform[tt.platform]
+		if got.Configured != tt.configured || got.Connected != tt.connected || got.Connectable != tt.connectable {
+			t.Errorf("%s: configured %v, connected %v, connectable %v; want %v, %v, %v", tt.platform,
+				got.Configured, got.Connected, got.Connectable, tt.configured, tt.connected, tt.connectable)
+		}
+		if got.DisplayName == "" || len(got.Capabilities) == 0 {
+			t.Errorf("%s: missing display name or capabilities: %+v", tt.platform, got)
+		}
+	}
+}
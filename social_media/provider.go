@@ -1,6 +1,16 @@
 package socialmedia
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,9 +25,11 @@ type SocialMediaProvider interface {
 	// RefreshToken uses a refresh token to get a new access token
 	RefreshToken(refreshToken string) (*TokenResponse, error)
 
-	// FetchReviews fetches reviews from the platform since the given time
-	// If since is zero, fetches all available reviews
-	FetchReviews(accessToken string, since time.Time) ([]*Review, error)
+	// FetchReviews fetches reviews for the connected account (the AccountID
+	// stored at connect time) since the given time.
+	// If since is zero, fetches all available reviews. If accountID is empty,
+	// the provider falls back to the account GetAccountInfo returns.
+	FetchReviews(accessToken, accountID string, since time.Time) ([]*Review, error)
 
 	// GetAccountInfo retrieves account information using the access token
 	GetAccountInfo(accessToken string) (*AccountInfo, error)
@@ -27,21 +39,99 @@ type SocialMediaProvider interface {
 
 	// ValidateToken checks if an access token is still valid
 	ValidateToken(accessToken string) (bool, error)
+
+	// RevokeToken withdraws the app's grant on the user's platform account
+	RevokeToken(accessToken string) error
 }
 
 // SyncService handles the synchronization of reviews from social media platforms
 type SyncService struct {
-	db        SocialMediaDB
-	providers map[string]SocialMediaProvider
-	encryptor TokenEncryptor
+	db                     SocialMediaDB
+	providers              map[string]SocialMediaProvider
+	encryptor              TokenEncryptor
+	syncOnReconnect        bool
+	manualSyncCooldown     time.Duration
+	tokenRefreshWindow     time.Duration
+	syncLogRetention       time.Duration
+	refreshableTokenWindow time.Duration
+	dedupStrategies        map[string]DedupStrategy
+	sentiment              SentimentAnalyzer
+	emailer                Emailer
+	dashboardURL           string
+	negativeThreshold      float64
+	failureAlertThreshold  int
+	jobs                   *JobQueue
+
+	// background tracks syncs started by SyncInBackground so shutdown can wait for them
+	background sync.WaitGroup
 }
 
 // NewSyncService creates a new sync service
 func NewSyncService(db SocialMediaDB, encryptor TokenEncryptor) *SyncService {
+	// Sync immediately after a connection is re-established (default on)
+	syncOnReconnect := os.Getenv("SYNC_ON_RECONNECT") != "false"
+
+	// Minimum time between manual syncs of a connection (default 5 minutes)
+	cooldownMinutes := 5
+	if envCooldown := os.Getenv("MANUAL_SYNC_COOLDOWN_MINUTES"); envCooldown != "" {
+		if parsed, err := strconv.Atoi(envCooldown); err == nil && parsed >= 0 {
+			cooldownMinutes = parsed
+		}
+	}
+
+	// Refresh long-lived tokens this many days before they expire (default 7)
+	refreshWindowDays := 7
+	if envWindow := os.Getenv("TOKEN_REFRESH_WINDOW_DAYS"); envWindow != "" {
+		if parsed, err := strconv.Atoi(envWindow); err == nil && parsed > 0 {
+			refreshWindowDays = parsed
+		}
+	}
+
+	// Refresh tokens that come with a refresh token this many hours before they expire (default 24)
+	refreshableWindowHours := 24
+	if envWindow := os.Getenv("REFRESHABLE_TOKEN_WINDOW_HOURS"); envWindow != "" {
+		if parsed, err := strconv.Atoi(envWindow); err == nil && parsed > 0 {
+			refreshableWindowHours = parsed
+		}
+	}
+
+	// Delete sync logs older than this many days (default 90)
+	retentionDays := 90
+	if envRetention := os.Getenv("SYNC_LOG_RETENTION_DAYS"); envRetention != "" {
+		if parsed, err := strconv.Atoi(envRetention); err == nil && parsed > 0 {
+			retentionDays = parsed
+		}
+	}
+
+	// Email about new reviews rated at or below this (default 2 stars)
+	negativeThreshold := 2.0
+	if envThreshold := os.Getenv("NEGATIVE_REVIEW_THRESHOLD"); envThreshold != "" {
+		if parsed, err := strconv.ParseFloat(envThreshold, 64); err == nil && parsed > 0 {
+			negativeThreshold = parsed
+		}
+	}
+
+	// Alert admins about connections whose last this-many syncs failed (default 3)
+	failureAlertThreshold := 3
+	if envFailures := os.Getenv("SYNC_FAILURE_ALERT_THRESHOLD"); envFailures != "" {
+		if parsed, err := strconv.Atoi(envFailures); err == nil && parsed > 0 {
+			failureAlertThreshold = parsed
+		}
+	}
+
 	return &SyncService{
-		db:        db,
-		providers: make(map[string]SocialMediaProvider),
-		encryptor: encryptor,
+		db:                     db,
+		providers:              make(map[string]SocialMediaProvider),
+		encryptor:              encryptor,
+		syncOnReconnect:        syncOnReconnect,
+		manualSyncCooldown:     time.Duration(cooldownMinutes) * time.Minute,
+		tokenRefreshWindow:     time.Duration(refreshWindowDays) * 24 * time.Hour,
+		syncLogRetention:       time.Duration(retentionDays) * 24 * time.Hour,
+		refreshableTokenWindow: time.Duration(refreshableWindowHours) * time.Hour,
+		dedupStrategies:        dedupStrategiesFromEnv(),
+		sentiment:              NewLexiconAnalyzer(),
+		negativeThreshold:      negativeThreshold,
+		failureAlertThreshold:  failureAlertThreshold,
 	}
 }
 
@@ -56,8 +146,71 @@ func (s *SyncService) GetProvider(platform string) (SocialMediaProvider, bool) {
 	return provider, ok
 }
 
+// SyncOnReconnect starts a background sync for a connection that was just
+// reconnected, unless disabled, already syncing, or synced within the manual
+// sync cooldown. Progress is recorded in the connection's sync logs.
+// Returns true if a sync was started.
+func (s *SyncService) SyncOnReconnect(connectionID int) bool {
+	if !s.syncOnReconnect {
+		return false
+	}
+
+	conn, err := s.db.GetAPIConnection(connectionID)
+	if err != nil {
+		return false
+	}
+
+	if conn.SyncStatus == SyncStatusSyncing {
+		return false
+	}
+	if conn.LastSyncAt != nil && time.Since(*conn.LastSyncAt) < s.manualSyncCooldown {
+		return false
+	}
+
+	s.SyncInBackground(connectionID, SyncTypeManual)
+	return true
+}
+
+// SyncInBackground syncs a connection on its own goroutine. Errors are logged
+// and recorded in the connection's sync logs. WaitForBackgroundSyncs waits for it.
+func (s *SyncService) SyncInBackground(connectionID int, syncType string) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		if _, err := s.SyncConnection(connectionID, syncType); err != nil {
+			log.Printf("Background sync failed for connection %d: %v", connectionID, err)
+		}
+	}()
+}
+
+// WaitForBackgroundSyncs waits for syncs started by SyncInBackground to
+// finish, or for ctx to be done, whichever comes first
+func (s *SyncService) WaitForBackgroundSyncs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // SyncConnection syncs reviews for a specific API connection
 func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncStats, error) {
+	return s.syncConnection(connectionID, syncType, nil)
+}
+
+// syncConnection runs a sync; retryOf, when set, is the failed sync log it retries
+func (s *SyncService) syncConnection(connectionID int, syncType string, retryOf *int) (*SyncStats, error) {
+	// A dry run fetches and compares reviews but writes nothing: no sync log,
+	// no connection status, no synced_reviews changes
+	dryRun := syncType == SyncTypeDryRun
+
 	// Get the API connection
 	conn, err := s.db.GetAPIConnection(connectionID)
 	if err != nil {
@@ -76,27 +229,40 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 		SyncType:        syncType,
 		Status:          "started",
 		StartedAt:       time.Now(),
+		RetryOf:         retryOf,
 	}
-	if err := s.db.CreateSyncLog(log); err != nil {
-		return nil, err
+	fail := func(err error) {
+		if !dryRun {
+			s.handleSyncError(conn, log, err)
+		}
 	}
 
-	// Update connection status
-	conn.SyncStatus = SyncStatusSyncing
-	if err := s.db.UpdateAPIConnection(conn); err != nil {
-		return nil, err
+	if !dryRun {
+		if err := s.db.CreateSyncLog(log); err != nil {
+			return nil, err
+		}
+
+		// Update connection status
+		conn.SyncStatus = SyncStatusSyncing
+		if err := s.db.UpdateAPIConnection(conn); err != nil {
+			return nil, err
+		}
 	}
 
 	// Decrypt access token
 	accessToken, err := s.encryptor.Decrypt(conn.AccessToken)
 	if err != nil {
-		s.handleSyncError(conn, log, err)
+		fail(err)
 		return nil, err
 	}
 
 	// Check if token is valid, refresh if needed
 	valid, err := provider.ValidateToken(accessToken)
 	if err != nil || !valid {
+		if dryRun {
+			// Refreshing would have to store the new token
+			return nil, ErrDryRunNeedsRefresh
+		}
 		if conn.RefreshToken != "" {
 			refreshToken, _ := s.encryptor.Decrypt(conn.RefreshToken)
 			tokenResp, err := provider.RefreshToken(refreshToken)
@@ -107,14 +273,7 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 			accessToken = tokenResp.AccessToken
 
 			// Update stored tokens
-			encryptedAccess, _ := s.encryptor.Encrypt(tokenResp.AccessToken)
-			conn.AccessToken = encryptedAccess
-			if tokenResp.RefreshToken != "" {
-				encryptedRefresh, _ := s.encryptor.Encrypt(tokenResp.RefreshToken)
-				conn.RefreshToken = encryptedRefresh
-			}
-			conn.TokenExpiresAt = tokenResp.ExpiresAt
-			s.db.UpdateAPIConnection(conn)
+			s.storeRefreshedToken(conn, tokenResp)
 		} else {
 			s.handleSyncError(conn, log, &ErrInvalidToken{})
 			return nil, &ErrInvalidToken{}
@@ -127,9 +286,9 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 		since = *conn.LastSyncAt
 	}
 
-	reviews, err := provider.FetchReviews(accessToken, since)
+	reviews, err := provider.FetchReviews(accessToken, conn.PlatformAccountID, since)
 	if err != nil {
-		s.handleSyncError(conn, log, err)
+		fail(err)
 		return nil, err
 	}
 
@@ -137,10 +296,11 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 	stats := &SyncStats{
 		TotalFetched: len(reviews),
 	}
+	var newNegatives []*SyncedReview
 
 	for _, review := range reviews {
-		// Check if review already exists
-		existing, err := s.db.GetSyncedReviewByPlatformID(conn.Platform, review.PlatformReviewID)
+		// Check if review already exists, per the platform's dedup strategy
+		existing := s.findExistingReview(conn, review)
 
 		syncedReview := &SyncedReview{
 			MerchantID:       conn.MerchantID,
@@ -156,23 +316,63 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 			IsVisible:        true,
 			Metadata:         review.Metadata,
 		}
+		if err := s.classifySentiment(syncedReview); err != nil {
+			stats.Errors = append(stats.Errors, err)
+		}
 
-		if err != nil || existing == nil {
-			// Create new review
-			if err := s.db.CreateSyncedReview(syncedReview); err != nil {
-				stats.Errors = append(stats.Errors, err)
+		if dryRun {
+			if existing == nil {
+				stats.TotalAdded++
 			} else {
+				stats.TotalUpdated++
+			}
+			continue
+		}
+
+		if existing == nil {
+			created, err := s.db.CreateSyncedReview(syncedReview)
+			if err != nil {
+				stats.Errors = append(stats.Errors, err)
+				continue
+			}
+			if created {
 				stats.TotalAdded++
+				if s.isNegative(syncedReview) {
+					newNegatives = append(newNegatives, syncedReview)
+				}
+				continue
 			}
-		} else {
-			// Update existing review
-			syncedReview.ID = existing.ID
-			if err := s.db.UpdateSyncedReview(syncedReview); err != nil {
+
+			// A concurrent sync stored it since the lookup; update that row instead
+			existing, err = s.db.GetSyncedReviewByPlatformID(conn.Platform, syncedReview.PlatformReviewID)
+			if err != nil {
 				stats.Errors = append(stats.Errors, err)
-			} else {
-				stats.TotalUpdated++
+				continue
+			}
+			if existing.MerchantID != conn.MerchantID {
+				stats.Errors = append(stats.Errors, fmt.Errorf("review %s is stored for another merchant", syncedReview.PlatformReviewID))
+				continue
+			}
+		}
+
+		// Update existing review, keeping what it said before if the reviewer edited it
+		syncedReview.ID = existing.ID
+		if reviewEdited(existing, syncedReview) {
+			if err := s.db.CreateSyncedReviewRevision(revisionOf(existing)); err != nil {
+				// Leave the review as it was so the edit is picked up, with history, next sync
+				stats.Errors = append(stats.Errors, err)
+				continue
 			}
 		}
+		if err := s.db.UpdateSyncedReview(syncedReview); err != nil {
+			stats.Errors = append(stats.Errors, err)
+		} else {
+			stats.TotalUpdated++
+		}
+	}
+
+	if dryRun {
+		return stats, nil
 	}
 
 	// Update connection
@@ -192,6 +392,11 @@ func (s *SyncService) SyncConnection(connectionID int, syncType string) (*SyncSt
 	log.CompletedAt = &now
 	s.db.UpdateSyncLog(log)
 
+	s.submitJob(&CrossPlatformDedupJob{service: s, MerchantID: conn.MerchantID})
+	if len(newNegatives) > 0 {
+		s.submitJob(&NotifyNegativeReviewsJob{service: s, Conn: conn, Reviews: newNegatives})
+	}
+
 	return stats, nil
 }
 
@@ -248,3 +453,81 @@ type ErrInvalidToken struct{}
 func (e *ErrInvalidToken) Error() string {
 	return "invalid or expired access token"
 }
+
+// ErrInvalidOAuthState is returned for an OAuth state that is unknown, already used or expired
+var ErrInvalidOAuthState = errors.New("invalid or expired OAuth state")
+
+// ErrDryRunNeedsRefresh is returned by a dry-run sync when the access token has
+// to be refreshed first, since storing the new token would be a write
+var ErrDryRunNeedsRefresh = errors.New("access token needs refreshing; run a normal sync first")
+
+// ProviderError is a non-200 response from a platform API
+type ProviderError struct {
+	StatusCode int
+	Platform   string
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return e.Message
+}
+
+// newProviderError reads a failed response into a ProviderError, keeping the
+// body in the message for logs
+func newProviderError(platform, action string, resp *http.Response) *ProviderError {
+	body, _ := io.ReadAll(resp.Body)
+	return &ProviderError{
+		StatusCode: resp.StatusCode,
+		Platform:   platform,
+		Message:    fmt.Sprintf("%s: %s - %s", action, resp.Status, string(body)),
+	}
+}
+
+// Sync error categories shown to merchants
+const (
+	ErrorCategoryRateLimit  = "rate_limit"
+	ErrorCategoryAuth       = "auth"
+	ErrorCategoryPermission = "permission"
+	ErrorCategoryNoBusiness = "no_business"
+	ErrorCategoryUnknown    = "unknown"
+)
+
+// CategorizeError maps a sync or connect error to a category. Provider HTTP
+// errors are classified by status code; anything else falls back to matching
+// the message.
+func CategorizeError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		switch providerErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return ErrorCategoryRateLimit
+		case http.StatusUnauthorized:
+			return ErrorCategoryAuth
+		case http.StatusForbidden:
+			return ErrorCategoryPermission
+		}
+	}
+
+	var invalidToken *ErrInvalidToken
+	if errors.As(err, &invalidToken) {
+		return ErrorCategoryAuth
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests"):
+		return ErrorCategoryRateLimit
+	case strings.Contains(message, "invalid_grant") || strings.Contains(message, "expired") || strings.Contains(message, "unauthorized"):
+		return ErrorCategoryAuth
+	case strings.Contains(message, "permission") || strings.Contains(message, "forbidden"):
+		return ErrorCategoryPermission
+	case strings.Contains(message, "no business") || strings.Contains(message, "no facebook pages") ||
+		strings.Contains(message, "no instagram business account") || strings.Contains(message, "no pages found"):
+		return ErrorCategoryNoBusiness
+	}
+	return ErrorCategoryUnknown
+}
@@ -0,0 +1,227 @@
+package socialmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSyncOnReconnectStartsOneSync(t *testing.T) {
+	db := newMemDB(testAPIConnection(1))
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+	s := newTestSyncService(db, provider)
+
+	if !s.SyncOnReconnect(1) {
+		t.Fatal("SyncOnReconnect didn't start a sync")
+	}
+	if err := s.WaitForBackgroundSyncs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.fetches) != 1 || len(db.syncLogs) != 1 {
+		t.Fatalf("fetched %d times with %d sync logs, want one sync", len(provider.fetches), len(db.syncLogs))
+	}
+	if log := db.syncLogs[0]; log.SyncType != SyncTypeManual || log.Status != "completed" {
+		t.Errorf("sync log = %s/%s, want a completed manual sync", log.SyncType, log.Status)
+	}
+}
+
+func TestSyncOnReconnectSkips(t *testing.T) {
+	recent := time.Now().Add(-time.Minute)
+	syncing := testAPIConnection(2)
+	syncing.SyncStatus = SyncStatusSyncing
+	justSynced := testAPIConnection(3)
+	justSynced.LastSyncAt = &recent
+
+	db := newMemDB(testAPIConnection(1), syncing, justSynced)
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+	s := newTestSyncService(db, provider)
+
+	for _, id := range []int{2, 3, 404} {
+		if s.SyncOnReconnect(id) {
+			t.Errorf("connection %d: sync started", id)
+		}
+	}
+
+	s.syncOnReconnect = false
+	if s.SyncOnReconnect(1) {
+		t.Error("sync started with SYNC_ON_RECONNECT=false")
+	}
+
+	s.WaitForBackgroundSyncs(context.Background())
+	if len(provider.fetches) != 0 {
+		t.Errorf("fetched %d times, want none", len(provider.fetches))
+	}
+}
+
+func TestSyncConnectionFetchesStoredAccount(t *testing.T) {
+	conn := testAPIConnection(1)
+	conn.PlatformAccountID = "accounts/2"
+	db := newMemDB(conn)
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+
+	if _, err := newTestSyncService(db, provider).SyncConnection(1, SyncTypeManual); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.fetches) != 1 || provider.fetches[0] != "accounts/2" {
+		t.Errorf("fetched accounts %v, want the stored accounts/2", provider.fetches)
+	}
+}
+
+func TestCategorizeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&ProviderError{StatusCode: http.StatusTooManyRequests, Message: "x"}, ErrorCategoryRateLimit},
+		{&ProviderError{StatusCode: http.StatusUnauthorized, Message: "x"}, ErrorCategoryAuth},
+		{&ProviderError{StatusCode: http.StatusForbidden, Message: "x"}, ErrorCategoryPermission},
+		{fmt.Errorf("sync: %w", &ProviderError{StatusCode: http.StatusForbidden, Message: "x"}), ErrorCategoryPermission},
+		// An unmapped status falls back to the message
+		{&ProviderError{StatusCode: http.StatusBadRequest, Message: "invalid_grant"}, ErrorCategoryAuth},
+		{&ErrInvalidToken{}, ErrorCategoryAuth},
+		{errors.New("Rate limit exceeded"), ErrorCategoryRateLimit},
+		{errors.New("token has expired"), ErrorCategoryAuth},
+		{errors.New("no Facebook pages found"), ErrorCategoryNoBusiness},
+		{errors.New("connection reset"), ErrorCategoryUnknown},
+	}
+	for _, tt := range tests {
+		if got := CategorizeError(tt.err); got != tt.want {
+			t.Errorf("CategorizeError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestProviderReturnsTypedError(t *testing.T) {
+	p := NewGoogleBusinessProvider("id", "secret", "https://example.com/callback")
+	p.httpClient = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "quota"}`, http.StatusTooManyRequests)
+	})
+
+	_, err := p.FetchReviews("token", "accounts/1", time.Time{})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusTooManyRequests || providerErr.Platform != PlatformGoogleBusiness {
+		t.Fatalf("err = %#v, want a Google ProviderError with status 429", err)
+	}
+	if !strings.Contains(err.Error(), "quota") || CategorizeError(err) != ErrorCategoryRateLimit {
+		t.Errorf("err = %v (%s), want the body kept and a rate limit category", err, CategorizeError(err))
+	}
+}
+
+func TestDryRunSyncWritesNothing(t *testing.T) {
+	db := newMemDB(testAPIConnection(1))
+	db.reviews[1] = &SyncedReview{ID: 1, MerchantID: 7, Platform: PlatformGoogleBusiness, PlatformReviewID: "r1", IsVisible: true}
+	provider := &fakeProvider{platform: PlatformGoogleBusiness, reviews: []*Review{
+		{PlatformReviewID: "r1", AuthorName: "Aina", ReviewText: "Edited", ReviewedAt: time.Now()},
+		{PlatformReviewID: "r2", AuthorName: "Ben", ReviewText: "New", ReviewedAt: time.Now()},
+	}}
+
+	stats, err := newTestSyncService(db, provider).SyncConnection(1, SyncTypeDryRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalFetched != 2 || stats.TotalAdded != 1 || stats.TotalUpdated != 1 {
+		t.Errorf("stats = %+v, want 2 fetched, 1 added, 1 updated", stats)
+	}
+	if db.writes != 0 || len(db.syncLogs) != 0 || len(db.reviews) != 1 {
+		t.Errorf("dry run made %d writes (%d sync logs, %d reviews)", db.writes, len(db.syncLogs), len(db.reviews))
+	}
+	if conn := db.connections[1]; conn.SyncStatus != SyncStatusPending || conn.LastSyncAt != nil {
+		t.Errorf("connection status %s, last sync %v; want it untouched", conn.SyncStatus, conn.LastSyncAt)
+	}
+}
+
+func TestDryRunSyncDoesNotRefreshTokens(t *testing.T) {
+	db := newMemDB(testAPIConnection(1))
+	provider := &fakeProvider{platform: PlatformGoogleBusiness, invalid: true}
+
+	if _, err := newTestSyncService(db, provider).SyncConnection(1, SyncTypeDryRun); !errors.Is(err, ErrDryRunNeedsRefresh) {
+		t.Errorf("err = %v, want ErrDryRunNeedsRefresh", err)
+	}
+	if len(provider.refreshed) != 0 || len(provider.fetches) != 0 || db.writes != 0 {
+		t.Errorf("refreshed %d times, fetched %d times, %d writes; want none", len(provider.refreshed), len(provider.fetches), db.writes)
+	}
+}
+
+// racedDB is a memDB where another sync always stores an earlier version of
+// a review first, so CreateSyncedReview finds it already there
+type racedDB struct {
+	*revisionDB
+}
+
+func (db *racedDB) CreateSyncedReview(review *SyncedReview) (bool, error) {
+	earlier := *review
+	earlier.ReviewText = "Okay"
+	db.memDB.CreateSyncedReview(&earlier)
+	return db.memDB.CreateSyncedReview(review)
+}
+
+func TestSyncUpdatesReviewStoredByConcurrentSync(t *testing.T) {
+	db := &racedDB{&revisionDB{memDB: newMemDB(testAPIConnection(1))}}
+	provider := &fakeProvider{platform: PlatformGoogleBusiness, reviews: []*Review{
+		{PlatformReviewID: "r1", AuthorName: "Aina", ReviewText: "Good", ReviewedAt: time.Now()},
+	}}
+
+	stats, err := newTestSyncService(db, provider).SyncConnection(1, SyncTypeManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalAdded != 0 || stats.TotalUpdated != 1 || len(stats.Errors) != 0 {
+		t.Errorf("stats = %+v, want one update and no errors", stats)
+	}
+	if len(db.reviews) != 1 || db.reviews[1].ReviewText != "Good" {
+		t.Errorf("reviews = %v, want the one row updated", db.reviews)
+	}
+	if len(db.revisions) != 1 || db.revisions[0].ReviewText != "Okay" {
+		t.Errorf("revisions = %v, want the concurrent sync's version kept", db.revisions)
+	}
+}
+
+func TestConcurrentSyncsStoreEachReviewOnce(t *testing.T) {
+	db := newMemDB(testAPIConnection(1))
+	provider := &fakeProvider{platform: PlatformGoogleBusiness, reviews: []*Review{
+		{PlatformReviewID: "r1", AuthorName: "Aina", ReviewText: "Good", ReviewedAt: time.Now()},
+		{PlatformReviewID: "r2", AuthorName: "Ben", ReviewText: "Fine", ReviewedAt: time.Now()},
+		{PlatformReviewID: "r3", AuthorName: "Chen", ReviewText: "Great", ReviewedAt: time.Now()},
+	}}
+	s := newTestSyncService(db, provider)
+
+	const syncs = 8
+	results := make(chan *SyncStats, syncs)
+	var wg sync.WaitGroup
+	for i := 0; i < syncs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, err := s.SyncConnection(1, SyncTypeManual)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results <- stats
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	added, updated := 0, 0
+	for stats := range results {
+		if len(stats.Errors) != 0 {
+			t.Errorf("sync errors: %v", stats.Errors)
+		}
+		added += stats.TotalAdded
+		updated += stats.TotalUpdated
+	}
+	if len(db.reviews) != 3 {
+		t.Errorf("stored %d reviews, want one row per review", len(db.reviews))
+	}
+	if added != 3 || added+updated != syncs*3 {
+		t.Errorf("added %d, updated %d; want 3 added and the rest updated", added, updated)
+	}
+}
@@ -0,0 +1,26 @@
+package socialmedia
+
+// reviewEdited reports whether an incoming review changes the stored one's
+// rating, text or reply
+func reviewEdited(stored, incoming *SyncedReview) bool {
+	return !sameRating(stored.Rating, incoming.Rating) ||
+		stored.ReviewText != incoming.ReviewText ||
+		stored.ReviewReply != incoming.ReviewReply
+}
+
+func sameRating(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// revisionOf captures a stored review's current rating, text and reply
+func revisionOf(review *SyncedReview) *SyncedReviewRevision {
+	return &SyncedReviewRevision{
+		SyncedReviewID: review.ID,
+		Rating:         review.Rating,
+		ReviewText:     review.ReviewText,
+		ReviewReply:    review.ReviewReply,
+	}
+}
@@ -0,0 +1,85 @@
+package socialmedia
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReviewEdited(t *testing.T) {
+	stored := &SyncedReview{Rating: floatPtr(4), ReviewText: "Good", ReviewReply: "Thanks"}
+	tests := map[string]struct {
+		incoming SyncedReview
+		want     bool
+	}{
+		"unchanged":      {SyncedReview{Rating: floatPtr(4), ReviewText: "Good", ReviewReply: "Thanks"}, false},
+		"rating changed": {SyncedReview{Rating: floatPtr(2), ReviewText: "Good", ReviewReply: "Thanks"}, true},
+		"rating removed": {SyncedReview{ReviewText: "Good", ReviewReply: "Thanks"}, true},
+		"text changed":   {SyncedReview{Rating: floatPtr(4), ReviewText: "Great", ReviewReply: "Thanks"}, true},
+		"reply changed":  {SyncedReview{Rating: floatPtr(4), ReviewText: "Good"}, true},
+	}
+	for name, tt := range tests {
+		if got := reviewEdited(stored, &tt.incoming); got != tt.want {
+			t.Errorf("%s: reviewEdited = %v, want %v", name, got, tt.want)
+		}
+	}
+	if reviewEdited(&SyncedReview{}, &SyncedReview{}) {
+		t.Error("two unrated reviews reported as edited")
+	}
+}
+
+// revisionDB is a memDB that records review revisions, or fails to when err is set
+type revisionDB struct {
+	*memDB
+	revisions []*SyncedReviewRevision
+	err       error
+}
+
+func (db *revisionDB) CreateSyncedReviewRevision(rev *SyncedReviewRevision) error {
+	if db.err != nil {
+		return db.err
+	}
+	db.revisions = append(db.revisions, rev)
+	return nil
+}
+
+func editedReviewSync(t *testing.T, revisionErr error) (*revisionDB, *SyncStats) {
+	t.Helper()
+	db := &revisionDB{memDB: newMemDB(testAPIConnection(1)), err: revisionErr}
+	db.reviews[1] = &SyncedReview{ID: 1, MerchantID: 7, Platform: PlatformGoogleBusiness, PlatformReviewID: "r1",
+		Rating: floatPtr(5), ReviewText: "Lovely", IsVisible: true}
+	provider := &fakeProvider{platform: PlatformGoogleBusiness, reviews: []*Review{
+		{PlatformReviewID: "r1", AuthorName: "Aina", Rating: floatPtr(2), ReviewText: "Went downhill", ReviewedAt: time.Now()},
+	}}
+
+	stats, err := newTestSyncService(db, provider).SyncConnection(1, SyncTypeManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, stats
+}
+
+func TestSyncRecordsEditedReviewRevision(t *testing.T) {
+	db, stats := editedReviewSync(t, nil)
+
+	if len(db.revisions) != 1 {
+		t.Fatalf("wrote %d revisions, want 1", len(db.revisions))
+	}
+	if rev := db.revisions[0]; rev.SyncedReviewID != 1 || rev.ReviewText != "Lovely" || *rev.Rating != 5 {
+		t.Errorf("revision = %+v, want the review as it was", rev)
+	}
+	if review := db.reviews[1]; review.ReviewText != "Went downhill" || stats.TotalUpdated != 1 {
+		t.Errorf("review text %q with %d updated, want the edit stored", review.ReviewText, stats.TotalUpdated)
+	}
+}
+
+func TestSyncKeepsReviewWhenRevisionFails(t *testing.T) {
+	db, stats := editedReviewSync(t, errors.New("insert failed"))
+
+	if review := db.reviews[1]; review.ReviewText != "Lovely" {
+		t.Errorf("review text %q, want it left for the next sync", review.ReviewText)
+	}
+	if stats.TotalUpdated != 0 || len(stats.Errors) != 1 {
+		t.Errorf("stats = %+v, want the error and no update", stats)
+	}
+}
@@ -0,0 +1,76 @@
+package socialmedia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReviewOrder controls how a public review list is ordered
+type ReviewOrder string
+
+const (
+	ReviewOrderChronological ReviewOrder = "chronological" // Newest first across every platform (default)
+	ReviewOrderBalanced      ReviewOrder = "balanced"      // Platforms take turns, newest first within each
+)
+
+// ParseReviewOrder reads an ?order= value, defaulting to chronological
+func ParseReviewOrder(value string) ReviewOrder {
+	if ReviewOrder(strings.ToLower(strings.TrimSpace(value))) == ReviewOrderBalanced {
+		return ReviewOrderBalanced
+	}
+	return ReviewOrderChronological
+}
+
+// queryBalancedReviews fetches the newest offset+limit matching reviews of each
+// platform and interleaves them, so one busy platform can't fill the page
+func (db *DB) queryBalancedReviews(where string, limit, offset int, args ...interface{}) ([]*SyncedReview, error) {
+	var batches [][]*SyncedReview
+	for _, platform := range SupportedPlatforms {
+		platformArgs := append(append([]interface{}{}, args...), platform)
+		platformWhere := fmt.Sprintf("%s AND platform = $%d", where, len(platformArgs))
+
+		batch, err := db.querySyncedReviews(platformWhere, offset+limit, 0, platformArgs...)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+
+	reviews := interleaveByPlatform(batches)
+	if offset >= len(reviews) {
+		return []*SyncedReview{}, nil
+	}
+	end := offset + limit
+	if end > len(reviews) {
+		end = len(reviews)
+	}
+	return reviews[offset:end], nil
+}
+
+// interleaveByPlatform merges per-platform batches (each newest first) round-robin.
+// The platform with the most recent review goes first in every round; once a
+// platform runs out the others keep taking turns.
+func interleaveByPlatform(batches [][]*SyncedReview) []*SyncedReview {
+	batches = append([][]*SyncedReview(nil), batches...)
+	sort.SliceStable(batches, func(i, j int) bool {
+		return batches[i][0].ReviewedAt.After(batches[j][0].ReviewedAt)
+	})
+
+	total := 0
+	for _, batch := range batches {
+		total += len(batch)
+	}
+
+	merged := make([]*SyncedReview, 0, total)
+	for round := 0; len(merged) < total; round++ {
+		for _, batch := range batches {
+			if round < len(batch) {
+				merged = append(merged, batch[round])
+			}
+		}
+	}
+	return merged
+}
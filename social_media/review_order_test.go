@@ -0,0 +1,84 @@
+package socialmedia
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReviewOrder(t *testing.T) {
+	for value, want := range map[string]ReviewOrder{
+		"":              ReviewOrderChronological,
+		"balanced":      ReviewOrderBalanced,
+		" Balanced ":    ReviewOrderBalanced,
+		"chronological": ReviewOrderChronological,
+		"random":        ReviewOrderChronological,
+	} {
+		if got := ParseReviewOrder(value); got != want {
+			t.Errorf("ParseReviewOrder(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestInterleaveByPlatform(t *testing.T) {
+	now := time.Now()
+	batch := func(prefix string, count int, newest time.Time) []*SyncedReview {
+		var reviews []*SyncedReview
+		for i := 0; i < count; i++ {
+			reviews = append(reviews, &SyncedReview{PlatformReviewID: fmt.Sprintf("%s%d", prefix, i+1), ReviewedAt: newest.Add(-time.Duration(i) * time.Hour)})
+		}
+		return reviews
+	}
+
+	// Google is busiest, but Facebook has the most recent review so it leads each round
+	merged := interleaveByPlatform([][]*SyncedReview{
+		batch("g", 4, now.Add(-time.Hour)),
+		batch("f", 2, now),
+		batch("i", 1, now.Add(-2*time.Hour)),
+	})
+
+	var ids []string
+	for _, review := range merged {
+		ids = append(ids, review.PlatformReviewID)
+	}
+	if got := strings.Join(ids, " "); got != "f1 g1 i1 f2 g2 g3 g4" {
+		t.Errorf("order = %s, want f1 g1 i1 f2 g2 g3 g4", got)
+	}
+
+	if merged := interleaveByPlatform(nil); len(merged) != 0 {
+		t.Errorf("no batches: got %d reviews", len(merged))
+	}
+}
+
+func TestGetPublicReviewsBalanced(t *testing.T) {
+	db := tableDB(t, syncedReviewTable(publicReviewFixture()...))
+	opts := PublicReviewOptions{Order: ReviewOrderBalanced, TextlessPolicy: TextlessShow}
+
+	// Facebook has the newest review so it leads each round until Google runs out
+	reviews, err := db.GetPublicReviews(7, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[1 3 2 4 7]" {
+		t.Errorf("order = %s, want [1 3 2 4 7]", got)
+	}
+
+	// Pages are cut from the merged order
+	opts.Limit, opts.Offset = 2, 1
+	if reviews, err = db.GetPublicReviews(7, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[3 2]" {
+		t.Errorf("second page = %s, want [3 2]", got)
+	}
+
+	// A platform filter leaves nothing to balance
+	opts = PublicReviewOptions{Order: ReviewOrderBalanced, Platform: PlatformFacebook, TextlessPolicy: TextlessShow}
+	if reviews, err = db.GetPublicReviews(7, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := reviewIDs(reviews); got != "[1 2 4 7]" {
+		t.Errorf("Facebook only = %s, want [1 2 4 7]", got)
+	}
+}
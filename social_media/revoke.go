@@ -0,0 +1,18 @@
+package socialmedia
+
+// RevokeConnectionToken decrypts a connection's access token and revokes the
+// grant with the platform, so disconnecting doesn't leave a live authorization
+// on the merchant's account
+func (s *SyncService) RevokeConnectionToken(conn *APIConnection) error {
+	provider, ok := s.GetProvider(conn.Platform)
+	if !ok {
+		return &ErrProviderNotFound{Platform: conn.Platform}
+	}
+
+	accessToken, err := s.encryptor.Decrypt(conn.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	return provider.RevokeToken(accessToken)
+}
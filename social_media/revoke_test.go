@@ -0,0 +1,100 @@
+package socialmedia
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestProvidersRevokeGrant(t *testing.T) {
+	google := NewGoogleBusinessProvider("id", "secret", "https://example.com/callback")
+	facebook := NewFacebookProvider("id", "secret", "https://example.com/callback")
+	instagram := NewInstagramProvider("id", "secret", "https://example.com/callback")
+
+	tests := []struct {
+		name     string
+		client   **http.Client
+		revoke   func(string) error
+		method   string
+		endpoint string
+	}{
+		{"google", &google.httpClient, google.RevokeToken, http.MethodPost, "oauth2.googleapis.com/revoke"},
+		{"facebook", &facebook.httpClient, facebook.RevokeToken, http.MethodDelete, "graph.facebook.com/v18.0/me/permissions"},
+		{"instagram", &instagram.httpClient, instagram.RevokeToken, http.MethodDelete, "graph.facebook.com/v18.0/me/permissions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, endpoint, token string
+			*tt.client = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				method, endpoint = r.Method, r.Host+r.URL.Path
+				r.ParseForm()
+				token = r.Form.Get("token") + r.Form.Get("access_token")
+			})
+
+			if err := tt.revoke("secret-token"); err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || endpoint != tt.endpoint || token != "secret-token" {
+				t.Errorf("called %s %s with token %q, want %s %s with the access token", method, endpoint, token, tt.method, tt.endpoint)
+			}
+		})
+	}
+}
+
+func TestRevokeTokenReportsPlatformRefusal(t *testing.T) {
+	p := NewGoogleBusinessProvider("id", "secret", "https://example.com/callback")
+	p.httpClient = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid_token"}`, http.StatusBadRequest)
+	})
+
+	var providerErr *ProviderError
+	if err := p.RevokeToken("secret-token"); !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want a ProviderError with status 400", err)
+	}
+}
+
+// revokeRecorder is a provider that records the tokens it was asked to revoke
+type revokeRecorder struct {
+	fakeProvider
+	revoked []string
+}
+
+func (p *revokeRecorder) RevokeToken(accessToken string) error {
+	p.revoked = append(p.revoked, accessToken)
+	return nil
+}
+
+// reversingEncryptor "encrypts" by reversing, so a missing Decrypt shows up
+type reversingEncryptor struct{}
+
+func (reversingEncryptor) Encrypt(plaintext string) (string, error)  { return reverse(plaintext), nil }
+func (reversingEncryptor) Decrypt(ciphertext string) (string, error) { return reverse(ciphertext), nil }
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func TestRevokeConnectionTokenDecrypts(t *testing.T) {
+	provider := &revokeRecorder{fakeProvider: fakeProvider{platform: PlatformGoogleBusiness}}
+	s := NewSyncService(newMemDB(), reversingEncryptor{})
+	s.RegisterProvider(provider)
+
+	conn := testAPIConnection(1)
+	conn.AccessToken = "nekot-terces"
+	if err := s.RevokeConnectionToken(conn); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.revoked) != 1 || provider.revoked[0] != "secret-token" {
+		t.Errorf("revoked %q, want the decrypted token", provider.revoked)
+	}
+
+	conn.Platform = "myspace"
+	var notFound *ErrProviderNotFound
+	if err := s.RevokeConnectionToken(conn); !errors.As(err, &notFound) {
+		t.Errorf("unknown platform: err = %v, want ErrProviderNotFound", err)
+	}
+}
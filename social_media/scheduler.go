@@ -1,22 +1,46 @@
 package socialmedia
 
 import (
+	"context"
 	"log"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
 // Scheduler handles periodic synchronization of reviews from social media platforms
 type Scheduler struct {
-	syncService  *SyncService
-	interval     time.Duration
-	batchSize    int
-	ticker       *time.Ticker
-	stopChan     chan struct{}
-	isRunning    bool
+	syncService *SyncService
+	interval    time.Duration
+	batchSize   int
+
+	// Token refresh runs on its own schedule so it doesn't wait on review syncs
+	tokenRefreshInterval time.Duration
+
+	// mu guards the run state below; Start, Stop and GetStatus may be called
+	// from different goroutines (e.g. shutdown while a status request is served)
+	mu                 sync.Mutex
+	ticker             *time.Ticker
+	tokenRefreshTicker *time.Ticker
+	stopChan           chan struct{}
+	isRunning          bool
+	startedAt          time.Time // when the sync ticker was started, for next_run_at
+
+	// Outcome of the most recent scheduled sync
+	lastRunAt        time.Time
+	lastRunDuration  time.Duration
+	lastRunSucceeded int
+	lastRunFailed    int
+	lastRunError     string
+
+	// workers tracks the goroutines started by Start so Shutdown can wait for them
+	workers sync.WaitGroup
 }
 
+// initialSyncDelay is how long after Start the first sync runs
+const initialSyncDelay = 30 * time.Second
+
 // NewScheduler creates a new scheduler with the sync service
 func NewScheduler(syncService *SyncService) *Scheduler {
 	// Get interval from environment or use default (6 hours)
@@ -35,17 +59,28 @@ func NewScheduler(syncService *SyncService) *Scheduler {
 		}
 	}
 
+	// Get token refresh interval from environment or use default (1 hour)
+	refreshHours := 1
+	if envRefresh := os.Getenv("TOKEN_REFRESH_INTERVAL_HOURS"); envRefresh != "" {
+		if parsed, err := strconv.Atoi(envRefresh); err == nil && parsed > 0 {
+			refreshHours = parsed
+		}
+	}
+
 	return &Scheduler{
-		syncService: syncService,
-		interval:    time.Duration(intervalHours) * time.Hour,
-		batchSize:   batchSize,
-		stopChan:    make(chan struct{}),
-		isRunning:   false,
+		syncService:          syncService,
+		interval:             time.Duration(intervalHours) * time.Hour,
+		batchSize:            batchSize,
+		tokenRefreshInterval: time.Duration(refreshHours) * time.Hour,
 	}
 }
 
-// Start begins the scheduled synchronization
+// Start begins the scheduled synchronization. Calling it while the scheduler
+// is already running does nothing, and it can be started again after Stop.
 func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.isRunning {
 		log.Println("[Scheduler] Already running")
 		return
@@ -53,23 +88,41 @@ func (s *Scheduler) Start() {
 
 	s.isRunning = true
 	s.ticker = time.NewTicker(s.interval)
+	s.tokenRefreshTicker = time.NewTicker(s.tokenRefreshInterval)
+	s.stopChan = make(chan struct{})
+	s.startedAt = time.Now()
 
-	log.Printf("[Scheduler] Starting with interval: %v, batch size: %d\n", s.interval, s.batchSize)
+	// The goroutines use their own copies so a later Start/Stop can't swap them out
+	ticker, tokenRefreshTicker, stop := s.ticker, s.tokenRefreshTicker, s.stopChan
 
-	// Run initial sync after a short delay
+	log.Printf("[Scheduler] Starting with interval: %v, batch size: %d, token refresh interval: %v\n",
+		s.interval, s.batchSize, s.tokenRefreshInterval)
+
+	s.workers.Add(2)
+
+	// Refresh tokens first, then run the initial sync after a short delay
 	go func() {
-		time.Sleep(30 * time.Second)
-		s.runSync()
+		defer s.workers.Done()
+		select {
+		case <-time.After(initialSyncDelay):
+			s.runTokenRefresh()
+			s.runSync()
+		case <-stop:
+		}
 	}()
 
-	// Run periodic syncs
+	// Run periodic syncs and token refreshes
 	go func() {
+		defer s.workers.Done()
 		for {
 			select {
-			case <-s.ticker.C:
+			case <-ticker.C:
 				s.runSync()
-			case <-s.stopChan:
-				s.ticker.Stop()
+			case <-tokenRefreshTicker.C:
+				s.runTokenRefresh()
+			case <-stop:
+				ticker.Stop()
+				tokenRefreshTicker.Stop()
 				log.Println("[Scheduler] Stopped")
 				return
 			}
@@ -79,24 +132,63 @@ func (s *Scheduler) Start() {
 
 // Stop stops the scheduled synchronization
 func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.isRunning {
 		return
 	}
 
 	s.isRunning = false
+	s.ticker = nil
+	s.tokenRefreshTicker = nil
 	close(s.stopChan)
 }
 
+// Shutdown stops the scheduler and waits for a sync or token refresh that is
+// already running to finish, including syncs started in the background after
+// a connect or reconnect, or for ctx to be done, whichever comes first
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.syncService.WaitForBackgroundSyncs(ctx)
+}
+
 // runSync executes the synchronization process
 func (s *Scheduler) runSync() {
 	log.Println("[Scheduler] Starting scheduled sync...")
 
 	startTime := time.Now()
+	successCount := 0
+	failCount := 0
+	var runErr error
+	defer func() {
+		s.recordRun(startTime, successCount, failCount, runErr)
+	}()
+
+	// Prune old sync logs so the table doesn't grow without bound
+	if deleted, err := s.syncService.CleanupSyncLogs(); err != nil {
+		log.Printf("[Scheduler] Error cleaning up sync logs: %v\n", err)
+	} else if deleted > 0 {
+		log.Printf("[Scheduler] Deleted %d old sync log(s)\n", deleted)
+	}
 
 	// Get all active connections
 	connections, err := s.syncService.db.GetActiveConnections()
 	if err != nil {
 		log.Printf("[Scheduler] Error getting active connections: %v\n", err)
+		runErr = err
 		return
 	}
 
@@ -108,9 +200,6 @@ func (s *Scheduler) runSync() {
 	log.Printf("[Scheduler] Found %d active connection(s)\n", len(connections))
 
 	// Sync connections in batches
-	successCount := 0
-	failCount := 0
-
 	for i := 0; i < len(connections); i += s.batchSize {
 		end := i + s.batchSize
 		if end > len(connections) {
@@ -173,6 +262,30 @@ func (s *Scheduler) runSync() {
 		duration, successCount, failCount)
 }
 
+// recordRun stores the outcome of a sync for GetStatus
+func (s *Scheduler) recordRun(startTime time.Time, succeeded, failed int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRunAt = startTime
+	s.lastRunDuration = time.Since(startTime)
+	s.lastRunSucceeded = succeeded
+	s.lastRunFailed = failed
+	s.lastRunError = ""
+	if err != nil {
+		s.lastRunError = err.Error()
+	}
+}
+
+// runTokenRefresh refreshes tokens that are about to expire, independent of review syncs
+func (s *Scheduler) runTokenRefresh() {
+	if refreshed, err := s.syncService.RefreshExpiringTokens(); err != nil {
+		log.Printf("[Scheduler] Error refreshing expiring tokens: %v\n", err)
+	} else if refreshed > 0 {
+		log.Printf("[Scheduler] Refreshed %d expiring token(s)\n", refreshed)
+	}
+}
+
 // SyncResult holds the result of a sync operation
 type SyncResult struct {
 	ConnectionID int
@@ -187,24 +300,59 @@ func (s *Scheduler) RunManualSync(connectionID int) (*SyncStats, error) {
 	return s.syncService.SyncConnection(connectionID, SyncTypeManual)
 }
 
-// GetStatus returns the current status of the scheduler
+// IsRunning reports whether the scheduler has been started and not stopped
+func (s *Scheduler) IsRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isRunning
+}
+
+// GetStatus returns the current status of the scheduler, including when the
+// next sync is due and how the last one went. Times are nil when not applicable.
 func (s *Scheduler) GetStatus() map[string]interface{} {
-	return map[string]interface{}{
-		"is_running":   s.isRunning,
-		"interval":     s.interval.String(),
-		"batch_size":   s.batchSize,
-		"next_run_in":  s.getTimeUntilNextRun(),
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	status := map[string]interface{}{
+		"is_running":             s.isRunning,
+		"interval":               s.interval.String(),
+		"batch_size":             s.batchSize,
+		"token_refresh_interval": s.tokenRefreshInterval.String(),
+		"next_run_at":            nil,
+		"next_run_in":            "N/A",
+		"last_run_at":            nil,
+		"last_run_duration":      nil,
+		"last_run_succeeded":     s.lastRunSucceeded,
+		"last_run_failed":        s.lastRunFailed,
+		"last_run_error":         s.lastRunError,
 	}
+
+	if next, ok := s.nextRunAt(now); ok {
+		status["next_run_at"] = next
+		status["next_run_in"] = next.Sub(now).Round(time.Second).String()
+	}
+	if !s.lastRunAt.IsZero() {
+		status["last_run_at"] = s.lastRunAt
+		status["last_run_duration"] = s.lastRunDuration.Round(time.Millisecond).String()
+	}
+	return status
 }
 
-// getTimeUntilNextRun calculates time until next scheduled run
-func (s *Scheduler) getTimeUntilNextRun() string {
-	if !s.isRunning || s.ticker == nil {
-		return "N/A"
+// nextRunAt is when the next scheduled sync will start: the delayed initial
+// sync if it hasn't happened yet, otherwise the next tick of the sync ticker,
+// which fires every interval from startedAt. Callers hold s.mu.
+func (s *Scheduler) nextRunAt(now time.Time) (time.Time, bool) {
+	if !s.isRunning || s.interval <= 0 {
+		return time.Time{}, false
+	}
+
+	if initial := s.startedAt.Add(initialSyncDelay); now.Before(initial) && s.lastRunAt.Before(s.startedAt) {
+		return initial, true
 	}
 
-	// This is an approximation since we can't directly get the next tick time
-	return s.interval.String()
+	ticks := now.Sub(s.startedAt)/s.interval + 1
+	return s.startedAt.Add(ticks * s.interval), true
 }
 
 // SyncStats helper methods
@@ -0,0 +1,110 @@
+package socialmedia
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	t.Setenv("SYNC_INTERVAL_HOURS", "6")
+	s := NewScheduler(newTestSyncService(newMemDB(), &fakeProvider{platform: PlatformGoogleBusiness}))
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s
+}
+
+func TestSchedulerConcurrentStartStop(t *testing.T) {
+	s := newTestScheduler(t)
+
+	// Run with -race: every access to the run state goes through the mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() { defer wg.Done(); s.Start() }()
+		go func() { defer wg.Done(); s.GetStatus(); s.IsRunning() }()
+		go func() { defer wg.Done(); s.Stop() }()
+	}
+	wg.Wait()
+
+	s.Stop()
+	if s.IsRunning() {
+		t.Error("running after Stop")
+	}
+}
+
+func TestSchedulerRestartAfterStop(t *testing.T) {
+	s := newTestScheduler(t)
+
+	s.Start()
+	s.Start()
+	if !s.IsRunning() {
+		t.Fatal("not running after Start")
+	}
+	s.Stop()
+	s.Stop()
+
+	// A second Start needs a fresh stop channel; reusing the closed one would end it at once
+	s.Start()
+	if !s.IsRunning() {
+		t.Fatal("not running after a restart")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown before the initial sync: %v", err)
+	}
+	if s.IsRunning() {
+		t.Error("running after Shutdown")
+	}
+}
+
+func TestSchedulerNextRunAt(t *testing.T) {
+	started := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	s := &Scheduler{interval: 6 * time.Hour, isRunning: true, startedAt: started}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		lastRun time.Time
+		want    time.Time
+	}{
+		{"before the initial sync", started.Add(10 * time.Second), time.Time{}, started.Add(initialSyncDelay)},
+		{"after the initial sync", started.Add(time.Minute), started.Add(initialSyncDelay), started.Add(6 * time.Hour)},
+		{"between ticks", started.Add(13 * time.Hour), started.Add(12 * time.Hour), started.Add(18 * time.Hour)},
+		{"on a tick", started.Add(12 * time.Hour), started.Add(6 * time.Hour), started.Add(18 * time.Hour)},
+	}
+	for _, tt := range tests {
+		s.lastRunAt = tt.lastRun
+		if got, ok := s.nextRunAt(tt.now); !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: next run %s (%v), want %s", tt.name, got, ok, tt.want)
+		}
+	}
+
+	s.isRunning = false
+	if _, ok := s.nextRunAt(started); ok {
+		t.Error("stopped scheduler reported a next run")
+	}
+}
+
+func TestSchedulerStatusReportsLastRun(t *testing.T) {
+	s := newTestScheduler(t)
+
+	status := s.GetStatus()
+	if status["last_run_at"] != nil || status["next_run_at"] != nil || status["next_run_in"] != "N/A" {
+		t.Errorf("status before any run = %v", status)
+	}
+
+	s.recordRun(time.Now().Add(-2*time.Second), 3, 1, errors.New("database unavailable"))
+	s.Start()
+	status = s.GetStatus()
+	if status["last_run_succeeded"] != 3 || status["last_run_failed"] != 1 || status["last_run_error"] != "database unavailable" {
+		t.Errorf("last run = %v", status)
+	}
+	if status["last_run_at"] == nil || status["last_run_duration"] == nil || status["next_run_at"] == nil || status["is_running"] != true {
+		t.Errorf("status = %v, want the last and next run times", status)
+	}
+}
@@ -0,0 +1,34 @@
+package socialmedia
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// Default OAuth scopes requested by each provider. Operators can replace them
+// with the <PLATFORM>_SCOPES env vars, e.g. to request reply permissions.
+var (
+	defaultFacebookScopes       = []string{"pages_show_list", "pages_read_engagement", "pages_manage_metadata"}
+	defaultInstagramScopes      = []string{"instagram_basic", "instagram_manage_comments", "instagram_manage_insights", "pages_show_list"}
+	defaultGoogleBusinessScopes = []string{"https://www.googleapis.com/auth/business.manage"}
+	defaultThreadsScopes        = []string{"threads_basic", "threads_read_replies", "threads_manage_mentions"}
+)
+
+// scopesFromEnv returns the scopes listed in env var key, separated by commas
+// or spaces. An unset or blank override falls back to defaults.
+func scopesFromEnv(key string, defaults []string) []string {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return defaults
+	}
+
+	scopes := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(scopes) == 0 {
+		log.Printf("%s is empty, using the default scopes", key)
+		return defaults
+	}
+	return scopes
+}
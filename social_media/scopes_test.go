@@ -0,0 +1,48 @@
+package socialmedia
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestScopesFromEnv(t *testing.T) {
+	defaults := []string{"a", "b"}
+	tests := map[string][]string{
+		"pages_show_list,pages_manage_posts":   {"pages_show_list", "pages_manage_posts"},
+		" pages_show_list  pages_manage_posts": {"pages_show_list", "pages_manage_posts"},
+		"one, two\tthree":                      {"one", "two", "three"},
+		" , ":                                  defaults,
+	}
+	for raw, want := range tests {
+		t.Setenv("TEST_SCOPES", raw)
+		if got := scopesFromEnv("TEST_SCOPES", defaults); !reflect.DeepEqual(got, want) {
+			t.Errorf("scopesFromEnv(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestAuthorizationURLScopes(t *testing.T) {
+	scope := func(authURL string) string {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query().Get("scope")
+	}
+
+	t.Setenv("GOOGLE_BUSINESS_SCOPES", "")
+	if got := scope(NewGoogleBusinessProvider("id", "secret", "https://example.com/cb").GetAuthorizationURL("s")); got != defaultGoogleBusinessScopes[0] {
+		t.Errorf("default Google scope = %q", got)
+	}
+
+	// Google joins scopes with spaces, Meta with commas
+	t.Setenv("GOOGLE_BUSINESS_SCOPES", "scope-a,scope-b")
+	if got := scope(NewGoogleBusinessProvider("id", "secret", "https://example.com/cb").GetAuthorizationURL("s")); got != "scope-a scope-b" {
+		t.Errorf("Google scope = %q, want space separated", got)
+	}
+	t.Setenv("FACEBOOK_SCOPES", "scope-a scope-b")
+	if got := scope(NewFacebookProvider("id", "secret", "https://example.com/cb").GetAuthorizationURL("s")); got != "scope-a,scope-b" {
+		t.Errorf("Facebook scope = %q, want comma separated", got)
+	}
+}
@@ -0,0 +1,77 @@
+package socialmedia
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// reviewSearchDocument is the indexed text of a review; it must match
+// idx_synced_reviews_search exactly for Postgres to use the index
+const reviewSearchDocument = `to_tsvector('simple', COALESCE(author_name, '') || ' ' || COALESCE(review_text, ''))`
+
+var (
+	searchIndexOnce    sync.Once
+	searchIndexPresent bool
+)
+
+// hasSearchIndex reports whether the full-text index migration has been applied.
+// Checked once per process; without it searches fall back to ILIKE.
+func (db *DB) hasSearchIndex() bool {
+	searchIndexOnce.Do(func() {
+		err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_synced_reviews_search')`).Scan(&searchIndexPresent)
+		if err != nil {
+			log.Printf("Failed to check for the review search index, using ILIKE: %v", err)
+			searchIndexPresent = false
+		}
+	})
+	return searchIndexPresent
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchSyncedReviews finds a merchant's reviews, hidden ones included, whose
+// author name or text matches q. Results are ordered by relevance then recency
+// and returned with the total number of matches.
+func (db *DB) SearchSyncedReviews(merchantID int, q string, limit, offset int) ([]*SyncedReview, int, error) {
+	var match, order string
+	var arg interface{}
+	if db.hasSearchIndex() {
+		// plainto_tsquery treats the input as plain words, so operators and
+		// punctuation in q can't produce a syntax error
+		match = reviewSearchDocument + ` @@ plainto_tsquery('simple', $2)`
+		order = `ts_rank(` + reviewSearchDocument + `, plainto_tsquery('simple', $2)) DESC, reviewed_at DESC`
+		arg = q
+	} else {
+		match = `(author_name ILIKE $2 OR review_text ILIKE $2)`
+		order = `reviewed_at DESC`
+		arg = "%" + escapeLike(q) + "%"
+	}
+	where := "merchant_id = $1 AND " + match
+
+	var total int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM synced_reviews WHERE `+where, merchantID, arg).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM synced_reviews
+		WHERE %s
+		ORDER BY %s
+		LIMIT $3 OFFSET $4
+	`, syncedReviewColumns, where, order)
+
+	rows, err := db.conn.Query(query, merchantID, arg, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews, err := scanSyncedReviews(rows)
+	return reviews, total, err
+}
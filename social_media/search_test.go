@@ -0,0 +1,83 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50% off_now\`); got != `50\% off\_now\\` {
+		t.Errorf("escapeLike = %q, want the wildcards and backslash escaped", got)
+	}
+}
+
+// searchWords splits text into words the way the 'simple' search config does
+var searchWords = regexp.MustCompile(`[[:alnum:]]+`)
+
+// matchesTSQuery is plainto_tsquery for the fake table: the document must
+// contain every word of the query
+func matchesTSQuery(row fakedb.Row, args []driver.Value) bool {
+	doc := map[string]bool{}
+	for _, w := range searchWords.FindAllString(strings.ToLower(row["author_name"].(string)+" "+row["review_text"].(string)), -1) {
+		doc[w] = true
+	}
+	for _, w := range searchWords.FindAllString(strings.ToLower(args[1].(string)), -1) {
+		if !doc[w] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchSyncedReviews(t *testing.T) {
+	now := time.Now()
+	table := syncedReviewTable(
+		&SyncedReview{ID: 1, MerchantID: 7, AuthorName: "Aina", ReviewText: "Got 50% off the latte", IsVisible: true, ReviewedAt: now},
+		&SyncedReview{ID: 2, MerchantID: 7, AuthorName: "Ben", ReviewText: "500 off is a typo", IsVisible: true, ReviewedAt: now.Add(-time.Hour)},
+		&SyncedReview{ID: 3, MerchantID: 8, AuthorName: "Chen", ReviewText: "50% off here too", IsVisible: true, ReviewedAt: now.Add(-2 * time.Hour)},
+		&SyncedReview{ID: 4, MerchantID: 7, AuthorName: "Dev", ReviewText: "Hidden, but 50% OFF", ReviewedAt: now.Add(-3 * time.Hour)},
+	)
+	table.Conditions[strings.Join(strings.Fields(reviewSearchDocument), " ")+" @@ plainto_tsquery('simple', $2)"] = matchesTSQuery
+
+	for _, indexed := range []bool{true, false} {
+		// The index check runs once per process; reset it for each case
+		searchIndexOnce = sync.Once{}
+		t.Cleanup(func() { searchIndexOnce = sync.Once{} })
+
+		conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+			switch {
+			case strings.Contains(query, "pg_indexes"):
+				return &fakedb.Result{Columns: []string{"exists"}, Rows: [][]driver.Value{{indexed}}}, nil
+			case strings.Contains(query, "COUNT(*)"):
+				return table.Count(query, args)
+			}
+			rows, err := table.Match(query, args)
+			return &fakedb.Result{Columns: table.Columns, Rows: rows}, err
+		})
+		db := NewDB(conn)
+
+		// Hidden reviews are included; another merchant's and "500 off" aren't
+		reviews, total, err := db.SearchSyncedReviews(7, "50% off", 10, 0)
+		if err != nil {
+			t.Fatalf("indexed %v: %v", indexed, err)
+		}
+		if got := reviewIDs(reviews); got != "[1 4]" || total != 2 {
+			t.Errorf("indexed %v: found %s of %d, want [1 4] of 2", indexed, got, total)
+		}
+
+		reviews, total, err = db.SearchSyncedReviews(7, "50% off", 1, 1)
+		if err != nil {
+			t.Fatalf("indexed %v: %v", indexed, err)
+		}
+		if got := reviewIDs(reviews); got != "[4]" || total != 2 {
+			t.Errorf("indexed %v: second page is %s of %d, want [4] of 2", indexed, got, total)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,121 @@
+package socialmedia
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sentiment labels stored on synced reviews
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+// SentimentAnalyzer classifies review text. Implementations may call an
+// external API; the default is LexiconAnalyzer. Score ranges from -1 to 1.
+type SentimentAnalyzer interface {
+	Analyze(text string) (label string, score float64, err error)
+}
+
+// lexiconNeutralBand is how far from zero a score must be to count as positive or negative
+const lexiconNeutralBand = 0.1
+
+// positiveWords and negativeWords cover common English review vocabulary plus
+// a few Malay words seen in local reviews
+var (
+	positiveWords = wordSet("good", "great", "excellent", "amazing", "awesome", "best", "love", "loved",
+		"lovely", "nice", "friendly", "delicious", "tasty", "fantastic", "perfect", "recommend",
+		"recommended", "helpful", "clean", "fresh", "fast", "wonderful", "happy", "polite",
+		"professional", "worth", "beautiful", "enjoyed", "satisfied", "comfortable",
+		"sedap", "bagus", "mantap", "terbaik", "puas")
+	negativeWords = wordSet("bad", "terrible", "awful", "worst", "horrible", "poor", "rude", "dirty",
+		"slow", "overpriced", "expensive", "disappointed", "disappointing", "bland",
+		"waste", "wrong", "broken", "unfriendly", "avoid", "hate", "hated", "stale",
+		"noisy", "unprofessional", "mediocre", "late",
+		"teruk", "lambat", "mahal", "kotor", "kecewa")
+	negationWords = wordSet("not", "no", "never", "isn't", "wasn't", "don't", "didn't", "won't",
+		"hardly", "tak", "tidak", "bukan")
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// LexiconAnalyzer scores text by counting positive and negative words. A
+// negation directly before a word flips it ("not good" counts as negative).
+type LexiconAnalyzer struct{}
+
+// NewLexiconAnalyzer returns the default, dependency-free analyzer
+func NewLexiconAnalyzer() *LexiconAnalyzer {
+	return &LexiconAnalyzer{}
+}
+
+// Analyze implements SentimentAnalyzer
+func (a *LexiconAnalyzer) Analyze(text string) (string, float64, error) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+
+	var positive, negative int
+	for i, word := range words {
+		polarity := 0
+		switch {
+		case positiveWords[word]:
+			polarity = 1
+		case negativeWords[word]:
+			polarity = -1
+		}
+		if polarity == 0 {
+			continue
+		}
+		if i > 0 && negationWords[words[i-1]] {
+			polarity = -polarity
+		}
+		if polarity > 0 {
+			positive++
+		} else {
+			negative++
+		}
+	}
+
+	if positive+negative == 0 {
+		return SentimentNeutral, 0, nil
+	}
+
+	score := float64(positive-negative) / float64(positive+negative)
+	switch {
+	case score > lexiconNeutralBand:
+		return SentimentPositive, score, nil
+	case score < -lexiconNeutralBand:
+		return SentimentNegative, score, nil
+	}
+	return SentimentNeutral, score, nil
+}
+
+// SetSentimentAnalyzer replaces the analyzer used during sync, e.g. with an external API backend
+func (s *SyncService) SetSentimentAnalyzer(analyzer SentimentAnalyzer) {
+	s.sentiment = analyzer
+}
+
+// classifySentiment fills in the review's sentiment. Reviews without text are
+// left unclassified, as are reviews the analyzer fails on.
+func (s *SyncService) classifySentiment(review *SyncedReview) error {
+	review.Sentiment = ""
+	review.SentimentScore = nil
+	if s.sentiment == nil || strings.TrimSpace(review.ReviewText) == "" {
+		return nil
+	}
+
+	label, score, err := s.sentiment.Analyze(review.ReviewText)
+	if err != nil {
+		return err
+	}
+	review.Sentiment = label
+	review.SentimentScore = &score
+	return nil
+}
@@ -0,0 +1,68 @@
+package socialmedia
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLexiconAnalyzer(t *testing.T) {
+	tests := []struct {
+		text  string
+		label string
+		score float64
+	}{
+		{"Great coffee, friendly staff!", SentimentPositive, 1},
+		{"Rude staff and dirty tables", SentimentNegative, -1},
+		{"The coffee was not good", SentimentNegative, -1},
+		{"Good food but slow service", SentimentNeutral, 0},
+		{"Makanan sedap, tapi mahal", SentimentNeutral, 0},
+		{"Sedap dan terbaik", SentimentPositive, 1},
+		{"We came on Tuesday", SentimentNeutral, 0},
+		{"", SentimentNeutral, 0},
+	}
+	for _, tt := range tests {
+		label, score, err := NewLexiconAnalyzer().Analyze(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if label != tt.label || score != tt.score {
+			t.Errorf("Analyze(%q) = %s %v, want %s %v", tt.text, label, score, tt.label, tt.score)
+		}
+	}
+}
+
+type failingAnalyzer struct{}
+
+func (failingAnalyzer) Analyze(string) (string, float64, error) {
+	return "", 0, errors.New("sentiment API unavailable")
+}
+
+func TestClassifySentiment(t *testing.T) {
+	s := newTestSyncService(newMemDB(), &fakeProvider{platform: PlatformGoogleBusiness})
+
+	review := &SyncedReview{ReviewText: "Lovely place"}
+	if err := s.classifySentiment(review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Sentiment != SentimentPositive || review.SentimentScore == nil || *review.SentimentScore != 1 {
+		t.Errorf("sentiment = %q %v, want positive 1", review.Sentiment, review.SentimentScore)
+	}
+
+	// A review edited down to a star rating loses its old classification
+	review.ReviewText = "  "
+	if err := s.classifySentiment(review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Sentiment != "" || review.SentimentScore != nil {
+		t.Errorf("textless sentiment = %q %v, want unclassified", review.Sentiment, review.SentimentScore)
+	}
+
+	s.SetSentimentAnalyzer(failingAnalyzer{})
+	review.ReviewText = "Lovely place"
+	if err := s.classifySentiment(review); err == nil {
+		t.Error("analyzer error was swallowed")
+	}
+	if review.Sentiment != "" || review.SentimentScore != nil {
+		t.Errorf("failed sentiment = %q %v, want unclassified", review.Sentiment, review.SentimentScore)
+	}
+}
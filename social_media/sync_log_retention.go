@@ -0,0 +1,18 @@
+package socialmedia
+
+import "time"
+
+// syncLogDeleteBatchSize caps how many sync logs one DELETE removes, keeping locks short
+const syncLogDeleteBatchSize = 1000
+
+// CleanupSyncLogs deletes sync and token refresh logs older than the
+// retention period and returns how many were removed
+func (s *SyncService) CleanupSyncLogs() (int64, error) {
+	cutoff := time.Now().Add(-s.syncLogRetention)
+	deleted, err := s.db.DeleteSyncLogsOlderThan(cutoff)
+	if err != nil {
+		return deleted, err
+	}
+	refreshLogs, err := s.db.DeleteTokenRefreshLogsOlderThan(cutoff)
+	return deleted + refreshLogs, err
+}
@@ -0,0 +1,64 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+func TestDeleteSyncLogsOlderThanDeletesInBatches(t *testing.T) {
+	remaining := int64(2*syncLogDeleteBatchSize + 500)
+	batches := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		batches++
+		deleted := min(remaining, args[1].(int64))
+		remaining -= deleted
+		return &fakedb.Result{RowsAffected: deleted}, nil
+	})
+	defer conn.Close()
+
+	total, err := NewDB(conn).DeleteSyncLogsOlderThan(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2*syncLogDeleteBatchSize+500 || batches != 3 {
+		t.Errorf("deleted %d in %d batches, want %d in 3", total, batches, 2*syncLogDeleteBatchSize+500)
+	}
+}
+
+func TestCleanupSyncLogsUsesRetention(t *testing.T) {
+	t.Setenv("SYNC_LOG_RETENTION_DAYS", "30")
+	cutoffs := map[string]time.Time{}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		table := "sync_logs"
+		if strings.Contains(query, "token_refresh_logs") {
+			table = "token_refresh_logs"
+		}
+		if _, seen := cutoffs[table]; seen {
+			return &fakedb.Result{}, nil
+		}
+		cutoffs[table] = args[0].(time.Time)
+		return &fakedb.Result{RowsAffected: 4}, nil
+	})
+	defer conn.Close()
+
+	deleted, err := NewSyncService(NewDB(conn), plainEncryptor{}).CleanupSyncLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 8 {
+		t.Errorf("deleted %d, want both tables' logs counted", deleted)
+	}
+	want := time.Now().AddDate(0, 0, -30)
+	for table, cutoff := range cutoffs {
+		if d := cutoff.Sub(want); d < -time.Minute || d > time.Minute {
+			t.Errorf("%s cutoff = %s, want 30 days ago", table, cutoff)
+		}
+	}
+	if len(cutoffs) != 2 {
+		t.Errorf("cleaned %v, want sync_logs and token_refresh_logs", cutoffs)
+	}
+}
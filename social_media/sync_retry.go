@@ -0,0 +1,31 @@
+package socialmedia
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	// ErrSyncLogNotFound is returned when retrying a sync log that doesn't exist
+	ErrSyncLogNotFound = errors.New("sync log not found")
+	// ErrSyncLogNotFailed is returned when retrying a sync that didn't fail
+	ErrSyncLogNotFailed = errors.New("only failed syncs can be retried")
+)
+
+// RetrySync re-runs the sync of a failed sync log's connection as a manual
+// sync. The new sync log records which log it retried.
+func (s *SyncService) RetrySync(logID int) (*SyncLog, *SyncStats, error) {
+	original, err := s.db.GetSyncLog(logID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrSyncLogNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if original.Status != SyncStatusFailed {
+		return original, nil, ErrSyncLogNotFailed
+	}
+
+	stats, err := s.syncConnection(original.APIConnectionID, SyncTypeManual, &original.ID)
+	return original, stats, err
+}
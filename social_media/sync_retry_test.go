@@ -0,0 +1,38 @@
+package socialmedia
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetrySync(t *testing.T) {
+	db := newMemDB(testAPIConnection(1))
+	db.syncLogs = []*SyncLog{
+		{ID: 1, APIConnectionID: 1, SyncType: SyncTypeScheduled, Status: SyncStatusFailed, StartedAt: time.Now()},
+		{ID: 2, APIConnectionID: 1, SyncType: SyncTypeScheduled, Status: "completed", StartedAt: time.Now()},
+	}
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+	s := newTestSyncService(db, provider)
+
+	original, stats, err := s.RetrySync(1)
+	if err != nil || original.ID != 1 || stats == nil {
+		t.Fatalf("RetrySync = %v, %v, %v", original, stats, err)
+	}
+	if len(db.syncLogs) != 3 {
+		t.Fatalf("%d sync logs, want the retry added", len(db.syncLogs))
+	}
+	retry := db.syncLogs[2]
+	if retry.SyncType != SyncTypeManual || retry.Status != "completed" || retry.RetryOf == nil || *retry.RetryOf != 1 {
+		t.Errorf("retry log = %+v, want a completed manual sync retrying log 1", retry)
+	}
+
+	if _, _, err := s.RetrySync(2); err != ErrSyncLogNotFailed {
+		t.Errorf("completed log: err = %v, want ErrSyncLogNotFailed", err)
+	}
+	if _, _, err := s.RetrySync(404); err != ErrSyncLogNotFound {
+		t.Errorf("missing log: err = %v, want ErrSyncLogNotFound", err)
+	}
+	if len(provider.fetches) != 1 {
+		t.Errorf("fetched %d times, want only the retry", len(provider.fetches))
+	}
+}
@@ -0,0 +1,292 @@
+package socialmedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Threads API endpoints. Threads runs on Meta's infrastructure but has its
+// own app credentials, OAuth dialog and Graph host.
+const (
+	threadsAuthURL  = "https://threads.net/oauth/authorize"
+	threadsGraphURL = "https://graph.threads.net"
+)
+
+// ThreadsProvider implements SocialMediaProvider for Threads replies and mentions.
+// Threads has no ratings, so synced reviews leave Rating nil.
+type ThreadsProvider struct {
+	appID       string
+	appSecret   string
+	redirectURI string
+	graphURL    string // Overridable for tests
+	scopes      string
+	httpClient  *http.Client
+}
+
+// NewThreadsProvider creates a new Threads provider
+func NewThreadsProvider(appID, appSecret, redirectURI string) *ThreadsProvider {
+	return &ThreadsProvider{
+		appID:       appID,
+		appSecret:   appSecret,
+		redirectURI: redirectURI,
+		graphURL:    threadsGraphURL,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		scopes:      strings.Join(scopesFromEnv("THREADS_SCOPES", defaultThreadsScopes), ","),
+	}
+}
+
+// GetPlatformName returns the platform identifier
+func (p *ThreadsProvider) GetPlatformName() string {
+	return PlatformThreads
+}
+
+// GetAuthorizationURL returns the OAuth authorization URL
+func (p *ThreadsProvider) GetAuthorizationURL(state string) string {
+	params := url.Values{}
+	params.Add("client_id", p.appID)
+	params.Add("redirect_uri", p.redirectURI)
+	params.Add("state", state)
+	params.Add("response_type", "code")
+	params.Add("scope", p.scopes)
+
+	return fmt.Sprintf("%s?%s", threadsAuthURL, params.Encode())
+}
+
+// threadsTokenResult is the token payload shared by the Threads token endpoints
+type threadsTokenResult struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// ExchangeCodeForToken exchanges an authorization code for a long-lived access token
+func (p *ThreadsProvider) ExchangeCodeForToken(code string) (*TokenResponse, error) {
+	form := url.Values{}
+	form.Add("client_id", p.appID)
+	form.Add("client_secret", p.appSecret)
+	form.Add("grant_type", "authorization_code")
+	form.Add("redirect_uri", p.redirectURI)
+	form.Add("code", code)
+
+	resp, err := p.httpClient.PostForm(p.graphURL+"/oauth/access_token", form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newProviderError(PlatformThreads, "token exchange failed", resp)
+	}
+
+	var result threadsTokenResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	// Short-lived tokens last an hour; swap for a 60-day one when possible
+	token, err := p.threadsToken("/access_token", url.Values{
+		"grant_type":    {"th_exchange_token"},
+		"client_secret": {p.appSecret},
+		"access_token":  {result.AccessToken},
+	}, "long-lived token exchange failed")
+	if err != nil {
+		token = &result
+	}
+
+	return threadsTokenResponse(token), nil
+}
+
+// RefreshToken extends a long-lived token; Threads refreshes the access token itself
+func (p *ThreadsProvider) RefreshToken(refreshToken string) (*TokenResponse, error) {
+	token, err := p.threadsToken("/refresh_access_token", url.Values{
+		"grant_type":   {"th_refresh_token"},
+		"access_token": {refreshToken},
+	}, "token refresh failed")
+	if err != nil {
+		return nil, err
+	}
+	return threadsTokenResponse(token), nil
+}
+
+// threadsToken calls a GET token endpoint
+func (p *ThreadsProvider) threadsToken(path string, params url.Values, action string) (*threadsTokenResult, error) {
+	resp, err := p.httpClient.Get(fmt.Sprintf("%s%s?%s", p.graphURL, path, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newProviderError(PlatformThreads, action, resp)
+	}
+
+	var result threadsTokenResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func threadsTokenResponse(token *threadsTokenResult) *TokenResponse {
+	return &TokenResponse{
+		AccessToken: token.AccessToken,
+		ExpiresIn:   token.ExpiresIn,
+		TokenType:   token.TokenType,
+		ExpiresAt:   time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+}
+
+// ValidateToken checks if an access token is still valid by reading the profile
+func (p *ThreadsProvider) ValidateToken(accessToken string) (bool, error) {
+	resp, err := p.httpClient.Get(fmt.Sprintf("%s/v1.0/me?fields=id&access_token=%s", p.graphURL, url.QueryEscape(accessToken)))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// RevokeToken is a no-op: Threads has no API to revoke a grant, the user
+// removes the app from their Threads settings. The token expires on its own.
+func (p *ThreadsProvider) RevokeToken(accessToken string) error {
+	return nil
+}
+
+// GetAccountInfo retrieves the Threads profile
+func (p *ThreadsProvider) GetAccountInfo(accessToken string) (*AccountInfo, error) {
+	profileURL := fmt.Sprintf("%s/v1.0/me?fields=id,username,threads_profile_picture_url&access_token=%s",
+		p.graphURL, url.QueryEscape(accessToken))
+
+	resp, err := p.httpClient.Get(profileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newProviderError(PlatformThreads, "failed to get profile", resp)
+	}
+
+	var result struct {
+		ID                string `json:"id"`
+		Username          string `json:"username"`
+		ProfilePictureURL string `json:"threads_profile_picture_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &AccountInfo{
+		AccountID:   result.ID,
+		AccountName: result.Username,
+		AvatarURL:   result.ProfilePictureURL,
+	}, nil
+}
+
+// threadsPost is a post, reply or mention as returned by the Threads API
+type threadsPost struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	Username  string `json:"username"`
+	Timestamp string `json:"timestamp"`
+	Permalink string `json:"permalink"`
+}
+
+// FetchReviews fetches replies to the account's posts and mentions of the account.
+// Threads doesn't have reviews or ratings, so these are stored as unrated reviews.
+func (p *ThreadsProvider) FetchReviews(accessToken, accountID string, since time.Time) ([]*Review, error) {
+	if accountID == "" {
+		accountInfo, err := p.GetAccountInfo(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		accountID = accountInfo.AccountID
+	}
+
+	sinceParam := ""
+	if !since.IsZero() {
+		sinceParam = fmt.Sprintf("&since=%d", since.Unix())
+	}
+
+	posts, err := p.fetchPosts(fmt.Sprintf("%s/v1.0/%s/threads?fields=id,text,timestamp,permalink%s&access_token=%s",
+		p.graphURL, accountID, sinceParam, url.QueryEscape(accessToken)), "failed to fetch threads")
+	if err != nil {
+		return nil, err
+	}
+
+	var allReviews []*Review
+
+	// Replies to each of the account's posts
+	for _, post := range posts {
+		replies, err := p.fetchPosts(fmt.Sprintf("%s/v1.0/%s/replies?fields=id,text,username,timestamp,permalink&access_token=%s",
+			p.graphURL, post.ID, url.QueryEscape(accessToken)), "failed to fetch replies")
+		if err != nil {
+			continue
+		}
+		for _, reply := range replies {
+			// Skip deleted and media-only replies
+			if reply.Username == "" || strings.TrimSpace(reply.Text) == "" {
+				continue
+			}
+			allReviews = append(allReviews, threadsReview(reply, "reply", post.ID))
+		}
+	}
+
+	// Posts by others that mention the account; not fatal if the permission is missing
+	mentions, err := p.fetchPosts(fmt.Sprintf("%s/v1.0/%s/mentions?fields=id,text,username,timestamp,permalink%s&access_token=%s",
+		p.graphURL, accountID, sinceParam, url.QueryEscape(accessToken)), "failed to fetch mentions")
+	if err == nil {
+		for _, mention := range mentions {
+			allReviews = append(allReviews, threadsReview(mention, "mention", ""))
+		}
+	}
+
+	return allReviews, nil
+}
+
+// fetchPosts reads one page of posts from a Threads list endpoint
+func (p *ThreadsProvider) fetchPosts(endpoint, action string) ([]threadsPost, error) {
+	resp, err := p.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newProviderError(PlatformThreads, action, resp)
+	}
+
+	var result struct {
+		Data []threadsPost `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// threadsReview converts a reply or mention to a normalized, unrated Review
+func threadsReview(post threadsPost, kind, threadID string) *Review {
+	postedAt, _ := time.Parse("2006-01-02T15:04:05-0700", post.Timestamp)
+
+	metadata := map[string]interface{}{
+		"type":      kind,
+		"permalink": post.Permalink,
+	}
+	if threadID != "" {
+		metadata["thread_id"] = threadID
+	}
+
+	return &Review{
+		PlatformReviewID: post.ID,
+		AuthorName:       post.Username,
+		ReviewText:       post.Text,
+		ReviewedAt:       postedAt,
+		Metadata:         metadata,
+	}
+}
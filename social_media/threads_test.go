@@ -0,0 +1,89 @@
+package socialmedia
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestThreadsFetchReviews(t *testing.T) {
+	p := NewThreadsProvider("id", "secret", "https://example.com/callback")
+	p.httpClient = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/42/threads":
+			if r.URL.Query().Get("since") != "1759309200" {
+				t.Errorf("threads since = %q, want the sync cursor", r.URL.Query().Get("since"))
+			}
+			fmt.Fprint(w, `{"data": [{"id": "p1"}, {"id": "p2"}]}`)
+		case "/v1.0/p1/replies":
+			fmt.Fprint(w, `{"data": [
+				{"id": "r1", "text": "Best kopi in town", "username": "aina", "timestamp": "2026-10-01T12:00:00+0000", "permalink": "https://threads.net/r1"},
+				{"id": "r2", "text": "", "username": "ben", "timestamp": "2026-10-01T12:00:00+0000"},
+				{"id": "r3", "text": "deleted", "username": ""}
+			]}`)
+		case "/v1.0/p2/replies":
+			// A failing thread doesn't stop the others
+			http.Error(w, `{"error": {"message": "unavailable"}}`, http.StatusInternalServerError)
+		case "/v1.0/42/mentions":
+			fmt.Fprint(w, `{"data": [{"id": "m1", "text": "Lunch at @cafe", "username": "chen", "timestamp": "2026-10-02T08:30:00+0000"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	reviews, err := p.FetchReviews("token", "42", time.Unix(1759309200, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("got %d reviews, want the one text reply and the mention", len(reviews))
+	}
+
+	reply, mention := reviews[0], reviews[1]
+	if reply.PlatformReviewID != "r1" || reply.AuthorName != "aina" || reply.Rating != nil ||
+		reply.Metadata["type"] != "reply" || reply.Metadata["thread_id"] != "p1" {
+		t.Errorf("reply = %+v", reply)
+	}
+	if want := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC); !reply.ReviewedAt.Equal(want) {
+		t.Errorf("reply ReviewedAt = %s, want %s", reply.ReviewedAt, want)
+	}
+	if mention.PlatformReviewID != "m1" || mention.Metadata["type"] != "mention" || mention.Metadata["thread_id"] != nil {
+		t.Errorf("mention = %+v", mention)
+	}
+}
+
+func TestThreadsExchangeFallsBackToShortLivedToken(t *testing.T) {
+	for _, longLived := range []bool{true, false} {
+		p := NewThreadsProvider("id", "secret", "https://example.com/callback")
+		p.httpClient = mockPlatformAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/oauth/access_token":
+				fmt.Fprint(w, `{"access_token": "short", "token_type": "bearer", "expires_in": 3600}`)
+			case "/access_token":
+				if !longLived {
+					http.Error(w, `{"error": {"message": "unsupported"}}`, http.StatusBadRequest)
+					return
+				}
+				if r.URL.Query().Get("access_token") != "short" {
+					t.Errorf("exchanged %q, want the short-lived token", r.URL.Query().Get("access_token"))
+				}
+				fmt.Fprint(w, `{"access_token": "long", "token_type": "bearer", "expires_in": 5184000}`)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		token, err := p.ExchangeCodeForToken("code")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "short"
+		if longLived {
+			want = "long"
+		}
+		if token.AccessToken != want {
+			t.Errorf("long-lived exchange ok %v: token = %q, want %q", longLived, token.AccessToken, want)
+		}
+	}
+}
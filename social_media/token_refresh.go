@@ -0,0 +1,140 @@
+package socialmedia
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// RefreshExpiringTokens proactively refreshes tokens before they expire, so a
+// long gap between syncs doesn't let them lapse. Connections with a refresh
+// token (Google) are refreshed with it once the access token expires within
+// the refreshable window. Long-lived tokens (Facebook, Instagram, Threads)
+// have no refresh token and are extended by exchanging the current access
+// token within the long-lived window; once they lapse the merchant has to
+// reconnect. Every attempt is recorded in token_refresh_logs.
+// Returns the number of connections refreshed.
+func (s *SyncService) RefreshExpiringTokens() (int, error) {
+	connections, err := s.db.GetActiveConnections()
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	now := time.Now()
+
+	for _, conn := range connections {
+		if conn.TokenExpiresAt.IsZero() {
+			continue
+		}
+
+		window := s.tokenRefreshWindow
+		if conn.RefreshToken != "" {
+			window = s.refreshableTokenWindow
+		}
+		if conn.TokenExpiresAt.After(now.Add(window)) {
+			continue
+		}
+
+		provider, ok := s.GetProvider(conn.Platform)
+		if !ok {
+			continue
+		}
+
+		previousExpiry := conn.TokenExpiresAt
+		if err := s.refreshConnectionToken(provider, conn); err != nil {
+			log.Printf("Token refresh: failed to refresh connection %d (%s), expires %s: %v",
+				conn.ID, conn.Platform, previousExpiry.Format(time.RFC3339), err)
+			s.recordTokenRefresh(conn.ID, previousExpiry, err)
+			continue
+		}
+
+		log.Printf("Token refresh: refreshed connection %d (%s), now expires %s",
+			conn.ID, conn.Platform, conn.TokenExpiresAt.Format(time.RFC3339))
+		s.recordTokenRefresh(conn.ID, conn.TokenExpiresAt, nil)
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// refreshConnectionToken refreshes one connection's token, using its refresh
+// token when it has one and its access token otherwise, and saves the result
+func (s *SyncService) refreshConnectionToken(provider SocialMediaProvider, conn *APIConnection) error {
+	encrypted := conn.AccessToken
+	if conn.RefreshToken != "" {
+		encrypted = conn.RefreshToken
+	}
+	token, err := s.encryptor.Decrypt(encrypted)
+	if err != nil {
+		return fmt.Errorf("decrypt token: %w", err)
+	}
+
+	tokenResp, err := provider.RefreshToken(token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storeRefreshedToken(conn, tokenResp); err != nil {
+		return fmt.Errorf("save refreshed token: %w", err)
+	}
+	return nil
+}
+
+// recordTokenRefresh writes the outcome of a refresh attempt to token_refresh_logs
+func (s *SyncService) recordTokenRefresh(connectionID int, expiresAt time.Time, refreshErr error) {
+	entry := &TokenRefreshLog{
+		APIConnectionID: connectionID,
+		Status:          SyncStatusCompleted,
+		ExpiresAt:       expiresAt,
+	}
+	if refreshErr != nil {
+		entry.Status = SyncStatusFailed
+		entry.ErrorMessage = refreshErr.Error()
+	}
+	if err := s.db.CreateTokenRefreshLog(entry); err != nil {
+		log.Printf("Token refresh: failed to record outcome for connection %d: %v", connectionID, err)
+	}
+}
+
+// storeRefreshedToken encrypts and saves a refreshed token, and updates conn to match
+func (s *SyncService) storeRefreshedToken(conn *APIConnection, tokenResp *TokenResponse) error {
+	encryptedAccess, err := s.encryptor.Encrypt(tokenResp.AccessToken)
+	if err != nil {
+		return err
+	}
+	var encryptedRefresh string
+	if tokenResp.RefreshToken != "" {
+		encryptedRefresh, err = s.encryptor.Encrypt(tokenResp.RefreshToken)
+		if err != nil {
+			return err
+		}
+	}
+	// Only the token columns are written, so a disconnect or edit made while
+	// the refresh was in flight isn't overwritten by conn's older values
+	if err := s.db.UpdateConnectionTokens(conn.ID, encryptedAccess, encryptedRefresh, tokenResp.ExpiresAt); err != nil {
+		return err
+	}
+	conn.AccessToken = encryptedAccess
+	if encryptedRefresh != "" {
+		conn.RefreshToken = encryptedRefresh
+	}
+	conn.TokenExpiresAt = tokenResp.ExpiresAt
+	return nil
+}
+
+// CheckConnectionToken decrypts a connection's access token and asks the
+// platform whether it is still valid
+func (s *SyncService) CheckConnectionToken(conn *APIConnection) (bool, error) {
+	provider, ok := s.GetProvider(conn.Platform)
+	if !ok {
+		return false, &ErrProviderNotFound{Platform: conn.Platform}
+	}
+
+	accessToken, err := s.encryptor.Decrypt(conn.AccessToken)
+	if err != nil {
+		return false, err
+	}
+
+	return provider.ValidateToken(accessToken)
+}
@@ -0,0 +1,122 @@
+package socialmedia
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshExpiringTokensExtendsLongLivedTokens(t *testing.T) {
+	t.Setenv("TOKEN_REFRESH_WINDOW_DAYS", "7")
+
+	// Facebook tokens have no refresh token; the access token itself is exchanged
+	expiring := testAPIConnection(1)
+	expiring.Platform = PlatformFacebook
+	expiring.RefreshToken = ""
+	expiring.AccessToken = "expiring-access"
+	expiring.TokenExpiresAt = time.Now().Add(3 * 24 * time.Hour)
+
+	fresh := testAPIConnection(2)
+	fresh.Platform = PlatformFacebook
+	fresh.RefreshToken = ""
+	fresh.AccessToken = "fresh-access"
+	fresh.TokenExpiresAt = time.Now().Add(30 * 24 * time.Hour)
+
+	db := newMemDB(expiring, fresh)
+	provider := &fakeProvider{platform: PlatformFacebook}
+	s := newTestSyncService(db, provider)
+
+	refreshed, err := s.RefreshExpiringTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed != 1 || len(provider.refreshed) != 1 || provider.refreshed[0] != "expiring-access" {
+		t.Fatalf("refreshed %d with %v, want only the token expiring in 3 days", refreshed, provider.refreshed)
+	}
+
+	if conn := db.connections[1]; conn.AccessToken != "new-access" || !conn.TokenExpiresAt.After(time.Now().Add(30*24*time.Hour)) {
+		t.Errorf("connection 1 = %q expiring %s, want the new long-lived token saved", conn.AccessToken, conn.TokenExpiresAt)
+	}
+	if conn := db.connections[2]; conn.AccessToken != "fresh-access" {
+		t.Errorf("connection 2 token = %q, want it left alone", conn.AccessToken)
+	}
+	if len(db.refreshLogs) != 1 || db.refreshLogs[0].APIConnectionID != 1 || db.refreshLogs[0].Status != SyncStatusCompleted {
+		t.Errorf("refresh logs = %+v, want one completed entry for connection 1", db.refreshLogs)
+	}
+}
+
+func TestRefreshExpiringTokensUsesRefreshTokens(t *testing.T) {
+	t.Setenv("TOKEN_REFRESH_WINDOW_DAYS", "7")
+	t.Setenv("REFRESHABLE_TOKEN_WINDOW_HOURS", "24")
+
+	// Google connections are refreshed within a day of expiry, not the 7-day long-lived window
+	expiring := testAPIConnection(1)
+	expiring.TokenExpiresAt = time.Now().Add(2 * time.Hour)
+	later := testAPIConnection(2)
+	later.TokenExpiresAt = time.Now().Add(3 * 24 * time.Hour)
+	soon := testAPIConnection(3)
+	soon.RefreshToken = "refresh-3"
+	soon.TokenExpiresAt = time.Now().Add(time.Hour)
+
+	db := newMemDB(expiring, later, soon)
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+	s := newTestSyncService(db, provider)
+
+	refreshed, err := s.RefreshExpiringTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed != 2 || len(provider.refreshed) != 2 || provider.refreshed[0] != "refresh" || provider.refreshed[1] != "refresh-3" {
+		t.Fatalf("refreshed %d with %v, want connections 1 and 3 refreshed with their refresh tokens", refreshed, provider.refreshed)
+	}
+	if conn := db.connections[1]; conn.AccessToken != "new-access" || conn.RefreshToken != "new-refresh" {
+		t.Errorf("connection 1 tokens = %q, %q, want the refreshed pair saved", conn.AccessToken, conn.RefreshToken)
+	}
+	if conn := db.connections[2]; conn.AccessToken != "access" {
+		t.Errorf("connection 2 token = %q, want it left alone", conn.AccessToken)
+	}
+
+	provider.refreshErr = errors.New("invalid_grant")
+	db.connections[1].TokenExpiresAt = time.Now().Add(time.Hour)
+	db.connections[3].TokenExpiresAt = time.Now().Add(time.Hour)
+	db.refreshLogs = nil
+	if refreshed, err := s.RefreshExpiringTokens(); err != nil || refreshed != 0 {
+		t.Fatalf("failing refresh: refreshed %d, err %v; want 0 and no error", refreshed, err)
+	}
+	if len(db.refreshLogs) != 2 {
+		t.Fatalf("refresh logs = %+v, want one per failed attempt", db.refreshLogs)
+	}
+	for _, entry := range db.refreshLogs {
+		if entry.Status != SyncStatusFailed || entry.ErrorMessage != "invalid_grant" {
+			t.Errorf("refresh log = %+v, want a failed entry with the provider error", entry)
+		}
+	}
+}
+
+func TestRefreshExpiringTokensKeepsConcurrentChanges(t *testing.T) {
+	t.Setenv("REFRESHABLE_TOKEN_WINDOW_HOURS", "24")
+
+	expiring := testAPIConnection(1)
+	expiring.TokenExpiresAt = time.Now().Add(time.Hour)
+	db := newMemDB(expiring)
+	provider := &fakeProvider{platform: PlatformGoogleBusiness}
+	// The merchant disconnects while the refresh request is in flight
+	provider.onRefresh = func() {
+		db.mu.Lock()
+		db.connections[1].IsActive = false
+		db.connections[1].ErrorMessage = "disconnected"
+		db.mu.Unlock()
+	}
+	s := newTestSyncService(db, provider)
+
+	if refreshed, err := s.RefreshExpiringTokens(); err != nil || refreshed != 1 {
+		t.Fatalf("refreshed %d, err %v; want 1", refreshed, err)
+	}
+	conn := db.connections[1]
+	if conn.AccessToken != "new-access" || conn.RefreshToken != "new-refresh" {
+		t.Errorf("tokens = %q, %q, want the refreshed pair saved", conn.AccessToken, conn.RefreshToken)
+	}
+	if conn.IsActive || conn.ErrorMessage != "disconnected" {
+		t.Errorf("connection active %v, error %q; want the disconnect kept", conn.IsActive, conn.ErrorMessage)
+	}
+}
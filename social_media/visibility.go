@@ -0,0 +1,69 @@
+package socialmedia
+
+import (
+	"os"
+	"strings"
+)
+
+// publicVisibilityCondition is the SQL form of PublicVisibility. Every query
+// that feeds a public surface must use it so the two can't diverge.
+const publicVisibilityCondition = "is_visible = true"
+
+// TextlessReviewPolicy controls how ratings without written text appear in
+// the public feed. They always count toward the average and review count.
+type TextlessReviewPolicy string
+
+const (
+	TextlessShow       TextlessReviewPolicy = "show"        // Shown like any other review
+	TextlessHide       TextlessReviewPolicy = "hide"        // Left out of the feed
+	TextlessRatingOnly TextlessReviewPolicy = "rating_only" // Shown as a compact rating (default)
+)
+
+// textlessCondition matches reviews that have written text
+const textlessCondition = "COALESCE(TRIM(review_text), '') <> ''"
+
+// TextlessReviewPolicyFromEnv reads TEXTLESS_REVIEW_POLICY, defaulting to rating-only
+func TextlessReviewPolicyFromEnv() TextlessReviewPolicy {
+	switch policy := TextlessReviewPolicy(strings.ToLower(os.Getenv("TEXTLESS_REVIEW_POLICY"))); policy {
+	case TextlessShow, TextlessHide, TextlessRatingOnly:
+		return policy
+	}
+	return TextlessRatingOnly
+}
+
+// PublicReviewOptions narrows the public review list; zero values mean no filter
+type PublicReviewOptions struct {
+	Platform       string
+	MinRating      float64
+	Limit          int
+	Offset         int
+	TextlessPolicy TextlessReviewPolicy // Empty uses TextlessReviewPolicyFromEnv
+	Order          ReviewOrder          // Empty is chronological
+}
+
+// Reasons a synced review is kept off the public page
+const (
+	HiddenReasonNotVisible = "Hidden from your public page"
+	HiddenReasonNoText     = "Rating without text, hidden from the public feed"
+)
+
+// PublicVisibility reports whether a review is shown publicly and, if not, why.
+// It mirrors the conditions GetPublicReviews applies under policy for reviews
+// already loaded in memory.
+func PublicVisibility(review *SyncedReview, policy TextlessReviewPolicy) (bool, string) {
+	if !review.IsVisible {
+		return false, HiddenReasonNotVisible
+	}
+	if policy == TextlessHide && strings.TrimSpace(review.ReviewText) == "" {
+		return false, HiddenReasonNoText
+	}
+	return true, ""
+}
+
+// ApplyPublicVisibility fills in the computed visibility fields for the dashboard
+func ApplyPublicVisibility(reviews []*SyncedReview) {
+	policy := TextlessReviewPolicyFromEnv()
+	for _, review := range reviews {
+		review.PubliclyVisible, review.HiddenReason = PublicVisibility(review, policy)
+	}
+}
@@ -0,0 +1,171 @@
+package socialmedia
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// fakeReview is a visible review in the fake synced_reviews table
+type fakeReview struct {
+	rating float64
+	text   string
+}
+
+// newReviewsDB answers the public review list and rating stats queries from
+// reviews. The text filter is only applied when a query asks for it, so the
+// tests see which queries do.
+func newReviewsDB(t *testing.T, reviews []fakeReview) *DB {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		matching := []fakeReview{}
+		for _, r := range reviews {
+			if strings.Contains(query, textlessCondition) && strings.TrimSpace(r.text) == "" {
+				continue
+			}
+			matching = append(matching, r)
+		}
+
+		switch {
+		case strings.Contains(query, "platform_review_id"):
+			res := &fakedb.Result{}
+			for _, column := range strings.Split(syncedReviewColumns, ",") {
+				res.Columns = append(res.Columns, strings.TrimSpace(column))
+			}
+			now := time.Now()
+			for i, r := range matching {
+				res.Rows = append(res.Rows, []driver.Value{
+					int64(i + 1), int64(1), nil, PlatformGoogleBusiness, fmt.Sprintf("review-%d", i),
+					"Author", "", r.rating, r.text, "",
+					now, now, true, nil, now, now,
+					nil, nil,
+				})
+			}
+			return res, nil
+		case strings.Contains(query, "avg_rating"):
+			sum := 0.0
+			for _, r := range matching {
+				sum += r.rating
+			}
+			avg := 0.0
+			if len(matching) > 0 {
+				avg = sum / float64(len(matching))
+			}
+			n := int64(len(matching))
+			return &fakedb.Result{
+				Columns: []string{"total_reviews", "platforms_connected", "rated_reviews", "avg_rating", "latest_review_date"},
+				Rows:    [][]driver.Value{{n, int64(1), n, avg, nil}},
+			}, nil
+		}
+		return &fakedb.Result{Columns: []string{"a", "b", "c"}}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return NewDB(conn)
+}
+
+func TestRatingOnlyReviewCountsButIsNotListedWithText(t *testing.T) {
+	db := newReviewsDB(t, []fakeReview{{5, "Great service"}, {1, "  "}})
+
+	listed, err := db.GetPublicReviews(1, PublicReviewOptions{TextlessPolicy: TextlessHide})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].ReviewText != "Great service" {
+		t.Errorf("hide policy listed %d reviews, want only the one with text", len(listed))
+	}
+
+	stats, err := db.GetMerchantReviewStats(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["total_reviews"] != 2 || stats["avg_rating"] != "3.0" {
+		t.Errorf("stats = %v/%v, want the rating-only review counted: 2/3.0", stats["total_reviews"], stats["avg_rating"])
+	}
+}
+
+func TestRatingOnlyPolicyMarksTextlessReviews(t *testing.T) {
+	db := newReviewsDB(t, []fakeReview{{5, "Great service"}, {4, ""}})
+
+	listed, err := db.GetPublicReviews(1, PublicReviewOptions{TextlessPolicy: TextlessRatingOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("rating_only policy listed %d reviews, want 2", len(listed))
+	}
+	if listed[0].RatingOnly || !listed[1].RatingOnly {
+		t.Errorf("RatingOnly = %v, %v; want false, true", listed[0].RatingOnly, listed[1].RatingOnly)
+	}
+}
+
+func TestPublicVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		review     SyncedReview
+		policy     TextlessReviewPolicy
+		wantShown  bool
+		wantReason string
+	}{
+		{"visible with text", SyncedReview{IsVisible: true, ReviewText: "Nice"}, TextlessHide, true, ""},
+		{"hidden by merchant", SyncedReview{IsVisible: false, ReviewText: "Nice"}, TextlessShow, false, HiddenReasonNotVisible},
+		{"textless under hide", SyncedReview{IsVisible: true, ReviewText: " "}, TextlessHide, false, HiddenReasonNoText},
+		{"textless under rating_only", SyncedReview{IsVisible: true}, TextlessRatingOnly, true, ""},
+		{"textless under show", SyncedReview{IsVisible: true}, TextlessShow, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shown, reason := PublicVisibility(&tt.review, tt.policy)
+			if shown != tt.wantShown || reason != tt.wantReason {
+				t.Errorf("PublicVisibility = %v, %q; want %v, %q", shown, reason, tt.wantShown, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestTextlessReviewPolicyFromEnv(t *testing.T) {
+	for env, want := range map[string]TextlessReviewPolicy{
+		"":            TextlessRatingOnly,
+		"show":        TextlessShow,
+		"HIDE":        TextlessHide,
+		"rating_only": TextlessRatingOnly,
+		"bogus":       TextlessRatingOnly,
+	} {
+		t.Setenv("TEXTLESS_REVIEW_POLICY", env)
+		if got := TextlessReviewPolicyFromEnv(); got != want {
+			t.Errorf("TEXTLESS_REVIEW_POLICY=%q: got %q, want %q", env, got, want)
+		}
+	}
+}
+
+func TestApplyPublicVisibilityReportsReason(t *testing.T) {
+	t.Setenv("TEXTLESS_REVIEW_POLICY", "hide")
+	reviews := []*SyncedReview{
+		{ID: 1, IsVisible: true, ReviewText: "Great"},
+		{ID: 2, IsVisible: false, ReviewText: "Rude staff"},
+		{ID: 3, IsVisible: true},
+	}
+	ApplyPublicVisibility(reviews)
+
+	want := []string{
+		`"publicly_visible":true`,
+		`"publicly_visible":false,"hidden_reason":"` + HiddenReasonNotVisible + `"`,
+		`"publicly_visible":false,"hidden_reason":"` + HiddenReasonNoText + `"`,
+	}
+	for i, review := range reviews {
+		data, err := json.Marshal(review)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want[i]) {
+			t.Errorf("review %d: %s, want %s", review.ID, data, want[i])
+		}
+	}
+	if data, _ := json.Marshal(reviews[0]); strings.Contains(string(data), "hidden_reason") {
+		t.Errorf("visible review has a hidden_reason: %s", data)
+	}
+}
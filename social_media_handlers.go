@@ -4,10 +4,16 @@ import (
 	"auto-gbp-review/social_media"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,15 +24,31 @@ type SocialMediaHandlers struct {
 	syncService *socialmedia.SyncService
 	scheduler   *socialmedia.Scheduler
 	providers   map[string]socialmedia.SocialMediaProvider
+	encryptor   socialmedia.TokenEncryptor
 }
 
-// NewSocialMediaHandlers creates a new social media handlers instance
-func NewSocialMediaHandlers(db *Database) *SocialMediaHandlers {
-	// Initialize encryption
-	encryptionKey := socialmedia.EncryptionKeyFromString(os.Getenv("ENCRYPTION_KEY"))
-	encryptor, err := socialmedia.NewAESEncryptor(encryptionKey)
+// newTokenEncryptorFromEnv builds the token encryptor from ENCRYPTION_KEY, tagged
+// with ENCRYPTION_KEY_ID, plus any retired keys in ENCRYPTION_KEYS_RETIRED that
+// existing tokens may still be encrypted with
+func newTokenEncryptorFromEnv() (*socialmedia.AESEncryptor, error) {
+	keyID := os.Getenv("ENCRYPTION_KEY_ID")
+	if keyID == "" {
+		keyID = socialmedia.DefaultKeyID
+	}
+	retired, err := socialmedia.ParseRetiredKeys(os.Getenv("ENCRYPTION_KEYS_RETIRED"))
+	if err != nil {
+		return nil, err
+	}
+	return socialmedia.NewVersionedAESEncryptor(keyID, os.Getenv("ENCRYPTION_KEY"), retired)
+}
+
+// NewSocialMediaHandlers creates a new social media handlers instance. Sync
+// follow-up work is handed to jobs.
+func NewSocialMediaHandlers(db *Database, jobs *socialmedia.JobQueue) *SocialMediaHandlers {
+	// Initialize encryption; a missing or short key would leave stored tokens effectively unprotected
+	encryptor, err := newTokenEncryptorFromEnv()
 	if err != nil {
-		log.Fatal("Failed to initialize encryptor:", err)
+		log.Fatal("Failed to initialize encryptor: ", err)
 	}
 
 	// Initialize social media database
@@ -71,6 +93,23 @@ func NewSocialMediaHandlers(db *Database) *SocialMediaHandlers {
 		syncService.RegisterProvider(igProvider)
 	}
 
+	// Threads (has its own app credentials, separate from Facebook's)
+	if os.Getenv("THREADS_APP_ID") != "" {
+		threadsProvider := socialmedia.NewThreadsProvider(
+			os.Getenv("THREADS_APP_ID"),
+			os.Getenv("THREADS_APP_SECRET"),
+			os.Getenv("THREADS_REDIRECT_URI"),
+		)
+		providers[socialmedia.PlatformThreads] = threadsProvider
+		syncService.RegisterProvider(threadsProvider)
+	}
+
+	// Negative review alerts (only when SMTP is configured)
+	if emailer := socialmedia.EmailerFromEnv(); emailer != nil {
+		syncService.SetEmailer(emailer, dashboardURL())
+	}
+	syncService.SetJobQueue(jobs)
+
 	// Create scheduler
 	scheduler := socialmedia.NewScheduler(syncService)
 	scheduler.Start()
@@ -80,9 +119,22 @@ func NewSocialMediaHandlers(db *Database) *SocialMediaHandlers {
 		syncService: syncService,
 		scheduler:   scheduler,
 		providers:   providers,
+		encryptor:   encryptor,
+	}
+}
+
+// dashboardURL is the absolute merchant dashboard URL for emails, or "" if BASE_URL is unset
+func dashboardURL() string {
+	baseURL := strings.TrimRight(os.Getenv("BASE_URL"), "/")
+	if baseURL == "" {
+		return ""
 	}
+	return baseURL + appPath("/dashboard/")
 }
 
+// oauthStateTTL is how long a merchant has to finish the provider's consent screen
+const oauthStateTTL = 10 * time.Minute
+
 // generateState generates a random state string for OAuth
 func generateState() string {
 	b := make([]byte, 32)
@@ -97,23 +149,25 @@ func (h *SocialMediaHandlers) ConnectPlatform(c *gin.Context) {
 	// Get merchant ID from authenticated user
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
 	// Check if provider exists
 	provider, ok := h.providers[platform]
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported platform"})
+		respondAPIError(c, http.StatusBadRequest, "Unsupported platform")
 		return
 	}
 
-	// Generate state for CSRF protection
+	// Generate state for CSRF protection and remember which merchant and user it was issued to
 	state := generateState()
-
-	// Store state in session (you should use a proper session store)
-	c.SetCookie("oauth_state", state, 3600, "/", "", false, true)
-	c.SetCookie("oauth_platform", platform, 3600, "/", "", false, true)
+	smDB := socialmedia.NewDB(h.db.DB)
+	if err := smDB.CreateOAuthState(state, merchantID, c.GetString("user_id"), platform, time.Now().Add(oauthStateTTL)); err != nil {
+		log.Printf("Failed to store OAuth state: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to start authorization")
+		return
+	}
 
 	// Redirect to OAuth authorization URL
 	authURL := provider.GetAuthorizationURL(state)
@@ -124,14 +178,20 @@ func (h *SocialMediaHandlers) ConnectPlatform(c *gin.Context) {
 func (h *SocialMediaHandlers) OAuthCallback(c *gin.Context) {
 	platform := c.Param("platform")
 
-	// Verify state
-	stateCookie, _ := c.Cookie("oauth_state")
-	stateParam := c.Query("state")
-
-	if stateCookie == "" || stateCookie != stateParam {
+	// Verify and consume the state; the merchant comes from the state it was issued for.
+	// Only the user who started the flow may finish it, so a consent link
+	// forwarded to someone else can't attach their account to this merchant.
+	smDB := socialmedia.NewDB(h.db.DB)
+	merchantID, err := smDB.ConsumeOAuthState(c.Query("state"), platform, c.GetString("user_id"))
+	if err == socialmedia.ErrInvalidOAuthState {
 		c.String(http.StatusBadRequest, "Invalid state parameter")
 		return
 	}
+	if err != nil {
+		log.Printf("Error verifying OAuth state: %v", err)
+		c.String(http.StatusInternalServerError, "Failed to verify authorization")
+		return
+	}
 
 	// Get authorization code
 	code := c.Query("code")
@@ -141,13 +201,6 @@ func (h *SocialMediaHandlers) OAuthCallback(c *gin.Context) {
 		return
 	}
 
-	// Get merchant ID from authenticated user
-	merchantID := c.GetInt("merchant_id")
-	if merchantID == 0 {
-		c.String(http.StatusUnauthorized, "Merchant not found")
-		return
-	}
-
 	// Get provider
 	provider, ok := h.providers[platform]
 	if !ok {
@@ -172,10 +225,7 @@ func (h *SocialMediaHandlers) OAuthCallback(c *gin.Context) {
 	}
 
 	// Encrypt tokens
-	encryptionKey := socialmedia.EncryptionKeyFromString(os.Getenv("ENCRYPTION_KEY"))
-	encryptor, _ := socialmedia.NewAESEncryptor(encryptionKey)
-
-	encryptedAccess, err := encryptor.Encrypt(tokenResp.AccessToken)
+	encryptedAccess, err := h.encryptor.Encrypt(tokenResp.AccessToken)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Failed to encrypt tokens")
 		return
@@ -183,72 +233,95 @@ func (h *SocialMediaHandlers) OAuthCallback(c *gin.Context) {
 
 	encryptedRefresh := ""
 	if tokenResp.RefreshToken != "" {
-		encryptedRefresh, _ = encryptor.Encrypt(tokenResp.RefreshToken)
+		encryptedRefresh, _ = h.encryptor.Encrypt(tokenResp.RefreshToken)
 	}
 
-	// Save API connection
-	smDB := socialmedia.NewDB(h.db.DB)
-	connection := &socialmedia.APIConnection{
-		MerchantID:          merchantID,
-		Platform:            platform,
-		PlatformAccountID:   accountInfo.AccountID,
-		PlatformAccountName: accountInfo.AccountName,
-		AccessToken:         encryptedAccess,
-		RefreshToken:        encryptedRefresh,
-		TokenExpiresAt:      tokenResp.ExpiresAt,
-		IsActive:            true,
-		SyncStatus:          socialmedia.SyncStatusPending,
+	// Save API connection. A reconnect, a reloaded callback or a provider retry all
+	// come back with the same account, so update that row instead of inserting a
+	// duplicate (api_connections is unique on merchant, platform and account).
+	existing, err := smDB.GetAPIConnectionByAccount(merchantID, platform, accountInfo.AccountID)
+	reconnected := err == nil
+
+	var connection *socialmedia.APIConnection
+	if reconnected {
+		connection = existing
+		connection.PlatformAccountName = accountInfo.AccountName
+		connection.AccountAvatarURL = accountInfo.AvatarURL
+		connection.AccessToken = encryptedAccess
+		if encryptedRefresh != "" {
+			connection.RefreshToken = encryptedRefresh
+		}
+		connection.TokenExpiresAt = tokenResp.ExpiresAt
+		connection.IsActive = true
+		connection.SyncStatus = socialmedia.SyncStatusPending
+		connection.ErrorMessage = ""
+		err = smDB.UpdateAPIConnection(connection)
+	} else {
+		connection = &socialmedia.APIConnection{
+			MerchantID:          merchantID,
+			Platform:            platform,
+			PlatformAccountID:   accountInfo.AccountID,
+			PlatformAccountName: accountInfo.AccountName,
+			AccountAvatarURL:    accountInfo.AvatarURL,
+			AccessToken:         encryptedAccess,
+			RefreshToken:        encryptedRefresh,
+			TokenExpiresAt:      tokenResp.ExpiresAt,
+			IsActive:            true,
+			SyncStatus:          socialmedia.SyncStatusPending,
+		}
+		err = smDB.CreateAPIConnection(connection)
 	}
-
-	err = smDB.CreateAPIConnection(connection)
 	if err != nil {
 		log.Printf("Error saving API connection: %v", err)
 		c.String(http.StatusInternalServerError, "Failed to save connection")
 		return
 	}
 
-	// Clear cookies
-	c.SetCookie("oauth_state", "", -1, "/", "", false, true)
-	c.SetCookie("oauth_platform", "", -1, "/", "", false, true)
-
-	// Trigger initial sync
-	go func() {
-		h.syncService.SyncConnection(connection.ID, socialmedia.SyncTypeManual)
-	}()
+	if reconnected {
+		// Refresh reviews right away instead of waiting for the next scheduler cycle
+		if h.syncService.SyncOnReconnect(connection.ID) {
+			log.Printf("Started sync after reconnecting connection %d", connection.ID)
+		}
+	} else {
+		// Trigger initial sync
+		h.syncService.SyncInBackground(connection.ID, socialmedia.SyncTypeManual)
+	}
 
 	// Redirect to dashboard
-	c.Redirect(http.StatusTemporaryRedirect, "/dashboard/integrations")
+	c.Redirect(http.StatusTemporaryRedirect, appPath("/dashboard/integrations"))
 }
 
 // GetConnections returns all API connections for the merchant
 func (h *SocialMediaHandlers) GetConnections(c *gin.Context) {
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
 	smDB := socialmedia.NewDB(h.db.DB)
 	connections, err := smDB.GetAPIConnectionsByMerchant(merchantID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get connections"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get connections")
 		return
 	}
+	socialmedia.MarkStale(connections, h.scheduler.Interval(), time.Now())
 
 	c.JSON(http.StatusOK, gin.H{"connections": connections})
 }
 
-// DisconnectPlatform removes an API connection
+// DisconnectPlatform revokes and removes an API connection. ?reviews=delete or
+// ?reviews=hide also deletes or hides the reviews it imported; by default they are kept.
 func (h *SocialMediaHandlers) DisconnectPlatform(c *gin.Context) {
 	connectionID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connection ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
 		return
 	}
 
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
@@ -257,30 +330,91 @@ func (h *SocialMediaHandlers) DisconnectPlatform(c *gin.Context) {
 	// Verify connection belongs to merchant
 	connection, err := smDB.GetAPIConnection(connectionID)
 	if err != nil || connection.MerchantID != merchantID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Connection not found"})
+		respondAPIError(c, http.StatusForbidden, "Connection not found")
+		return
+	}
+
+	reviewsAction := c.Query("reviews")
+	if reviewsAction != "" && reviewsAction != "delete" && reviewsAction != "hide" {
+		respondAPIError(c, http.StatusBadRequest, "reviews must be delete or hide")
+		return
+	}
+
+	// Revoke the grant with the platform; a failure shouldn't block disconnecting
+	if err := h.syncService.RevokeConnectionToken(connection); err != nil {
+		log.Printf("Failed to revoke token for connection %d: %v", connectionID, err)
+	}
+
+	// Handle imported reviews before the connection goes (the FK would detach them)
+	switch reviewsAction {
+	case "delete":
+		_, err = smDB.DeleteSyncedReviewsByConnection(connectionID)
+	case "hide":
+		_, err = smDB.HideSyncedReviewsByConnection(connectionID)
+	}
+	if err != nil {
+		respondAPIError(c, http.StatusInternalServerError, "Failed to update synced reviews")
 		return
 	}
 
 	err = smDB.DeleteAPIConnection(connectionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete connection"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to delete connection")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Connection removed successfully"})
 }
 
-// TriggerSync manually triggers a sync for a connection
+// GetConnectionStatus checks with the platform whether a connection's token still works
+func (h *SocialMediaHandlers) GetConnectionStatus(c *gin.Context) {
+	connectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+
+	// Verify connection belongs to merchant
+	connection, err := smDB.GetAPIConnection(connectionID)
+	if err != nil || connection.MerchantID != merchantID {
+		respondAPIError(c, http.StatusForbidden, "Connection not found")
+		return
+	}
+
+	valid, err := h.syncService.CheckConnectionToken(connection)
+	if err != nil {
+		log.Printf("Failed to validate token for connection %d: %v", connectionID, err)
+		valid = false
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":      valid,
+		"expires_at": connection.TokenExpiresAt,
+		// Without a refresh token the sync can't recover on its own
+		"needs_reauth": !valid && connection.RefreshToken == "",
+	})
+}
+
+// TriggerSync manually triggers a sync for a connection. With ?dry_run=true it
+// only reports what a sync would add and update, without saving anything.
 func (h *SocialMediaHandlers) TriggerSync(c *gin.Context) {
 	connectionID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connection ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
 		return
 	}
 
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
@@ -289,22 +423,33 @@ func (h *SocialMediaHandlers) TriggerSync(c *gin.Context) {
 	// Verify connection belongs to merchant
 	connection, err := smDB.GetAPIConnection(connectionID)
 	if err != nil || connection.MerchantID != merchantID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Connection not found"})
+		respondAPIError(c, http.StatusForbidden, "Connection not found")
 		return
 	}
 
+	syncType := socialmedia.SyncTypeManual
+	dryRun := c.Query("dry_run") == "true"
+	if dryRun {
+		syncType = socialmedia.SyncTypeDryRun
+	}
+
 	// Trigger sync
-	stats, err := h.syncService.SyncConnection(connectionID, socialmedia.SyncTypeManual)
+	stats, err := h.syncService.SyncConnection(connectionID, syncType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Sync failed",
-			"details": err.Error(),
+		respondAPIErrorDetails(c, http.StatusInternalServerError, "Sync failed", gin.H{
+			"error":    err.Error(),
+			"category": socialmedia.CategorizeError(err),
 		})
 		return
 	}
 
+	message := "Sync completed"
+	if dryRun {
+		message = "Dry run completed, nothing was saved"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Sync completed",
+		"message": message,
+		"dry_run": dryRun,
 		"stats": gin.H{
 			"fetched": stats.TotalFetched,
 			"added":   stats.TotalAdded,
@@ -313,11 +458,54 @@ func (h *SocialMediaHandlers) TriggerSync(c *gin.Context) {
 	})
 }
 
+// ClearConnectionError dismisses the last sync error on a merchant's connection without syncing.
+// A successful sync clears it on its own; this is for when the merchant has already fixed the cause.
+func (h *SocialMediaHandlers) ClearConnectionError(c *gin.Context) {
+	connectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+
+	// Verify connection belongs to merchant
+	connection, err := smDB.GetAPIConnection(connectionID)
+	if err != nil || connection.MerchantID != merchantID {
+		respondAPIError(c, http.StatusForbidden, "Connection not found")
+		return
+	}
+
+	if err := smDB.ClearConnectionError(connectionID); err != nil {
+		log.Printf("Failed to clear error for connection %d: %v", connectionID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to clear error")
+		return
+	}
+
+	h.db.logAuditEvent(c, "connection_error_cleared", "api_connection", strconv.Itoa(connectionID), map[string]interface{}{
+		"platform":      connection.Platform,
+		"error_message": connection.ErrorMessage,
+	})
+
+	connection.ErrorMessage = ""
+	c.JSON(http.StatusOK, gin.H{"message": "Error cleared", "connection": connection})
+}
+
+// maxSyncedReviewsLimit caps the page size of the synced review list, which
+// API key holders can request directly
+const maxSyncedReviewsLimit = 100
+
 // GetSyncedReviews returns synced reviews for the merchant
 func (h *SocialMediaHandlers) GetSyncedReviews(c *gin.Context) {
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
@@ -326,30 +514,156 @@ func (h *SocialMediaHandlers) GetSyncedReviews(c *gin.Context) {
 	offset := 0
 
 	if limitParam := c.Query("limit"); limitParam != "" {
-		if l, err := strconv.Atoi(limitParam); err == nil {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 {
 			limit = l
 		}
 	}
+	if limit > maxSyncedReviewsLimit {
+		limit = maxSyncedReviewsLimit
+	}
 
 	if offsetParam := c.Query("offset"); offsetParam != "" {
-		if o, err := strconv.Atoi(offsetParam); err == nil {
+		if o, err := strconv.Atoi(offsetParam); err == nil && o > 0 {
 			offset = o
 		}
 	}
 
+	filter, err := syncedReviewFilter(c)
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	smDB := socialmedia.NewDB(h.db.DB)
-	reviews, err := smDB.GetSyncedReviewsByMerchant(merchantID, limit, offset)
+	// Dashboard view: include hidden reviews and explain why they're hidden
+	reviews, total, err := smDB.GetSyncedReviewsFiltered(merchantID, filter, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reviews"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get reviews")
+		return
+	}
+	socialmedia.ApplyPublicVisibility(reviews)
+
+	// The integrations page loads the list with htmx and swaps it in as is
+	if c.GetHeader("HX-Request") != "" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := syncedReviewsFragment.Execute(c.Writer, reviews); err != nil {
+			log.Printf("Failed to render synced reviews for merchant %d: %v", merchantID, err)
+		}
 		return
 	}
 
 	// Get stats
 	stats, _ := smDB.GetMerchantReviewStats(merchantID)
 
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":  reviews,
+		"stats":    stats,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": offset+len(reviews) < total,
+	})
+}
+
+// syncedReviewsFragment is the dashboard's synced review list. Reviews kept
+// off the public page get a Hidden badge whose tooltip gives the reason.
+var syncedReviewsFragment = template.Must(template.New("synced-reviews").Funcs(template.FuncMap{
+	"platformName": socialmedia.PlatformDisplayName,
+	"rating": func(rating *float64) string {
+		if rating == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.1f", *rating)
+	},
+}).Parse(`{{if not .}}<p class="text-gray-500 text-center py-8">No synced reviews yet.</p>
+{{else}}<ul class="divide-y divide-gray-200">
+{{range .}}	<li class="py-4">
+		<div class="flex items-center justify-between">
+			<p class="text-sm font-medium text-gray-900">{{.AuthorName}} <span class="font-normal text-gray-500">on {{platformName .Platform}}</span></p>
+			<div class="flex items-center space-x-2 text-sm">
+				{{with rating .Rating}}<span class="text-yellow-500"><i class="fas fa-star"></i> {{.}}</span>{{end}}
+				{{if not .PubliclyVisible}}<span class="hidden-badge inline-flex items-center px-2 py-0.5 rounded text-xs font-medium bg-gray-100 text-gray-700" title="{{.HiddenReason}}"><i class="fas fa-eye-slash mr-1"></i>Hidden</span>{{end}}
+			</div>
+		</div>
+		{{if .ReviewText}}<p class="mt-1 text-sm text-gray-700">{{.ReviewText}}</p>{{end}}
+		<p class="mt-1 text-xs text-gray-400">{{.ReviewedAt.Format "2 Jan 2006"}}</p>
+	</li>
+{{end}}</ul>
+{{end}}`))
+
+// syncedReviewFilter reads the platform, min_rating and max_rating filters of a
+// synced review list request
+func syncedReviewFilter(c *gin.Context) (socialmedia.ReviewFilter, error) {
+	filter := socialmedia.ReviewFilter{Platform: c.Query("platform")}
+	if filter.Platform != "" && !socialmedia.IsSupportedPlatform(filter.Platform) {
+		return filter, fmt.Errorf("platform must be one of %s", strings.Join(socialmedia.SupportedPlatforms, ", "))
+	}
+
+	for _, bound := range []struct {
+		param string
+		dest  **float64
+	}{{"min_rating", &filter.MinRating}, {"max_rating", &filter.MaxRating}} {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		rating, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(rating >= 0 && rating <= 5) {
+			return filter, fmt.Errorf("%s must be a number from 0 to 5", bound.param)
+		}
+		*bound.dest = &rating
+	}
+
+	if filter.MinRating != nil && filter.MaxRating != nil && *filter.MinRating > *filter.MaxRating {
+		return filter, errors.New("min_rating can't be greater than max_rating")
+	}
+	return filter, nil
+}
+
+// maxReviewSearchLimit caps the page size of review searches
+const maxReviewSearchLimit = 100
+
+// SearchSyncedReviews searches the merchant's synced reviews by author and text
+func (h *SocialMediaHandlers) SearchSyncedReviews(c *gin.Context) {
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		respondAPIError(c, http.StatusBadRequest, "Search query is required")
+		return
+	}
+
+	limit := 20
+	offset := 0
+
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxReviewSearchLimit {
+		limit = maxReviewSearchLimit
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	reviews, total, err := smDB.SearchSyncedReviews(merchantID, q, limit, offset)
+	if err != nil {
+		log.Printf("Error searching reviews for merchant %d: %v", merchantID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to search reviews")
+		return
+	}
+	socialmedia.ApplyPublicVisibility(reviews)
+
 	c.JSON(http.StatusOK, gin.H{
 		"reviews": reviews,
-		"stats":   stats,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
 	})
 }
 
@@ -357,41 +671,420 @@ func (h *SocialMediaHandlers) GetSyncedReviews(c *gin.Context) {
 func (h *SocialMediaHandlers) IntegrationsPage(c *gin.Context) {
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.Redirect(http.StatusTemporaryRedirect, "/login")
+		c.Redirect(http.StatusTemporaryRedirect, appPath("/login"))
 		return
 	}
 
 	smDB := socialmedia.NewDB(h.db.DB)
 	connections, _ := smDB.GetAPIConnectionsByMerchant(merchantID)
+	socialmedia.MarkStale(connections, h.scheduler.Interval(), time.Now())
+
+	platforms := make(map[string]bool)
+	for _, status := range socialmedia.BuildPlatformStatuses(h.configuredPlatforms(), connections) {
+		platforms[status.Platform] = status.Configured
+	}
+
+	notifyOnNegative, weeklyDigest := false, false
+	if settings, err := smDB.GetNotificationSettings(merchantID); err == nil {
+		notifyOnNegative, weeklyDigest = settings.NotifyOnNegative, settings.WeeklyDigest
+	}
 
 	renderPage(c, "templates/layouts/base.html", "templates/merchant/integrations.html", gin.H{
-		"title":       "Social Media Integrations",
+		"title":            "Social Media Integrations",
+		"connections":      connections,
+		"platforms":        platforms,
+		"notifyOnNegative": notifyOnNegative,
+		"weeklyDigest":     weeklyDigest,
+	})
+}
+
+// UpdateNotificationSettings turns negative review alerts and the weekly digest email on or off
+func (h *SocialMediaHandlers) UpdateNotificationSettings(c *gin.Context) {
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	notifyOnNegative := c.PostForm("notify_on_negative") == "true"
+	weeklyDigest := c.PostForm("weekly_digest") == "true"
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	if err := smDB.SetNotificationSettings(merchantID, notifyOnNegative, weeklyDigest); err != nil {
+		log.Printf("Failed to update notification settings for merchant %d: %v", merchantID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to update notification settings")
+		return
+	}
+
+	h.db.logAuditEvent(c, "notification_settings_updated", "merchant", strconv.Itoa(merchantID), map[string]interface{}{
+		"notify_on_negative": notifyOnNegative,
+		"weekly_digest":      weeklyDigest,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Notification settings updated",
+		"notify_on_negative": notifyOnNegative,
+		"weekly_digest":      weeklyDigest,
+	})
+}
+
+// configuredPlatforms reports which platforms have a provider registered,
+// i.e. whose credentials are present in the environment
+func (h *SocialMediaHandlers) configuredPlatforms() map[string]bool {
+	configured := make(map[string]bool, len(h.providers))
+	for platform := range h.providers {
+		configured[platform] = true
+	}
+	return configured
+}
+
+// GetPlatforms lists every supported platform with its status for the merchant
+func (h *SocialMediaHandlers) GetPlatforms(c *gin.Context) {
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	connections, err := smDB.GetAPIConnectionsByMerchant(merchantID)
+	if err != nil {
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get connections")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"platforms": socialmedia.BuildPlatformStatuses(h.configuredPlatforms(), connections)})
+}
+
+// adminConnectionsPageSize is how many connections the admin list shows per page
+const adminConnectionsPageSize = 50
+
+// adminConnectionsQuery reads the platform/status filters and page number of an admin connection list request
+func adminConnectionsQuery(c *gin.Context) (socialmedia.ConnectionFilter, int) {
+	filter := socialmedia.ConnectionFilter{
+		Platform:   c.Query("platform"),
+		SyncStatus: c.Query("status"),
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 1 {
+		page = p
+	}
+
+	return filter, page
+}
+
+// AdminConnectionsPage shows all connections across merchants for admin monitoring
+func (h *SocialMediaHandlers) AdminConnectionsPage(c *gin.Context) {
+	filter, page := adminConnectionsQuery(c)
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	connections, total, err := smDB.GetAllAPIConnectionsWithMerchant(filter, adminConnectionsPageSize, (page-1)*adminConnectionsPageSize)
+	if err != nil {
+		log.Printf("Error fetching admin connections: %v", err)
+		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+			"error": "Failed to load connections",
+		})
+		return
+	}
+
+	totalPages := (total + adminConnectionsPageSize - 1) / adminConnectionsPageSize
+
+	failing, err := smDB.GetFailingConnections(h.syncService.FailureAlertThreshold())
+	if err != nil {
+		log.Printf("Error fetching failing connections: %v", err)
+	}
+
+	platforms := make([]gin.H, 0, len(socialmedia.SupportedPlatforms))
+	for _, platform := range socialmedia.SupportedPlatforms {
+		platforms = append(platforms, gin.H{"value": platform, "label": socialmedia.PlatformDisplayName(platform)})
+	}
+	statuses := []string{
+		socialmedia.SyncStatusPending, socialmedia.SyncStatusSyncing,
+		socialmedia.SyncStatusCompleted, socialmedia.SyncStatusFailed,
+	}
+
+	renderPage(c, "templates/layouts/base.html", "templates/admin/connections.html", gin.H{
+		"title":          "Connections",
+		"connections":    connections,
+		"total":          total,
+		"page":           page,
+		"prevPage":       page - 1,
+		"nextPage":       page + 1,
+		"hasNext":        page < totalPages,
+		"totalPages":     totalPages,
+		"platforms":      platforms,
+		"statuses":       statuses,
+		"filterPlatform": filter.Platform,
+		"filterStatus":   filter.SyncStatus,
+		"failing":        failing,
+		"failureAlertAt": h.syncService.FailureAlertThreshold(),
+	})
+}
+
+// AdminGetConnections returns all connections across merchants as JSON
+func (h *SocialMediaHandlers) AdminGetConnections(c *gin.Context) {
+	filter, page := adminConnectionsQuery(c)
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	connections, total, err := smDB.GetAllAPIConnectionsWithMerchant(filter, adminConnectionsPageSize, (page-1)*adminConnectionsPageSize)
+	if err != nil {
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get connections")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
 		"connections": connections,
-		"platforms": map[string]bool{
-			"google_business": os.Getenv("GOOGLE_CLIENT_ID") != "",
-			"facebook":        os.Getenv("FACEBOOK_APP_ID") != "",
-			"instagram":       os.Getenv("FACEBOOK_APP_ID") != "",
+		"total":       total,
+		"page":        page,
+		"per_page":    adminConnectionsPageSize,
+	})
+}
+
+// AdminFailingConnections lists active connections whose recent syncs keep
+// failing. ?min_failures= overrides SYNC_FAILURE_ALERT_THRESHOLD.
+func (h *SocialMediaHandlers) AdminFailingConnections(c *gin.Context) {
+	minFailures := h.syncService.FailureAlertThreshold()
+	if raw := c.Query("min_failures"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondAPIError(c, http.StatusBadRequest, "min_failures must be a positive number")
+			return
+		}
+		minFailures = parsed
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	failing, err := smDB.GetFailingConnections(minFailures)
+	if err != nil {
+		log.Printf("Error fetching failing connections: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get failing connections")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"connections":  failing,
+		"min_failures": minFailures,
+	})
+}
+
+// AdminForceSync syncs any merchant's connection, bypassing the ownership check in TriggerSync
+func (h *SocialMediaHandlers) AdminForceSync(c *gin.Context) {
+	connectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	connection, err := smDB.GetAPIConnection(connectionID)
+	if err != nil {
+		respondAPIError(c, http.StatusNotFound, "Connection not found")
+		return
+	}
+
+	stats, err := h.syncService.SyncConnection(connectionID, socialmedia.SyncTypeManual)
+
+	details := map[string]interface{}{
+		"merchant_id": connection.MerchantID,
+		"platform":    connection.Platform,
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	h.db.logAuditEvent(c, "connection_force_synced", "api_connection", strconv.Itoa(connectionID), details)
+
+	if err != nil {
+		respondAPIErrorDetails(c, http.StatusInternalServerError, "Sync failed", gin.H{
+			"error":    err.Error(),
+			"category": socialmedia.CategorizeError(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sync completed",
+		"stats": gin.H{
+			"fetched": stats.TotalFetched,
+			"added":   stats.TotalAdded,
+			"updated": stats.TotalUpdated,
 		},
 	})
 }
 
-// AdminConnectionsPage shows all connections for admin
-func (h *SocialMediaHandlers) AdminConnectionsPage(c *gin.Context) {
-	// This would show all connections across all merchants for admin monitoring
-	c.String(http.StatusOK, "Admin connections page - TODO")
+// AdminCleanupSyncLogs deletes sync logs past the retention period on demand
+func (h *SocialMediaHandlers) AdminCleanupSyncLogs(c *gin.Context) {
+	deleted, err := h.syncService.CleanupSyncLogs()
+	if err != nil {
+		log.Printf("Failed to clean up sync logs: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to clean up sync logs")
+		return
+	}
+
+	h.db.logAuditEvent(c, "sync_logs_cleaned", "sync_logs", "", map[string]interface{}{
+		"deleted": deleted,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sync logs cleaned up", "deleted": deleted})
+}
+
+// GetReviewHistory lists the earlier versions of one of the merchant's synced
+// reviews, newest first, alongside the current one
+func (h *SocialMediaHandlers) GetReviewHistory(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	merchantID := c.GetInt("merchant_id")
+	if merchantID == 0 {
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+
+	review, err := smDB.GetSyncedReview(reviewID)
+	if err != nil || review.MerchantID != merchantID {
+		respondAPIError(c, http.StatusNotFound, "Review not found")
+		return
+	}
+
+	revisions, err := smDB.GetSyncedReviewRevisions(reviewID)
+	if err != nil {
+		log.Printf("Error getting history for review %d: %v", reviewID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get review history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"review":    review,
+		"revisions": revisions,
+	})
+}
+
+// AdminRetrySyncLog re-runs a failed sync for the connection it belonged to
+func (h *SocialMediaHandlers) AdminRetrySyncLog(c *gin.Context) {
+	logID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid sync log ID")
+		return
+	}
+
+	original, stats, err := h.syncService.RetrySync(logID)
+	switch {
+	case errors.Is(err, socialmedia.ErrSyncLogNotFound):
+		respondAPIError(c, http.StatusNotFound, "Sync log not found")
+		return
+	case errors.Is(err, socialmedia.ErrSyncLogNotFailed):
+		respondAPIError(c, http.StatusConflict, "Only failed syncs can be retried")
+		return
+	case original == nil:
+		log.Printf("Failed to load sync log %d: %v", logID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to load sync log")
+		return
+	}
+
+	details := map[string]interface{}{
+		"api_connection_id": original.APIConnectionID,
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	h.db.logAuditEvent(c, "sync_log_retried", "sync_log", strconv.Itoa(logID), details)
+
+	if err != nil {
+		respondAPIErrorDetails(c, http.StatusInternalServerError, "Sync failed", gin.H{
+			"error":    err.Error(),
+			"category": socialmedia.CategorizeError(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Sync completed",
+		"retry_of":          logID,
+		"api_connection_id": original.APIConnectionID,
+		"stats": gin.H{
+			"fetched": stats.TotalFetched,
+			"added":   stats.TotalAdded,
+			"updated": stats.TotalUpdated,
+		},
+	})
+}
+
+// AdminSchedulerStatus reports whether the sync scheduler is running, when it
+// runs next and how its last run went
+func (h *SocialMediaHandlers) AdminSchedulerStatus(c *gin.Context) {
+	if h.scheduler == nil {
+		respondAPIError(c, http.StatusServiceUnavailable, "Scheduler not configured")
+		return
+	}
+	c.JSON(http.StatusOK, h.scheduler.GetStatus())
+}
+
+// AdminReencryptTokens rewrites all stored platform tokens with the current
+// encryption key, for use after rotating ENCRYPTION_KEY
+func (h *SocialMediaHandlers) AdminReencryptTokens(c *gin.Context) {
+	result, err := h.syncService.ReencryptTokens()
+	if err != nil {
+		log.Printf("Failed to re-encrypt tokens: %v", err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to re-encrypt tokens")
+		return
+	}
+
+	h.db.logAuditEvent(c, "tokens_reencrypted", "api_connections", "", map[string]interface{}{
+		"connections": result.Connections,
+		"reencrypted": result.Reencrypted,
+		"failed":      result.Failed,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateConnectionNotes sets the admin-only notes on a connection
+func (h *SocialMediaHandlers) UpdateConnectionNotes(c *gin.Context) {
+	connectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
+		return
+	}
+
+	notes := strings.TrimSpace(c.PostForm("admin_notes"))
+	if utf8.RuneCountInString(notes) > socialmedia.MaxAdminNotesLength {
+		respondAPIError(c, http.StatusBadRequest, fmt.Sprintf("Notes must be %d characters or fewer", socialmedia.MaxAdminNotesLength))
+		return
+	}
+
+	smDB := socialmedia.NewDB(h.db.DB)
+	connection, err := smDB.GetAPIConnection(connectionID)
+	if err != nil {
+		respondAPIError(c, http.StatusNotFound, "Connection not found")
+		return
+	}
+
+	if err := smDB.UpdateAdminNotes(connectionID, notes); err != nil {
+		log.Printf("Failed to update notes for connection %d: %v", connectionID, err)
+		respondAPIError(c, http.StatusInternalServerError, "Failed to update notes")
+		return
+	}
+
+	connection.AdminNotes = notes
+	c.JSON(http.StatusOK, gin.H{"connection": socialmedia.NewAdminAPIConnection(connection)})
 }
 
 // GetSyncLogs returns sync logs for a connection
 func (h *SocialMediaHandlers) GetSyncLogs(c *gin.Context) {
 	connectionID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid connection ID"})
+		respondAPIError(c, http.StatusBadRequest, "Invalid connection ID")
 		return
 	}
 
 	merchantID := c.GetInt("merchant_id")
 	if merchantID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Merchant not found"})
+		respondAPIError(c, http.StatusUnauthorized, "Merchant not found")
 		return
 	}
 
@@ -402,14 +1095,14 @@ func (h *SocialMediaHandlers) GetSyncLogs(c *gin.Context) {
 	if role != "admin" {
 		connection, err := smDB.GetAPIConnection(connectionID)
 		if err != nil || connection.MerchantID != merchantID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Connection not found"})
+			respondAPIError(c, http.StatusForbidden, "Connection not found")
 			return
 		}
 	}
 
 	logs, err := smDB.GetSyncLogsByConnection(connectionID, 20)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get logs"})
+		respondAPIError(c, http.StatusInternalServerError, "Failed to get logs")
 		return
 	}
 
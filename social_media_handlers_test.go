@@ -0,0 +1,623 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+	socialmedia "auto-gbp-review/social_media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// connectionsFixture is an in-memory api_connections table behind the social
+// media handlers
+type connectionsFixture struct {
+	mu          sync.Mutex
+	connections map[int64]*socialmedia.APIConnection
+	audited     []string // audit log actions
+	inserted    int      // connections created
+	synced      []int64  // connections a sync log was started for
+	syncLogErr  error    // returned when a sync log is read
+}
+
+func newConnectionsFixture(connections ...*socialmedia.APIConnection) *connectionsFixture {
+	f := &connectionsFixture{connections: map[int64]*socialmedia.APIConnection{}}
+	for _, conn := range connections {
+		f.connections[int64(conn.ID)] = conn
+	}
+	return f
+}
+
+// row returns a connection in the column order of the connection queries
+func (f *connectionsFixture) row(conn *socialmedia.APIConnection) []driver.Value {
+	var lastSyncAt driver.Value
+	if conn.LastSyncAt != nil {
+		lastSyncAt = *conn.LastSyncAt
+	}
+	return []driver.Value{
+		int64(conn.ID), int64(conn.MerchantID), conn.Platform, conn.PlatformAccountID, conn.PlatformAccountName,
+		conn.AccessToken, conn.RefreshToken, conn.TokenExpiresAt, conn.IsActive, lastSyncAt,
+		conn.SyncStatus, conn.ErrorMessage, conn.AdminNotes, conn.AccountAvatarURL, conn.CreatedAt, conn.UpdatedAt,
+	}
+}
+
+// handlers returns SocialMediaHandlers over the fixture
+func (f *connectionsFixture) handlers(t *testing.T) *SocialMediaHandlers {
+	t.Helper()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case strings.Contains(query, "FROM api_connections\n\t\tWHERE id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 16)}
+			if conn, ok := f.connections[args[0].(int64)]; ok {
+				res.Rows = [][]driver.Value{f.row(conn)}
+			}
+			return res, nil
+		case strings.Contains(query, "FROM api_connections\n\t\tWHERE merchant_id = $1"):
+			res := &fakedb.Result{Columns: make([]string, 16)}
+			for _, conn := range f.connections {
+				if int64(conn.MerchantID) == args[0] {
+					res.Rows = append(res.Rows, f.row(conn))
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "DELETE FROM oauth_states"):
+			// Every state except "bad" was issued to user-1 for merchant 7's Google connection
+			res := &fakedb.Result{Columns: make([]string, 4)}
+			if args[0] != "bad" {
+				res.Rows = [][]driver.Value{{int64(7), "user-1", socialmedia.PlatformGoogleBusiness, time.Now().Add(time.Minute)}}
+			}
+			return res, nil
+		case strings.Contains(query, "AND platform_account_id = $3"):
+			res := &fakedb.Result{Columns: make([]string, 16)}
+			for _, conn := range f.connections {
+				if int64(conn.MerchantID) == args[0] && conn.Platform == args[1] && conn.PlatformAccountID == args[2] {
+					res.Rows = [][]driver.Value{f.row(conn)}
+				}
+			}
+			return res, nil
+		case strings.Contains(query, "SET platform_account_id = $1"):
+			if conn, ok := f.connections[args[10].(int64)]; ok {
+				conn.PlatformAccountName = args[1].(string)
+				conn.AccessToken = args[2].(string)
+				conn.IsActive = args[5].(bool)
+				conn.SyncStatus = args[7].(string)
+				conn.ErrorMessage = args[8].(string)
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "INSERT INTO api_connections"):
+			f.inserted++
+			id := int64(len(f.connections) + 1)
+			f.connections[id] = &socialmedia.APIConnection{ID: int(id), MerchantID: int(args[0].(int64)), Platform: args[1].(string), PlatformAccountID: args[2].(string)}
+			now := time.Now()
+			return &fakedb.Result{Columns: make([]string, 3), Rows: [][]driver.Value{{id, now, now}}}, nil
+		case strings.Contains(query, "INSERT INTO sync_logs"):
+			f.synced = append(f.synced, args[0].(int64))
+			return &fakedb.Result{Columns: make([]string, 2), Rows: [][]driver.Value{{int64(len(f.synced)), time.Now()}}}, nil
+		case strings.Contains(query, "FROM sync_logs\n\t\tWHERE id = $1"):
+			if f.syncLogErr != nil {
+				return nil, f.syncLogErr
+			}
+			return &fakedb.Result{Columns: make([]string, 11)}, nil
+		case strings.Contains(query, "UPDATE sync_logs"):
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SELECT cross_platform_dedup FROM merchants"):
+			return &fakedb.Result{Columns: make([]string, 1), Rows: [][]driver.Value{{false}}}, nil
+		case strings.Contains(query, "SET error_message = ''"):
+			if conn, ok := f.connections[args[0].(int64)]; ok {
+				conn.ErrorMessage = ""
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "INSERT INTO audit_logs"):
+			f.audited = append(f.audited, args[2].(string))
+			return &fakedb.Result{RowsAffected: 1}, nil
+		case strings.Contains(query, "SET admin_notes = $1"):
+			if conn, ok := f.connections[args[1].(int64)]; ok {
+				conn.AdminNotes = args[0].(string)
+			}
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	return &SocialMediaHandlers{
+		db:        &Database{DB: conn},
+		scheduler: socialmedia.NewScheduler(nil),
+	}
+}
+
+// testConnection is an active Google connection of merchant 7
+func testConnection(id int) *socialmedia.APIConnection {
+	now := time.Now()
+	return &socialmedia.APIConnection{
+		ID:                  id,
+		MerchantID:          7,
+		Platform:            socialmedia.PlatformGoogleBusiness,
+		PlatformAccountID:   "accounts/1",
+		PlatformAccountName: "Cafe",
+		AccessToken:         "encrypted-token",
+		TokenExpiresAt:      now.Add(time.Hour),
+		IsActive:            true,
+		LastSyncAt:          &now,
+		SyncStatus:          socialmedia.SyncStatusCompleted,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+}
+
+// asMerchant is middleware standing in for the merchant lookup
+func asMerchant(merchantID int) gin.HandlerFunc {
+	return func(c *gin.Context) { c.Set("merchant_id", merchantID) }
+}
+
+func TestAdminCanSetConnectionNotes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newConnectionsFixture(testConnection(1))
+	h := f.handlers(t)
+
+	router := gin.New()
+	router.POST("/api/admin/social-media/connections/:id/notes", h.UpdateConnectionNotes)
+
+	w := postForm(router, "/api/admin/social-media/connections/1/notes", url.Values{"admin_notes": {"  Owner asked for weekly syncs  "}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), `"admin_notes":"Owner asked for weekly syncs"`) {
+		t.Errorf("body = %s, want the trimmed notes in the admin view", w.Body)
+	}
+	if f.connections[1].AdminNotes != "Owner asked for weekly syncs" {
+		t.Errorf("stored notes = %q", f.connections[1].AdminNotes)
+	}
+
+	tooLong := strings.Repeat("é", socialmedia.MaxAdminNotesLength+1)
+	if w := postForm(router, "/api/admin/social-media/connections/1/notes", url.Values{"admin_notes": {tooLong}}); w.Code != http.StatusBadRequest {
+		t.Errorf("over-long notes: status = %d, want 400", w.Code)
+	}
+	if w := postForm(router, "/api/admin/social-media/connections/9/notes", url.Values{"admin_notes": {"x"}}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown connection: status = %d, want 404", w.Code)
+	}
+}
+
+func TestMerchantConnectionsOmitAdminNotes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := testConnection(1)
+	conn.AdminNotes = "Owner is difficult"
+	h := newConnectionsFixture(conn).handlers(t)
+
+	router := gin.New()
+	router.GET("/api/social-media/connections", asMerchant(7), h.GetConnections)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/social-media/connections", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+
+	var body struct {
+		Connections []map[string]interface{} `json:"connections"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Connections) != 1 {
+		t.Fatalf("got %d connections, want 1", len(body.Connections))
+	}
+	if _, ok := body.Connections[0]["admin_notes"]; ok || strings.Contains(w.Body.String(), "Owner is difficult") {
+		t.Errorf("merchant response exposes admin notes: %s", w.Body)
+	}
+}
+
+func TestMerchantConnectionsIncludeAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withAvatar := testConnection(1)
+	withAvatar.AccountAvatarURL = "https://example.com/avatar.png"
+	h := newConnectionsFixture(withAvatar).handlers(t)
+
+	router := gin.New()
+	router.GET("/api/social-media/connections", asMerchant(7), h.GetConnections)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/social-media/connections", nil))
+	if !strings.Contains(w.Body.String(), `"account_avatar_url":"https://example.com/avatar.png"`) {
+		t.Errorf("body = %s, want the account avatar", w.Body)
+	}
+}
+
+func TestClearConnectionError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	failed := testConnection(1)
+	failed.ErrorMessage = "token expired"
+	failed.SyncStatus = socialmedia.SyncStatusFailed
+	f := newConnectionsFixture(failed)
+	h := f.handlers(t)
+
+	router := gin.New()
+	router.POST("/api/social-media/connections/:id/clear-error", asMerchant(7), h.ClearConnectionError)
+	router.POST("/api/other/connections/:id/clear-error", asMerchant(8), h.ClearConnectionError)
+
+	w := postForm(router, "/api/other/connections/1/clear-error", url.Values{})
+	if w.Code != http.StatusForbidden || f.connections[1].ErrorMessage == "" {
+		t.Fatalf("another merchant: status = %d, error %q; want 403 and the error kept", w.Code, f.connections[1].ErrorMessage)
+	}
+
+	w = postForm(router, "/api/social-media/connections/1/clear-error", url.Values{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+	if conn := f.connections[1]; conn.ErrorMessage != "" || conn.SyncStatus != socialmedia.SyncStatusFailed || !conn.IsActive {
+		t.Errorf("connection = %q/%s, want only the error cleared", conn.ErrorMessage, conn.SyncStatus)
+	}
+	if len(f.audited) != 1 || f.audited[0] != "connection_error_cleared" {
+		t.Errorf("audit log = %v, want connection_error_cleared", f.audited)
+	}
+}
+
+// stubProvider is a provider whose OAuth exchange always yields the same account
+type stubProvider struct {
+	socialmedia.SocialMediaProvider
+	platform, accountID string
+}
+
+func (p stubProvider) GetPlatformName() string { return p.platform }
+func (p stubProvider) ExchangeCodeForToken(code string) (*socialmedia.TokenResponse, error) {
+	return &socialmedia.TokenResponse{AccessToken: "access-" + code, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+func (p stubProvider) ValidateToken(accessToken string) (bool, error) { return true, nil }
+func (p stubProvider) FetchReviews(accessToken, accountID string, since time.Time) ([]*socialmedia.Review, error) {
+	return nil, nil
+}
+func (p stubProvider) GetAccountInfo(accessToken string) (*socialmedia.AccountInfo, error) {
+	return &socialmedia.AccountInfo{AccountID: p.accountID, AccountName: "Cafe Renamed"}, nil
+}
+
+// plainTokens stores tokens as they are
+type plainTokens struct{}
+
+func (plainTokens) Encrypt(plaintext string) (string, error)  { return plaintext, nil }
+func (plainTokens) Decrypt(ciphertext string) (string, error) { return ciphertext, nil }
+
+func TestOAuthCallbackRepeatUpdatesConnection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SYNC_ON_RECONNECT", "false")
+
+	failed := testConnection(1)
+	failed.IsActive = false
+	failed.SyncStatus = socialmedia.SyncStatusFailed
+	failed.ErrorMessage = "token revoked"
+	f := newConnectionsFixture(failed)
+	h := f.handlers(t)
+	h.providers = map[string]socialmedia.SocialMediaProvider{
+		socialmedia.PlatformGoogleBusiness: stubProvider{platform: socialmedia.PlatformGoogleBusiness, accountID: "accounts/1"},
+	}
+	h.encryptor = plainTokens{}
+	h.syncService = socialmedia.NewSyncService(socialmedia.NewDB(h.db.DB), h.encryptor)
+
+	router := gin.New()
+	router.GET("/api/social-media/callback/:platform", asUser("user-1"), h.OAuthCallback)
+
+	// Two reconnects of the same account, each with its own state
+	for i, code := range []string{"first", "second"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/social-media/callback/google_business?state=s-"+code+"&code="+code, nil))
+		if w.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("callback %d: status = %d, want a redirect (body %s)", i+1, w.Code, w.Body)
+		}
+	}
+
+	if f.inserted != 0 || len(f.connections) != 1 {
+		t.Fatalf("inserted %d connections, want the existing one updated", f.inserted)
+	}
+	conn := f.connections[1]
+	if !conn.IsActive || conn.SyncStatus != socialmedia.SyncStatusPending || conn.ErrorMessage != "" ||
+		conn.AccessToken != "access-second" || conn.PlatformAccountName != "Cafe Renamed" {
+		t.Errorf("connection = %+v, want it reactivated with the new token and name", conn)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/social-media/callback/google_business?state=bad&code=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid state: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAdminForceSyncBypassesOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newConnectionsFixture(testConnection(1))
+	h := f.handlers(t)
+	h.encryptor = plainTokens{}
+	h.syncService = socialmedia.NewSyncService(socialmedia.NewDB(h.db.DB), h.encryptor)
+	h.syncService.RegisterProvider(stubProvider{platform: socialmedia.PlatformGoogleBusiness})
+
+	router := gin.New()
+	router.POST("/api/social-media/connections/:id/sync", asMerchant(8), h.TriggerSync)
+	router.POST("/api/admin/social-media/connections/:id/sync", asUser("admin-1"), h.AdminForceSync)
+
+	// Merchant 8 doesn't own connection 1
+	if w := postForm(router, "/api/social-media/connections/1/sync", url.Values{}); w.Code != http.StatusForbidden || len(f.synced) != 0 {
+		t.Fatalf("merchant sync of another's connection: status = %d after %d syncs; want 403 and none", w.Code, len(f.synced))
+	}
+
+	w := postForm(router, "/api/admin/social-media/connections/1/sync", url.Values{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin sync: status = %d, want 200 (body %s)", w.Code, w.Body)
+	}
+	if len(f.synced) != 1 || f.synced[0] != 1 {
+		t.Errorf("synced %v, want connection 1", f.synced)
+	}
+	if len(f.audited) != 1 || f.audited[0] != "connection_force_synced" {
+		t.Errorf("audit log = %v, want connection_force_synced", f.audited)
+	}
+
+	if w := postForm(router, "/api/admin/social-media/connections/9/sync", url.Values{}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown connection: status = %d, want 404", w.Code)
+	}
+}
+
+func TestAdminRetrySyncLogLookupErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newConnectionsFixture(testConnection(1))
+	h := f.handlers(t)
+	h.syncService = socialmedia.NewSyncService(socialmedia.NewDB(h.db.DB), plainTokens{})
+
+	router := gin.New()
+	router.POST("/api/admin/social-media/sync-logs/:id/retry", asUser("admin-1"), h.AdminRetrySyncLog)
+
+	if w := postForm(router, "/api/admin/social-media/sync-logs/5/retry", url.Values{}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown sync log: status = %d, want 404", w.Code)
+	}
+
+	f.syncLogErr = errors.New("connection reset")
+	w := postForm(router, "/api/admin/social-media/sync-logs/5/retry", url.Values{})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("failing lookup: status = %d, want 500", w.Code)
+	}
+	if len(f.synced) != 0 || len(f.audited) != 0 {
+		t.Errorf("failing lookup: %d syncs and audit log %v; want neither", len(f.synced), f.audited)
+	}
+}
+
+// tokenChecker is a provider that reports only "live-token" as valid
+type tokenChecker struct {
+	stubProvider
+}
+
+func (p tokenChecker) ValidateToken(accessToken string) (bool, error) {
+	return accessToken == "live-token", nil
+}
+
+func TestGetConnectionStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	live := testConnection(1)
+	live.AccessToken = "live-token"
+	refreshable := testConnection(2)
+	refreshable.RefreshToken = "refresh-token"
+	dead := testConnection(3)
+	f := newConnectionsFixture(live, refreshable, dead)
+	h := f.handlers(t)
+	h.syncService = socialmedia.NewSyncService(socialmedia.NewDB(h.db.DB), plainTokens{})
+	h.syncService.RegisterProvider(tokenChecker{stubProvider{platform: socialmedia.PlatformGoogleBusiness}})
+
+	router := gin.New()
+	router.GET("/connections/:id/status", asMerchant(7), h.GetConnectionStatus)
+	router.GET("/other/connections/:id/status", asMerchant(8), h.GetConnectionStatus)
+
+	tests := []struct {
+		path        string
+		valid       bool
+		needsReauth bool
+	}{
+		{"/connections/1/status", true, false},
+		{"/connections/2/status", false, false},
+		{"/connections/3/status", false, true},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		var body struct {
+			Valid       bool      `json:"valid"`
+			NeedsReauth bool      `json:"needs_reauth"`
+			ExpiresAt   time.Time `json:"expires_at"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+			t.Fatalf("%s: status = %d, body %s", tt.path, w.Code, w.Body)
+		}
+		if body.Valid != tt.valid || body.NeedsReauth != tt.needsReauth || body.ExpiresAt.IsZero() {
+			t.Errorf("%s: got %+v, want valid=%v needs_reauth=%v and an expiry", tt.path, body, tt.valid, tt.needsReauth)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other/connections/1/status", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("other merchant: status = %d, want 403", w.Code)
+	}
+}
+
+func TestGetSyncedReviewsPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const total = 3
+	now := time.Now()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT COUNT(*) FROM synced_reviews WHERE merchant_id = $1"):
+			return &fakedb.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(total)}}}, nil
+		case strings.Contains(query, "LIMIT $2 OFFSET $3"):
+			res := &fakedb.Result{Columns: make([]string, 18)}
+			limit, offset := args[1].(int64), args[2].(int64)
+			for id := offset + 1; id <= total && id <= offset+limit; id++ {
+				res.Rows = append(res.Rows, []driver.Value{
+					id, int64(7), nil, socialmedia.PlatformGoogleBusiness, fmt.Sprintf("r%d", id),
+					"Aina", "", 5.0, "Good", "",
+					now, now, true, []byte("{}"), now, now,
+					nil, nil,
+				})
+			}
+			return res, nil
+		}
+		return nil, errors.New("no stats in this test")
+	})
+	defer conn.Close()
+
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/reviews", asMerchant(7), h.GetSyncedReviews)
+
+	tests := []struct {
+		query                string
+		limit, offset, count int
+		hasMore              bool
+	}{
+		{"", 50, 0, 3, false},
+		{"?limit=2", 2, 0, 2, true},
+		{"?limit=2&offset=2", 2, 2, 1, false},
+		{"?limit=0&offset=-4", 50, 0, 3, false},
+		{"?limit=500", maxSyncedReviewsLimit, 0, 3, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reviews"+tt.query, nil))
+		var body struct {
+			Reviews []json.RawMessage `json:"reviews"`
+			Total   int               `json:"total"`
+			Limit   int               `json:"limit"`
+			Offset  int               `json:"offset"`
+			HasMore bool              `json:"has_more"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+			t.Fatalf("%q: status = %d, body %s", tt.query, w.Code, w.Body)
+		}
+		if body.Total != total || body.Limit != tt.limit || body.Offset != tt.offset ||
+			len(body.Reviews) != tt.count || body.HasMore != tt.hasMore {
+			t.Errorf("%q: total %d, limit %d, offset %d, %d reviews, has_more %v; want limit %d, offset %d, %d reviews, has_more %v",
+				tt.query, body.Total, body.Limit, body.Offset, len(body.Reviews), body.HasMore, tt.limit, tt.offset, tt.count, tt.hasMore)
+		}
+	}
+}
+
+func TestGetSyncedReviewsFragment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT COUNT(*) FROM synced_reviews WHERE merchant_id = $1"):
+			return &fakedb.Result{Columns: []string{"count"}, Rows: [][]driver.Value{{int64(2)}}}, nil
+		case strings.Contains(query, "LIMIT $2 OFFSET $3"):
+			return &fakedb.Result{Columns: make([]string, 18), Rows: [][]driver.Value{
+				{int64(1), int64(7), nil, socialmedia.PlatformGoogleBusiness, "r1", "Aina", "", 5.0, "Lovely <b>coffee</b>", "",
+					now, now, true, []byte("{}"), now, now, nil, nil},
+				{int64(2), int64(7), nil, socialmedia.PlatformFacebook, "r2", "Ben", "", 2.0, "Slow", "",
+					now, now, false, []byte("{}"), now, now, nil, nil},
+			}}, nil
+		}
+		return nil, errors.New("no stats in this test")
+	})
+	defer conn.Close()
+
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/reviews", asMerchant(7), h.GetSyncedReviews)
+
+	req := httptest.NewRequest(http.MethodGet, "/reviews?limit=10", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status = %d, content type %q; want an HTML fragment", w.Code, w.Header().Get("Content-Type"))
+	}
+	if n := strings.Count(body, "hidden-badge"); n != 1 {
+		t.Errorf("%d hidden badges, want 1 for the review hidden by the merchant:\n%s", n, body)
+	}
+	if !strings.Contains(body, `title="`+socialmedia.HiddenReasonNotVisible+`"`) {
+		t.Errorf("hidden badge has no reason tooltip:\n%s", body)
+	}
+	if strings.Contains(body, "<b>coffee</b>") || !strings.Contains(body, "Lovely &lt;b&gt;coffee&lt;/b&gt;") {
+		t.Errorf("review text not escaped:\n%s", body)
+	}
+}
+
+func TestGetSyncedReviewsFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	row := func(id, merchantID int64, platform string, rating driver.Value, visible bool) []driver.Value {
+		return []driver.Value{id, merchantID, nil, platform, fmt.Sprint("r", id), "Aina", "", rating, "Good", "",
+			now, now, visible, []byte("{}"), now, now, nil, nil}
+	}
+	reviews := &fakedb.Table{
+		Columns: []string{"id", "merchant_id", "api_connection_id", "platform", "platform_review_id",
+			"author_name", "author_photo_url", "rating", "review_text", "review_reply",
+			"reviewed_at", "synced_at", "is_visible", "metadata", "created_at", "updated_at",
+			"sentiment", "sentiment_score"},
+		Rows: [][]driver.Value{
+			row(1, 7, socialmedia.PlatformFacebook, 3.0, true),
+			row(2, 7, socialmedia.PlatformFacebook, 5.0, true),
+			row(3, 7, socialmedia.PlatformGoogleBusiness, 3.0, true),
+			row(4, 7, socialmedia.PlatformFacebook, 1.0, true),
+			row(5, 8, socialmedia.PlatformFacebook, 3.0, true),
+			row(6, 7, socialmedia.PlatformFacebook, 4.5, false),
+			row(7, 7, socialmedia.PlatformFacebook, nil, true),
+		},
+	}
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT COUNT(*) FROM synced_reviews WHERE"):
+			return reviews.Count(query, args)
+		case strings.Contains(query, "LIMIT $"):
+			rows, err := reviews.Match(query, args)
+			return &fakedb.Result{Columns: reviews.Columns, Rows: rows}, err
+		}
+		return nil, errors.New("no stats in this test")
+	})
+	defer conn.Close()
+
+	h := &SocialMediaHandlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/reviews", asMerchant(7), h.GetSyncedReviews)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reviews"+query, nil))
+		return w
+	}
+
+	// Hidden reviews are listed; unrated ones fall outside any rating range
+	for query, want := range map[string]string{
+		"?platform=facebook&min_rating=2&max_rating=4.5":                  "[1 6] of 2",
+		"?platform=facebook&min_rating=2&max_rating=4.5&limit=1&offset=1": "[6] of 2",
+		"?min_rating=3&max_rating=3":                                      "[1 3] of 2",
+	} {
+		w := get(query)
+		var body struct {
+			Reviews []socialmedia.SyncedReview `json:"reviews"`
+			Total   int                        `json:"total"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+			t.Fatalf("%s: status = %d, body %s", query, w.Code, w.Body)
+		}
+		var ids []int
+		for _, review := range body.Reviews {
+			ids = append(ids, review.ID)
+		}
+		if got := fmt.Sprintf("%v of %d", ids, body.Total); got != want {
+			t.Errorf("%s: got reviews %s, want %s", query, got, want)
+		}
+	}
+
+	for _, query := range []string{"?platform=myspace", "?min_rating=six", "?max_rating=5.5", "?min_rating=4&max_rating=2"} {
+		if code := get(query).Code; code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, code)
+		}
+	}
+}
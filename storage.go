@@ -7,13 +7,55 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Storage abstracts where uploaded files such as merchant logos are kept
+type Storage interface {
+	// Upload stores the file under folder and returns its public URL
+	Upload(file multipart.File, header *multipart.FileHeader, folder string) (string, error)
+
+	// Delete removes the object at path (relative to the bucket)
+	Delete(path string) error
+
+	// ObjectPath returns the object path for a public URL produced by Upload.
+	// The boolean is false for URLs hosted elsewhere.
+	ObjectPath(publicURL string) (string, bool)
+}
+
+// NewStorage returns the storage backend selected by STORAGE_BACKEND (supabase or s3)
+func NewStorage() (Storage, error) {
+	switch backend := getEnvWithDefault("STORAGE_BACKEND", "supabase"); backend {
+	case "supabase":
+		return &SupabaseStorage{}, nil
+	case "s3":
+		return NewS3Storage()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND: %s", backend)
+	}
+}
+
+// SupabaseStorage stores files in a Supabase Storage bucket
+type SupabaseStorage struct{}
+
+// Upload uploads a file to Supabase Storage
+func (s *SupabaseStorage) Upload(file multipart.File, header *multipart.FileHeader, folder string) (string, error) {
+	return uploadToSupabase(file, header, folder)
+}
+
+// Delete removes an object from Supabase Storage
+func (s *SupabaseStorage) Delete(path string) error {
+	return deleteFromSupabase(path)
+}
+
+// ObjectPath extracts the bucket object path from a Supabase public URL
+func (s *SupabaseStorage) ObjectPath(publicURL string) (string, bool) {
+	return supabaseObjectPath(publicURL)
+}
+
 // StorageConfig holds Supabase storage configuration
 type StorageConfig struct {
 	SupabaseURL        string
@@ -39,39 +81,14 @@ func uploadToSupabase(file multipart.File, header *multipart.FileHeader, folder
 		return "", fmt.Errorf("Supabase configuration missing. Please check SUPABASE_URL and SUPABASE_SERVICE_KEY")
 	}
 
-	// Generate unique filename with timestamp
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext == "" {
-		ext = ".jpg" // default extension
-	}
-
-	// Validate file extension
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
-	isValidExt := false
-	for _, validExt := range allowedExts {
-		if ext == validExt {
-			isValidExt = true
-			break
-		}
-	}
-	if !isValidExt {
-		return "", fmt.Errorf("invalid file type. Allowed: jpg, jpeg, png, gif, webp")
+	fileBytes, contentType, ext, err := readImageUpload(file)
+	if err != nil {
+		return "", err
 	}
 
 	// Create unique filename: folder/timestamp_uuid.ext
 	filename := fmt.Sprintf("%s/%d_%s%s", folder, time.Now().Unix(), uuid.New().String()[:8], ext)
 
-	// Read file content
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file: %v", err)
-	}
-
-	// Check file size (limit to 5MB)
-	if len(fileBytes) > 5*1024*1024 {
-		return "", fmt.Errorf("file too large. Maximum size is 5MB")
-	}
-
 	// Build Supabase Storage API URL
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", storageConfig.SupabaseURL, storageConfig.StorageBucket, filename)
 
@@ -83,7 +100,7 @@ func uploadToSupabase(file multipart.File, header *multipart.FileHeader, folder
 
 	// Set headers
 	req.Header.Set("Authorization", "Bearer "+storageConfig.SupabaseServiceKey)
-	req.Header.Set("Content-Type", header.Header.Get("Content-Type"))
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Cache-Control", "3600")
 
 	// Make the request
@@ -104,3 +121,93 @@ func uploadToSupabase(file multipart.File, header *multipart.FileHeader, folder
 	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", storageConfig.SupabaseURL, storageConfig.StorageBucket, filename)
 	return publicURL, nil
 }
+
+// readImageUpload reads an uploaded file, enforcing the size limit and image type
+func readImageUpload(file multipart.File) ([]byte, string, string, error) {
+	// Read file content
+	fileBytes, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	// Check file size (MAX_UPLOAD_BYTES, default 5MB)
+	if int64(len(fileBytes)) > maxUploadBytes {
+		return nil, "", "", fmt.Errorf("file too large. Maximum size is %s", uploadLimitLabel())
+	}
+
+	// Validate file type from its content rather than the filename or form header
+	contentType, ext, err := detectImageType(fileBytes)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return fileBytes, contentType, ext, nil
+}
+
+// allowedImageTypes maps sniffed MIME types to the extension used for stored objects
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// detectImageType sniffs the first 512 bytes of a file and returns its MIME type and extension
+func detectImageType(fileBytes []byte) (string, string, error) {
+	sniffLen := len(fileBytes)
+	if sniffLen > 512 {
+		sniffLen = 512
+	}
+
+	contentType := http.DetectContentType(fileBytes[:sniffLen])
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("invalid file type (detected %s). Allowed: jpg, jpeg, png, gif, webp", contentType)
+	}
+	return contentType, ext, nil
+}
+
+// supabaseObjectPath returns the object path within the storage bucket for a public URL
+// produced by uploadToSupabase. The boolean is false for externally-hosted URLs.
+func supabaseObjectPath(publicURL string) (string, bool) {
+	storageConfig := getStorageConfig()
+	if storageConfig.SupabaseURL == "" || publicURL == "" {
+		return "", false
+	}
+
+	prefix := fmt.Sprintf("%s/storage/v1/object/public/%s/", storageConfig.SupabaseURL, storageConfig.StorageBucket)
+	if !strings.HasPrefix(publicURL, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(publicURL, prefix), true
+}
+
+// deleteFromSupabase removes an object from Supabase Storage
+func deleteFromSupabase(objectPath string) error {
+	storageConfig := getStorageConfig()
+
+	if storageConfig.SupabaseURL == "" || storageConfig.SupabaseServiceKey == "" {
+		return fmt.Errorf("Supabase configuration missing. Please check SUPABASE_URL and SUPABASE_SERVICE_KEY")
+	}
+
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", storageConfig.SupabaseURL, storageConfig.StorageBucket, objectPath)
+
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+storageConfig.SupabaseServiceKey)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
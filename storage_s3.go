@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+)
+
+// S3Storage stores files in an S3-compatible bucket (AWS S3, MinIO, R2, ...)
+type S3Storage struct {
+	client    *s3.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3Storage creates an S3 backend from S3_BUCKET, S3_REGION, S3_ENDPOINT and S3_PUBLIC_URL.
+// Credentials come from the standard AWS environment variables or shared config.
+func NewS3Storage() (*S3Storage, error) {
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET environment variable is required for the s3 storage backend")
+	}
+	region := getEnvWithDefault("S3_REGION", "us-east-1")
+	endpoint := os.Getenv("S3_ENDPOINT")
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			// Custom endpoints (MinIO etc.) generally need path-style addressing
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	// Public URL base defaults to the virtual-hosted AWS URL or the custom endpoint
+	publicURL := strings.TrimSuffix(os.Getenv("S3_PUBLIC_URL"), "/")
+	if publicURL == "" {
+		if endpoint != "" {
+			publicURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(endpoint, "/"), bucket)
+		} else {
+			publicURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+		}
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    bucket,
+		publicURL: publicURL,
+	}, nil
+}
+
+// Upload uploads a file to the bucket and returns its public URL
+func (s *S3Storage) Upload(file multipart.File, header *multipart.FileHeader, folder string) (string, error) {
+	fileBytes, contentType, ext, err := readImageUpload(file)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s/%d_%s%s", folder, time.Now().Unix(), uuid.New().String()[:8], ext)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		Body:         bytes.NewReader(fileBytes),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("max-age=3600"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %v", err)
+	}
+
+	return fmt.Sprintf("%s/%s", s.publicURL, key), nil
+}
+
+// Delete removes an object from the bucket
+func (s *S3Storage) Delete(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		return fmt.Errorf("delete failed: %v", err)
+	}
+	return nil
+}
+
+// ObjectPath extracts the object key from a public URL in this bucket
+func (s *S3Storage) ObjectPath(publicURL string) (string, bool) {
+	prefix := s.publicURL + "/"
+	if publicURL == "" || !strings.HasPrefix(publicURL, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(publicURL, prefix), true
+}
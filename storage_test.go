@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"auto-gbp-review/internal/fakedb"
+)
+
+// memFile is an in-memory multipart.File
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+// pngBytes is a 1x1 transparent PNG
+var pngBytes, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+
+func TestReadImageUploadAcceptsPNG(t *testing.T) {
+	data, contentType, ext, err := readImageUpload(memFile{bytes.NewReader(pngBytes)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "image/png" || ext != ".png" || !bytes.Equal(data, pngBytes) {
+		t.Errorf("got %s %s (%d bytes), want image/png .png with the file's bytes", contentType, ext, len(data))
+	}
+}
+
+func TestReadImageUploadRejectsDisguisedText(t *testing.T) {
+	// A text file named and labelled as an image is still text
+	_, _, _, err := readImageUpload(memFile{bytes.NewReader([]byte("<?php echo 'hello'; ?>\n"))})
+	if err == nil || !strings.Contains(err.Error(), "text/plain") {
+		t.Errorf("err = %v, want an invalid file type error naming text/plain", err)
+	}
+}
+
+// mockSupabaseStorage points the storage config at a test server that answers
+// every request with status and records the method and path of each
+func mockSupabaseStorage(t *testing.T, status int) *[]string {
+	t.Helper()
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer service-key" {
+			t.Errorf("%s %s: missing service key", r.Method, r.URL.Path)
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("SUPABASE_URL", server.URL)
+	t.Setenv("SUPABASE_SERVICE_KEY", "service-key")
+	t.Setenv("STORAGE_BUCKET", "merchant-logos")
+	return &requests
+}
+
+func TestDeleteFromSupabase(t *testing.T) {
+	requests := mockSupabaseStorage(t, http.StatusOK)
+
+	if err := deleteFromSupabase("logos/123_abc.png"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 || (*requests)[0] != "DELETE /storage/v1/object/merchant-logos/logos/123_abc.png" {
+		t.Errorf("requests = %v, want one DELETE of the object", *requests)
+	}
+}
+
+func TestDeleteFromSupabaseReportsFailure(t *testing.T) {
+	mockSupabaseStorage(t, http.StatusNotFound)
+
+	if err := deleteFromSupabase("logos/missing.png"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want the failed status", err)
+	}
+}
+
+func TestSupabaseObjectPath(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "https://project.supabase.co")
+	t.Setenv("STORAGE_BUCKET", "merchant-logos")
+
+	for url, want := range map[string]string{
+		"https://project.supabase.co/storage/v1/object/public/merchant-logos/logos/1_a.png": "logos/1_a.png",
+		"https://project.supabase.co/storage/v1/object/public/other-bucket/logos/1_a.png":   "",
+		"https://cdn.example.com/logo.png":                                                  "",
+		"":                                                                                  "",
+	} {
+		path, ok := supabaseObjectPath(url)
+		if path != want || ok != (want != "") {
+			t.Errorf("supabaseObjectPath(%q) = %q, %v; want %q", url, path, ok, want)
+		}
+	}
+}
+
+func TestDeleteReplacedLogo(t *testing.T) {
+	requests := mockSupabaseStorage(t, http.StatusOK)
+	base := getStorageConfig().SupabaseURL + "/storage/v1/object/public/merchant-logos/"
+	shared := base + "logos/2_shared.png"
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		return &fakedb.Result{Columns: []string{"exists"}, Rows: [][]driver.Value{{args[0] == shared}}}, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+	h := &Handlers{db: &Database{DB: conn}, storage: &SupabaseStorage{}}
+
+	for _, logoURL := range []string{
+		base + "logos/1_old.png",
+		shared,
+		base + "banners/1_hero.png",
+		base + "logos/../banners/1_hero.png",
+		base + "logos/%2e%2e/banners/1_hero.png",
+		"https://cdn.example.com/logos/1_old.png",
+	} {
+		h.deleteReplacedLogo(logoURL, 1)
+	}
+	if want := []string{"DELETE /storage/v1/object/merchant-logos/logos/1_old.png"}; fmt.Sprint(*requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want only %v", *requests, want)
+	}
+}
+
+func TestNewStorageSelectsBackend(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	tests := []struct {
+		backend string
+		bucket  string
+		want    string
+		wantErr bool
+	}{
+		{"", "", "*main.SupabaseStorage", false},
+		{"supabase", "", "*main.SupabaseStorage", false},
+		{"s3", "logos", "*main.S3Storage", false},
+		{"s3", "", "", true},
+		{"ftp", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("STORAGE_BACKEND", tt.backend)
+		t.Setenv("S3_BUCKET", tt.bucket)
+
+		storage, err := NewStorage()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("STORAGE_BACKEND=%q S3_BUCKET=%q: err = %v", tt.backend, tt.bucket, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", storage); !tt.wantErr && got != tt.want {
+			t.Errorf("STORAGE_BACKEND=%q: got %s, want %s", tt.backend, got, tt.want)
+		}
+	}
+}
+
+func TestS3StorageUploadAndDelete(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("S3_BUCKET", "logos")
+	t.Setenv("S3_ENDPOINT", server.URL)
+	t.Setenv("S3_PUBLIC_URL", "https://cdn.example.com/")
+	storage, err := NewS3Storage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := storage.Upload(memFile{bytes.NewReader(pngBytes)}, nil, "merchants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, ok := storage.ObjectPath(url)
+	if !ok || !strings.HasPrefix(key, "merchants/") || !strings.HasSuffix(key, ".png") {
+		t.Fatalf("Upload returned %q, want a public URL for a merchants/*.png key", url)
+	}
+	if err := storage.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"PUT /logos/" + key, "DELETE /logos/" + key}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	if _, ok := storage.ObjectPath("https://elsewhere.example.com/merchants/a.png"); ok {
+		t.Error("ObjectPath accepted a URL outside the bucket")
+	}
+}
@@ -0,0 +1,12 @@
+-- Migration: Analytics sampling for high-traffic merchants
+-- Created: 2025-10-29
+-- Description: Lets admins store 1 in N page views per merchant, with each stored row weighted by N
+
+ALTER TABLE public.merchants
+    ADD COLUMN IF NOT EXISTS analytics_sample_rate INTEGER NOT NULL DEFAULT 1 CHECK (analytics_sample_rate >= 1);
+
+ALTER TABLE public.page_views
+    ADD COLUMN IF NOT EXISTS weight INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN public.merchants.analytics_sample_rate IS 'Store 1 in N page views (1 = exact tracking)';
+COMMENT ON COLUMN public.page_views.weight IS 'Number of views this row represents (equals the sample rate at insert time)';
@@ -0,0 +1,8 @@
+-- Migration: Admin-only notes on API connections
+-- Created: 2025-10-29
+-- Description: Lets admins record triage context; never exposed to merchants
+
+ALTER TABLE api_connections
+    ADD COLUMN IF NOT EXISTS admin_notes TEXT;
+
+COMMENT ON COLUMN api_connections.admin_notes IS 'Internal notes for admins triaging connection issues (not shown to merchants)';
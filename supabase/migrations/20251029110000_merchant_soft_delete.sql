@@ -0,0 +1,10 @@
+-- Migration: Soft delete for merchants
+-- Created: 2025-10-29
+-- Description: Deleting a merchant sets deleted_at instead of cascading to details, reviews and connections
+
+ALTER TABLE merchants
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_merchants_deleted_at ON merchants(deleted_at);
+
+COMMENT ON COLUMN merchants.deleted_at IS 'When the merchant was soft-deleted by an admin (NULL = not deleted)';
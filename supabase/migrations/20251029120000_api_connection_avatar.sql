@@ -0,0 +1,8 @@
+-- Migration: Avatar of the connected platform account
+-- Created: 2025-10-29
+-- Description: Populated from the provider's account info on connect/reconnect
+
+ALTER TABLE api_connections
+    ADD COLUMN IF NOT EXISTS account_avatar_url TEXT;
+
+COMMENT ON COLUMN api_connections.account_avatar_url IS 'Profile picture URL of the connected platform account, if the platform provides one';
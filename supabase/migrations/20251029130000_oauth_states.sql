@@ -0,0 +1,16 @@
+-- Migration: Pending OAuth authorization requests
+-- Created: 2025-10-29
+-- Description: The state sent to the provider is stored here instead of a cookie so the
+-- callback can land on any instance; each state is single-use and short-lived
+
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state VARCHAR(255) PRIMARY KEY,
+    merchant_id INTEGER NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    platform VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires_at ON oauth_states(expires_at);
+
+COMMENT ON TABLE oauth_states IS 'Single-use OAuth state values, deleted when the callback consumes them or once expired';
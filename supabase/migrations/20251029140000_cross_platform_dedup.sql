@@ -0,0 +1,8 @@
+-- Migration: Opt-in cross-platform review dedup
+-- Created: 2025-10-29
+-- Description: Lets a merchant collapse the same review synced from more than one platform (e.g. a Facebook page and its Instagram)
+
+ALTER TABLE public.merchants
+    ADD COLUMN IF NOT EXISTS cross_platform_dedup BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.merchants.cross_platform_dedup IS 'Hide synced reviews duplicated across platforms (same author, text and day), keeping one copy visible';
@@ -0,0 +1,10 @@
+-- Migration: Full-text search over synced reviews
+-- Created: 2025-10-29
+-- Description: GIN index backing the merchant review search. The expression must
+-- match reviewSearchDocument in social_media/search.go or the index won't be used.
+-- 'simple' is used because reviews come in several languages.
+
+CREATE INDEX IF NOT EXISTS idx_synced_reviews_search ON synced_reviews
+    USING GIN (to_tsvector('simple', COALESCE(author_name, '') || ' ' || COALESCE(review_text, '')));
+
+COMMENT ON INDEX idx_synced_reviews_search IS 'Full-text search over author name and review text';
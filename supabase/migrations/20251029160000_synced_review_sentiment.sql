@@ -0,0 +1,12 @@
+-- Migration: Sentiment of synced reviews
+-- Created: 2025-10-29
+-- Description: Stores a positive/neutral/negative label and score for reviews with text, set during sync
+
+ALTER TABLE synced_reviews
+    ADD COLUMN IF NOT EXISTS sentiment VARCHAR(20) CHECK (sentiment IN ('positive', 'neutral', 'negative')),
+    ADD COLUMN IF NOT EXISTS sentiment_score NUMERIC(4, 3);
+
+CREATE INDEX IF NOT EXISTS idx_synced_reviews_sentiment ON synced_reviews(merchant_id, sentiment);
+
+COMMENT ON COLUMN synced_reviews.sentiment IS 'positive, neutral or negative; NULL for reviews without text';
+COMMENT ON COLUMN synced_reviews.sentiment_score IS 'Analyzer score from -1 (negative) to 1 (positive)';
@@ -0,0 +1,8 @@
+-- Migration: Email alerts for new negative reviews
+-- Created: 2025-10-29
+-- Description: Lets a merchant opt into an email when a sync brings in low-star reviews
+
+ALTER TABLE public.merchants
+    ADD COLUMN IF NOT EXISTS notify_on_negative BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.merchants.notify_on_negative IS 'Email the owner when a sync adds reviews at or below NEGATIVE_REVIEW_THRESHOLD';
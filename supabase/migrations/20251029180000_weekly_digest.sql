@@ -0,0 +1,10 @@
+-- Migration: Weekly digest emails
+-- Created: 2025-10-29
+-- Description: Opt-in weekly summary of reviews and analytics, sent once per merchant per week
+
+ALTER TABLE public.merchants
+    ADD COLUMN IF NOT EXISTS weekly_digest BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS last_digest_sent_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN public.merchants.weekly_digest IS 'Email the owner a weekly review and analytics summary';
+COMMENT ON COLUMN public.merchants.last_digest_sent_at IS 'When the last weekly digest was sent; prevents duplicates across restarts';
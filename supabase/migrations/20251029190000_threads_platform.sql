@@ -0,0 +1,13 @@
+-- Migration: Threads as a review source
+-- Created: 2025-10-29
+-- Description: Allows 'threads' in the platform columns of api_connections and synced_reviews
+
+ALTER TABLE api_connections DROP CONSTRAINT IF EXISTS api_connections_platform_check;
+ALTER TABLE api_connections
+    ADD CONSTRAINT api_connections_platform_check
+    CHECK (platform IN ('google_business', 'facebook', 'instagram', 'threads'));
+
+ALTER TABLE synced_reviews DROP CONSTRAINT IF EXISTS synced_reviews_platform_check;
+ALTER TABLE synced_reviews
+    ADD CONSTRAINT synced_reviews_platform_check
+    CHECK (platform IN ('google_business', 'facebook', 'instagram', 'threads'));
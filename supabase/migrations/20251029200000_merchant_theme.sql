@@ -0,0 +1,12 @@
+-- Migration: Per-merchant page theme
+-- Created: 2025-10-29
+-- Description: Adds secondary color, text color and font to merchant_details alongside theme_color; NULL keeps the default look
+
+ALTER TABLE public.merchant_details
+    ADD COLUMN IF NOT EXISTS secondary_color VARCHAR(7),
+    ADD COLUMN IF NOT EXISTS text_color VARCHAR(7),
+    ADD COLUMN IF NOT EXISTS font_family VARCHAR(20);
+
+COMMENT ON COLUMN public.merchant_details.secondary_color IS 'Button color on the public pages as #RRGGBB, NULL for the default';
+COMMENT ON COLUMN public.merchant_details.text_color IS 'Text color on the public pages as #RRGGBB, NULL for the default';
+COMMENT ON COLUMN public.merchant_details.font_family IS 'Font key from themeFonts (sans, serif, rounded, mono), NULL for the default';
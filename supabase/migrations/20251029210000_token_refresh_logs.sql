@@ -0,0 +1,20 @@
+-- Migration: Token refresh logs
+-- Created: 2025-10-29
+-- Description: Records each proactive token refresh attempt made by the scheduler, separate from review syncs
+
+CREATE TABLE IF NOT EXISTS token_refresh_logs (
+    id SERIAL PRIMARY KEY,
+    api_connection_id INTEGER NOT NULL REFERENCES api_connections(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL CHECK (status IN ('completed', 'failed')),
+    error_message TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_refresh_logs_api_connection ON token_refresh_logs(api_connection_id);
+CREATE INDEX IF NOT EXISTS idx_token_refresh_logs_created_at ON token_refresh_logs(created_at DESC);
+
+ALTER TABLE token_refresh_logs ENABLE ROW LEVEL SECURITY;
+
+COMMENT ON TABLE token_refresh_logs IS 'Outcome of each scheduled token refresh; pruned with sync logs after SYNC_LOG_RETENTION_DAYS';
+COMMENT ON COLUMN token_refresh_logs.expires_at IS 'Token expiry after the refresh, or the old expiry when it failed';
@@ -0,0 +1,12 @@
+-- Migration: Soft delete for review templates
+-- Created: 2025-10-29
+-- Description: Deleting a review template sets deleted_at so it can be restored for 30 days; a background job purges older rows
+
+ALTER TABLE public.merchant_reviews
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_merchant_reviews_deleted_at
+    ON public.merchant_reviews(deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN public.merchant_reviews.deleted_at IS 'When the merchant deleted the template (NULL = not deleted); restorable until purged after 30 days';
@@ -0,0 +1,16 @@
+-- Migration: Deduplicate repeat page views
+-- Created: 2025-10-29
+-- Description: Counts repeat views from the same visitor within the dedup window separately instead of storing a row for each
+
+CREATE TABLE IF NOT EXISTS public.page_view_repeats (
+    merchant_id INTEGER NOT NULL REFERENCES public.merchants(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    repeats INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (merchant_id, day)
+);
+
+-- Supports the "viewed recently from this IP" lookup done on every page view
+CREATE INDEX IF NOT EXISTS idx_page_views_merchant_ip_created ON public.page_views(merchant_id, ip_address, created_at);
+
+COMMENT ON TABLE public.page_view_repeats IS 'Daily count of page views suppressed as repeats from the same visitor';
+COMMENT ON COLUMN public.page_view_repeats.repeats IS 'Views from an IP that had already viewed the page within the dedup window';
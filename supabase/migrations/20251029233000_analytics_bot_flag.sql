@@ -0,0 +1,12 @@
+-- Migration: Flag bot traffic in analytics
+-- Created: 2025-10-29
+-- Description: Marks page views and link clicks from crawlers and monitors so dashboards can exclude them
+
+ALTER TABLE public.page_views
+    ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE public.link_clicks
+    ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.page_views.is_bot IS 'True when the user agent matched the bot filter at insert time';
+COMMENT ON COLUMN public.link_clicks.is_bot IS 'True when the user agent matched the bot filter at insert time';
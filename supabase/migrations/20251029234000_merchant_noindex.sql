@@ -0,0 +1,8 @@
+-- Migration: Per-merchant search indexing toggle
+-- Created: 2025-10-29
+-- Description: Lets a merchant keep their business page out of search engines
+
+ALTER TABLE public.merchant_details
+    ADD COLUMN IF NOT EXISTS noindex BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.merchant_details.noindex IS 'When true the business page asks search engines not to index it and is left out of the sitemap';
@@ -0,0 +1,17 @@
+-- Migration: Synced review edit history
+-- Created: 2025-10-29
+-- Description: Keeps the previous rating, text and reply of a synced review each time a sync finds it edited
+
+CREATE TABLE IF NOT EXISTS public.synced_review_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    synced_review_id INTEGER NOT NULL REFERENCES public.synced_reviews(id) ON DELETE CASCADE,
+    rating DECIMAL(2,1),
+    review_text TEXT,
+    review_reply TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_synced_review_revisions_review ON public.synced_review_revisions(synced_review_id, created_at DESC);
+
+COMMENT ON TABLE public.synced_review_revisions IS 'Previous versions of synced reviews, recorded when the reviewer edits them on the platform';
+COMMENT ON COLUMN public.synced_review_revisions.created_at IS 'When the edit was picked up by a sync (the values are what the review said before then)';
@@ -0,0 +1,8 @@
+-- Migration: Link retried syncs to the failed sync they retry
+-- Created: 2025-10-30
+-- Description: Admins can re-run a failed sync; the new sync log points back at the original
+
+ALTER TABLE sync_logs
+    ADD COLUMN IF NOT EXISTS retry_of INTEGER REFERENCES sync_logs(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN sync_logs.retry_of IS 'The failed sync log this sync was started to retry (NULL for ordinary syncs)';
@@ -0,0 +1,26 @@
+-- Migration: Guarantee one synced review per platform review
+-- Created: 2025-10-30
+-- Description: Syncs upsert on (platform, platform_review_id). The table was created with a
+-- UNIQUE constraint on those columns, whose index has the name used below, so this is a no-op
+-- there; databases whose table predates the constraint get duplicates removed and the index added.
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_indexes
+        WHERE tablename = 'synced_reviews'
+          AND indexname = 'synced_reviews_platform_platform_review_id_key'
+    ) THEN
+        -- Keep the earliest copy of each duplicated review
+        DELETE FROM synced_reviews a
+        USING synced_reviews b
+        WHERE a.platform = b.platform
+          AND a.platform_review_id = b.platform_review_id
+          AND a.id > b.id;
+
+        CREATE UNIQUE INDEX synced_reviews_platform_platform_review_id_key
+            ON synced_reviews(platform, platform_review_id);
+    END IF;
+END $$;
+
+COMMENT ON INDEX synced_reviews_platform_platform_review_id_key IS 'One row per platform review; target of the sync upsert';
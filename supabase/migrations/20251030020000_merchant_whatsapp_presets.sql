@@ -0,0 +1,9 @@
+-- Migration: Per-locale WhatsApp preset messages
+-- Created: 2025-10-30
+-- Description: Lets merchants set a different WhatsApp message for each page locale,
+-- keeping whatsapp_preset_text as the fallback
+
+ALTER TABLE public.merchant_details
+    ADD COLUMN IF NOT EXISTS whatsapp_presets JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN public.merchant_details.whatsapp_presets IS 'WhatsApp preset text keyed by locale (ms, zh); missing locales use whatsapp_preset_text';
@@ -0,0 +1,9 @@
+-- Migration: Cached Google Place ID
+-- Created: 2025-10-30
+-- Description: Stores the Place ID found through the Places API so business pages
+-- don't look it up on every view
+
+ALTER TABLE public.merchant_details
+    ADD COLUMN IF NOT EXISTS google_place_id TEXT;
+
+COMMENT ON COLUMN public.merchant_details.google_place_id IS 'Place ID from the Places API text search; cleared when the business name or address changes';
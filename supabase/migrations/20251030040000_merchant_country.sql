@@ -0,0 +1,9 @@
+-- Migration: Merchant country
+-- Created: 2025-10-30
+-- Description: Records which country a business is in so Waze links are only
+-- built from location data for that country. Existing merchants are Malaysian.
+
+ALTER TABLE public.merchant_details
+    ADD COLUMN IF NOT EXISTS country VARCHAR(2) DEFAULT 'MY';
+
+COMMENT ON COLUMN public.merchant_details.country IS 'ISO 3166-1 alpha-2 country code; NULL or empty when unknown, which makes Waze links fall back to an address search';
@@ -0,0 +1,14 @@
+-- Migration: Short links
+-- Created: 2025-10-30
+-- Description: Short codes that redirect to a merchant's business page, for print and SMS
+
+CREATE TABLE IF NOT EXISTS public.short_links (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    merchant_id INTEGER NOT NULL UNIQUE REFERENCES public.merchants(id) ON DELETE CASCADE,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+COMMENT ON TABLE public.short_links IS 'One short code per merchant; /s/:code redirects to the business page';
+COMMENT ON COLUMN public.short_links.clicks IS 'Number of times the short link has been followed';
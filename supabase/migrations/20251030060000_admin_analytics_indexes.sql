@@ -0,0 +1,12 @@
+-- Migration: Indexes for the admin analytics overview
+-- Created: 2025-10-30
+-- Description: The admin dashboard totals non-bot views and clicks across all
+-- merchants for a recent period, so it filters on created_at rather than merchant_id
+
+CREATE INDEX IF NOT EXISTS idx_page_views_human_created
+    ON public.page_views(created_at) INCLUDE (merchant_id, weight)
+    WHERE NOT is_bot;
+
+CREATE INDEX IF NOT EXISTS idx_link_clicks_human_created
+    ON public.link_clicks(created_at) INCLUDE (merchant_id, platform)
+    WHERE NOT is_bot;
@@ -0,0 +1,20 @@
+-- Migration: Merchant API keys
+-- Created: 2025-10-30
+-- Description: Bearer keys for the read-only /api/v1 endpoints, each scoped to one merchant
+
+CREATE TABLE IF NOT EXISTS public.api_keys (
+    id SERIAL PRIMARY KEY,
+    merchant_id INTEGER NOT NULL REFERENCES public.merchants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_merchant_id ON public.api_keys(merchant_id);
+
+COMMENT ON TABLE public.api_keys IS 'Merchant API keys; only a SHA-256 hash of each key is stored';
+COMMENT ON COLUMN public.api_keys.key_prefix IS 'First characters of the key, shown so merchants can tell keys apart';
+COMMENT ON COLUMN public.api_keys.revoked_at IS 'Set when the key is revoked; revoked keys are rejected';
@@ -0,0 +1,12 @@
+-- Migration: Bind OAuth states to the user who started the flow
+-- Created: 2025-10-30
+-- Description: The callback only accepts a state completed by the same user who requested it
+
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS auth_user_id UUID;
+
+-- Pending states from before this migration can't be checked; they expire within minutes anyway
+DELETE FROM oauth_states WHERE auth_user_id IS NULL;
+
+ALTER TABLE oauth_states ALTER COLUMN auth_user_id SET NOT NULL;
+
+COMMENT ON COLUMN oauth_states.auth_user_id IS 'Supabase user who started the authorization; only they can complete it';
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -98,8 +99,8 @@ func SupabaseLogin(c *gin.Context) {
 	}
 
 	// Set the access token as a cookie
-	c.SetCookie("sb_access_token", user.AccessToken, 3600, "/", "", false, true)
-	c.SetCookie("sb_refresh_token", user.RefreshToken, 86400*7, "/", "", false, true)
+	c.SetCookie("sb_access_token", user.AccessToken, 3600, cookiePath(), "", false, true)
+	c.SetCookie("sb_refresh_token", user.RefreshToken, 86400*7, cookiePath(), "", false, true)
 
 	// Get user role from JWT custom claims (injected by Auth Hook)
 	role, err := extractRoleFromJWT(user.AccessToken)
@@ -110,9 +111,9 @@ func SupabaseLogin(c *gin.Context) {
 
 	// Redirect based on role
 	if role == "admin" || role == "superadmin" {
-		c.Redirect(http.StatusFound, "/admin")
+		c.Redirect(http.StatusFound, appPath("/admin"))
 	} else {
-		c.Redirect(http.StatusFound, "/dashboard")
+		c.Redirect(http.StatusFound, appPath("/dashboard"))
 	}
 }
 
@@ -164,6 +165,10 @@ func SupabaseLogout(c *gin.Context) {
 	accessToken, _ := c.Cookie("sb_access_token")
 	
 	if accessToken != "" {
+		if supabaseSessions != nil {
+			supabaseSessions.Delete(accessToken)
+		}
+
 		client := GetSupabaseClient()
 		ctx := context.Background()
 		err := client.Auth.SignOut(ctx, accessToken)
@@ -174,11 +179,108 @@ func SupabaseLogout(c *gin.Context) {
 	}
 	
 	// Clear cookies
-	c.SetCookie("sb_access_token", "", -1, "/", "", false, true)
-	c.SetCookie("sb_refresh_token", "", -1, "/", "", false, true)
-	c.SetCookie("auth_token", "", -1, "/", "", false, true) // Clear old JWT cookie too
+	c.SetCookie("sb_access_token", "", -1, cookiePath(), "", false, true)
+	c.SetCookie("sb_refresh_token", "", -1, cookiePath(), "", false, true)
+	c.SetCookie("auth_token", "", -1, cookiePath(), "", false, true) // Clear old JWT cookie too
+	clearImpersonationCookie(c)
 	
-	c.Redirect(http.StatusFound, "/")
+	c.Redirect(http.StatusFound, appPath("/"))
+}
+
+// requireLogin sends unauthenticated API clients a 401 and browsers to the login page
+func requireLogin(c *gin.Context) {
+	if isAPIRequest(c) {
+		respondAPIError(c, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	c.Redirect(http.StatusFound, appPath("/login"))
+	c.Abort()
+}
+
+// supabaseSessionAuth is the part of the Supabase Auth client used to validate sessions
+type supabaseSessionAuth interface {
+	User(ctx context.Context, userToken string) (*supa.User, error)
+	RefreshUser(ctx context.Context, userToken, refreshToken string) (*supa.AuthenticatedDetails, error)
+}
+
+// authenticateSession validates the access token and, if that fails, tries the
+// refresh token cookie. It returns the user and the access token now in effect
+// (the refreshed one, which is also written back to the cookies). ok is true
+// only when one of the two succeeded.
+func authenticateSession(c *gin.Context, auth supabaseSessionAuth, accessToken string) (user *supa.User, token string, ok bool) {
+	ctx := context.Background()
+
+	user, err := auth.User(ctx, accessToken)
+	if err == nil {
+		return user, accessToken, true
+	}
+
+	refreshToken, _ := c.Cookie("sb_refresh_token")
+	if refreshToken == "" {
+		return nil, "", false
+	}
+
+	refreshed, err := auth.RefreshUser(ctx, accessToken, refreshToken)
+	if err != nil {
+		log.Printf("Session refresh failed: %v", err)
+		return nil, "", false
+	}
+
+	c.SetCookie("sb_access_token", refreshed.AccessToken, 3600, cookiePath(), "", false, true)
+	c.SetCookie("sb_refresh_token", refreshed.RefreshToken, 86400*7, cookiePath(), "", false, true)
+	return &refreshed.User, refreshed.AccessToken, true
+}
+
+// resolveSession returns the session for an access token, from the cache when
+// possible, then by verifying the JWT locally when AUTH_VERIFY_MODE=local.
+// Otherwise it validates with Supabase (refreshing if needed), reads
+// the role from the JWT custom claims injected by the Auth Hook, which also
+// rejects banned users, and caches the result under the token now in effect.
+func resolveSession(c *gin.Context, auth supabaseSessionAuth, cache sessionCache, accessToken string) (*authSession, bool) {
+	if cache != nil {
+		if session, ok := cache.Get(accessToken); ok {
+			return session, true
+		}
+	}
+
+	if supabaseJWTSecret != nil {
+		session, err := verifySupabaseJWT(accessToken, supabaseJWTSecret)
+		if err == nil {
+			if cache != nil {
+				cache.Set(accessToken, session, jwtExpiry(accessToken))
+			}
+			return session, true
+		}
+	}
+
+	user, accessToken, ok := authenticateSession(c, auth, accessToken)
+	if !ok {
+		return nil, false
+	}
+
+	role, err := extractRoleFromJWT(accessToken)
+	if err != nil {
+		log.Printf("Error extracting role from JWT: %v", err)
+		return nil, false
+	}
+
+	session := &authSession{UserID: user.ID, Email: user.Email, Role: role}
+	if cache != nil {
+		cache.Set(accessToken, session, jwtExpiry(accessToken))
+	}
+	return session, true
+}
+
+// jwtExpiry returns the token's exp claim, or the zero time if it has none
+func jwtExpiry(tokenString string) time.Time {
+	token, _, err := jwt.NewParser(jwt.WithoutClaimsValidation()).ParseUnverified(tokenString, &jwt.RegisteredClaims{})
+	if err != nil {
+		return time.Time{}
+	}
+	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && claims.ExpiresAt != nil {
+		return claims.ExpiresAt.Time
+	}
+	return time.Time{}
 }
 
 // SupabaseAuthMiddleware validates Supabase Auth tokens
@@ -187,49 +289,26 @@ func SupabaseAuthMiddleware(requiredRole string) gin.HandlerFunc {
 		// Get access token from cookie
 		accessToken, err := c.Cookie("sb_access_token")
 		if err != nil {
-			c.Redirect(http.StatusFound, "/login")
-			c.Abort()
+			requireLogin(c)
 			return
 		}
 		
-		// Validate token with Supabase
-		client := GetSupabaseClient()
-		ctx := context.Background()
-		user, err := client.Auth.User(ctx, accessToken)
-		
-		if err != nil {
-			// Try to refresh the token
-			refreshToken, _ := c.Cookie("sb_refresh_token")
-			if refreshToken != "" {
-				newUser, err := client.Auth.RefreshUser(ctx, accessToken, refreshToken)
-				if err == nil {
-					// Update cookies with new tokens
-					c.SetCookie("sb_access_token", newUser.AccessToken, 3600, "/", "", false, true)
-					c.SetCookie("sb_refresh_token", newUser.RefreshToken, 86400*7, "/", "", false, true)
-					
-					user = &newUser.User
-				}
-			}
-			
-			if err != nil {
-				c.Redirect(http.StatusFound, "/login")
-				c.Abort()
-				return
-			}
-		}
-		
-		// Get role from JWT custom claims (injected by Auth Hook)
-		// The Auth Hook also checks if user is banned
-		role, err := extractRoleFromJWT(accessToken)
-		if err != nil {
-			log.Printf("Error extracting role from JWT: %v", err)
-			c.Redirect(http.StatusFound, "/login")
-			c.Abort()
+		session, ok := resolveSession(c, GetSupabaseClient().Auth, supabaseSessions, accessToken)
+		if !ok {
+			// Neither token works any more; drop them so the login page starts clean
+			c.SetCookie("sb_access_token", "", -1, cookiePath(), "", false, true)
+			c.SetCookie("sb_refresh_token", "", -1, cookiePath(), "", false, true)
+			requireLogin(c)
 			return
 		}
+		role := session.Role
 
 		// Check if user has required role
 		if requiredRole != "" && !hasRequiredRole(role, requiredRole) {
+			if isAPIRequest(c) {
+				respondAPIError(c, http.StatusForbidden, "You don't have permission to access this resource")
+				return
+			}
 			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
 				"error": "Access denied. You don't have permission to access this page.",
 			})
@@ -237,10 +316,24 @@ func SupabaseAuthMiddleware(requiredRole string) gin.HandlerFunc {
 			return
 		}
 
+		// Admins viewing as a merchant act as the merchant's owner, read-only
+		userID := session.UserID
+		if requiredRole == "merchant" {
+			if imp := activeImpersonation(c, session); imp != nil {
+				if !impersonationReadOnly(c) {
+					denyWhileImpersonating(c)
+					return
+				}
+				c.Set("impersonation", imp)
+				userID = imp.Subject
+				role = "merchant"
+			}
+		}
+
 		// Set user info in context
-		c.Set("user_id", user.ID)
+		c.Set("user_id", userID)
 		c.Set("user_role", role)
-		c.Set("user_email", user.Email)
+		c.Set("user_email", session.Email)
 
 		c.Next()
 	}
@@ -276,9 +369,9 @@ func SupabaseRedirectIfAuthenticated() gin.HandlerFunc {
 		}
 
 		if role == "admin" || role == "superadmin" {
-			c.Redirect(http.StatusFound, "/admin")
+			c.Redirect(http.StatusFound, appPath("/admin"))
 		} else {
-			c.Redirect(http.StatusFound, "/dashboard")
+			c.Redirect(http.StatusFound, appPath("/dashboard"))
 		}
 		c.Abort()
 	}
@@ -287,52 +380,73 @@ func SupabaseRedirectIfAuthenticated() gin.HandlerFunc {
 // ForgotPasswordPage renders the forgot password page
 func ForgotPasswordPage(c *gin.Context) {
 	renderPage(c, "templates/layouts/auth.html", "templates/auth/forgot_password.html", gin.H{
-		"title": "Reset Password",
+		"title":   "Reset Password",
+		"success": c.Query("reset_sent") == "true",
 	})
 }
 
-// ForgotPassword handles password reset requests
+// Password reset requests are limited per client IP and per email address to
+// stop email-bombing through Supabase
+var passwordResetIPLimiter, passwordResetEmailLimiter *rateLimiter
+
+// loadPasswordResetLimits builds the reset limiters from env (requests per hour)
+func loadPasswordResetLimits() {
+	passwordResetIPLimiter = newRateLimiter(envInt("PASSWORD_RESET_IP_LIMIT", 10), time.Hour)
+	passwordResetEmailLimiter = newRateLimiter(envInt("PASSWORD_RESET_EMAIL_LIMIT", 3), time.Hour)
+}
+
+// ForgotPassword handles password reset requests. The response is the same
+// whether or not the account exists so it can't be used to enumerate emails;
+// the real outcome is only logged.
 func ForgotPassword(c *gin.Context) {
-	email := c.PostForm("email")
+	email := strings.ToLower(strings.TrimSpace(c.PostForm("email")))
 	log.Printf("Password reset requested for: %s", email)
-	
-	client := GetSupabaseClient()
-	ctx := context.Background()
-	
+
+	if !passwordResetIPLimiter.Allow(c.ClientIP()) || !passwordResetEmailLimiter.Allow(email) {
+		log.Printf("Password reset rate limited for %s from %s", email, c.ClientIP())
+		c.Status(http.StatusTooManyRequests)
+		renderPage(c, "templates/layouts/auth.html", "templates/auth/forgot_password.html", gin.H{
+			"title": "Reset Password",
+			"error": "Too many reset requests. Please try again later.",
+		})
+		return
+	}
+
 	// Check if user exists using Supabase Management API
-	userExists, err := checkUserExistsSupabase(email)
+	userExists, err := resetUserExists(email)
 	log.Printf("User check for %s: exists=%t, err=%v", email, userExists, err)
-	
+
 	if err != nil {
 		log.Printf("Error checking user existence: %v", err)
 		// Continue with password reset attempt for security
 	} else if !userExists {
-		log.Printf("User %s does not exist, showing error", email)
-		renderPage(c, "templates/layouts/auth.html", "templates/auth/forgot_password.html", gin.H{
-			"error": "No account found with this email address.",
-		})
+		log.Printf("User %s does not exist, not sending reset email", email)
+		c.Redirect(http.StatusFound, appPath("/forgot-password?reset_sent=true"))
 		return
 	}
-	
+
 	log.Printf("User %s exists, proceeding with password reset", email)
-	
+
 	// Request password reset - use environment-aware redirect URL
 	redirectURL := getResetPasswordURL(c)
 	log.Printf("Sending password reset for %s to redirect URL: %s", email, redirectURL)
-	
-	err = client.Auth.ResetPasswordForEmail(ctx, email, redirectURL)
-	
-	if err != nil {
+
+	// A failure is only logged: an error page here would reveal that the account exists
+	if err := sendPasswordResetEmail(email, redirectURL); err != nil {
 		log.Printf("Password reset error for %s: %v", email, err)
-		renderPage(c, "templates/layouts/auth.html", "templates/auth/forgot_password.html", gin.H{
-			"error": "Failed to send reset email. Please check your email address and try again.",
-		})
-		return
 	}
 
-	c.Redirect(http.StatusFound, "/forgot-password?reset_sent=true")
+	c.Redirect(http.StatusFound, appPath("/forgot-password?reset_sent=true"))
 }
 
+// The Supabase calls behind ForgotPassword; tests replace them
+var (
+	resetUserExists        = checkUserExistsSupabase
+	sendPasswordResetEmail = func(email, redirectURL string) error {
+		return GetSupabaseClient().Auth.ResetPasswordForEmail(context.Background(), email, redirectURL)
+	}
+)
+
 // checkUserExistsSupabase checks if a user exists using Node.js helper
 func checkUserExistsSupabase(email string) (bool, error) {
 	cmd := exec.Command("node", "check_user.js", email)
@@ -353,7 +467,7 @@ func getResetPasswordURL(c *gin.Context) string {
 		scheme = "https"
 	}
 	host := c.Request.Host
-	return fmt.Sprintf("%s://%s/auth/callback", scheme, host)
+	return fmt.Sprintf("%s://%s%s", scheme, host, appPath("/auth/callback"))
 }
 
 // ResetPasswordPage renders the reset password form (when user clicks link in email)
@@ -370,9 +484,7 @@ func ResetPassword(c *gin.Context) {
 	confirmPassword := c.PostForm("confirm_password")
 	
 	if newPassword != confirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Passwords do not match",
-		})
+		respondAPIError(c, http.StatusBadRequest, "Passwords do not match")
 		return
 	}
 	
@@ -385,9 +497,7 @@ func ResetPassword(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to reset password",
-		})
+		respondAPIError(c, http.StatusBadRequest, "Failed to reset password")
 		return
 	}
 
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	supa "github.com/nedpals/supabase-go"
+)
+
+// forgotPasswordRequest posts the forgot password form for email
+func forgotPasswordRequest(email string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST("/forgot-password", ForgotPassword)
+
+	form := url.Values{"email": {email}}
+	req := httptest.NewRequest(http.MethodPost, "/forgot-password", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// stubPasswordReset replaces the Supabase calls for the test and counts sent emails
+func stubPasswordReset(t *testing.T, exists bool, existsErr, sendErr error) *int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PASSWORD_RESET_IP_LIMIT", "100")
+	t.Setenv("PASSWORD_RESET_EMAIL_LIMIT", "100")
+	loadPasswordResetLimits()
+
+	sent := 0
+	origExists, origSend := resetUserExists, sendPasswordResetEmail
+	resetUserExists = func(string) (bool, error) { return exists, existsErr }
+	sendPasswordResetEmail = func(string, string) error {
+		sent++
+		return sendErr
+	}
+	t.Cleanup(func() { resetUserExists, sendPasswordResetEmail = origExists, origSend })
+	return &sent
+}
+
+func TestForgotPasswordResponseIsUniform(t *testing.T) {
+	tests := []struct {
+		name      string
+		exists    bool
+		existsErr error
+		sendErr   error
+		wantSent  int
+	}{
+		{"existing account", true, nil, nil, 1},
+		{"unknown account", false, nil, nil, 0},
+		{"send fails", true, nil, errors.New("smtp down"), 1},
+		{"existence check fails", false, errors.New("node missing"), nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := stubPasswordReset(t, tt.exists, tt.existsErr, tt.sendErr)
+
+			w := forgotPasswordRequest("someone@example.com")
+			if w.Code != http.StatusFound || !strings.HasSuffix(w.Header().Get("Location"), "/forgot-password?reset_sent=true") {
+				t.Errorf("response = %d %q, want the same reset_sent redirect for every account", w.Code, w.Header().Get("Location"))
+			}
+			if *sent != tt.wantSent {
+				t.Errorf("sent %d emails, want %d", *sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordRateLimit(t *testing.T) {
+	sent := stubPasswordReset(t, true, nil, nil)
+	t.Setenv("PASSWORD_RESET_EMAIL_LIMIT", "2")
+	loadPasswordResetLimits()
+
+	for i := 0; i < 2; i++ {
+		if w := forgotPasswordRequest("Someone@Example.com "); w.Code != http.StatusFound {
+			t.Fatalf("request %d: status = %d, want 302", i+1, w.Code)
+		}
+	}
+
+	// Same address, differently cased: still the same email for the limiter
+	if w := forgotPasswordRequest("someone@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	if *sent != 2 {
+		t.Errorf("sent %d emails, want 2", *sent)
+	}
+}
+
+// fakeSessionAuth accepts only validToken and refreshes only with validRefresh
+type fakeSessionAuth struct {
+	validToken   string
+	validRefresh string
+	refreshed    string // Access token handed out by a successful refresh
+}
+
+func (f *fakeSessionAuth) User(ctx context.Context, userToken string) (*supa.User, error) {
+	if userToken != f.validToken {
+		return nil, errors.New("token expired")
+	}
+	return &supa.User{ID: "user-1"}, nil
+}
+
+func (f *fakeSessionAuth) RefreshUser(ctx context.Context, userToken, refreshToken string) (*supa.AuthenticatedDetails, error) {
+	if refreshToken != f.validRefresh {
+		return nil, errors.New("invalid refresh token")
+	}
+	return &supa.AuthenticatedDetails{AccessToken: f.refreshed, RefreshToken: "new-refresh", User: supa.User{ID: "user-1"}}, nil
+}
+
+func TestAuthenticateSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := &fakeSessionAuth{validToken: "valid", validRefresh: "refresh", refreshed: "fresh"}
+
+	tests := []struct {
+		name         string
+		accessToken  string
+		refreshToken string
+		wantOK       bool
+		wantToken    string
+	}{
+		{"valid token", "valid", "", true, "valid"},
+		{"expired token, working refresh", "expired", "refresh", true, "fresh"},
+		{"expired token, failing refresh", "expired", "revoked", false, ""},
+		{"expired token, no refresh cookie", "expired", "", false, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/dashboard/", nil)
+		if tt.refreshToken != "" {
+			c.Request.AddCookie(&http.Cookie{Name: "sb_refresh_token", Value: tt.refreshToken})
+		}
+
+		user, token, ok := authenticateSession(c, auth, tt.accessToken)
+		if ok != tt.wantOK || token != tt.wantToken {
+			t.Errorf("%s: ok %v, token %q; want %v, %q", tt.name, ok, token, tt.wantOK, tt.wantToken)
+			continue
+		}
+		if ok && user.ID != "user-1" {
+			t.Errorf("%s: user = %+v, want user-1", tt.name, user)
+		}
+
+		cookies := w.Header().Values("Set-Cookie")
+		if refreshed := tt.wantToken == "fresh"; refreshed != (len(cookies) == 2) {
+			t.Errorf("%s: set cookies %v", tt.name, cookies)
+		} else if refreshed && (!strings.Contains(cookies[0], "sb_access_token=fresh") || !strings.Contains(cookies[1], "sb_refresh_token=new-refresh")) {
+			t.Errorf("%s: refreshed tokens not written back: %v", tt.name, cookies)
+		}
+	}
+}
+
+func TestSupabaseAuthMiddlewareClearsDeadSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var refreshes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/v1/token" && r.URL.Query().Get("grant_type") == "refresh_token" {
+			refreshes++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Invalid Refresh Token"}`))
+	}))
+	defer server.Close()
+
+	origClient, origSessions, origSecret := supabaseClient, supabaseSessions, supabaseJWTSecret
+	supabaseClient = supa.CreateClient(server.URL, "anon-key")
+	supabaseSessions, supabaseJWTSecret = nil, nil
+	t.Cleanup(func() { supabaseClient, supabaseSessions, supabaseJWTSecret = origClient, origSessions, origSecret })
+
+	router := gin.New()
+	router.GET("/dashboard/", SupabaseAuthMiddleware("merchant"), func(c *gin.Context) {
+		t.Error("handler ran without a session")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/", nil)
+	req.AddCookie(&http.Cookie{Name: "sb_access_token", Value: "expired"})
+	req.AddCookie(&http.Cookie{Name: "sb_refresh_token", Value: "revoked"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound || w.Header().Get("Location") != appPath("/login") {
+		t.Errorf("response = %d %q, want a redirect to the login page", w.Code, w.Header().Get("Location"))
+	}
+	if refreshes != 1 {
+		t.Errorf("tried the refresh token %d times, want once", refreshes)
+	}
+	cleared := map[string]bool{}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Value == "" && cookie.MaxAge < 0 {
+			cleared[cookie.Name] = true
+		}
+	}
+	if !cleared["sb_access_token"] || !cleared["sb_refresh_token"] {
+		t.Errorf("cookies = %v, want both session cookies cleared", w.Header().Values("Set-Cookie"))
+	}
+}
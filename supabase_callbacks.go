@@ -1,6 +1,7 @@
 package main
 
 import (
+	"auto-gbp-review/utils"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -22,8 +23,8 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 	tokenType := c.Query("type")
 	redirectTo := c.Query("redirect_to")
 
-	log.Printf("Auth callback received: token_hash=%s, type=%s, redirect_to=%s",
-		tokenHash, tokenType, redirectTo)
+	log.Printf("Auth callback received: token_hash=%s (length: %d), type=%s, redirect_to=%s",
+		utils.Redacted, len(tokenHash), tokenType, utils.RedactString(redirectTo))
 
 	if tokenHash == "" || tokenType == "" {
 		renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
@@ -50,7 +51,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 	var resp *supa.AuthenticatedDetails
 
 	log.Printf("Attempting to verify OTP:")
-	log.Printf("  - TokenHash: %s (length: %d)", tokenHash, len(tokenHash))
+	log.Printf("  - TokenHash: %s (length: %d)", utils.Redacted, len(tokenHash))
 	log.Printf("  - Type: %s", tokenType)
 	log.Printf("  - RedirectTo: %s", redirectTo)
 	log.Printf("  - Supabase URL from env: %s", client.BaseURL)
@@ -82,7 +83,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		}
 
 		log.Printf("Making direct HTTP request to: %s", verifyURL)
-		log.Printf("Request body: %s", string(jsonBody))
+		log.Printf("Request body: %s", utils.RedactJSON(jsonBody))
 
 		req, err := http.NewRequestWithContext(ctx, "POST", verifyURL, bytes.NewBuffer(jsonBody))
 		if err != nil {
@@ -99,8 +100,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		req.Header.Set("apikey", os.Getenv("SUPABASE_ANON_KEY"))
 		req.Header.Set("Authorization", "Bearer "+os.Getenv("SUPABASE_ANON_KEY"))
 
-		httpClient := &http.Client{}
-		httpResp, err := httpClient.Do(req)
+		httpResp, err := supabaseHTTPClient.Do(req)
 		if err != nil {
 			log.Printf("Error making request: %v", err)
 			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
@@ -117,7 +117,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		}
 
 		log.Printf("Response status: %d", httpResp.StatusCode)
-		log.Printf("Response body: %s", string(respBody))
+		log.Printf("Response body: %s", utils.RedactJSON(respBody))
 
 		if httpResp.StatusCode != 200 {
 			log.Printf("Verification failed with status %d", httpResp.StatusCode)
@@ -178,8 +178,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		req.Header.Set("apikey", os.Getenv("SUPABASE_ANON_KEY"))
 		req.Header.Set("Authorization", "Bearer "+os.Getenv("SUPABASE_ANON_KEY"))
 
-		httpClient := &http.Client{}
-		httpResp, err := httpClient.Do(req)
+		httpResp, err := supabaseHTTPClient.Do(req)
 		if err != nil {
 			log.Printf("Error making recovery request: %v", err)
 			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
@@ -196,7 +195,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		}
 
 		log.Printf("Recovery response status: %d", httpResp.StatusCode)
-		log.Printf("Recovery response body: %s", string(respBody))
+		log.Printf("Recovery response body: %s", utils.RedactJSON(respBody))
 
 		if httpResp.StatusCode != 200 {
 			log.Printf("Recovery verification failed with status %d", httpResp.StatusCode)
@@ -219,8 +218,8 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		}
 
 		// Store the access token for password reset and redirect to reset page
-		c.SetCookie("reset_access_token", authDetails.AccessToken, 600, "/", "", false, true)
-		c.Redirect(http.StatusFound, "/reset-password?flow=recovery")
+		c.SetCookie("reset_access_token", authDetails.AccessToken, 600, cookiePath(), "", false, true)
+		c.Redirect(http.StatusFound, appPath("/reset-password?flow=recovery"))
 		log.Printf("Password recovery initiated for: %s", authDetails.User.Email)
 		return
 
@@ -261,8 +260,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		req.Header.Set("apikey", os.Getenv("SUPABASE_ANON_KEY"))
 		req.Header.Set("Authorization", "Bearer "+os.Getenv("SUPABASE_ANON_KEY"))
 
-		httpClient := &http.Client{}
-		httpResp, err := httpClient.Do(req)
+		httpResp, err := supabaseHTTPClient.Do(req)
 		if err != nil {
 			log.Printf("Error making email change request: %v", err)
 			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
@@ -279,7 +277,7 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 		}
 
 		log.Printf("Email change response status: %d", httpResp.StatusCode)
-		log.Printf("Email change response body: %s", string(respBody))
+		log.Printf("Email change response body: %s", utils.RedactJSON(respBody))
 
 		if httpResp.StatusCode != 200 {
 			log.Printf("Email change verification failed with status %d", httpResp.StatusCode)
@@ -323,25 +321,25 @@ func HandleSupabaseAuthCallback(c *gin.Context) {
 
 	// Set authentication cookies for successful verification
 	if resp.AccessToken != "" {
-		c.SetCookie("sb_access_token", resp.AccessToken, 3600, "/", "", false, true)
-		c.SetCookie("sb_refresh_token", resp.RefreshToken, 86400*7, "/", "", false, true)
+		c.SetCookie("sb_access_token", resp.AccessToken, 3600, cookiePath(), "", false, true)
+		c.SetCookie("sb_refresh_token", resp.RefreshToken, 86400*7, cookiePath(), "", false, true)
 	}
 
 	// Handle different auth types
 	switch tokenType {
 	case "signup", "email":
 		// Email verification successful - redirect to dashboard
-		c.Redirect(http.StatusFound, "/dashboard?verified=true")
+		c.Redirect(http.StatusFound, appPath("/dashboard?verified=true"))
 		log.Printf("Email verified for: %s", userEmail)
 
 	case "email_change":
 		// Email change confirmation
-		c.Redirect(http.StatusFound, "/dashboard?email_changed=true")
+		c.Redirect(http.StatusFound, appPath("/dashboard?email_changed=true"))
 		log.Printf("Email changed for: %s", userEmail)
 
 	default:
 		log.Printf("Unhandled auth type in success flow: %s", tokenType)
-		c.Redirect(http.StatusFound, "/dashboard")
+		c.Redirect(http.StatusFound, appPath("/dashboard"))
 	}
 }
 
@@ -351,7 +349,7 @@ func ResetPasswordCallback(c *gin.Context) {
 	accessToken, _ := c.Cookie("reset_access_token")
 
 	if accessToken == "" {
-		c.Redirect(http.StatusFound, "/forgot-password?error=session_expired")
+		c.Redirect(http.StatusFound, appPath("/forgot-password?error=session_expired"))
 		return
 	}
 
@@ -397,10 +395,10 @@ func ResetPasswordCallback(c *gin.Context) {
 	log.Printf("Password reset successful")
 
 	// Clear reset session cookie
-	c.SetCookie("reset_access_token", "", -1, "/", "", false, true)
+	c.SetCookie("reset_access_token", "", -1, cookiePath(), "", false, true)
 
 	// Redirect to login with success message
-	c.Redirect(http.StatusFound, "/login?password_reset=true")
+	c.Redirect(http.StatusFound, appPath("/login?password_reset=true"))
 }
 
 // extractEmailFromRedirect attempts to extract email from redirect URL
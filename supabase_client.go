@@ -1,8 +1,14 @@
 package main
 
 import (
+	"auto-gbp-review/utils"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
+	"time"
 
 	supa "github.com/nedpals/supabase-go"
 )
@@ -37,4 +43,110 @@ func GetSupabaseURL() string {
 // GetSupabaseServiceKey returns the Supabase service role key from environment
 func GetSupabaseServiceKey() string {
 	return os.Getenv("SUPABASE_SERVICE_ROLE_KEY")
-}
\ No newline at end of file
+}
+// supabaseHTTPTimeout bounds every direct call to the Supabase REST APIs
+const supabaseHTTPTimeout = 15 * time.Second
+
+// supabaseHTTPClient is shared by the direct Supabase API calls so connections
+// are reused and a slow Supabase can't hang a request indefinitely
+var supabaseHTTPClient = &http.Client{Timeout: supabaseHTTPTimeout}
+
+// createSupabaseAdminUser creates a confirmed user through the Admin API at
+// baseURL and returns the new user's ID
+func createSupabaseAdminUser(baseURL, serviceRoleKey, email, password string) (string, error) {
+	// Don't set user_metadata to avoid trigger conflict
+	requestBody := map[string]interface{}{
+		"email":         email,
+		"password":      password,
+		"email_confirm": true, // Auto-confirm email
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	log.Printf("Request body: %s", utils.RedactJSON(jsonData))
+
+	url := fmt.Sprintf("%s/auth/v1/admin/users", baseURL)
+	log.Printf("Making request to: %s", url)
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("apikey", serviceRoleKey)
+	req.Header.Set("Authorization", "Bearer "+serviceRoleKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := supabaseHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Printf("Failed to decode response body, status: %d", resp.StatusCode)
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	log.Printf("Response status: %d", resp.StatusCode)
+	log.Printf("Response body: %s", utils.RedactMap(result))
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		errorMsg := supabaseErrorMessage(result)
+		log.Printf("API error - Status: %d, Message: %s, Full response: %s", resp.StatusCode, errorMsg, utils.RedactMap(result))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, errorMsg)
+	}
+
+	// Extract user ID from response
+	userID, ok := result["id"].(string)
+	if !ok {
+		log.Printf("User ID not found in response: %s", utils.RedactMap(result))
+		return "", fmt.Errorf("user ID not found in response")
+	}
+
+	return userID, nil
+}
+
+// deleteSupabaseAdminUser removes a user through the Admin API at baseURL. It
+// is used to undo a user created for an onboarding step that later failed.
+func deleteSupabaseAdminUser(baseURL, serviceRoleKey, userID string) error {
+	url := fmt.Sprintf("%s/auth/v1/admin/users/%s", baseURL, userID)
+
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("apikey", serviceRoleKey)
+	req.Header.Set("Authorization", "Bearer "+serviceRoleKey)
+
+	resp, err := supabaseHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		var result map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, supabaseErrorMessage(result))
+	}
+
+	return nil
+}
+
+// supabaseErrorMessage picks the error text out of a Supabase error response,
+// which uses message, error or msg depending on the endpoint
+func supabaseErrorMessage(result map[string]interface{}) string {
+	for _, key := range []string{"message", "error", "msg"} {
+		if msg, ok := result[key].(string); ok {
+			return msg
+		}
+	}
+	return "Unknown error"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSupabaseHTTPClientHasTimeout(t *testing.T) {
+	if supabaseHTTPClient.Timeout != supabaseHTTPTimeout || supabaseHTTPTimeout <= 0 {
+		t.Errorf("supabaseHTTPClient.Timeout = %s, want %s", supabaseHTTPClient.Timeout, supabaseHTTPTimeout)
+	}
+}
+
+func TestCreateSupabaseAdminUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/auth/v1/admin/users" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("apikey") != "service-key" || r.Header.Get("Authorization") != "Bearer service-key" {
+			t.Errorf("request not authenticated with the service role key")
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["email_confirm"] != true {
+			t.Errorf("email_confirm = %v, want true", body["email_confirm"])
+		}
+		if body["email"] == "taken@example.com" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"msg": "A user with this email address has already been registered"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "user-1", "email": "new@example.com"}`))
+	}))
+	defer server.Close()
+
+	userID, err := createSupabaseAdminUser(server.URL, "service-key", "new@example.com", "secret123")
+	if err != nil || userID != "user-1" {
+		t.Errorf("createSupabaseAdminUser = %q, %v, want user-1", userID, err)
+	}
+
+	_, err = createSupabaseAdminUser(server.URL, "service-key", "taken@example.com", "secret123")
+	if err == nil || !strings.Contains(err.Error(), "status 422") || !strings.Contains(err.Error(), "already been registered") {
+		t.Errorf("duplicate email: err = %v, want the Supabase message and status", err)
+	}
+}
+
+func TestDeleteSupabaseAdminUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/auth/v1/admin/users/user-1" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "User not found"}`))
+	}))
+	defer server.Close()
+
+	if err := deleteSupabaseAdminUser(server.URL, "service-key", "user-1"); err != nil {
+		t.Errorf("delete existing user: %v", err)
+	}
+	if err := deleteSupabaseAdminUser(server.URL, "service-key", "user-2"); err == nil || !strings.Contains(err.Error(), "User not found") {
+		t.Errorf("delete missing user: err = %v, want the Supabase message", err)
+	}
+}
+
+func TestSupabaseErrorMessage(t *testing.T) {
+	tests := []struct {
+		result map[string]interface{}
+		want   string
+	}{
+		{map[string]interface{}{"message": "a", "error": "b"}, "a"},
+		{map[string]interface{}{"error": "b", "msg": "c"}, "b"},
+		{map[string]interface{}{"msg": "c"}, "c"},
+		{nil, "Unknown error"},
+	}
+	for _, tt := range tests {
+		if got := supabaseErrorMessage(tt.result); got != tt.want {
+			t.Errorf("supabaseErrorMessage(%v) = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
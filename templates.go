@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// templateCache holds parsed layout+content template pairs keyed by their paths
+// and locale, since the t function is bound to a locale at parse time
+type templateCache struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+	devMode   bool
+}
+
+var templates = newTemplateCache()
+
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		templates: make(map[string]*template.Template),
+		devMode:   os.Getenv("DEV_MODE") == "true",
+	}
+}
+
+func templateKey(layout string, content string, locale string) string {
+	return layout + "|" + content + "|" + locale
+}
+
+// parseTemplate parses a layout and content page with the locale's template functions
+func parseTemplate(layout string, content string, locale string) (*template.Template, error) {
+	return template.New(filepath.Base(layout)).Funcs(templateFuncs(locale)).ParseFiles(layout, content)
+}
+
+// pageTemplates lists every layout and content pair the handlers render, so a
+// missing or broken file is caught at startup instead of on first request
+var pageTemplates = [][2]string{
+	{"templates/layouts/auth.html", "templates/auth/forgot_password.html"},
+	{"templates/layouts/auth.html", "templates/auth/login.html"},
+	{"templates/layouts/auth.html", "templates/auth/register.html"},
+	{"templates/layouts/auth.html", "templates/auth/reset_password.html"},
+	{"templates/layouts/base.html", "templates/admin/audit_logs.html"},
+	{"templates/layouts/base.html", "templates/admin/connections.html"},
+	{"templates/layouts/base.html", "templates/admin/dashboard.html"},
+	{"templates/layouts/base.html", "templates/admin/merchant_edit.html"},
+	{"templates/layouts/base.html", "templates/admin/merchant_form.html"},
+	{"templates/layouts/base.html", "templates/admin/merchants.html"},
+	{"templates/layouts/base.html", "templates/business.html"},
+	{"templates/layouts/base.html", "templates/error.html"},
+	{"templates/layouts/base.html", "templates/home.html"},
+	{"templates/layouts/base.html", "templates/merchant.html"},
+	{"templates/layouts/base.html", "templates/merchant/integrations.html"},
+	{"templates/layouts/base.html", "templates/merchant_dashboard.html"},
+	{"templates/layouts/base.html", "templates/merchant_profile.html"},
+}
+
+// validateTemplates checks that each layout and content pair exists and parses.
+// It only reads the files, so it is safe to call more than once and in DEV_MODE.
+func validateTemplates(pairs [][2]string) error {
+	for _, pair := range pairs {
+		layout, content := pair[0], pair[1]
+		for _, path := range pair {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("template %s: %w", path, err)
+			}
+		}
+		if _, err := parseTemplate(layout, content, defaultLocale); err != nil {
+			return fmt.Errorf("template %s with %s: %w", content, layout, err)
+		}
+	}
+	return nil
+}
+
+// initTemplateCache parses every layout and content page pair once at startup.
+// In DEV_MODE templates are parsed per request so edits show up without a restart.
+func initTemplateCache() {
+	templates = newTemplateCache()
+	if templates.devMode {
+		log.Println("DEV_MODE enabled, templates will be parsed on every request")
+		return
+	}
+
+	layouts, err := filepath.Glob("templates/layouts/*.html")
+	if err != nil {
+		log.Printf("Failed to list layouts: %v", err)
+		return
+	}
+
+	var contents []string
+	for _, pattern := range []string{"templates/*.html", "templates/*/*.html"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Printf("Failed to list templates for %s: %v", pattern, err)
+			continue
+		}
+		for _, match := range matches {
+			if strings.HasPrefix(match, "templates/layouts/") || match == "templates/base.html" {
+				continue
+			}
+			contents = append(contents, match)
+		}
+	}
+
+	for _, locale := range supportedLocales {
+		for _, layout := range layouts {
+			for _, content := range contents {
+				tmpl, err := parseTemplate(layout, content, locale)
+				if err != nil {
+					log.Printf("Template parsing error for %s with %s: %v", content, layout, err)
+					continue
+				}
+				templates.templates[templateKey(layout, content, locale)] = tmpl
+			}
+		}
+	}
+
+	log.Printf("Template cache loaded with %d templates", len(templates.templates))
+}
+
+// Get returns the parsed template for a layout and content pair in a locale,
+// parsing and caching it on a miss
+func (tc *templateCache) Get(layout string, content string, locale string) (*template.Template, error) {
+	if tc.devMode {
+		return parseTemplate(layout, content, locale)
+	}
+
+	key := templateKey(layout, content, locale)
+
+	tc.mu.RLock()
+	tmpl, ok := tc.templates[key]
+	tc.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := parseTemplate(layout, content, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	tc.mu.Lock()
+	tc.templates[key] = tmpl
+	tc.mu.Unlock()
+
+	return tmpl, nil
+}
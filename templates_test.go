@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testLayout  = "templates/layouts/base.html"
+	testContent = "templates/error.html"
+)
+
+func TestTemplateCacheRendersCachedTemplate(t *testing.T) {
+	tc := &templateCache{templates: make(map[string]*template.Template)}
+
+	first, err := tc.Get(testLayout, testContent, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := tc.Get(testLayout, testContent, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("second Get parsed the template again instead of using the cache")
+	}
+
+	var buf bytes.Buffer
+	if err := second.Execute(&buf, applyPageDefaults(gin.H{"error": "Business not found"}, "en")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Business not found") {
+		t.Error("cached template didn't render the page data")
+	}
+}
+
+func TestTemplateCacheDevModeParsesEachTime(t *testing.T) {
+	tc := &templateCache{templates: make(map[string]*template.Template), devMode: true}
+
+	first, _ := tc.Get(testLayout, testContent, "en")
+	second, _ := tc.Get(testLayout, testContent, "en")
+	if first == nil || first == second || len(tc.templates) != 0 {
+		t.Error("DEV_MODE should parse on every Get without caching")
+	}
+}
+
+func BenchmarkTemplateCacheGet(b *testing.B) {
+	tc := &templateCache{templates: make(map[string]*template.Template)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := tc.Get(testLayout, testContent, "en"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseTemplate is the per-request cost the cache avoids
+func BenchmarkParseTemplate(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseTemplate(testLayout, testContent, "en"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBaseLayoutNoindex(t *testing.T) {
+	tmpl, err := parseTemplate(testLayout, testContent, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const robots = `<meta name="robots" content="noindex">`
+
+	for _, noindex := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, applyPageDefaults(gin.H{"error": "Oops", "noindex": noindex}, "en")); err != nil {
+			t.Fatal(err)
+		}
+		page := buf.String()
+		if got := strings.Contains(page, robots); got != noindex {
+			t.Errorf("noindex %v: robots meta present = %v", noindex, got)
+		}
+		if noindex && strings.Index(page, robots) > strings.Index(page, "</head>") {
+			t.Error("robots meta rendered outside <head>")
+		}
+	}
+}
+
+func TestPageTemplatesAreValid(t *testing.T) {
+	if err := validateTemplates(pageTemplates); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPageTemplatesListEveryRenderedPage(t *testing.T) {
+	listed := map[[2]string]bool{}
+	for _, pair := range pageTemplates {
+		listed[pair] = true
+	}
+
+	sources, _ := filepath.Glob("*.go")
+	pagePair := regexp.MustCompile(`"(templates/layouts/[a-z_]+\.html)", "(templates/[a-z_/]+\.html)"`)
+	for _, source := range sources {
+		if strings.HasSuffix(source, "_test.go") {
+			continue
+		}
+		code, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range pagePair.FindAllStringSubmatch(string(code), -1) {
+			if !listed[[2]string{m[1], m[2]}] {
+				t.Errorf("%s renders %s with %s, which pageTemplates doesn't list", source, m[2], m[1])
+			}
+		}
+	}
+}
+
+func TestValidateTemplatesRejects(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.html")
+	os.WriteFile(broken, []byte(`{{define "content"}}{{if .x}}{{end}}`), 0o644)
+
+	for name, pair := range map[string][2]string{
+		"missing file":  {"templates/layouts/base.html", filepath.Join(dir, "missing.html")},
+		"broken syntax": {"templates/layouts/base.html", broken},
+	} {
+		if err := validateTemplates([][2]string{pair}); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
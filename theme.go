@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hexColorPattern matches #RGB and #RRGGBB colors
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// themeColorField is a color on the merchant profile form; blank keeps the default look
+type themeColorField struct {
+	Name  string
+	Label string
+}
+
+// themeColorFields lists the profile form fields that hold colors
+var themeColorFields = []themeColorField{
+	{Name: "theme_color", Label: "Theme Color"},
+	{Name: "secondary_color", Label: "Button Color"},
+	{Name: "text_color", Label: "Text Color"},
+}
+
+// themeFont is a font merchants can pick for their public pages
+type themeFont struct {
+	Key   string
+	Label string
+	Stack template.CSS
+}
+
+// themeFonts are the selectable fonts. Only system stacks are offered so the
+// pages don't have to load web fonts, and the key (not the CSS) is stored.
+var themeFonts = []themeFont{
+	{Key: "sans", Label: "Sans-serif", Stack: `ui-sans-serif, system-ui, -apple-system, "Segoe UI", Roboto, sans-serif`},
+	{Key: "serif", Label: "Serif", Stack: `Georgia, Cambria, "Times New Roman", serif`},
+	{Key: "rounded", Label: "Rounded", Stack: `ui-rounded, "SF Pro Rounded", "Nunito", sans-serif`},
+	{Key: "mono", Label: "Monospace", Stack: `ui-monospace, SFMono-Regular, Menlo, Consolas, monospace`},
+}
+
+// Defaults used when a merchant hasn't picked a value, matching the pages' original look
+const (
+	defaultTextColor  = "#111827"
+	defaultFontFamily = template.CSS("inherit")
+)
+
+// pageTheme is the set of CSS custom properties injected into the public pages
+type pageTheme struct {
+	PrimaryColor   string
+	SecondaryColor string
+	TextColor      string
+	FontFamily     template.CSS
+}
+
+// themeForDetails resolves a merchant's theme, falling back to the defaults for unset values
+func themeForDetails(details *MerchantDetails) pageTheme {
+	theme := pageTheme{
+		PrimaryColor: branding.DefaultThemeColor,
+		TextColor:    defaultTextColor,
+		FontFamily:   defaultFontFamily,
+	}
+	if details == nil {
+		theme.SecondaryColor = theme.PrimaryColor
+		return theme
+	}
+
+	if details.ThemeColor != "" {
+		theme.PrimaryColor = details.ThemeColor
+	}
+	theme.SecondaryColor = theme.PrimaryColor
+	if details.SecondaryColor != "" {
+		theme.SecondaryColor = details.SecondaryColor
+	}
+	if details.TextColor != "" {
+		theme.TextColor = details.TextColor
+	}
+	if font, ok := findThemeFont(details.FontFamily); ok {
+		theme.FontFamily = font.Stack
+	}
+	return theme
+}
+
+// findThemeFont looks up a font by key
+func findThemeFont(key string) (themeFont, bool) {
+	for _, font := range themeFonts {
+		if font.Key == key {
+			return font, true
+		}
+	}
+	return themeFont{}, false
+}
+
+// normalizeHexColor trims raw, expands #RGB to #RRGGBB and lower-cases it.
+// Empty input is returned as-is.
+func normalizeHexColor(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(raw, "#") {
+		raw = "#" + raw
+	}
+	if !hexColorPattern.MatchString(raw) {
+		return "", errors.New("must be a hex color like #1A2B3C")
+	}
+	if len(raw) == 4 {
+		raw = string([]byte{'#', raw[1], raw[1], raw[2], raw[2], raw[3], raw[3]})
+	}
+	return strings.ToLower(raw), nil
+}
+
+// normalizeThemeFields reads and validates the theme fields of a profile form.
+// It returns the cleaned values by field name and one message per invalid field.
+func normalizeThemeFields(c *gin.Context) (map[string]string, []string) {
+	values := make(map[string]string, len(themeColorFields)+1)
+	var problems []string
+
+	for _, field := range themeColorFields {
+		raw := c.PostForm(field.Name)
+		normalized, err := normalizeHexColor(raw)
+		if err != nil {
+			problems = append(problems, field.Label+" "+err.Error())
+			values[field.Name] = strings.TrimSpace(raw)
+			continue
+		}
+		values[field.Name] = normalized
+	}
+
+	font := strings.TrimSpace(c.PostForm("font_family"))
+	if _, ok := findThemeFont(font); font != "" && !ok {
+		problems = append(problems, "Font is not one of the available fonts")
+		font = ""
+	}
+	values["font_family"] = font
+
+	return values, problems
+}
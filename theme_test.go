@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeHexColor(t *testing.T) {
+	tests := []struct {
+		raw, want string
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"  ", "", false},
+		{"#1A2B3C", "#1a2b3c", false},
+		{"1a2b3c", "#1a2b3c", false},
+		{" #ABC ", "#aabbcc", false},
+		{"#abcd", "", true},
+		{"#ggg", "", true},
+		{"red", "", true},
+		{"#fff; background: url(x)", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeHexColor(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeHexColor(%q) = %q, %v; want %q, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestThemeForDetails(t *testing.T) {
+	defaults := themeForDetails(nil)
+	if defaults.PrimaryColor != branding.DefaultThemeColor || defaults.SecondaryColor != defaults.PrimaryColor ||
+		defaults.TextColor != defaultTextColor || defaults.FontFamily != defaultFontFamily {
+		t.Errorf("theme without details = %+v, want the defaults", defaults)
+	}
+
+	// The button color follows the theme color unless set
+	theme := themeForDetails(&MerchantDetails{ThemeColor: "#112233", FontFamily: "serif"})
+	if theme.PrimaryColor != "#112233" || theme.SecondaryColor != "#112233" || !strings.HasPrefix(string(theme.FontFamily), "Georgia") {
+		t.Errorf("theme = %+v, want the theme color on buttons and the serif stack", theme)
+	}
+
+	theme = themeForDetails(&MerchantDetails{SecondaryColor: "#445566", TextColor: "#000000", FontFamily: "comic"})
+	if theme.SecondaryColor != "#445566" || theme.TextColor != "#000000" || theme.FontFamily != defaultFontFamily {
+		t.Errorf("theme = %+v, want the set colors and the default font for an unknown key", theme)
+	}
+}
+
+func TestNormalizeThemeFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	form := url.Values{
+		"theme_color":     {"#ABC"},
+		"secondary_color": {"blue"},
+		"text_color":      {""},
+		"font_family":     {"papyrus"},
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/dashboard/profile", strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	values, problems := normalizeThemeFields(c)
+	if values["theme_color"] != "#aabbcc" || values["secondary_color"] != "blue" || values["text_color"] != "" || values["font_family"] != "" {
+		t.Errorf("values = %v", values)
+	}
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], "Button Color ") || !strings.HasPrefix(problems[1], "Font ") {
+		t.Errorf("problems = %q, want the button color and the font", problems)
+	}
+}
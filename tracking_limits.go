@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Token buckets for the public tracking endpoints, so views and clicks can't
+// be inflated by replaying requests. Built by loadTrackingLimits.
+var (
+	trackingIPLimiter       = newTokenBucketLimiter(60, time.Minute)
+	trackingMerchantLimiter = newTokenBucketLimiter(600, time.Minute)
+)
+
+// loadTrackingLimits builds the tracking limiters from env: each allows a
+// burst of that many requests and refills at that many per minute
+func loadTrackingLimits() {
+	trackingIPLimiter = newTokenBucketLimiter(envInt("TRACKING_IP_LIMIT", 60), time.Minute)
+	trackingMerchantLimiter = newTokenBucketLimiter(envInt("TRACKING_MERCHANT_LIMIT", 600), time.Minute)
+}
+
+// admitTrackingRequest checks a tracking request before anything is recorded:
+// the client IP is within its limit, the merchant exists and is active, and
+// the merchant is within its limit. It returns the merchant's analytics
+// sampling rate, or false after answering the request itself.
+func (h *Handlers) admitTrackingRequest(c *gin.Context, merchantID int) (int, bool) {
+	if !trackingIPLimiter.Allow(c.ClientIP()) {
+		log.Printf("Tracking rate limited for ip=%s", c.ClientIP())
+		respondAPIError(c, http.StatusTooManyRequests, "too many tracking requests")
+		return 0, false
+	}
+
+	sampleRate := 1
+	err := h.db.QueryRow(`
+		SELECT analytics_sample_rate FROM merchants
+		WHERE id = $1 AND is_active = true AND deleted_at IS NULL
+	`, merchantID).Scan(&sampleRate)
+	if err == sql.ErrNoRows {
+		respondAPIError(c, http.StatusNotFound, "merchant not found")
+		return 0, false
+	}
+	if err != nil {
+		log.Printf("Failed to look up merchant %d for tracking: %v", merchantID, err)
+		respondAPIError(c, http.StatusInternalServerError, "failed to track")
+		return 0, false
+	}
+
+	// Keyed only on real merchants so made-up ids can't grow the limiter
+	if !trackingMerchantLimiter.Allow(strconv.Itoa(merchantID)) {
+		log.Printf("Tracking rate limited for merchant_id=%d", merchantID)
+		respondAPIError(c, http.StatusTooManyRequests, "too many tracking requests")
+		return 0, false
+	}
+
+	return sampleRate, true
+}
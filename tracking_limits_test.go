@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auto-gbp-review/internal/fakedb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	l := newTokenBucketLimiter(3, time.Minute)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if !l.allowAt("a", start) {
+			t.Fatalf("event %d of the burst refused", i+1)
+		}
+	}
+	if l.allowAt("a", start) {
+		t.Error("event past the burst allowed")
+	}
+	if !l.allowAt("b", start) {
+		t.Error("keys are limited independently")
+	}
+
+	// Three tokens a minute: one is back after 20 seconds, not before
+	if l.allowAt("a", start.Add(19*time.Second)) {
+		t.Error("allowed before a token refilled")
+	}
+	if !l.allowAt("a", start.Add(21*time.Second)) {
+		t.Error("refused after a token refilled")
+	}
+}
+
+func TestTokenBucketLimiterPrunesIdleKeys(t *testing.T) {
+	l := newTokenBucketLimiter(3, time.Minute)
+	start := time.Now()
+
+	l.allowAt("idle", start)
+	l.allowAt("busy", start.Add(61*time.Second))
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("bucket idle for a whole window was kept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket in use was dropped")
+	}
+}
+
+// trackingTestRouter serves TrackPageView over a fake database with one
+// active merchant, id 1, and counts the page views inserted
+func trackingTestRouter(t *testing.T, ipLimit int) (*gin.Engine, *int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRACKING_IP_LIMIT", fmt.Sprint(ipLimit))
+	t.Setenv("TRACKING_MERCHANT_LIMIT", "100")
+	loadTrackingLimits()
+
+	inserted := 0
+	conn := fakedb.Open(func(query string, args []driver.Value) (*fakedb.Result, error) {
+		switch {
+		case strings.Contains(query, "SELECT analytics_sample_rate"):
+			res := &fakedb.Result{Columns: []string{"analytics_sample_rate"}}
+			if args[0] == int64(1) {
+				res.Rows = [][]driver.Value{{int64(1)}}
+			}
+			return res, nil
+		case strings.Contains(query, "SELECT EXISTS"):
+			return &fakedb.Result{Columns: []string{"exists"}, Rows: [][]driver.Value{{false}}}, nil
+		case strings.Contains(query, "INSERT INTO page_views"):
+			inserted++
+			return &fakedb.Result{RowsAffected: 1}, nil
+		}
+		t.Fatalf("unexpected query: %s", query)
+		return nil, nil
+	})
+	t.Cleanup(func() { conn.Close() })
+
+	h := &Handlers{db: &Database{DB: conn}}
+	router := gin.New()
+	router.GET("/api/track/view", h.TrackPageView)
+	return router, &inserted
+}
+
+func trackView(router *gin.Engine, merchantID int) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/track/view?merchant_id=%d", merchantID), nil))
+	return w.Code
+}
+
+func TestTrackPageViewRateLimit(t *testing.T) {
+	router, inserted := trackingTestRouter(t, 2)
+
+	for i := 0; i < 2; i++ {
+		if code := trackView(router, 1); code != http.StatusOK {
+			t.Fatalf("view %d: status = %d, want 200", i+1, code)
+		}
+	}
+	if code := trackView(router, 1); code != http.StatusTooManyRequests {
+		t.Errorf("view past the limit: status = %d, want 429", code)
+	}
+	if *inserted != 2 {
+		t.Errorf("inserted %d page views, want 2", *inserted)
+	}
+}
+
+func TestTrackPageViewUnknownMerchant(t *testing.T) {
+	router, inserted := trackingTestRouter(t, 10)
+
+	if code := trackView(router, 999); code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", code)
+	}
+	if *inserted != 0 {
+		t.Errorf("inserted %d page views for an unknown merchant", *inserted)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renderProxyRanges are the private ranges Render's load balancers reach the
+// service from. Render terminates TLS and appends the visitor's address to
+// X-Forwarded-For before forwarding the request.
+var renderProxyRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// trustedProxies returns the proxies whose X-Forwarded-For header is believed:
+// TRUSTED_PROXIES (comma-separated IPs or CIDRs) when set, Render's ranges when
+// RENDER=true, and otherwise none, so the connecting address is used as-is.
+func trustedProxies() []string {
+	if raw, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		var proxies []string
+		for _, proxy := range strings.Split(raw, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				proxies = append(proxies, proxy)
+			}
+		}
+		return proxies
+	}
+	if os.Getenv("RENDER") == "true" {
+		return renderProxyRanges
+	}
+	return nil
+}
+
+// configureTrustedProxies sets which proxies gin believes when resolving
+// c.ClientIP(). gin walks X-Forwarded-For (then X-Real-IP) from the right,
+// skipping trusted proxy addresses, and uses the first untrusted one; requests
+// that don't come from a trusted proxy use the connecting address. Without
+// this gin trusts every proxy, letting any visitor spoof their IP.
+func configureTrustedProxies(router *gin.Engine) error {
+	proxies := trustedProxies()
+	router.RemoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+
+	if len(proxies) == 0 {
+		log.Println("No trusted proxies configured; client IPs are taken from the connection")
+	} else {
+		log.Printf("Trusting X-Forwarded-For from proxies: %s", strings.Join(proxies, ", "))
+	}
+	return nil
+}
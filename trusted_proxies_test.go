@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	t.Setenv("RENDER", "true")
+	t.Setenv("TRUSTED_PROXIES", " 10.1.0.0/16, ,203.0.113.7 ")
+	if got := trustedProxies(); !reflect.DeepEqual(got, []string{"10.1.0.0/16", "203.0.113.7"}) {
+		t.Errorf("with TRUSTED_PROXIES: %v", got)
+	}
+
+	os.Unsetenv("TRUSTED_PROXIES")
+	if got := trustedProxies(); !reflect.DeepEqual(got, renderProxyRanges) {
+		t.Errorf("on Render: %v, want Render's ranges", got)
+	}
+	t.Setenv("RENDER", "")
+	if got := trustedProxies(); got != nil {
+		t.Errorf("elsewhere: %v, want no proxies", got)
+	}
+}
+
+func TestConfigureTrustedProxiesResolvesClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+
+	router := gin.New()
+	if err := configureTrustedProxies(router); err != nil {
+		t.Fatal(err)
+	}
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+	clientIP := func(remoteAddr, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	if got := clientIP("10.2.3.4:5000", "198.51.100.9, 10.9.9.9"); got != "198.51.100.9" {
+		t.Errorf("through a trusted proxy: client IP %q, want the forwarded visitor", got)
+	}
+	if got := clientIP("198.51.100.20:5000", "1.2.3.4"); got != "198.51.100.20" {
+		t.Errorf("spoofed header: client IP %q, want the connecting address", got)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "not-an-ip")
+	if err := configureTrustedProxies(gin.New()); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadBytes caps a single uploaded file (MAX_UPLOAD_BYTES, default 5MB)
+var maxUploadBytes int64 = 5 << 20
+
+const (
+	// uploadFormOverhead leaves room for the other form fields and multipart framing
+	uploadFormOverhead = 1 << 20
+	// multipartMemory is how much of a multipart body is held in memory; the rest spools to disk
+	multipartMemory = 1 << 20
+)
+
+// loadUploadLimits reads MAX_UPLOAD_BYTES
+func loadUploadLimits() {
+	maxUploadBytes = int64(envInt("MAX_UPLOAD_BYTES", int(maxUploadBytes)))
+}
+
+// uploadLimitLabel formats the upload limit for error messages, e.g. "5MB"
+func uploadLimitLabel() string {
+	switch {
+	case maxUploadBytes%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", maxUploadBytes>>20)
+	case maxUploadBytes >= 1<<10:
+		return fmt.Sprintf("%dKB", maxUploadBytes>>10)
+	}
+	return fmt.Sprintf("%d bytes", maxUploadBytes)
+}
+
+// LimitUploadSize bounds the request body of upload routes and parses multipart
+// forms with a small in-memory cap, answering 413 when the body is too large
+// instead of buffering it
+func LimitUploadSize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes+uploadFormOverhead)
+
+		if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			c.Next()
+			return
+		}
+
+		err := c.Request.ParseMultipartForm(multipartMemory)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Printf("Upload rejected on %s: body exceeds %d bytes", c.FullPath(), maxBytesErr.Limit)
+			message := "File too large. Maximum size is " + uploadLimitLabel()
+			if c.GetHeader("HX-Request") != "" {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"success": false,
+					"errors":  []string{message},
+				})
+				return
+			}
+			c.Status(http.StatusRequestEntityTooLarge)
+			renderPage(c, "templates/layouts/base.html", "templates/error.html", gin.H{
+				"title": "Upload Too Large",
+				"error": message,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
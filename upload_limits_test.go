@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withUploadLimit sets maxUploadBytes for one test
+func withUploadLimit(t *testing.T, limit int64) {
+	t.Helper()
+	orig := maxUploadBytes
+	maxUploadBytes = limit
+	t.Cleanup(func() { maxUploadBytes = orig })
+}
+
+// uploadRequest builds a multipart POST with a logo of size bytes
+func uploadRequest(t *testing.T, size int) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("business_name", "Cafe")
+	part, err := form.CreateFormFile("logo", "logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte{0}, size))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/dashboard/profile", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestLimitUploadSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withUploadLimit(t, 1<<10)
+
+	router := gin.New()
+	router.POST("/dashboard/profile", LimitUploadSize(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.PostForm("business_name"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, 512))
+	if w.Code != http.StatusOK || w.Body.String() != "Cafe" {
+		t.Fatalf("small upload: status = %d, body %q; want the form passed through", w.Code, w.Body)
+	}
+
+	// Over the file limit plus the form overhead
+	req := uploadRequest(t, 2<<20)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "Maximum size is 1KB") {
+		t.Errorf("oversized upload: status = %d, body %s; want 413 with the limit", w.Code, w.Body)
+	}
+}
+
+func TestReadImageUploadRejectsOversizedFile(t *testing.T) {
+	withUploadLimit(t, int64(len(pngBytes)-1))
+
+	_, _, _, err := readImageUpload(memFile{bytes.NewReader(pngBytes)})
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("err = %v, want the file rejected as too large", err)
+	}
+}
+
+func TestUploadLimitLabel(t *testing.T) {
+	for limit, want := range map[int64]string{5 << 20: "5MB", 1536 << 10: "1536KB", 2048: "2KB", 500: "500 bytes"} {
+		withUploadLimit(t, limit)
+		if got := uploadLimitLabel(); got != want {
+			t.Errorf("uploadLimitLabel(%d) = %q, want %q", limit, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// placesAPIBaseURL is the Google Places web service root; swapped out when testing against a mock
+var placesAPIBaseURL = "https://maps.googleapis.com/maps/api/place"
+
+// placesHTTPClient keeps a slow Places API from holding up page renders
+var placesHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// How long Place Details results are reused. Failures are kept for less time
+// so a place that gains reviews, or a quota that resets, is picked up soon.
+const (
+	placeDetailsCacheTTL     = 6 * time.Hour
+	placeDetailsFailureTTL   = 10 * time.Minute
+	placeDetailsCacheMaxSize = 1000
+)
+
+var (
+	// ErrPlaceNotFound is returned when Google has no place for the ID
+	ErrPlaceNotFound = errors.New("place not found")
+	// ErrPlacesQuota is returned when the Places API key is over its quota or rate limit
+	ErrPlacesQuota = errors.New("places API quota exceeded")
+)
+
+// placeDetailsResponse is the part of a Place Details response we read
+type placeDetailsResponse struct {
+	Result struct {
+		Rating           float64 `json:"rating"`
+		UserRatingsTotal int     `json:"user_ratings_total"`
+	} `json:"result"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// placeDetailsEntry is a cached Place Details lookup, successful or not
+type placeDetailsEntry struct {
+	rating    float64
+	total     int
+	err       error
+	expiresAt time.Time
+}
+
+var (
+	placeDetailsMu    sync.Mutex
+	placeDetailsCache = map[string]placeDetailsEntry{}
+)
+
+// GetGooglePlaceDetails returns a place's overall Google rating and review
+// count. Results are cached so busy business pages stay within quota.
+func GetGooglePlaceDetails(placeID string) (rating float64, total int, err error) {
+	if placeID == "" {
+		return 0, 0, ErrPlaceNotFound
+	}
+
+	placeDetailsMu.Lock()
+	entry, ok := placeDetailsCache[placeID]
+	placeDetailsMu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.rating, entry.total, entry.err
+	}
+
+	rating, total, err = fetchGooglePlaceDetails(placeID)
+	if err != nil && !errors.Is(err, ErrPlaceNotFound) && !errors.Is(err, ErrPlacesQuota) {
+		// Network and decode errors aren't cached; the next page view retries
+		return 0, 0, err
+	}
+
+	ttl := placeDetailsCacheTTL
+	if err != nil {
+		ttl = placeDetailsFailureTTL
+	}
+
+	placeDetailsMu.Lock()
+	if len(placeDetailsCache) >= placeDetailsCacheMaxSize {
+		now := time.Now()
+		for id, e := range placeDetailsCache {
+			if now.After(e.expiresAt) {
+				delete(placeDetailsCache, id)
+			}
+		}
+	}
+	if len(placeDetailsCache) < placeDetailsCacheMaxSize {
+		placeDetailsCache[placeID] = placeDetailsEntry{
+			rating:    rating,
+			total:     total,
+			err:       err,
+			expiresAt: time.Now().Add(ttl),
+		}
+	}
+	placeDetailsMu.Unlock()
+
+	return rating, total, err
+}
+
+// GooglePlaceDetailsCachedUntil returns when the cached details for placeID
+// expire, or the zero time if nothing fresh is cached. Pages that show the
+// rating fold it into their ETag and cache key, so a refreshed rating isn't
+// hidden behind a page that otherwise hasn't changed.
+func GooglePlaceDetailsCachedUntil(placeID string) time.Time {
+	placeDetailsMu.Lock()
+	entry, ok := placeDetailsCache[placeID]
+	placeDetailsMu.Unlock()
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return time.Time{}
+	}
+	return entry.expiresAt
+}
+
+// fetchGooglePlaceDetails calls the Place Details endpoint for just the rating fields
+func fetchGooglePlaceDetails(placeID string) (float64, int, error) {
+	apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
+	if apiKey == "" {
+		return 0, 0, fmt.Errorf("GOOGLE_PLACES_API_KEY not set")
+	}
+
+	apiURL := fmt.Sprintf(
+		"%s/details/json?place_id=%s&fields=rating,user_ratings_total&key=%s",
+		placesAPIBaseURL,
+		url.QueryEscape(placeID),
+		url.QueryEscape(apiKey),
+	)
+
+	resp, err := placesHTTPClient.Get(apiURL)
+	if err != nil {
+		// The request URL carries the API key
+		return 0, 0, fmt.Errorf("failed to make API request: %s", strings.ReplaceAll(err.Error(), "key="+url.QueryEscape(apiKey), "key="+Redacted))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return 0, 0, ErrPlacesQuota
+	}
+
+	var result placeDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode API response: %v", err)
+	}
+
+	switch result.Status {
+	case "OK":
+		return result.Result.Rating, result.Result.UserRatingsTotal, nil
+	case "ZERO_RESULTS", "NOT_FOUND":
+		return 0, 0, ErrPlaceNotFound
+	case "OVER_QUERY_LIMIT":
+		log.Printf("GetGooglePlaceDetails: quota exceeded: %s", result.ErrorMessage)
+		return 0, 0, ErrPlacesQuota
+	default:
+		return 0, 0, fmt.Errorf("API returned status: %s %s", result.Status, result.ErrorMessage)
+	}
+}
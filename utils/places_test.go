@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockPlacesAPI points the Places client at a test server answering Place
+// Details for each place ID with the given body (or status code, if the body
+// is a number). It returns how many requests the server received.
+func mockPlacesAPI(t *testing.T, responses map[string]string) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/details/json" || r.URL.Query().Get("key") != "test-key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		body := responses[r.URL.Query().Get("place_id")]
+		var code int
+		if _, err := fmt.Sscanf(body, "%d", &code); err == nil {
+			w.WriteHeader(code)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	origURL := placesAPIBaseURL
+	placesAPIBaseURL = server.URL
+	t.Setenv("GOOGLE_PLACES_API_KEY", "test-key")
+	t.Cleanup(func() {
+		placesAPIBaseURL = origURL
+		placeDetailsMu.Lock()
+		placeDetailsCache = map[string]placeDetailsEntry{}
+		placeDetailsMu.Unlock()
+	})
+	return &requests
+}
+
+func TestGetGooglePlaceDetails(t *testing.T) {
+	mockPlacesAPI(t, map[string]string{
+		"ok":      `{"status":"OK","result":{"rating":4.6,"user_ratings_total":128}}`,
+		"missing": `{"status":"ZERO_RESULTS"}`,
+		"gone":    `{"status":"NOT_FOUND"}`,
+		"quota":   `{"status":"OVER_QUERY_LIMIT","error_message":"You have exceeded your daily request quota"}`,
+		"limited": "429",
+		"denied":  `{"status":"REQUEST_DENIED","error_message":"bad key"}`,
+	})
+
+	rating, total, err := GetGooglePlaceDetails("ok")
+	if err != nil || rating != 4.6 || total != 128 {
+		t.Errorf("ok: got %v, %d, %v; want 4.6, 128, nil", rating, total, err)
+	}
+
+	for placeID, want := range map[string]error{
+		"missing": ErrPlaceNotFound,
+		"gone":    ErrPlaceNotFound,
+		"quota":   ErrPlacesQuota,
+		"limited": ErrPlacesQuota,
+		"":        ErrPlaceNotFound,
+	} {
+		if _, _, err := GetGooglePlaceDetails(placeID); !errors.Is(err, want) {
+			t.Errorf("%q: err = %v, want %v", placeID, err, want)
+		}
+	}
+
+	if _, _, err := GetGooglePlaceDetails("denied"); err == nil || !strings.Contains(err.Error(), "REQUEST_DENIED") {
+		t.Errorf("denied: err = %v, want the API status", err)
+	}
+}
+
+func TestGetGooglePlaceDetailsIsCached(t *testing.T) {
+	requests := mockPlacesAPI(t, map[string]string{
+		"ok":      `{"status":"OK","result":{"rating":4.6,"user_ratings_total":128}}`,
+		"missing": `{"status":"ZERO_RESULTS"}`,
+		"denied":  `{"status":"REQUEST_DENIED"}`,
+	})
+
+	if !GooglePlaceDetailsCachedUntil("ok").IsZero() {
+		t.Error("nothing should be cached yet")
+	}
+
+	for i := 0; i < 3; i++ {
+		GetGooglePlaceDetails("ok")
+		GetGooglePlaceDetails("missing")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want one per place", got)
+	}
+
+	until := GooglePlaceDetailsCachedUntil("ok")
+	if ttl := time.Until(until); ttl <= placeDetailsFailureTTL || ttl > placeDetailsCacheTTL {
+		t.Errorf("success cached for %v, want about %v", ttl, placeDetailsCacheTTL)
+	}
+	if ttl := time.Until(GooglePlaceDetailsCachedUntil("missing")); ttl <= 0 || ttl > placeDetailsFailureTTL {
+		t.Errorf("not-found cached for %v, want about %v", ttl, placeDetailsFailureTTL)
+	}
+
+	// Unexpected statuses aren't cached, so the next view retries
+	GetGooglePlaceDetails("denied")
+	GetGooglePlaceDetails("denied")
+	if got := requests.Load(); got != 4 {
+		t.Errorf("made %d requests, want the denied lookup retried", got)
+	}
+}
+
+func TestGetGooglePlaceDetailsRedactsKey(t *testing.T) {
+	t.Setenv("GOOGLE_PLACES_API_KEY", "secret-key")
+	origURL := placesAPIBaseURL
+	placesAPIBaseURL = "http://127.0.0.1:0"
+	defer func() { placesAPIBaseURL = origURL }()
+
+	_, _, err := GetGooglePlaceDetails("unreachable")
+	if err == nil || strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("err = %v, want a request error without the API key", err)
+	}
+}
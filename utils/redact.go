@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log output
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are field names whose values must never reach the logs
+var sensitiveKeys = map[string]bool{
+	"password":       true,
+	"access_token":   true,
+	"refresh_token":  true,
+	"client_secret":  true,
+	"code":           true,
+	"token_hash":     true,
+	"provider_token": true,
+}
+
+// sensitiveParamPattern matches key=value pairs (query strings, form bodies) for sensitive keys
+var sensitiveParamPattern = regexp.MustCompile(`(?i)\b(password|access_token|refresh_token|client_secret|code|token_hash|provider_token)=([^&\s]+)`)
+
+// IsSensitiveKey reports whether a field name holds a secret
+func IsSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactJSON returns a JSON document with sensitive fields replaced, at any
+// depth. Input that isn't JSON is treated as key=value text.
+func RedactJSON(data []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return RedactString(string(data))
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+// RedactString scrubs sensitive key=value pairs from free-form text such as URLs
+func RedactString(s string) string {
+	return sensitiveParamPattern.ReplaceAllString(s, "$1="+Redacted)
+}
+
+// RedactMap formats a decoded JSON object for logging with sensitive fields replaced
+func RedactMap(m map[string]interface{}) string {
+	return fmt.Sprintf("%+v", redactValue(m))
+}
+
+// redactValue walks decoded JSON, replacing values of sensitive keys
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if IsSensitiveKey(key) {
+				out[key] = Redacted
+			} else {
+				out[key] = redactValue(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = redactValue(child)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	in := `{"email":"a@example.com","password":"hunter2","session":{"Access_Token":"at-1","user":{"refresh_token":"rt-1"}},"identities":[{"provider_token":"pt-1","id":"x"}]}`
+	got := RedactJSON([]byte(in))
+
+	for _, secret := range []string{"hunter2", "at-1", "rt-1", "pt-1"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("RedactJSON left %q in %s", secret, got)
+		}
+	}
+	for _, kept := range []string{`"email":"a@example.com"`, `"id":"x"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("RedactJSON dropped %s: %s", kept, got)
+		}
+	}
+}
+
+func TestRedactJSONFallsBackToText(t *testing.T) {
+	got := RedactJSON([]byte("grant_type=password&password=hunter2&email=a@example.com"))
+	if want := "grant_type=password&password=" + Redacted + "&email=a@example.com"; got != want {
+		t.Errorf("RedactJSON(form) = %q, want %q", got, want)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	for in, want := range map[string]string{
+		"/auth/callback?code=abc123&state=s":      "/auth/callback?code=" + Redacted + "&state=s",
+		"GET /x?access_token=t1 refresh_token=t2": "GET /x?access_token=" + Redacted + " refresh_token=" + Redacted,
+		"CLIENT_SECRET=s3":                        "CLIENT_SECRET=" + Redacted,
+		"zipcode=12345":                           "zipcode=12345",
+		"nothing to hide":                         "nothing to hide",
+	} {
+		if got := RedactString(in); got != want {
+			t.Errorf("RedactString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedactMap(t *testing.T) {
+	got := RedactMap(map[string]interface{}{"token_hash": "th-1", "type": "recovery"})
+	if strings.Contains(got, "th-1") || !strings.Contains(got, "type:recovery") {
+		t.Errorf("RedactMap = %s, want token_hash hidden and type kept", got)
+	}
+}
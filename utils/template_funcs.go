@@ -109,9 +109,13 @@ func GetGooglePlaceID(businessName, address string) (string, error) {
 	return placeID, nil
 }
 
-// GenerateWazeURL creates a Waze URL similar to the example format
-func GenerateWazeURL(businessName, address, placeID string) string {
-	if placeID == "" {
+// GenerateWazeURL creates a Waze URL similar to the example format. The
+// structured live-map link is only used when the place ID is known and the
+// state and city can be found for the merchant's country; otherwise it falls
+// back to a plain address search.
+func GenerateWazeURL(businessName, address, placeID, country string) string {
+	state, city, ok := parseLocationFromAddress(address, country)
+	if placeID == "" || !ok {
 		// Fallback to simple search
 		return fmt.Sprintf("https://waze.com/ul?q=%s&navigate=yes", url.QueryEscape(address))
 	}
@@ -122,70 +126,88 @@ func GenerateWazeURL(businessName, address, placeID string) string {
 	businessSlug = regexp.MustCompile(`\s+`).ReplaceAllString(businessSlug, "-")
 	businessSlug = strings.Trim(businessSlug, "-")
 
-	// Parse location from address
-	state, city := parseLocationFromAddress(address)
-
 	return fmt.Sprintf(
-		"https://www.waze.com/live-map/directions/my/%s/%s/%s?navigate=yes&utm_campaign=default&utm_source=waze_website&utm_medium=lm_share_location&to=place.%s",
-		state, city, businessSlug, placeID,
+		"https://www.waze.com/live-map/directions/%s/%s/%s/%s?navigate=yes&utm_campaign=default&utm_source=waze_website&utm_medium=lm_share_location&to=place.%s",
+		strings.ToLower(country), state, city, businessSlug, placeID,
 	)
 }
 
-func parseLocationFromAddress(address string) (state, city string) {
-	// Default values
-	state = "johor-darul-tazim"
-	city = "johor-bahru"
-
-	if address == "" {
-		return state, city
-	}
+// wazePlace maps a name found in an address to the slug Waze uses in its URLs
+type wazePlace struct {
+	Name string
+	Slug string
+}
 
-	addressLower := strings.ToLower(address)
+// wazeRegions holds the states and cities recognised in one country's addresses.
+// Entries are checked in order, so longer names that contain shorter ones go first.
+type wazeRegions struct {
+	States []wazePlace
+	Cities []wazePlace
+}
 
-	// Malaysian states mapping
-	stateMap := map[string]string{
-		"johor":           "johor-darul-tazim",
-		"kuala lumpur":    "kuala-lumpur",
-		"selangor":        "selangor",
-		"penang":          "penang",
-		"perak":           "perak",
-		"kedah":           "kedah",
-		"kelantan":        "kelantan",
-		"terengganu":      "terengganu",
-		"pahang":          "pahang",
-		"negeri sembilan": "negeri-sembilan",
-		"melaka":          "melaka",
-		"sabah":           "sabah",
-		"sarawak":         "sarawak",
-	}
+// wazeLocations are the countries with structured Waze links, keyed by ISO 3166-1 alpha-2 code
+var wazeLocations = map[string]wazeRegions{
+	"MY": {
+		States: []wazePlace{
+			{"johor", "johor-darul-tazim"},
+			{"kuala lumpur", "kuala-lumpur"},
+			{"selangor", "selangor"},
+			{"penang", "penang"},
+			{"perak", "perak"},
+			{"kedah", "kedah"},
+			{"kelantan", "kelantan"},
+			{"terengganu", "terengganu"},
+			{"pahang", "pahang"},
+			{"negeri sembilan", "negeri-sembilan"},
+			{"melaka", "melaka"},
+			{"sabah", "sabah"},
+			{"sarawak", "sarawak"},
+		},
+		Cities: []wazePlace{
+			{"johor bahru", "johor-bahru"},
+			{"kuala lumpur", "kuala-lumpur"},
+			{"petaling jaya", "petaling-jaya"},
+			{"shah alam", "shah-alam"},
+			{"george town", "george-town"},
+			{"ipoh", "ipoh"},
+			{"kuching", "kuching"},
+			{"kota kinabalu", "kota-kinabalu"},
+		},
+	},
+}
 
-	// City mapping (simplified)
-	cityMap := map[string]string{
-		"johor bahru":   "johor-bahru",
-		"kuala lumpur":  "kuala-lumpur",
-		"petaling jaya": "petaling-jaya",
-		"shah alam":     "shah-alam",
-		"george town":   "george-town",
-		"ipoh":          "ipoh",
-		"kuching":       "kuching",
-		"kota kinabalu": "kota-kinabalu",
+// parseLocationFromAddress finds the Waze state and city slugs in an address
+// using the country's data set. ok is false when the country has no data or
+// either part can't be found.
+func parseLocationFromAddress(address, country string) (state, city string, ok bool) {
+	regions, known := wazeLocations[strings.ToUpper(country)]
+	if !known || address == "" {
+		return "", "", false
 	}
 
-	// Check for states
-	for stateName, stateSlug := range stateMap {
-		if strings.Contains(addressLower, stateName) {
-			state = stateSlug
-			break
-		}
+	addressLower := strings.ToLower(address)
+	state = matchWazePlace(addressLower, regions.States)
+	city = matchWazePlace(addressLower, regions.Cities)
+	if state == "" || city == "" {
+		return "", "", false
 	}
+	return state, city, true
+}
 
-	// Check for cities
-	for cityName, citySlug := range cityMap {
-		if strings.Contains(addressLower, cityName) {
-			city = citySlug
-			break
+// matchWazePlace returns the slug of the first place named in the address
+func matchWazePlace(addressLower string, places []wazePlace) string {
+	for _, place := range places {
+		if strings.Contains(addressLower, place.Name) {
+			return place.Slug
 		}
 	}
+	return ""
+}
 
-	return state, city
+// Slugify converts text into a URL-friendly slug (lowercase, hyphen separated, alphanumeric only)
+func Slugify(text string) string {
+	slug := strings.ToLower(strings.TrimSpace(text))
+	slug = regexp.MustCompile(`[^a-z0-9\s-]`).ReplaceAllString(slug, "")
+	slug = regexp.MustCompile(`[\s-]+`).ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-")
 }
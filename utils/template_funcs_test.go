@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	for input, want := range map[string]string{
+		"Joe's Café & Bar":      "joes-caf-bar",
+		"  Kedai   Makan Ali  ": "kedai-makan-ali",
+		"Already-a--slug":       "already-a-slug",
+		"--Trim me--":           "trim-me",
+		"面包店":                   "",
+		"":                      "",
+	} {
+		if got := Slugify(input); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseLocationFromAddress(t *testing.T) {
+	tests := []struct {
+		address, country string
+		state, city      string
+		ok               bool
+	}{
+		{"12 Jalan Wong Ah Fook, Johor Bahru, Johor", "MY", "johor-darul-tazim", "johor-bahru", true},
+		{"1 Jalan SS2, Petaling Jaya, Selangor", "my", "selangor", "petaling-jaya", true},
+		{"Lot 5, Kuala Lumpur", "MY", "kuala-lumpur", "kuala-lumpur", true},
+		{"Somewhere in Selangor", "MY", "", "", false},
+		{"1 Orchard Road, Singapore", "SG", "", "", false},
+		{"12 Jalan Wong Ah Fook, Johor Bahru, Johor", "", "", "", false},
+	}
+	for _, tt := range tests {
+		state, city, ok := parseLocationFromAddress(tt.address, tt.country)
+		if state != tt.state || city != tt.city || ok != tt.ok {
+			t.Errorf("parseLocationFromAddress(%q, %q) = %q, %q, %v; want %q, %q, %v",
+				tt.address, tt.country, state, city, ok, tt.state, tt.city, tt.ok)
+		}
+	}
+}
+
+func TestGenerateWazeURL(t *testing.T) {
+	const address = "12 Jalan Wong Ah Fook, Johor Bahru, Johor"
+
+	got := GenerateWazeURL("Kopi & Co.", address, "ChIJ123", "MY")
+	if !strings.HasPrefix(got, "https://www.waze.com/live-map/directions/my/johor-darul-tazim/johor-bahru/kopi-co?") ||
+		!strings.HasSuffix(got, "&to=place.ChIJ123") {
+		t.Errorf("GenerateWazeURL = %q", got)
+	}
+
+	search := "https://waze.com/ul?q=" + url.QueryEscape(address) + "&navigate=yes"
+	for name, country := range map[string]string{"no data for the country": "SG", "unknown country": ""} {
+		if got := GenerateWazeURL("Kopi & Co.", address, "ChIJ123", country); got != search {
+			t.Errorf("%s: %q, want the search link", name, got)
+		}
+	}
+	if got := GenerateWazeURL("Kopi & Co.", address, "", "MY"); got != search {
+		t.Errorf("no place ID: %q, want the search link", got)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// whatsAppPresetField is the profile form field holding the preset for a locale.
+// The default locale uses the original whatsapp_preset_text field.
+func whatsAppPresetField(locale string) string {
+	return "whatsapp_preset_" + locale
+}
+
+// whatsAppPresetsFromForm reads the per-locale WhatsApp presets from the profile
+// form, leaving out blank ones so they fall back to the default message
+func whatsAppPresetsFromForm(c *gin.Context) map[string]string {
+	presets := map[string]string{}
+	for _, locale := range supportedLocales {
+		if locale == defaultLocale {
+			continue
+		}
+		if text := strings.TrimSpace(c.PostForm(whatsAppPresetField(locale))); text != "" {
+			presets[locale] = text
+		}
+	}
+	return presets
+}
+
+// whatsAppPresetFor picks the WhatsApp message for the visitor's locale,
+// falling back to the merchant's single preset text
+func (d *MerchantDetails) whatsAppPresetFor(locale string) string {
+	if text := d.WhatsAppPresets[locale]; text != "" {
+		return text
+	}
+	return d.WhatsAppPresetText
+}
+
+// encodeWhatsAppPresets stores the presets for the whatsapp_presets JSONB column
+func encodeWhatsAppPresets(presets map[string]string) []byte {
+	if len(presets) == 0 {
+		return []byte("{}")
+	}
+	data, err := json.Marshal(presets)
+	if err != nil {
+		return []byte("{}")
+	}
+	return data
+}
+
+// decodeWhatsAppPresets reads the whatsapp_presets column; an unreadable value
+// is treated as no presets so the single preset text is still used
+func decodeWhatsAppPresets(data []byte) map[string]string {
+	presets := map[string]string{}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &presets)
+	}
+	return presets
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWhatsAppPresetFor(t *testing.T) {
+	details := &MerchantDetails{
+		WhatsAppPresetText: "Hi, I'd like to book",
+		WhatsAppPresets:    map[string]string{"ms": "Hai, saya nak tempah"},
+	}
+	tests := map[string]string{
+		"ms": "Hai, saya nak tempah",
+		"zh": "Hi, I'd like to book",
+		"en": "Hi, I'd like to book",
+	}
+	for locale, want := range tests {
+		if got := details.whatsAppPresetFor(locale); got != want {
+			t.Errorf("locale %s: preset %q, want %q", locale, got, want)
+		}
+	}
+
+	links := generateBusinessLinks(&Merchant{}, &MerchantDetails{PhoneNumber: "+60 12-345 6789", WhatsAppPresets: details.WhatsAppPresets}, "ms")
+	if !strings.HasSuffix(links.WhatsAppWebLink, "&text="+url.QueryEscape("Hai, saya nak tempah")) {
+		t.Errorf("web link %q doesn't carry the Malay message", links.WhatsAppWebLink)
+	}
+}
+
+func TestWhatsAppPresetsFromForm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	form := url.Values{
+		"whatsapp_preset_text": {"Hello"},
+		"whatsapp_preset_en":   {"ignored, en uses whatsapp_preset_text"},
+		"whatsapp_preset_ms":   {"  Hai  "},
+		"whatsapp_preset_zh":   {"   "},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+
+	if got := whatsAppPresetsFromForm(c); !reflect.DeepEqual(got, map[string]string{"ms": "Hai"}) {
+		t.Errorf("presets = %v, want only the Malay one", got)
+	}
+}
+
+func TestWhatsAppPresetsEncoding(t *testing.T) {
+	presets := map[string]string{"ms": "Hai", "zh": "你好"}
+	if got := decodeWhatsAppPresets(encodeWhatsAppPresets(presets)); !reflect.DeepEqual(got, presets) {
+		t.Errorf("round trip = %v, want %v", got, presets)
+	}
+	if got := string(encodeWhatsAppPresets(nil)); got != "{}" {
+		t.Errorf("no presets encoded as %q, want {}", got)
+	}
+	for _, stored := range []string{"", "not json"} {
+		if got := decodeWhatsAppPresets([]byte(stored)); got == nil || len(got) != 0 {
+			t.Errorf("decoding %q = %v, want an empty map", stored, got)
+		}
+	}
+}